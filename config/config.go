@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,14 +14,34 @@ type Config struct {
 	Server  ServerConfig
 	AI      AIConfig
 	Logging LoggingConfig
+	Debug   DebugConfig
+	Admin   AdminConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
+	Host         string // Interface to bind to; empty binds all interfaces
 	Port         string
+	BindAddr     string // Full listen address overriding Host and Port, e.g. "127.0.0.1:9000" or "unix:/run/intent.sock"
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// ShutdownTimeout bounds how long shutdown waits for in-flight requests
+	ShutdownTimeout time.Duration
+	// MaxBodyBytes bounds the size of intent request bodies
+	MaxBodyBytes int64
+	// DefaultResponseFields are the intent fields in responses when a request
+	// doesn't ask for others with ?fields= (every field when empty)
+	DefaultResponseFields []string
+	// CompressionMinBytes is the smallest JSON response gzipped for clients
+	// that accept it; negative disables compression
+	CompressionMinBytes int
+	// FailOnUnknown answers intent requests classified as UNKNOWN with HTTP
+	// 422 instead of 200, unless a request passes ?require_intent=false
+	FailOnUnknown bool
+	// LogBodies logs the text and intent of every intent request, with the
+	// values of sensitive entities masked
+	LogBodies bool
 }
 
 // AIConfig holds AI provider configuration
@@ -36,14 +59,33 @@ type LoggingConfig struct {
 	Level string
 }
 
+// DebugConfig holds configuration for profiling and runtime stats endpoints
+type DebugConfig struct {
+	Enabled   bool   // Mount /debug/pprof and /api/v1/stats (off by default)
+	AuthToken string // Bearer token required to access the debug endpoints
+}
+
+// AdminConfig holds configuration for the endpoints that change the live config
+type AdminConfig struct {
+	AuthToken string // Bearer token required by /api/v1/reload and PATCH /api/v1/intents/{name}
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:         getEnv("PORT", "8080"),
-			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 30*time.Second),
-			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 30*time.Second),
-			IdleTimeout:  getDurationEnv("IDLE_TIMEOUT", 60*time.Second),
+			Host:                  getEnv("HOST", ""),
+			Port:                  getEnv("PORT", "8080"),
+			BindAddr:              getEnv("BIND_ADDR", ""),
+			ReadTimeout:           getDurationEnv("READ_TIMEOUT", 30*time.Second),
+			WriteTimeout:          getDurationEnv("WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:           getDurationEnv("IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout:       getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+			MaxBodyBytes:          int64(getIntEnv("MAX_BODY_BYTES", 64<<10)),
+			DefaultResponseFields: getListEnv("DEFAULT_RESPONSE_FIELDS"),
+			CompressionMinBytes:   getIntEnv("COMPRESSION_MIN_BYTES", 1024),
+			FailOnUnknown:         getBoolEnv("FAIL_ON_UNKNOWN", false),
+			LogBodies:             getBoolEnv("LOG_BODIES", false),
 		},
 		AI: AIConfig{
 			ProviderType: getEnv("AI_PROVIDER", "openai"),
@@ -56,7 +98,54 @@ func Load() *Config {
 		Logging: LoggingConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
+		Debug: DebugConfig{
+			Enabled:   getBoolEnv("DEBUG_ENDPOINTS_ENABLED", false),
+			AuthToken: getEnv("DEBUG_AUTH_TOKEN", ""),
+		},
+		Admin: AdminConfig{
+			AuthToken: getEnv("ADMIN_AUTH_TOKEN", ""),
+		},
+	}
+}
+
+// ListenAddress returns the network and address the server listens on.
+// BIND_ADDR wins when set: a "unix:" prefix or a path selects a Unix socket,
+// anything else must be host:port. Otherwise the address is HOST:PORT, which
+// is ":8080" by default.
+func (c ServerConfig) ListenAddress() (network, address string, err error) {
+	if c.BindAddr != "" {
+		if path, ok := strings.CutPrefix(c.BindAddr, "unix:"); ok || strings.ContainsRune(c.BindAddr, '/') {
+			if !ok {
+				path = c.BindAddr
+			}
+			if path == "" {
+				return "", "", fmt.Errorf("invalid BIND_ADDR %q: missing socket path", c.BindAddr)
+			}
+			return "unix", path, nil
+		}
+		if err := validateHostPort(c.BindAddr); err != nil {
+			return "", "", fmt.Errorf("invalid BIND_ADDR %q: %w", c.BindAddr, err)
+		}
+		return "tcp", c.BindAddr, nil
 	}
+
+	address = net.JoinHostPort(c.Host, c.Port)
+	if err := validateHostPort(address); err != nil {
+		return "", "", fmt.Errorf("invalid HOST %q or PORT %q: %w", c.Host, c.Port, err)
+	}
+	return "tcp", address, nil
+}
+
+// validateHostPort checks that address is host:port with a numeric port
+func validateHostPort(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+		return fmt.Errorf("port %q must be a number from 0 to 65535", port)
+	}
+	return nil
 }
 
 // getEnv gets environment variable with fallback
@@ -67,6 +156,17 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getListEnv gets a comma-separated environment variable, dropping blank items
+func getListEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getIntEnv gets integer environment variable with fallback
 func getIntEnv(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
@@ -87,6 +187,16 @@ func getFloatEnv(key string, fallback float64) float64 {
 	return fallback
 }
 
+// getBoolEnv gets boolean environment variable with fallback
+func getBoolEnv(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return fallback
+}
+
 // getDurationEnv gets duration environment variable with fallback
 func getDurationEnv(key string, fallback time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
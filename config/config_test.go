@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestServerConfig_ListenAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      ServerConfig
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{name: "default", config: ServerConfig{Port: "8080"}, wantNetwork: "tcp", wantAddress: ":8080"},
+		{name: "host", config: ServerConfig{Host: "127.0.0.1", Port: "9000"}, wantNetwork: "tcp", wantAddress: "127.0.0.1:9000"},
+		{name: "ipv6 host", config: ServerConfig{Host: "::1", Port: "9000"}, wantNetwork: "tcp", wantAddress: "[::1]:9000"},
+		{name: "bind addr wins", config: ServerConfig{Host: "127.0.0.1", Port: "9000", BindAddr: "10.0.0.5:7000"}, wantNetwork: "tcp", wantAddress: "10.0.0.5:7000"},
+		{name: "unix prefix", config: ServerConfig{Port: "8080", BindAddr: "unix:intent.sock"}, wantNetwork: "unix", wantAddress: "intent.sock"},
+		{name: "socket path", config: ServerConfig{Port: "8080", BindAddr: "/run/intent.sock"}, wantNetwork: "unix", wantAddress: "/run/intent.sock"},
+		{name: "bad port", config: ServerConfig{Port: "http"}, wantErr: true},
+		{name: "port out of range", config: ServerConfig{Port: "70000"}, wantErr: true},
+		{name: "bind addr without port", config: ServerConfig{Port: "8080", BindAddr: "localhost"}, wantErr: true},
+		{name: "empty socket path", config: ServerConfig{Port: "8080", BindAddr: "unix:"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, address, err := tt.config.ListenAddress()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListenAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if network != tt.wantNetwork || address != tt.wantAddress {
+				t.Errorf("ListenAddress() = %s %s, want %s %s", network, address, tt.wantNetwork, tt.wantAddress)
+			}
+		})
+	}
+}
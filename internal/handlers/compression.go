@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultCompressionMinBytes is the smallest response body compressed when
+// COMPRESSION_MIN_BYTES is unset
+const DefaultCompressionMinBytes = 1024
+
+// CompressionMiddleware gzips JSON responses of at least minBytes for clients
+// that send "Accept-Encoding: gzip". Smaller bodies gain little and aren't
+// compressed, nor are other content types such as Server-Sent Events, which
+// must reach the client as they are flushed. A negative minBytes disables
+// compression.
+func CompressionMiddleware(minBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if minBytes < 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, minBytes: minBytes, status: http.StatusOK}
+			defer gw.Close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding = strings.TrimSpace(coding); coding != "gzip" && coding != "*" {
+			continue
+		}
+		// "gzip;q=0" refuses it
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter holds back the start of a response until it knows
+// whether to compress it: when the body reaches minBytes, when the handler
+// flushes or when it returns
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minBytes      int
+	status        int
+	headerPending bool // WriteHeader was called but not passed on yet
+	decided       bool
+	buf           bytes.Buffer
+	gz            *gzip.Writer // Set once the response is being compressed
+}
+
+// WriteHeader holds the status until the body decides the encoding. Responses
+// that can't be compressed are passed on at once so streams aren't delayed.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.decided || w.headerPending {
+		return
+	}
+	w.status, w.headerPending = status, true
+	if !w.compressible() {
+		w.decide(false)
+	}
+}
+
+// Write buffers the body until it is big enough to compress
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.headerPending = true
+		if w.compressible() {
+			w.buf.Write(p)
+			if w.buf.Len() < w.minBytes {
+				return len(p), nil
+			}
+			return len(p), w.decide(true)
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends what has been written so far, deciding the encoding first
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close finishes the response once the handler has returned
+func (w *gzipResponseWriter) Close() error {
+	if !w.decided {
+		if !w.headerPending && w.buf.Len() == 0 {
+			return nil
+		}
+		// A body that never reached minBytes goes out as it is
+		return w.decide(false)
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible reports whether the response is JSON in no other encoding
+func (w *gzipResponseWriter) compressible() bool {
+	header := w.Header()
+	return strings.Contains(header.Get("Content-Type"), "json") && header.Get("Content-Encoding") == "" &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified
+}
+
+// decide passes on the held status and buffered body, compressed or not
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	if w.headerPending {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
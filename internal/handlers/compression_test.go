@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jsonHandler answers with a JSON body of size bytes
+func jsonHandler(size int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `"`+strings.Repeat("a", size-2)+`"`)
+	})
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		minBytes       int
+		acceptEncoding string
+		size           int
+		wantGzip       bool
+	}{
+		{name: "large JSON", minBytes: 1024, acceptEncoding: "gzip, deflate, br", size: 4096, wantGzip: true},
+		{name: "no Accept-Encoding", minBytes: 1024, size: 4096},
+		{name: "gzip refused", minBytes: 1024, acceptEncoding: "gzip;q=0, br", size: 4096},
+		{name: "small JSON", minBytes: 1024, acceptEncoding: "gzip", size: 100},
+		{name: "exactly the threshold", minBytes: 1024, acceptEncoding: "gzip", size: 1024, wantGzip: true},
+		{name: "disabled", minBytes: -1, acceptEncoding: "gzip", size: 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/intent", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			CompressionMiddleware(tt.minBytes)(jsonHandler(tt.size)).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusCreated {
+				t.Errorf("status = %d, want the handler's 201", rec.Code)
+			}
+			body := rec.Body.Bytes()
+			if gzipped := rec.Header().Get("Content-Encoding") == "gzip"; gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip %v", rec.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if tt.wantGzip {
+				reader, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				if body, err = io.ReadAll(reader); err != nil {
+					t.Fatalf("reading gzipped body: %v", err)
+				}
+			}
+			if len(body) != tt.size {
+				t.Errorf("body is %d bytes, want %d", len(body), tt.size)
+			}
+			if vary := rec.Header().Get("Vary"); tt.minBytes >= 0 && vary != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", vary)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_SkipsEventStreams(t *testing.T) {
+	handler := CompressionMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "event: task\ndata: {}\n\n")
+		http.NewResponseController(w).Flush()
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/intent/stream?text=hi", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("Content-Encoding = %q, want event streams uncompressed", encoding)
+	}
+	if !rec.Flushed {
+		t.Error("stream was not flushed through the middleware")
+	}
+	if got := rec.Body.String(); got != "event: task\ndata: {}\n\n" {
+		t.Errorf("body = %q, want the event as written", got)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"myllm/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// startTime records when the process started, for uptime reporting
+var startTime = time.Now()
+
+// RegisterDebugRoutes mounts pprof under /debug/pprof, runtime stats under
+// /api/v1/stats, intentService's compiled config under /api/v1/debug/compiled
+// and its shadow stats under /api/v1/debug/shadow. Nothing is mounted unless enabled is true and a
+// token is set; every request must carry "Authorization: Bearer <token>".
+func RegisterDebugRoutes(router *mux.Router, intentService *services.IntentService, enabled bool, token string) bool {
+	if !enabled || token == "" {
+		return false
+	}
+
+	debug := router.PathPrefix("/debug/pprof").Subrouter()
+	debug.Use(requireBearerToken(token))
+	debug.HandleFunc("/cmdline", pprof.Cmdline)
+	debug.HandleFunc("/profile", pprof.Profile)
+	debug.HandleFunc("/symbol", pprof.Symbol)
+	debug.HandleFunc("/trace", pprof.Trace)
+	debug.PathPrefix("/").HandlerFunc(pprof.Index)
+
+	stats := router.Path("/api/v1/stats").Subrouter()
+	stats.Use(requireBearerToken(token))
+	stats.Methods("GET").HandlerFunc(RuntimeStats)
+
+	compiled := router.Path("/api/v1/debug/compiled").Subrouter()
+	compiled.Use(requireBearerToken(token))
+	compiled.Methods("GET").HandlerFunc(CompiledConfigHandler(intentService))
+
+	shadow := router.Path("/api/v1/debug/shadow").Subrouter()
+	shadow.Use(requireBearerToken(token))
+	shadow.Methods("GET").HandlerFunc(ShadowStatsHandler(intentService))
+
+	return true
+}
+
+// RegisterAdminRoutes mounts POST /api/v1/reload and PATCH
+// /api/v1/intents/{name}, which change the config intentService is running
+// with. Nothing is mounted unless a token is set; every request must carry
+// "Authorization: Bearer <token>".
+func RegisterAdminRoutes(router *mux.Router, intentService *services.IntentService, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	reload := router.Path("/api/v1/reload").Subrouter()
+	reload.Use(requireBearerToken(token))
+	reload.Methods("POST").HandlerFunc(ReloadHandler(intentService))
+
+	intents := router.Path("/api/v1/intents/{name}").Subrouter()
+	intents.Use(requireBearerToken(token))
+	intents.Methods("PATCH").HandlerFunc(UpdateIntentHandler(intentService))
+
+	return true
+}
+
+// CompiledConfigHandler dumps the patterns the enhanced local provider is
+// running with, so operators can check what is live after reloads. Other
+// providers answer 501.
+func CompiledConfigHandler(intentService *services.IntentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		compiled, ok := intentService.CompiledConfig()
+		if !ok {
+			respondWithError(w, http.StatusNotImplemented, "Provider "+intentService.GetAIProviderName()+" has no compiled config")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, compiled)
+	}
+}
+
+// ShadowStatsHandler returns the shadow mode totals and most recent
+// disagreements. It answers 404 when shadow mode is off.
+func ShadowStatsHandler(intentService *services.IntentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		stats := intentService.ShadowStats()
+		if stats == nil {
+			respondWithError(w, http.StatusNotFound, "Shadow mode is not enabled")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, stats)
+	}
+}
+
+// RuntimeStats returns goroutine count, memory statistics and uptime
+func RuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	uptime := time.Since(startTime)
+	response := map[string]interface{}{
+		"goroutines":     runtime.NumGoroutine(),
+		"uptime":         uptime.Round(time.Second).String(),
+		"uptime_seconds": int64(uptime.Seconds()),
+		"memory": map[string]interface{}{
+			"alloc_bytes":       mem.Alloc,
+			"total_alloc_bytes": mem.TotalAlloc,
+			"sys_bytes":         mem.Sys,
+			"heap_objects":      mem.HeapObjects,
+			"num_gc":            mem.NumGC,
+		},
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// requireBearerToken rejects requests that don't present the expected bearer token
+func requireBearerToken(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				w.Header().Set("Content-Type", "application/json")
+				respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
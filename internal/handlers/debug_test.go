@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+	"myllm/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// newDebugTestRouter mirrors main's route setup around the debug routes
+func newDebugTestRouter(enabled bool, token string) *mux.Router {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/health", HealthCheck).Methods("GET")
+	RegisterDebugRoutes(router, nil, enabled, token)
+	return router
+}
+
+func TestRegisterDebugRoutes_DisabledByDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		token   string
+	}{
+		{"toggle off", false, "secret"},
+		{"toggle on without token", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newDebugTestRouter(tt.enabled, tt.token)
+
+			for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/api/v1/stats", "/api/v1/debug/compiled", "/api/v1/debug/shadow"} {
+				req := httptest.NewRequest("GET", path, nil)
+				req.Header.Set("Authorization", "Bearer secret")
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				if rec.Code != http.StatusNotFound {
+					t.Errorf("GET %s status = %d, want %d", path, rec.Code, http.StatusNotFound)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterDebugRoutes_Enabled(t *testing.T) {
+	router := newDebugTestRouter(true, "secret")
+
+	tests := []struct {
+		name       string
+		path       string
+		auth       string
+		wantStatus int
+	}{
+		{"stats without token", "/api/v1/stats", "", http.StatusUnauthorized},
+		{"stats with wrong token", "/api/v1/stats", "Bearer nope", http.StatusUnauthorized},
+		{"stats with token but no scheme", "/api/v1/stats", "secret", http.StatusUnauthorized},
+		{"stats with token", "/api/v1/stats", "Bearer secret", http.StatusOK},
+		{"pprof index without token", "/debug/pprof/", "", http.StatusUnauthorized},
+		{"pprof index with token", "/debug/pprof/", "Bearer secret", http.StatusOK},
+		{"compiled config without token", "/api/v1/debug/compiled", "", http.StatusUnauthorized},
+		{"shadow stats without token", "/api/v1/debug/shadow", "", http.StatusUnauthorized},
+		{"health unaffected", "/api/v1/health", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+const compiledTestConfig = `{
+  "domain": "%s",
+  "version": "1.2",
+  "intents": {
+    "CreateNote": {
+      "description": "Create a note",
+      "keywords": ["note", "jot"],
+      "phrases": ["take a note"],
+      "regex": ["(?i)^note:"]
+    }
+  },
+  "entities": {
+    "title": {"type": "title", "regex": ["\"([^\"]+)\""]}
+  },
+  "synonyms": {"note": ["memo"]}
+}`
+
+func TestCompiledConfigHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "intents.json")
+	writeConfig := func(domain string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(fmt.Sprintf(compiledTestConfig, domain)), 0o644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	writeConfig("before")
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+	t.Setenv("INTENT_CONFIG_PATH", path)
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	router := mux.NewRouter()
+	RegisterDebugRoutes(router, service, true, "secret")
+	dump := func() models.CompiledConfigResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/debug/compiled", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+		}
+		var response models.CompiledConfigResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := dump()
+	compiled, exists := response.Languages["en"]
+	if !exists {
+		t.Fatalf("languages = %v, want en", response.Languages)
+	}
+	if compiled.Domain != "before" || compiled.Version != "1.2" {
+		t.Errorf("domain, version = %q, %q, want before, 1.2", compiled.Domain, compiled.Version)
+	}
+	checks := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"intent regexes", compiled.IntentRegexes["CreateNote"], []string{"(?i)^note:"}},
+		{"entity regexes", compiled.EntityRegexes["title"], []string{`"([^"]+)"`}},
+		{"keywords", compiled.Keywords["CreateNote"], []string{"note", "jot"}},
+		{"phrases", compiled.Phrases["CreateNote"], []string{"take a note"}},
+		{"exact phrases", compiled.ExactPhrases["take a note"], "CreateNote"},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.got, check.want) {
+			t.Errorf("%s = %v, want %v", check.name, check.got, check.want)
+		}
+	}
+	if synonyms := compiled.Synonyms["memo"]; !slices.Contains(synonyms, "note") {
+		t.Errorf("synonyms of memo = %v, want note", synonyms)
+	}
+
+	// The dump follows reloads
+	writeConfig("after")
+	if _, err := service.ReloadConfig(); err != nil {
+		t.Fatalf("ReloadConfig() error = %v", err)
+	}
+	reloaded := dump()
+	if reloaded.Languages["en"].Domain != "after" || reloaded.ConfigVersion <= response.ConfigVersion {
+		t.Errorf("after reload: domain %q, config version %d, want after and a version above %d",
+			reloaded.Languages["en"].Domain, reloaded.ConfigVersion, response.ConfigVersion)
+	}
+}
+
+func TestCompiledConfigHandler_NotSupported(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	t.Setenv("INTENT_CONFIG_PATH", "")
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	CompiledConfigHandler(service)(rec, httptest.NewRequest("GET", "/api/v1/debug/compiled", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
+
+func TestShadowStatsHandler_NotEnabled(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	t.Setenv("SHADOW_INTENT_CONFIG_PATH", "")
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ShadowStatsHandler(service)(rec, httptest.NewRequest("GET", "/api/v1/debug/shadow", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestRegisterAdminRoutes(t *testing.T) {
+	service := newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test"))
+
+	unmounted := mux.NewRouter()
+	if RegisterAdminRoutes(unmounted, service, "") {
+		t.Error("RegisterAdminRoutes() = true without a token, want false")
+	}
+
+	router := mux.NewRouter()
+	router.PathPrefix("/api/v1").Subrouter().HandleFunc("/intents", ListIntentsHandler(service)).Methods("GET")
+	RegisterAdminRoutes(router, service, "secret")
+
+	tests := []struct {
+		name       string
+		router     *mux.Router
+		method     string
+		path       string
+		auth       string
+		wantStatus int
+	}{
+		{"reload without token configured", unmounted, "POST", "/api/v1/reload", "Bearer secret", http.StatusNotFound},
+		{"toggle without token configured", unmounted, "PATCH", "/api/v1/intents/CreateNote", "Bearer secret", http.StatusNotFound},
+		{"reload without token", router, "POST", "/api/v1/reload", "", http.StatusUnauthorized},
+		{"toggle without token", router, "PATCH", "/api/v1/intents/CreateNote", "", http.StatusUnauthorized},
+		{"toggle with wrong token", router, "PATCH", "/api/v1/intents/CreateNote", "Bearer nope", http.StatusUnauthorized},
+		{"toggle with token but no scheme", router, "PATCH", "/api/v1/intents/CreateNote", "secret", http.StatusUnauthorized},
+		{"toggle with token", router, "PATCH", "/api/v1/intents/CreateNote", "Bearer secret", http.StatusOK},
+		{"list unaffected", router, "GET", "/api/v1/intents", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"enabled": true}`))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %d, want %d (body %s)", tt.method, tt.path, rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
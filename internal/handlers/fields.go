@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"myllm/internal/models"
+)
+
+// intentFields are the names of the intent fields a response can be
+// projected to
+var intentFields = models.JSONFieldNames(models.Intent{})
+
+// SetDefaultResponseFields projects every intent response to the given
+// intent fields, e.g. "task" and "vars", unless a request asks for others
+// with ?fields=. Unknown names are logged and dropped.
+func (h *IntentHandler) SetDefaultResponseFields(fields []string) {
+	known, unknown := splitKnownFields(fields)
+	if len(unknown) > 0 {
+		slog.Warn("Ignoring unknown DEFAULT_RESPONSE_FIELDS", "fields", unknown)
+	}
+	h.defaultFields = known
+}
+
+// responseFields returns the intent fields requested with ?fields=, else the
+// default ones, and the requested names that aren't intent fields. No fields
+// means the whole intent.
+func (h *IntentHandler) responseFields(r *http.Request) (fields, unknown []string) {
+	requested := r.URL.Query().Get("fields")
+	if requested == "" {
+		return h.defaultFields, nil
+	}
+	return splitKnownFields(strings.Split(requested, ","))
+}
+
+// splitKnownFields separates intent field names from unknown ones, dropping
+// blanks
+func splitKnownFields(names []string) (known, unknown []string) {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+		case slices.Contains(intentFields, name):
+			known = append(known, name)
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	return known, unknown
+}
+
+// projectResponse marshals response with its intent cut down to fields. The
+// projection works on the encoded JSON, so it covers every field the intent
+// encodes.
+func projectResponse(response models.IntentResponse, fields []string) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var projected map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &projected); err != nil {
+		return nil, err
+	}
+
+	var intent map[string]json.RawMessage
+	if err := json.Unmarshal(projected["intent"], &intent); err != nil {
+		return nil, fmt.Errorf("failed to decode intent: %w", err)
+	}
+	for name := range intent {
+		if !slices.Contains(fields, name) {
+			delete(intent, name)
+		}
+	}
+	if projected["intent"], err = json.Marshal(intent); err != nil {
+		return nil, err
+	}
+	return projected, nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const fieldsTestConfig = `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"], "required": ["email", "phone"]}
+  },
+  "entities": {
+    "email": {"type": "email", "regex": ["([a-z]+@[a-z]+\\.com)"]}
+  }
+}`
+
+func TestExtractIntent_Fields(t *testing.T) {
+	service := newEnhancedTestService(t, fieldsTestConfig)
+	body := `{"text": "Add contact bob@example.com"}`
+
+	tests := []struct {
+		name         string
+		defaults     []string
+		query        string
+		wantIntent   []string
+		wantTopLevel []string
+		wantWarning  string
+	}{
+		{
+			name:         "every field",
+			wantIntent:   []string{"confidence", "follow_up", "missing", "task", "vars"},
+			wantTopLevel: []string{"intent", "success"},
+		},
+		{
+			name:         "task and vars",
+			query:        "?fields=task,vars",
+			wantIntent:   []string{"task", "vars"},
+			wantTopLevel: []string{"intent", "success"},
+		},
+		{
+			name:         "unknown field",
+			query:        "?fields=task,%20bogus",
+			wantIntent:   []string{"task"},
+			wantTopLevel: []string{"intent", "success"},
+			wantWarning:  "bogus",
+		},
+		{
+			name:         "server default",
+			defaults:     []string{"task", "vars", "nope"},
+			wantIntent:   []string{"task", "vars"},
+			wantTopLevel: []string{"intent", "success"},
+		},
+		{
+			name:         "request overrides the default",
+			defaults:     []string{"task", "vars"},
+			query:        "?fields=missing",
+			wantIntent:   []string{"missing"},
+			wantTopLevel: []string{"intent", "success"},
+		},
+		{
+			name:         "other top-level fields are kept",
+			query:        "?fields=task&debug=true",
+			wantIntent:   []string{"task"},
+			wantTopLevel: []string{"debug", "intent", "success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewIntentHandler(service, 0)
+			handler.SetDefaultResponseFields(tt.defaults)
+
+			rec := httptest.NewRecorder()
+			handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent"+tt.query, strings.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			var response map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var intent map[string]json.RawMessage
+			if err := json.Unmarshal(response["intent"], &intent); err != nil {
+				t.Fatalf("failed to decode intent: %v", err)
+			}
+			if got := sortedNames(intent); !reflect.DeepEqual(got, tt.wantIntent) {
+				t.Errorf("intent fields = %v, want %v", got, tt.wantIntent)
+			}
+			if got := sortedNames(response); !reflect.DeepEqual(got, tt.wantTopLevel) {
+				t.Errorf("response fields = %v, want %v", got, tt.wantTopLevel)
+			}
+
+			warning := rec.Header().Get("Warning")
+			if tt.wantWarning == "" && warning != "" || !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("Warning = %q, want it to mention %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// sortedNames returns the keys of a decoded JSON object, sorted
+func sortedNames(object map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
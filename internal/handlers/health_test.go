@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"myllm/internal/services"
+)
+
+// newOllamaTestService creates an IntentService backed by the Ollama provider
+// pointed at a stub server, whose availability is controlled by the returned flag
+func newOllamaTestService(t *testing.T) (*services.IntentService, *atomic.Bool) {
+	t.Helper()
+
+	up := &atomic.Bool{}
+	up.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"models": []}`))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("AI_PROVIDER", "ollama")
+	t.Setenv("AI_BASE_URL", server.URL)
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+	if name := service.GetAIProviderName(); name != "Ollama" {
+		t.Fatalf("provider = %s, want Ollama", name)
+	}
+	return service, up
+}
+
+func TestReadinessHandler(t *testing.T) {
+	service, up := newOllamaTestService(t)
+	handler := ReadinessHandler(service)
+
+	tests := []struct {
+		name       string
+		up         bool
+		wantStatus int
+		wantHealth string
+	}{
+		{"provider available", true, http.StatusOK, "healthy"},
+		{"provider down", false, http.StatusServiceUnavailable, "unhealthy"},
+		{"provider recovered", true, http.StatusOK, "healthy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up.Store(tt.up)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/health/ready", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			var body struct {
+				Status   string `json:"status"`
+				Provider struct {
+					Name      string `json:"name"`
+					Available bool   `json:"available"`
+				} `json:"provider"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Status != tt.wantHealth || body.Provider.Name != "Ollama" || body.Provider.Available != tt.up {
+				t.Errorf("body = %+v, want status %s with Ollama available = %v", body, tt.wantHealth, tt.up)
+			}
+		})
+	}
+}
+
+func TestHealthCheck_LiveWhileProviderDown(t *testing.T) {
+	_, up := newOllamaTestService(t)
+	up.Store(false)
+
+	rec := httptest.NewRecorder()
+	HealthCheck(rec, httptest.NewRequest("GET", "/api/v1/health/live", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("liveness status = %d, want %d while the provider is down", rec.Code, http.StatusOK)
+	}
+}
@@ -1,25 +1,86 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"myllm/internal/logging"
 	"myllm/internal/models"
 	"myllm/internal/services"
+
+	"github.com/gorilla/mux"
 )
 
+// requestTimeoutBuffer is added to the provider timeout for the handler's deadline
+const requestTimeoutBuffer = 2 * time.Second
+
+// maxSessionIDLength bounds session IDs accepted from clients
+const maxSessionIDLength = 128
+
+// defaultMaxBodyBytes bounds intent request bodies when no limit is configured
+const defaultMaxBodyBytes = 64 << 10
+
 // IntentHandler handles HTTP requests for intent extraction
 type IntentHandler struct {
 	intentService *services.IntentService
+	maxBodyBytes  int64
+	defaultFields []string // Intent fields in responses when a request doesn't pick any
+	failOnUnknown bool     // Answer UNKNOWN with 422 unless a request passes ?require_intent=false
+	logBodies     bool     // Log each request's text and intent, redacted
 }
 
-// NewIntentHandler creates a new intent handler
-func NewIntentHandler(intentService *services.IntentService) *IntentHandler {
+// NewIntentHandler creates a new intent handler accepting request bodies of
+// up to maxBodyBytes, or 64KB if it is not positive
+func NewIntentHandler(intentService *services.IntentService, maxBodyBytes int64) *IntentHandler {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
 	return &IntentHandler{
 		intentService: intentService,
+		maxBodyBytes:  maxBodyBytes,
+	}
+}
+
+// SetFailOnUnknown makes intent requests classified as UNKNOWN, or as the
+// config's fallback_intent, fail with 422
+// by default. A request can still choose with ?require_intent=true or false.
+func (h *IntentHandler) SetFailOnUnknown(fail bool) {
+	h.failOnUnknown = fail
+}
+
+// requireIntent reports whether an UNKNOWN classification should fail the request
+func (h *IntentHandler) requireIntent(r *http.Request) bool {
+	if require, err := strconv.ParseBool(r.URL.Query().Get("require_intent")); err == nil {
+		return require
 	}
+	return h.failOnUnknown
+}
+
+// SetLogBodies logs the text and resulting intent of every intent request,
+// for debugging integrations. Values of entities marked sensitive are masked.
+func (h *IntentHandler) SetLogBodies(enabled bool) {
+	h.logBodies = enabled
+}
+
+// logBody logs a request's text and its intent or error, redacted
+func (h *IntentHandler) logBody(ctx context.Context, text string, status int, intent *models.Intent, errMessage string) {
+	attrs := []any{"status", status, "text", h.intentService.RedactText(text, intent)}
+	if intent != nil {
+		attrs = append(attrs, "intent", h.intentService.RedactIntent(intent))
+	}
+	if errMessage != "" {
+		attrs = append(attrs, "error", errMessage)
+	}
+	logging.FromContext(ctx).Info("Intent request body", attrs...)
 }
 
 // ExtractIntent handles POST requests to extract intent from natural language
@@ -29,8 +90,8 @@ func (h *IntentHandler) ExtractIntent(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var request models.IntentRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+	if status, message := decodeJSONBody(w, r, h.maxBodyBytes, &request); status != 0 {
+		respondWithError(w, status, message)
 		return
 	}
 
@@ -39,15 +100,68 @@ func (h *IntentHandler) ExtractIntent(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Text field is required")
 		return
 	}
+	if len(request.SessionID) > maxSessionIDLength {
+		respondWithError(w, http.StatusBadRequest, "session_id must be at most "+strconv.Itoa(maxSessionIDLength)+" characters")
+		return
+	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	// Give the provider its full deadline plus time to build the response
+	ctx, cancel := context.WithTimeout(r.Context(), h.intentService.RequestTimeout()+requestTimeoutBuffer)
 	defer cancel()
 
-	// Extract intent
-	intent, err := h.intentService.ExtractIntent(ctx, request.Text)
+	// Candidate intents are opt-in to keep default responses lean
+	if alternatives, _ := strconv.ParseBool(r.URL.Query().Get("alternatives")); alternatives || request.Alternatives {
+		ctx = services.WithAlternatives(ctx)
+	}
+	if spans, _ := strconv.ParseBool(r.URL.Query().Get("spans")); spans {
+		ctx = services.WithSpans(ctx)
+	}
+	if request.Lang != "" {
+		ctx = services.WithLanguage(ctx, request.Lang)
+	}
+	if request.Provider != "" {
+		ctx = services.WithProvider(ctx, request.Provider)
+	}
+	if request.Region != "" {
+		var err error
+		if ctx, err = services.WithRegion(ctx, request.Region); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if request.ReferenceTime != nil || request.TZ != "" {
+		var reference time.Time
+		if request.ReferenceTime != nil {
+			reference = *request.ReferenceTime
+		}
+		var err error
+		if ctx, err = services.WithDateReference(ctx, reference, request.TZ); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Extract intent, merging in earlier turns of the conversation
+	intent, err := h.intentService.ExtractIntentWithContext(ctx, request.Text, services.Conversation{
+		SessionID: request.SessionID,
+		Context:   request.Context,
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to extract intent: "+err.Error())
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidProviderResponse):
+			status = http.StatusBadGateway
+		case errors.Is(err, services.ErrNoProvider):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, services.ErrUnknownProvider):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrTextTooLong):
+			status = http.StatusUnprocessableEntity
+		}
+		if h.logBodies {
+			h.logBody(ctx, request.Text, status, nil, err.Error())
+		}
+		respondWithError(w, status, "Failed to extract intent: "+err.Error())
 		return
 	}
 
@@ -58,12 +172,146 @@ func (h *IntentHandler) ExtractIntent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return success response
+	status := http.StatusOK
 	response := models.IntentResponse{
-		Success: true,
-		Intent:  *intent,
+		Success:   true,
+		Intent:    *intent,
+		SessionID: request.SessionID,
+	}
+	// Callers that treat UNKNOWN as an error get a 422 that still carries the
+	// intent, follow-up questions included. The config's fallback_intent
+	// counts as UNKNOWN.
+	if h.intentService.Unrecognized(intent.Task) && h.requireIntent(r) {
+		status = http.StatusUnprocessableEntity
+		response.Success = false
+		response.Error = "No intent recognized"
+	}
+	if h.logBodies {
+		h.logBody(ctx, request.Text, status, intent, response.Error)
+	}
+	// The classification trace is opt-in and never cached or sent to webhooks
+	if debug, _ := strconv.ParseBool(r.URL.Query().Get("debug")); debug {
+		response.Debug = h.intentService.Trace(ctx, request.Text)
+	}
+	// Both texts side by side show what normalization changed
+	if includeText, _ := strconv.ParseBool(r.URL.Query().Get("include_text")); includeText {
+		response.RawText = request.Text
+		response.NormalizedText = h.intentService.NormalizedText(request.Text)
+	}
+
+	// Clients can ask for just the intent fields they use, e.g. ?fields=task,vars
+	fields, unknown := h.responseFields(r)
+	if len(unknown) > 0 {
+		w.Header().Set("Warning", fmt.Sprintf(`299 - "Unknown fields ignored: %s"`, strings.Join(unknown, ", ")))
+	}
+	if len(fields) == 0 {
+		respondWithJSON(w, status, response)
+		return
+	}
+	projected, err := projectResponse(response, fields)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to project response: "+err.Error())
+		return
+	}
+	respondWithJSON(w, status, projected)
+}
+
+// StreamIntent handles GET requests that stream extraction stages as
+// Server-Sent Events: token (streaming providers only), task, entity and
+// follow_up, then done with the full response or error on failure
+func (h *IntentHandler) StreamIntent(w http.ResponseWriter, r *http.Request) {
+	text := r.URL.Query().Get("text")
+	if text == "" {
+		w.Header().Set("Content-Type", "application/json")
+		respondWithError(w, http.StatusBadRequest, "text query parameter is required")
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering events
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	send := func(event string, data interface{}) error {
+		if err := writeEvent(w, event, data); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	// The request context is cancelled when the client disconnects, which
+	// stops the extraction and any provider call in flight
+	ctx, cancel := context.WithTimeout(r.Context(), h.intentService.RequestTimeout()+requestTimeoutBuffer)
+	defer cancel()
+
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		ctx = services.WithLanguage(ctx, lang)
+	}
+
+	intent, err := h.intentService.StreamIntent(ctx, text, func(event services.StreamEvent) error {
+		return send(event.Type, event.Data)
+	})
+	if err == nil {
+		err = intent.Validate()
+	}
+	if err != nil {
+		if r.Context().Err() != nil {
+			return // Client went away
+		}
+		logging.FromContext(ctx).Warn("Streamed extraction failed", "error", err)
+		send("error", models.IntentResponse{Success: false, Error: "Failed to extract intent: " + err.Error()})
+		return
+	}
+
+	send("done", models.IntentResponse{Success: true, Intent: *intent})
+}
+
+// decodeJSONBody decodes a JSON request body of at most maxBytes into dst,
+// rejecting unknown fields. On failure it returns the status and a message
+// saying what was wrong with the body; on success the status is 0.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, maxBytes int64, dst interface{}) (int, string) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == nil {
+		return 0, ""
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must be at most %d bytes", maxBytesErr.Limit)
+	case errors.As(err, &syntaxErr):
+		return http.StatusBadRequest, fmt.Sprintf("Malformed JSON at byte %d: %v", syntaxErr.Offset, err)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest, "Malformed JSON: body ends unexpectedly"
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return http.StatusBadRequest, fmt.Sprintf("Request body must be a JSON object, not %s", typeErr.Value)
+		}
+		return http.StatusBadRequest, fmt.Sprintf("Field %q must be %s, not %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	case errors.Is(err, io.EOF):
+		return http.StatusBadRequest, "Request body is empty"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		return http.StatusBadRequest, "Unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	}
+	return http.StatusBadRequest, "Invalid request body: " + err.Error()
+}
+
+// writeEvent writes one Server-Sent Event with a JSON data line
+func writeEvent(w io.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
 }
 
 // DebugHandler returns debug information about the current AI provider
@@ -79,6 +327,216 @@ func DebugHandler(intentService *services.IntentService) http.HandlerFunc {
 	}
 }
 
+// ListIntentsHandler describes the intents the active provider supports, sorted
+// by name. Disabled intents are left out unless ?include_disabled=true is set.
+// Providers without an intent config answer 501.
+func ListIntentsHandler(intentService *services.IntentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		config, ok := intentService.GetIntentConfig()
+		if !ok {
+			respondWithError(w, http.StatusNotImplemented, "Provider "+intentService.GetAIProviderName()+" does not expose an intent config")
+			return
+		}
+		includeDisabled := r.URL.Query().Get("include_disabled") == "true"
+
+		response := models.IntentsResponse{
+			Domain:  config.Domain,
+			Version: config.Version,
+			Intents: make([]models.IntentSummary, 0, len(config.Intents)),
+		}
+		for name, intent := range config.Intents {
+			if !intent.IsEnabled() && !includeDisabled {
+				continue
+			}
+			response.Intents = append(response.Intents, intentSummary(name, intent))
+		}
+		sort.Slice(response.Intents, func(i, j int) bool {
+			return response.Intents[i].Name < response.Intents[j].Name
+		})
+
+		respondWithJSON(w, http.StatusOK, response)
+	}
+}
+
+// ExplainHandler scores text against every intent without extracting it and
+// returns the breakdown, for config authors debugging misclassifications.
+// Request bodies are limited like intent requests, to maxBodyBytes or 64KB if
+// it is not positive. Providers that don't score intents answer 501.
+func ExplainHandler(intentService *services.IntentService, maxBodyBytes int64) http.HandlerFunc {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request models.IntentRequest
+		if status, message := decodeJSONBody(w, r, maxBodyBytes, &request); status != 0 {
+			respondWithError(w, status, message)
+			return
+		}
+		if request.Text == "" {
+			respondWithError(w, http.StatusBadRequest, "Text field is required")
+			return
+		}
+
+		explanation, err := intentService.Explain(request.Text)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrExplainNotSupported) {
+				status = http.StatusNotImplemented
+			}
+			respondWithError(w, status, "Failed to explain: "+err.Error())
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, explanation)
+	}
+}
+
+// maxUpdateIntentBodyBytes bounds PATCH /api/v1/intents/{name} bodies
+const maxUpdateIntentBodyBytes = 1 << 10
+
+// updateIntentRequest is the body of PATCH /api/v1/intents/{name}
+type updateIntentRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// UpdateIntentHandler enables or disables an intent at runtime. The change is
+// kept in memory only and is undone by the next config reload.
+func UpdateIntentHandler(intentService *services.IntentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req updateIntentRequest
+		if status, message := decodeJSONBody(w, r, maxUpdateIntentBodyBytes, &req); status != 0 {
+			respondWithError(w, status, message)
+			return
+		}
+		if req.Enabled == nil {
+			respondWithError(w, http.StatusBadRequest, "Field 'enabled' is required")
+			return
+		}
+
+		name := mux.Vars(r)["name"]
+		if err := intentService.SetIntentEnabled(name, *req.Enabled); err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, services.ErrUnknownIntent):
+				status = http.StatusNotFound
+			case errors.Is(err, services.ErrToggleNotSupported):
+				status = http.StatusNotImplemented
+			}
+			respondWithError(w, status, "Failed to update intent: "+err.Error())
+			return
+		}
+
+		config, _ := intentService.GetIntentConfig()
+		respondWithJSON(w, http.StatusOK, intentSummary(name, config.Intents[name]))
+	}
+}
+
+// intentSummary describes a configured intent for API clients
+func intentSummary(name string, intent models.IntentPattern) models.IntentSummary {
+	return models.IntentSummary{
+		Name:          name,
+		Description:   intent.Description,
+		Variables:     nonNil(intent.Variables),
+		Required:      nonNil(intent.Required),
+		Priority:      intent.Priority,
+		Enabled:       intent.IsEnabled(),
+		RequiredOneOf: intent.RequiredOneOf,
+	}
+}
+
+// nonNil returns an empty slice for nil so lists encode as [] rather than null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// ReloadHandler re-reads the intent config from INTENT_CONFIG_PATH. A config
+// that fails to load or validate is rejected with 422 and the old one is kept.
+func ReloadHandler(intentService *services.IntentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		config, err := intentService.ReloadConfig()
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, services.ErrReloadNotSupported) {
+				status = http.StatusConflict
+			}
+			respondWithError(w, status, "Failed to reload config: "+err.Error())
+			return
+		}
+
+		response := map[string]interface{}{
+			"success":   true,
+			"provider":  intentService.GetAIProviderName(),
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		}
+		if config != nil {
+			response["domain"] = config.Domain
+			response["version"] = config.Version
+			response["intents"] = len(config.Intents)
+		}
+		respondWithJSON(w, http.StatusOK, response)
+	}
+}
+
+// SchemaHandler returns the JSON Schema of the intent endpoints' responses
+func SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	respondWithJSON(w, http.StatusOK, models.IntentResponseSchema())
+}
+
+// maxConfigBodySize bounds the configs accepted for validation
+const maxConfigBodySize = 1 << 20
+
+// ValidateConfigHandler checks a candidate intent config sent as the request
+// body, JSON or YAML (a Content-Type containing "yaml"), without loading it.
+// All problems are listed with 422; a usable config answers 200.
+func ValidateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigBodySize))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Config body is required")
+		return
+	}
+
+	format := models.ConfigFormatJSON
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		format = models.ConfigFormatYAML
+	}
+
+	var problems []error
+	config, err := models.ParseIntentConfig(body, format)
+	if err != nil {
+		problems = []error{err}
+	} else {
+		problems = services.ValidateConfig(config)
+	}
+
+	if len(problems) > 0 {
+		response := models.ValidateConfigResponse{Valid: false}
+		for _, problem := range problems {
+			response.Errors = append(response.Errors, problem.Error())
+		}
+		respondWithJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, models.ValidateConfigResponse{Valid: true})
+}
+
 // respondWithJSON sends a JSON response
 func respondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.WriteHeader(statusCode)
@@ -0,0 +1,890 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+	"myllm/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+const reloadTestConfig = `{
+  "domain": "%s",
+  "intents": {
+    "CreateNote": {"description": "Create a note", "keywords": ["note"]}
+  }
+}`
+
+// newEnhancedTestService creates an IntentService backed by the enhanced local
+// provider with the given JSON config
+func newEnhancedTestService(t *testing.T, config string) *services.IntentService {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "intents.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+	t.Setenv("INTENT_CONFIG_PATH", path)
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+	return service
+}
+
+func TestReloadHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "intents.json")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	writeConfig(fmt.Sprintf(reloadTestConfig, "before"))
+
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+	t.Setenv("INTENT_CONFIG_PATH", path)
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+	handler := ReloadHandler(service)
+
+	reload := func() (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("POST", "/api/v1/reload", nil))
+		var body map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return rec, body
+	}
+
+	writeConfig(fmt.Sprintf(reloadTestConfig, "after"))
+	rec, body := reload()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %v)", rec.Code, body)
+	}
+	if body["domain"] != "after" {
+		t.Errorf("domain = %v, want after", body["domain"])
+	}
+
+	writeConfig(`{"domain": "", "intents": {}}`)
+	rec, body = reload()
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", rec.Code)
+	}
+	if errMsg, _ := body["error"].(string); !strings.Contains(errMsg, "domain is required") {
+		t.Errorf("error = %q, want the validation error", errMsg)
+	}
+	if name := service.GetAIProviderName(); !strings.Contains(name, "after") {
+		t.Errorf("provider = %q, want the previous config still active", name)
+	}
+}
+
+func TestReloadHandler_NotSupported(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	t.Setenv("INTENT_CONFIG_PATH", "")
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ReloadHandler(service)(rec, httptest.NewRequest("POST", "/api/v1/reload", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409", rec.Code)
+	}
+}
+
+func TestExtractIntent_AlternativesOptIn(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateNote": {"description": "Create a note", "keywords": ["note", "add"]},
+    "DeleteNote": {"description": "Delete a note", "keywords": ["note", "delete"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+
+	tests := []struct {
+		name  string
+		query string
+		body  string
+		want  int
+	}{
+		{"default response is lean", "", `{"text": "add a note"}`, 0},
+		{"query parameter", "?alternatives=true", `{"text": "add a note"}`, 2},
+		{"request field", "", `{"text": "add a note", "alternatives": true}`, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent"+tt.query, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			var response models.IntentResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Intent.Alternatives) != tt.want {
+				t.Errorf("Alternatives = %+v, want %d", response.Intent.Alternatives, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractIntent_Spans(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"], "variables": ["email"]}
+  },
+  "entities": {
+    "email": {"type": "email", "regex": ["([a-z]+@[a-z]+\\.com)"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+	text := "Add contact bob@example.com"
+	body := `{"text": "` + text + `"}`
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if strings.Contains(rec.Body.String(), `"spans"`) {
+		t.Errorf("body = %s, want no spans without ?spans=true", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent?spans=true", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var response models.IntentResponse
+	if err := json.NewDecoder(strings.NewReader(rec.Body.String())).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	spans := response.Intent.Spans["email"]
+	if len(spans) != 1 || text[spans[0].Start:spans[0].End] != "bob@example.com" {
+		t.Errorf("spans = %+v, want the offsets of bob@example.com", response.Intent.Spans)
+	}
+}
+
+func TestExtractIntent_DebugTrace(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"], "variables": ["email"]}
+  },
+  "entities": {
+    "email": {"type": "email", "regex": ["([a-z]+@[a-z]+\\.com)"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+	body := `{"text": "Add contact bob@example.com"}`
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if strings.Contains(rec.Body.String(), `"debug"`) {
+		t.Errorf("body = %s, want no debug object without ?debug=true", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent?debug=true", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var response models.IntentResponse
+	if err := json.NewDecoder(strings.NewReader(rec.Body.String())).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	debug := response.Debug
+	if debug == nil {
+		t.Fatalf("debug = nil, want a trace: %s", rec.Body)
+	}
+	if debug.NormalizedText != "add contact <email>" {
+		t.Errorf("normalized_text = %q, want the email masked", debug.NormalizedText)
+	}
+	if !reflect.DeepEqual(debug.Tokens, []string{"add", "contact", "<email>"}) {
+		t.Errorf("tokens = %v, want add, contact, <email>", debug.Tokens)
+	}
+	if !reflect.DeepEqual(debug.MatchedKeywords["CreateContact"], []string{"add", "contact"}) {
+		t.Errorf("matched_keywords = %v, want add and contact for CreateContact", debug.MatchedKeywords)
+	}
+	if debug.TopTask != "CreateContact" || debug.Score <= 0 || debug.Threshold != models.FallbackConfidenceThreshold {
+		t.Errorf("debug = %+v, want CreateContact's score and threshold", debug)
+	}
+	if got := strings.Count(rec.Body.String(), "bob@example.com"); got != 1 {
+		t.Errorf("email appears %d times in %s, want only in vars", got, rec.Body)
+	}
+}
+
+func TestExtractIntent_IncludeText(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"], "variables": ["email"]}
+  },
+  "abbreviations": {"pls": "please"},
+  "entities": {
+    "email": {"type": "email", "regex": ["([a-z]+@[a-z]+\\.com)"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+	body := `{"text": "  Pls ADD   contact: bob@example.com!!"}`
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if strings.Contains(rec.Body.String(), `"raw_text"`) || strings.Contains(rec.Body.String(), `"normalized_text"`) {
+		t.Errorf("body = %s, want no texts without ?include_text=true", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent?include_text=true", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var response models.IntentResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.RawText != "  Pls ADD   contact: bob@example.com!!" {
+		t.Errorf("raw_text = %q, want the text as sent", response.RawText)
+	}
+	// Abbreviations expanded, lowercased, whitespace collapsed and the
+	// punctuation the enhanced local provider ignores dropped
+	if response.NormalizedText != "please add contact bob@example.com" {
+		t.Errorf("normalized_text = %q, want please add contact bob@example.com", response.NormalizedText)
+	}
+}
+
+func TestListIntentsHandler(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "notes",
+  "version": "2.0",
+  "intents": {
+    "DeleteNote": {"description": "Delete a note", "keywords": ["delete"], "priority": 5, "variables": ["id"], "required": ["id"]},
+    "CreateNote": {"description": "Create a note", "keywords": ["note"], "priority": 8, "variables": ["content", "tag"], "required": ["content"]},
+    "ListNotes": {"description": "List notes", "keywords": ["list"]}
+  }
+}`)
+
+	rec := httptest.NewRecorder()
+	ListIntentsHandler(service)(rec, httptest.NewRequest("GET", "/api/v1/intents", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var response models.IntentsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := models.IntentsResponse{
+		Domain:  "notes",
+		Version: "2.0",
+		Intents: []models.IntentSummary{
+			{Name: "CreateNote", Description: "Create a note", Variables: []string{"content", "tag"}, Required: []string{"content"}, Priority: 8, Enabled: true},
+			{Name: "DeleteNote", Description: "Delete a note", Variables: []string{"id"}, Required: []string{"id"}, Priority: 5, Enabled: true},
+			{Name: "ListNotes", Description: "List notes", Variables: []string{}, Required: []string{}, Enabled: true},
+		},
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("response = %+v, want %+v", response, want)
+	}
+}
+
+func TestListIntentsHandler_NotImplemented(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ListIntentsHandler(service)(rec, httptest.NewRequest("GET", "/api/v1/intents", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
+
+// listIntentNames returns the names served by the intents listing for query
+func listIntentNames(t *testing.T, service *services.IntentService, query string) []string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	ListIntentsHandler(service)(rec, httptest.NewRequest("GET", "/api/v1/intents"+query, nil))
+	var response models.IntentsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	var names []string
+	for _, intent := range response.Intents {
+		names = append(names, intent.Name)
+	}
+	return names
+}
+
+func TestUpdateIntentHandler(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "notes",
+  "intents": {
+    "CreateNote": {"description": "Create a note", "keywords": ["note"]},
+    "DeleteNote": {"description": "Delete a note", "keywords": ["delete"]}
+  }
+}`)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/intents/{name}", UpdateIntentHandler(service)).Methods("PATCH")
+
+	tests := []struct {
+		name    string
+		intent  string
+		body    string
+		status  int
+		enabled []string
+		all     []string
+	}{
+		{name: "disable", intent: "DeleteNote", body: `{"enabled": false}`, status: http.StatusOK, enabled: []string{"CreateNote"}, all: []string{"CreateNote", "DeleteNote"}},
+		{name: "enable again", intent: "DeleteNote", body: `{"enabled": true}`, status: http.StatusOK, enabled: []string{"CreateNote", "DeleteNote"}, all: []string{"CreateNote", "DeleteNote"}},
+		{name: "unknown intent", intent: "ArchiveNote", body: `{"enabled": false}`, status: http.StatusNotFound, enabled: []string{"CreateNote", "DeleteNote"}, all: []string{"CreateNote", "DeleteNote"}},
+		{name: "missing field", intent: "DeleteNote", body: `{}`, status: http.StatusBadRequest, enabled: []string{"CreateNote", "DeleteNote"}, all: []string{"CreateNote", "DeleteNote"}},
+		{name: "unknown field", intent: "DeleteNote", body: `{"enabled": false, "priority": 3}`, status: http.StatusBadRequest, enabled: []string{"CreateNote", "DeleteNote"}, all: []string{"CreateNote", "DeleteNote"}},
+		{name: "body too large", intent: "DeleteNote", body: `{"enabled": false` + strings.Repeat(" ", 2048) + `}`, status: http.StatusRequestEntityTooLarge, enabled: []string{"CreateNote", "DeleteNote"}, all: []string{"CreateNote", "DeleteNote"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("PATCH", "/api/v1/intents/"+tt.intent, strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if got := listIntentNames(t, service, ""); !reflect.DeepEqual(got, tt.enabled) {
+				t.Errorf("listed intents = %v, want %v", got, tt.enabled)
+			}
+			if got := listIntentNames(t, service, "?include_disabled=true"); !reflect.DeepEqual(got, tt.all) {
+				t.Errorf("listed intents with disabled = %v, want %v", got, tt.all)
+			}
+		})
+	}
+}
+
+func TestUpdateIntentHandler_NotImplemented(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest("PATCH", "/api/v1/intents/CREATE_CONTACT", strings.NewReader(`{"enabled": false}`)), map[string]string{"name": "CREATE_CONTACT"})
+	UpdateIntentHandler(service)(rec, req)
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
+
+func TestExplainHandler(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "notes",
+  "intents": {
+    "CreateNote": {"description": "Create a note", "keywords": ["note", "write"], "phrases": ["make a note"]},
+    "DeleteNote": {"description": "Delete a note", "keywords": ["delete", "note"]}
+  }
+}`)
+
+	rec := httptest.NewRecorder()
+	ExplainHandler(service, 0)(rec, httptest.NewRequest("POST", "/api/v1/explain", strings.NewReader(`{"text": "make a note about lunch"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var response models.ExplainResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Task != "CreateNote" {
+		t.Fatalf("Task = %s, want CreateNote", response.Task)
+	}
+	if len(response.Intents) != 2 || response.Intents[0].Task != "CreateNote" || response.Intents[0].Breakdown.PhraseHit != "make a note" {
+		t.Errorf("intents = %+v, want CreateNote first with its phrase hit", response.Intents)
+	}
+
+	rec = httptest.NewRecorder()
+	ExplainHandler(service, 0)(rec, httptest.NewRequest("POST", "/api/v1/explain", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status without text = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	ExplainHandler(service, 0)(rec, httptest.NewRequest("POST", "/api/v1/explain", strings.NewReader(`{"text": "make a note", "txt": "typo"}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Unknown field") {
+		t.Errorf("status with unknown field = %d (%s), want 400 naming the field", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	ExplainHandler(service, 32)(rec, httptest.NewRequest("POST", "/api/v1/explain", strings.NewReader(`{"text": "`+strings.Repeat("note ", 20)+`"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status with oversized body = %d, want 413", rec.Code)
+	}
+}
+
+func TestExplainHandler_NotImplemented(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	service, err := services.NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ExplainHandler(service, 0)(rec, httptest.NewRequest("POST", "/api/v1/explain", strings.NewReader(`{"text": "add contact"}`)))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
+
+// sseEvent is one parsed Server-Sent Event
+type sseEvent struct {
+	name string
+	data string
+}
+
+// parseEvents splits a Server-Sent Events body into its events
+func parseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				event.name = name
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				event.data = data
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestStreamIntent(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateEvent": {
+      "description": "Create a calendar event",
+      "keywords": ["schedule", "meeting"],
+      "variables": ["title", "date"],
+      "required": ["title", "date"]
+    }
+  },
+  "entities": {
+    "date": {"type": "date", "regex": ["(?i)\\b(today|tomorrow)\\b"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+
+	rec := httptest.NewRecorder()
+	handler.StreamIntent(rec, httptest.NewRequest("GET", "/api/v1/intent/stream?text=schedule+a+meeting+tomorrow", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+
+	events := parseEvents(t, rec.Body.String())
+	var names []string
+	for _, event := range events {
+		names = append(names, event.name)
+	}
+	want := []string{"task", "entity", "follow_up", "done"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("events = %v, want %v", names, want)
+	}
+
+	var task models.TaskEvent
+	if err := json.Unmarshal([]byte(events[0].data), &task); err != nil || task.Task != "CreateEvent" {
+		t.Errorf("task event = %s, want CreateEvent", events[0].data)
+	}
+	var entity models.EntityEvent
+	if err := json.Unmarshal([]byte(events[1].data), &entity); err != nil || entity.Name != "date" || entity.Value != "tomorrow" {
+		t.Errorf("entity event = %s, want date=tomorrow", events[1].data)
+	}
+	var done models.IntentResponse
+	if err := json.Unmarshal([]byte(events[3].data), &done); err != nil || !done.Success || done.Intent.Task != "CreateEvent" {
+		t.Errorf("done event = %s, want successful CreateEvent", events[3].data)
+	}
+}
+
+func TestStreamIntent_MissingText(t *testing.T) {
+	handler := NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 0)
+
+	rec := httptest.NewRecorder()
+	handler.StreamIntent(rec, httptest.NewRequest("GET", "/api/v1/intent/stream", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestExtractIntent_Session(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateEvent": {
+      "description": "Create a calendar event",
+      "keywords": ["schedule", "meeting"],
+      "variables": ["title", "date"],
+      "required": ["title", "date"]
+    }
+  },
+  "entities": {
+    "date": {"type": "date", "regex": ["(?i)\\b(today|tomorrow)\\b"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+
+	send := func(body string) models.IntentResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+		var response models.IntentResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	first := send(`{"text": "schedule a meeting tomorrow", "session_id": "chat-42"}`)
+	if first.SessionID != "chat-42" || !reflect.DeepEqual(first.Intent.Missing, []string{"title"}) {
+		t.Fatalf("first turn = %+v, want session echoed and title missing", first)
+	}
+
+	second := send(`{"text": "Quarterly review", "session_id": "chat-42"}`)
+	if second.Intent.Task != "CreateEvent" || second.Intent.Vars["title"] != "Quarterly review" || !second.Intent.IsComplete {
+		t.Errorf("second turn = %+v, want the reply used as the title", second.Intent)
+	}
+}
+
+func TestExtractIntent_SessionIDTooLong(t *testing.T) {
+	handler := NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 0)
+
+	body := fmt.Sprintf(`{"text": "add a note", "session_id": %q}`, strings.Repeat("x", 129))
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestExtractIntent_InvalidTimezone(t *testing.T) {
+	handler := NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 0)
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(`{"text": "add a note", "tz": "Mars/Olympus"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "invalid time zone") {
+		t.Errorf("body = %s, want the time zone error", rec.Body.String())
+	}
+}
+
+func TestExtractIntent_UnsupportedRegion(t *testing.T) {
+	handler := NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 0)
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(`{"text": "add a note", "region": "Atlantis"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "unsupported region") {
+		t.Errorf("body = %s, want the region error", rec.Body.String())
+	}
+}
+
+func TestExtractIntent_BodyErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"oversized body", fmt.Sprintf(`{"text": %q}`, strings.Repeat("x", 200)), http.StatusRequestEntityTooLarge, "at most 100 bytes"},
+		{"unknown field", `{"text": "add a note", "txet": "typo"}`, http.StatusBadRequest, `Unknown field "txet"`},
+		{"wrong type", `{"text": 42}`, http.StatusBadRequest, `Field "text" must be string`},
+		{"syntax error", `{"text": "add a note",}`, http.StatusBadRequest, "Malformed JSON at byte"},
+		{"truncated", `{"text": "add a`, http.StatusBadRequest, "ends unexpectedly"},
+		{"empty", ``, http.StatusBadRequest, "empty"},
+	}
+
+	handler := NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 100)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var response models.IntentResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !strings.Contains(response.Error, tt.wantMessage) {
+				t.Errorf("error = %q, want it to contain %q", response.Error, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestExtractIntent_NoProvider(t *testing.T) {
+	handler := NewIntentHandler(&services.IntentService{}, 0)
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(`{"text": "add a note"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestValidateConfigHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantErrors  []string
+	}{
+		{name: "valid JSON", body: fmt.Sprintf(reloadTestConfig, "notes"), wantStatus: http.StatusOK},
+		{
+			name:        "valid YAML",
+			contentType: "application/yaml",
+			body:        "domain: notes\nintents:\n  CreateNote:\n    description: Create a note\n    keywords: [note]\n",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name: "every problem is listed",
+			body: `{
+  "intents": {"CreateNote": {"description": "Create a note", "regex": ["(note"]}},
+  "entities": {"date": {"regex": ["(\\d+)-(\\d+)"]}}
+}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []string{
+				"domain is required",
+				`invalid regex for entity date: "(\\d+)-(\\d+)" needs a (?P<value>...) group or exactly one capturing group, found 2`,
+				"invalid regex for intent CreateNote: error parsing regexp: missing closing ): `(note`",
+			},
+		},
+		{name: "malformed", body: `{"domain": `, wantStatus: http.StatusUnprocessableEntity, wantErrors: []string{"failed to parse config file: unexpected end of JSON input"}},
+		{name: "empty", body: " ", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/validate-config", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			ValidateConfigHandler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				return
+			}
+
+			var response models.ValidateConfigResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Valid != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("Valid = %v, want %v", response.Valid, tt.wantStatus == http.StatusOK)
+			}
+			if !reflect.DeepEqual(response.Errors, tt.wantErrors) {
+				t.Errorf("Errors = %q, want %q", response.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestExtractIntent_UnknownProvider(t *testing.T) {
+	service := newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test"))
+	handler := NewIntentHandler(service, 0)
+
+	rec := httptest.NewRecorder()
+	body := `{"text": "add a note", "provider": "ollama"}`
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for a provider that isn't configured: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	body = `{"text": "add a note", "provider": "enhanced_local"}`
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 for the configured provider: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestExtractIntent_TextOverflow(t *testing.T) {
+	body := `{"text": "add a note that the quarterly report is due on friday"}`
+
+	t.Setenv("MAX_TEXT_LENGTH", "20")
+	t.Setenv("TEXT_OVERFLOW", "reject")
+	handler := NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 0)
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422 in reject mode: %s", rec.Code, rec.Body)
+	}
+
+	t.Setenv("TEXT_OVERFLOW", "truncate")
+	handler = NewIntentHandler(newEnhancedTestService(t, fmt.Sprintf(reloadTestConfig, "test")), 0)
+	rec = httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 in truncate mode: %s", rec.Code, rec.Body)
+	}
+	var response models.IntentResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Intent.Vars["truncated"] != true {
+		t.Errorf("Vars = %v, want truncated set", response.Intent.Vars)
+	}
+}
+
+func TestExtractIntent_RequireIntent(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+	extract := func(url, text string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ExtractIntent(rec, httptest.NewRequest("POST", url, strings.NewReader(`{"text": "`+text+`"}`)))
+		return rec
+	}
+
+	// Default: UNKNOWN is a successful classification
+	if rec := extract("/api/v1/intent", "what is the weather"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"task":"UNKNOWN"`) {
+		t.Errorf("default: status = %d, body = %s, want 200 with UNKNOWN", rec.Code, rec.Body)
+	}
+
+	rec := extract("/api/v1/intent?require_intent=true", "what is the weather")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("require_intent: status = %d, want 422: %s", rec.Code, rec.Body)
+	}
+	var response models.IntentResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Success || response.Error == "" || response.Intent.Task != "UNKNOWN" {
+		t.Errorf("response = %+v, want an error carrying the UNKNOWN intent", response)
+	}
+
+	// A recognized intent is unaffected by the gate
+	if rec := extract("/api/v1/intent?require_intent=true", "add contact"); rec.Code != http.StatusOK {
+		t.Errorf("recognized: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	// FAIL_ON_UNKNOWN makes the gate the default; a request can opt out
+	handler.SetFailOnUnknown(true)
+	if rec := extract("/api/v1/intent", "what is the weather"); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("FAIL_ON_UNKNOWN: status = %d, want 422", rec.Code)
+	}
+	if rec := extract("/api/v1/intent?require_intent=false", "what is the weather"); rec.Code != http.StatusOK {
+		t.Errorf("require_intent=false: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestExtractIntent_RequireIntentFallbackFollowUp(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"]}
+  },
+  "fallback_intent": "Clarify",
+  "fallback_follow_up": ["Do you want to add a contact?"]
+}`)
+	handler := NewIntentHandler(service, 0)
+	handler.SetFailOnUnknown(true)
+
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(`{"text": "what is the weather"}`)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422: %s", rec.Code, rec.Body)
+	}
+	var response models.IntentResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Intent.Task != "Clarify" || len(response.Intent.FollowUp) != 1 || response.Intent.FollowUp[0] != "Do you want to add a contact?" {
+		t.Errorf("intent = %+v, want the fallback with its follow-up question", response.Intent)
+	}
+}
+
+func TestExtractIntent_LogBodies(t *testing.T) {
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"], "variables": ["email"]}
+  },
+  "entities": {
+    "email": {"type": "email", "regex": ["([a-z]+@[a-z]+\\.com)"], "sensitive": true}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+	body := `{"text": "add contact bob@example.com"}`
+	logs := captureLogs(t, "info")
+
+	// Off by default
+	rec := httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if strings.Contains(logs.String(), "Intent request body") {
+		t.Errorf("logs = %s, want no bodies logged by default", logs)
+	}
+
+	handler.SetLogBodies(true)
+	rec = httptest.NewRecorder()
+	handler.ExtractIntent(rec, httptest.NewRequest("POST", "/api/v1/intent", strings.NewReader(body)))
+	if !strings.Contains(rec.Body.String(), "bob@example.com") {
+		t.Errorf("body = %s, want the response itself unredacted", rec.Body)
+	}
+	logged := logs.String()
+	if !strings.Contains(logged, "Intent request body") || !strings.Contains(logged, `"task":"CreateContact"`) {
+		t.Fatalf("logs = %s, want the request and intent logged", logged)
+	}
+	if strings.Contains(logged, "bob@example.com") {
+		t.Errorf("logs = %s, want the sensitive email masked", logged)
+	}
+	if !strings.Contains(logged, `"text":"add contact [REDACTED]"`) || !strings.Contains(logged, `"email":"[REDACTED]"`) {
+		t.Errorf("logs = %s, want the email masked in the text and the vars", logged)
+	}
+}
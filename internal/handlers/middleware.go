@@ -1,30 +1,109 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
+
+	"myllm/internal/logging"
+	"myllm/internal/metrics"
+	"myllm/internal/services"
+
+	"github.com/gorilla/mux"
 )
 
-// LoggingMiddleware logs HTTP requests with timing information
+// RequestIDHeader carries the request ID on requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 64
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// LoggingMiddleware assigns each request an ID, stores it in the request
+// context and logs one structured line per request when it completes
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		// Log request details
-		log.Printf("Request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		// Keep an upstream proxy's ID so lines can be correlated across services
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
 
-		// Call next handler
-		next.ServeHTTP(w, r)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+
+		slog.LogAttrs(r.Context(), slog.LevelInfo, "request",
+			slog.String("request_id", requestID),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.Int("status", recorder.status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+	})
+}
+
+// MetricsMiddleware counts requests by method, route template and status.
+// Routes are labelled by template ("/api/v1/intent") to keep cardinality low.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		metrics.ObserveRequest(r.Method, route, recorder.status)
+	})
+}
 
-		// Log response time
-		duration := time.Since(start)
-		log.Printf("Response: %s %s completed in %v", r.Method, r.URL.Path, duration)
+// InFlightTracker counts requests that are being served, so shutdown can
+// report how many it is waiting for
+type InFlightTracker struct {
+	count atomic.Int64
+}
+
+// Middleware counts a request from when it arrives until its handler returns
+func (t *InFlightTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.count.Add(1)
+		defer t.count.Add(-1)
+		next.ServeHTTP(w, r)
 	})
 }
 
-// HealthCheck handles health check requests
+// Count returns the number of requests currently being served
+func (t *InFlightTracker) Count() int64 {
+	return t.count.Load()
+}
+
+// HealthCheck handles liveness checks. It only reports that the process is
+// serving requests, so an outage of the AI provider doesn't get it restarted.
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -38,3 +117,33 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Simple JSON response for health check
 	json.NewEncoder(w).Encode(response)
 }
+
+// providerCheckTimeout bounds the provider availability check in readiness
+// checks, which orchestrators expect to answer quickly
+const providerCheckTimeout = 2 * time.Second
+
+// ReadinessHandler handles readiness checks. It reports the AI provider's
+// availability and answers 503 while the provider is down, so traffic is
+// routed elsewhere until it recovers.
+func ReadinessHandler(intentService *services.IntentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), providerCheckTimeout)
+		defer cancel()
+		available := intentService.ProviderAvailable(ctx)
+
+		status, statusCode := "healthy", http.StatusOK
+		if !available {
+			status, statusCode = "unhealthy", http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		respondWithJSON(w, statusCode, map[string]interface{}{
+			"status":    status,
+			"timestamp": time.Now().UTC(),
+			"service":   "intent-recognition-api",
+			"provider": map[string]interface{}{
+				"name":      intentService.GetAIProviderName(),
+				"available": available,
+			},
+		})
+	}
+}
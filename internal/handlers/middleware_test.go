@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myllm/internal/logging"
+	"myllm/internal/metrics"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// captureLogs sends the default logger's output to a buffer for the test
+func captureLogs(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(logging.New(&buf, level))
+	t.Cleanup(func() { slog.SetDefault(original) })
+	return &buf
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	logs := captureLogs(t, "info")
+
+	var contextID string
+	handler := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID = logging.RequestID(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest("POST", "/api/v1/intent", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+		t.Fatalf("log output %q is not one JSON line: %v", logs.String(), err)
+	}
+
+	want := map[string]interface{}{
+		"method":      "POST",
+		"path":        "/api/v1/intent",
+		"remote_addr": "10.0.0.1:1234",
+		"status":      float64(http.StatusTeapot),
+	}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("%s = %v, want %v", key, line[key], value)
+		}
+	}
+	if _, ok := line["duration_ms"].(float64); !ok {
+		t.Errorf("duration_ms = %v, want a number", line["duration_ms"])
+	}
+
+	requestID, _ := line["request_id"].(string)
+	if requestID == "" || requestID != contextID || requestID != rec.Header().Get(RequestIDHeader) {
+		t.Errorf("request_id = %q, context = %q, header = %q, want the same generated ID", requestID, contextID, rec.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestLoggingMiddleware_ReusesUpstreamRequestID(t *testing.T) {
+	captureLogs(t, "info")
+
+	var contextID string
+	handler := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	req.Header.Set(RequestIDHeader, "upstream-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if contextID != "upstream-123" {
+		t.Errorf("request ID = %q, want the upstream upstream-123", contextID)
+	}
+}
+
+func TestMetricsMiddleware_LabelsRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/test/metrics/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}).Methods("GET")
+	router.Use(MetricsMiddleware)
+
+	for _, id := range []string{"1", "2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/metrics/"+id, nil))
+	}
+
+	counter := metrics.HTTPRequests.WithLabelValues("GET", "/test/metrics/{id}", "202")
+	if got := testutil.ToFloat64(counter); got != 2 {
+		t.Errorf("requests for route template = %v, want 2", got)
+	}
+}
+
+func TestInFlightTracker(t *testing.T) {
+	tracker := &InFlightTracker{}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/intent", nil))
+			done <- struct{}{}
+		}()
+	}
+	<-entered
+	<-entered
+
+	if got := tracker.Count(); got != 2 {
+		t.Errorf("Count() = %d while serving, want 2", got)
+	}
+
+	close(release)
+	<-done
+	<-done
+	if got := tracker.Count(); got != 0 {
+		t.Errorf("Count() = %d after the handlers returned, want 0", got)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSchemaHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SchemaHandler(rec, httptest.NewRequest("GET", "/api/v1/schema", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var schema map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&schema); err != nil {
+		t.Fatalf("failed to decode schema: %v", err)
+	}
+
+	service := newEnhancedTestService(t, `{
+  "domain": "test",
+  "intents": {
+    "CreateContact": {"description": "Create a contact", "keywords": ["add", "contact"], "required": ["email", "phone"]},
+    "DeleteContact": {"description": "Delete a contact", "keywords": ["delete", "contact"]}
+  },
+  "entities": {
+    "email": {"type": "email", "regex": ["([a-z]+@[a-z]+\\.com)"]}
+  }
+}`)
+	handler := NewIntentHandler(service, 0)
+
+	tests := []struct {
+		name string
+		url  string
+		body string
+	}{
+		{"extracted", "/api/v1/intent", `{"text": "Add contact bob@example.com"}`},
+		{"every option", "/api/v1/intent?debug=true&spans=true", `{"text": "Add contact bob@example.com", "alternatives": true}`},
+		{"error", "/api/v1/intent", `{"text": ""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ExtractIntent(rec, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			var response interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			for _, problem := range validateSchema(schema, schema, response, "$") {
+				t.Errorf("response doesn't match the schema: %s", problem)
+			}
+		})
+	}
+
+	// A response the schema must reject, so the validator isn't vacuous
+	invalid := map[string]interface{}{"success": "yes", "intent": map[string]interface{}{"vars": nil, "extra": 1}}
+	if problems := validateSchema(schema, schema, invalid, "$"); len(problems) != 3 {
+		t.Errorf("problems = %q, want the wrong success type, the missing task and the unknown extra", problems)
+	}
+}
+
+// validateSchema checks value against the subset of JSON Schema that
+// models.IntentResponseSchema uses: $ref, type, properties, required,
+// additionalProperties and items
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) []string {
+	if ref, exists := schema["$ref"].(string); exists {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		def, _ := root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+		if def == nil {
+			return []string{fmt.Sprintf("%s: unresolved $ref %s", path, ref)}
+		}
+		schema = def
+	}
+
+	if types, exists := schema["type"]; exists {
+		allowed, isList := types.([]interface{})
+		if !isList {
+			allowed = []interface{}{types}
+		}
+		matched := false
+		for _, allowedType := range allowed {
+			if hasJSONType(value, allowedType.(string)) {
+				matched = true
+			}
+		}
+		if !matched {
+			return []string{fmt.Sprintf("%s: %v isn't of type %v", path, value, types)}
+		}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, exists := value[name.(string)]; !exists {
+				problems = append(problems, fmt.Sprintf("%s: missing required %s", path, name))
+			}
+		}
+		for name, field := range value {
+			fieldPath := path + "." + name
+			if property, exists := properties[name]; exists {
+				problems = append(problems, validateSchema(root, property.(map[string]interface{}), field, fieldPath)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					problems = append(problems, fmt.Sprintf("%s: not in the schema", fieldPath))
+				}
+			case map[string]interface{}:
+				problems = append(problems, validateSchema(root, additional, field, fieldPath)...)
+			}
+		}
+	case []interface{}:
+		if items, exists := schema["items"].(map[string]interface{}); exists {
+			for i, item := range value {
+				problems = append(problems, validateSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// hasJSONType reports whether a decoded JSON value is of a JSON Schema type
+func hasJSONType(value interface{}, jsonType string) bool {
+	switch value := value.(type) {
+	case nil:
+		return jsonType == "null"
+	case bool:
+		return jsonType == "boolean"
+	case string:
+		return jsonType == "string"
+	case float64:
+		return jsonType == "number" || jsonType == "integer" && value == math.Trunc(value)
+	case []interface{}:
+		return jsonType == "array"
+	case map[string]interface{}:
+		return jsonType == "object"
+	}
+	return false
+}
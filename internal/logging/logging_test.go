@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"":        slog.LevelInfo,
+		"verbose": slog.LevelInfo,
+	}
+
+	for input, want := range tests {
+		if got := ParseLevel(input); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(New(&buf, "info"))
+	t.Cleanup(func() { slog.SetDefault(original) })
+
+	ctx := WithRequestID(context.Background(), "abc123")
+	FromContext(ctx).Debug("silenced at info level")
+	FromContext(ctx).Info("provider call")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log output %q is not one JSON line: %v", buf.String(), err)
+	}
+	if line["request_id"] != "abc123" || line["msg"] != "provider call" {
+		t.Errorf("line = %v, want the info line tagged with request_id abc123", line)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	first, second := NewRequestID(), NewRequestID()
+	if len(first) != 16 || first == second {
+		t.Errorf("NewRequestID() = %q, %q, want distinct 16-character IDs", first, second)
+	}
+}
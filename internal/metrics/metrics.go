@@ -0,0 +1,107 @@
+// Package metrics defines the Prometheus metrics exported at /metrics.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "intent"
+
+var (
+	// HTTPRequests counts handled requests by method, route template and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// Classifications counts extracted intents by task, UNKNOWN included
+	Classifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "classifications_total",
+		Help:      "Extracted intents by task and provider. UNKNOWN counts inputs that were not classified.",
+	}, []string{"task", "provider"})
+
+	// ProviderErrors counts failed extractions by provider
+	ProviderErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_errors_total",
+		Help:      "Extractions that failed, by provider.",
+	}, []string{"provider"})
+
+	// ExtractionDuration observes how long extractions take, failures included
+	ExtractionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "extraction_duration_seconds",
+		Help:      "Time taken to extract an intent, by provider.",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"provider"})
+
+	// ProviderFallbacks counts requests answered by a fallback provider
+	ProviderFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_fallbacks_total",
+		Help:      "Requests answered by a fallback provider, by the provider type intended and the one used.",
+	}, []string{"intended", "used"})
+
+	// CacheLookups counts intent cache lookups by result, "hit" or "miss"
+	CacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Intent cache lookups, by result (hit or miss).",
+	}, []string{"result"})
+
+	// ShadowComparisons counts shadow classifications by result, "agree",
+	// "disagree" or "error"
+	ShadowComparisons = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shadow_comparisons_total",
+		Help:      "Shadow classifications compared with the active result, by result (agree, disagree or error).",
+	}, []string{"result"})
+)
+
+// Register adds the metrics to the default Prometheus registry. It panics if
+// called twice.
+func Register() {
+	prometheus.MustRegister(HTTPRequests, Classifications, ProviderErrors, ExtractionDuration, CacheLookups, ProviderFallbacks, ShadowComparisons)
+}
+
+// ObserveExtraction records one extraction: its duration, and either the
+// classified task or a provider error
+func ObserveExtraction(provider, task string, duration time.Duration, err error) {
+	ExtractionDuration.WithLabelValues(provider).Observe(duration.Seconds())
+	if err != nil {
+		ProviderErrors.WithLabelValues(provider).Inc()
+		return
+	}
+	Classifications.WithLabelValues(task, provider).Inc()
+}
+
+// ObserveFallback records one request answered by the used provider type
+// instead of the intended one
+func ObserveFallback(intended, used string) {
+	ProviderFallbacks.WithLabelValues(intended, used).Inc()
+}
+
+// ObserveCacheLookup records one intent cache lookup
+func ObserveCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveShadowComparison records one shadow classification result: "agree",
+// "disagree" or "error"
+func ObserveShadowComparison(result string) {
+	ShadowComparisons.WithLabelValues(result).Inc()
+}
+
+// ObserveRequest records one handled HTTP request
+func ObserveRequest(method, route string, status int) {
+	HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveExtraction(t *testing.T) {
+	const provider = "test-observe-extraction"
+
+	ObserveExtraction(provider, "CreateContact", 10*time.Millisecond, nil)
+	ObserveExtraction(provider, "UNKNOWN", 5*time.Millisecond, nil)
+	ObserveExtraction(provider, "UNKNOWN", 5*time.Millisecond, nil)
+	ObserveExtraction(provider, "", time.Second, errors.New("provider unavailable"))
+
+	if got := testutil.ToFloat64(Classifications.WithLabelValues("CreateContact", provider)); got != 1 {
+		t.Errorf("CreateContact classifications = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(Classifications.WithLabelValues("UNKNOWN", provider)); got != 2 {
+		t.Errorf("UNKNOWN classifications = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(ProviderErrors.WithLabelValues(provider)); got != 1 {
+		t.Errorf("provider errors = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(ExtractionDuration); got < 1 {
+		t.Errorf("extraction duration series = %d, want at least 1", got)
+	}
+}
+
+func TestObserveCacheLookup(t *testing.T) {
+	hits := testutil.ToFloat64(CacheLookups.WithLabelValues("hit"))
+	misses := testutil.ToFloat64(CacheLookups.WithLabelValues("miss"))
+
+	ObserveCacheLookup(true)
+	ObserveCacheLookup(false)
+	ObserveCacheLookup(false)
+
+	if got := testutil.ToFloat64(CacheLookups.WithLabelValues("hit")) - hits; got != 1 {
+		t.Errorf("hits = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(CacheLookups.WithLabelValues("miss")) - misses; got != 2 {
+		t.Errorf("misses = %v, want 2", got)
+	}
+}
+
+func TestObserveFallback(t *testing.T) {
+	before := testutil.ToFloat64(ProviderFallbacks.WithLabelValues("openai", "local"))
+
+	ObserveFallback("openai", "local")
+	ObserveFallback("openai", "local")
+	ObserveFallback("openai", "ollama")
+
+	if got := testutil.ToFloat64(ProviderFallbacks.WithLabelValues("openai", "local")) - before; got != 2 {
+		t.Errorf("openai -> local fallbacks = %v, want 2", got)
+	}
+}
+
+func TestObserveShadowComparison(t *testing.T) {
+	before := testutil.ToFloat64(ShadowComparisons.WithLabelValues("disagree"))
+
+	ObserveShadowComparison("agree")
+	ObserveShadowComparison("disagree")
+	ObserveShadowComparison("disagree")
+
+	if got := testutil.ToFloat64(ShadowComparisons.WithLabelValues("disagree")) - before; got != 2 {
+		t.Errorf("disagreements = %v, want 2", got)
+	}
+}
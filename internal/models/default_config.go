@@ -0,0 +1,22 @@
+package models
+
+import (
+	_ "embed"
+	"fmt"
+)
+
+// defaultConfigJSON is the personal assistant config used when no config
+// path is set, bundled into the binary
+//
+//go:embed default_config.json
+var defaultConfigJSON []byte
+
+// GetDefaultConfig returns a default configuration for personal assistant.
+// Each call parses the bundled JSON, so callers may modify the result.
+func GetDefaultConfig() *IntentConfig {
+	config, err := ParseIntentConfig(defaultConfigJSON, ConfigFormatJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled default config: %v", err))
+	}
+	return config
+}
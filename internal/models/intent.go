@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Intent represents the extracted intent and variables from natural language
@@ -14,18 +15,197 @@ type Intent struct {
 	Missing    []string               `json:"missing,omitempty"`     // Required fields that are missing
 	FollowUp   []string               `json:"follow_up,omitempty"`   // Questions to ask for missing info
 	IsComplete bool                   `json:"is_complete,omitempty"` // Whether all required fields are present
+	Warnings   []string               `json:"warnings,omitempty"`    // Problems found while validating the provider response
+	// MaxDepthReached is set when a session ran out of follow-up turns. The
+	// intent is returned as-is with the remaining fields left in Missing.
+	MaxDepthReached bool `json:"max_depth_reached,omitempty"`
+	// Alternatives lists the best-scoring candidate intents, best first. Only
+	// set when the request asks for alternatives.
+	Alternatives []IntentCandidate `json:"alternatives,omitempty"`
+	// Spans locates where each entity's values were found in the request
+	// text, in extraction order. Only set when the request asks for spans.
+	Spans map[string][]Span `json:"spans,omitempty"`
+}
+
+// Span is the byte range [Start, End) of an entity value in the original text
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// IntentCandidate is a scored candidate intent
+type IntentCandidate struct {
+	Task       string  `json:"task"`
+	Confidence float64 `json:"confidence"`
+}
+
+// How a keyword matched the input
+const (
+	KeywordMatchExact   = "exact"
+	KeywordMatchSynonym = "synonym"
+	KeywordMatchFuzzy   = "fuzzy"
+)
+
+// KeywordMatch describes how one keyword of an intent matched the input
+type KeywordMatch struct {
+	Keyword string  `json:"keyword"`
+	Match   string  `json:"match,omitempty"` // KeywordMatch* constant, empty when it didn't match
+	Via     string  `json:"via,omitempty"`   // Synonym or misspelled word that matched
+	Score   float64 `json:"score"`
+}
+
+// ScoreBreakdown holds the components of an intent's classification score
+type ScoreBreakdown struct {
+	Regex         float64        `json:"regex"`
+	RegexHit      string         `json:"regex_hit,omitempty"` // First matching pattern
+	Phrase        float64        `json:"phrase"`
+	PhraseHit     string         `json:"phrase_hit,omitempty"` // First matching phrase
+	Keyword       float64        `json:"keyword"`              // Keyword scores averaged over all keywords
+	Keywords      []KeywordMatch `json:"keywords"`
+	WordOverlap   float64        `json:"word_overlap"`
+	LengthBonus   float64        `json:"length_bonus"`
+	PriorityBoost float64        `json:"priority_boost"`
+}
+
+// Total returns the score used to rank the intent
+func (b ScoreBreakdown) Total() float64 {
+	return b.Regex + b.Phrase + b.Keyword + b.WordOverlap + b.LengthBonus + b.PriorityBoost
+}
+
+// IntentExplanation describes how one intent scored against the input
+type IntentExplanation struct {
+	Task           string         `json:"task"`
+	Skipped        string         `json:"skipped,omitempty"` // Why the intent wasn't ranked: "disabled" or "negated"
+	Score          float64        `json:"score"`
+	Threshold      float64        `json:"threshold"`
+	AboveThreshold bool           `json:"above_threshold"`
+	Breakdown      ScoreBreakdown `json:"breakdown"`
+}
+
+// ExplainResponse is the scoring breakdown for a dry-run classification
+type ExplainResponse struct {
+	Text           string              `json:"text"`
+	NormalizedText string              `json:"normalized_text"`
+	ScoredText     string              `json:"scored_text"` // Normalized text without negated words
+	Task           string              `json:"task"`
+	Confidence     float64             `json:"confidence"`
+	ExactMatch     bool                `json:"exact_match"` // Task came from an exact phrase, not from the scores
+	Intents        []IntentExplanation `json:"intents"`
+}
+
+// DebugTrace shows how a request's text was classified, for troubleshooting.
+// Extracted entity values are masked in NormalizedText and Tokens as
+// "<entity>", so the trace doesn't repeat them.
+type DebugTrace struct {
+	Provider        string              `json:"provider"`
+	Language        string              `json:"language,omitempty"`
+	NormalizedText  string              `json:"normalized_text,omitempty"`
+	Tokens          []string            `json:"tokens,omitempty"`           // Words scored, without stop words and negated words
+	MatchedKeywords map[string][]string `json:"matched_keywords,omitempty"` // Keywords that matched, by task
+	ExactMatch      bool                `json:"exact_match,omitempty"`      // Task came from an exact phrase, not from the scores
+	TopTask         string              `json:"top_task,omitempty"`         // Classified task, or the best-scoring one when the text was UNKNOWN
+	Score           float64             `json:"score"`                      // Raw score of TopTask
+	Threshold       float64             `json:"threshold"`                  // Confidence threshold TopTask was held to
+	EntityMethods   map[string]string   `json:"entity_methods,omitempty"`   // How each entity's value was found, e.g. regex or keyword
 }
 
 // IntentRequest represents the incoming request to extract intent
 type IntentRequest struct {
-	Text string `json:"text" validate:"required"`
+	Text         string                 `json:"text" validate:"required"`
+	Alternatives bool                   `json:"alternatives,omitempty"` // Include the top candidate intents in the response
+	SessionID    string                 `json:"session_id,omitempty"`   // Continue a multi-turn conversation
+	Context      map[string]interface{} `json:"context,omitempty"`      // Vars collected in earlier turns
+	Lang         string                 `json:"lang,omitempty"`         // Language of the text, e.g. "es" (detected or the default when empty)
+	Region       string                 `json:"region,omitempty"`       // Region phone numbers are dialled from, e.g. "GB" (the config's default_region when empty)
+	// ReferenceTime and TZ are what relative dates such as "tomorrow" are
+	// resolved against (default: the server time in the config's zone)
+	ReferenceTime *time.Time `json:"reference_time,omitempty"`
+	TZ            string     `json:"tz,omitempty"`
+	// Provider forces one provider type, e.g. "ollama", to answer instead of
+	// the configured provider or AI_PROVIDER_CHAIN
+	Provider string `json:"provider,omitempty"`
 }
 
 // IntentResponse represents the response with extracted intent
 type IntentResponse struct {
-	Success bool   `json:"success"`
-	Intent  Intent `json:"intent,omitempty"`
-	Error   string `json:"error,omitempty"`
+	Success   bool   `json:"success"`
+	Intent    Intent `json:"intent,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Debug is only set when the request asks for it with ?debug=true
+	Debug *DebugTrace `json:"debug,omitempty"`
+	// RawText and NormalizedText are the text as sent and as classified,
+	// only set when the request asks for them with ?include_text=true
+	RawText        string `json:"raw_text,omitempty"`
+	NormalizedText string `json:"normalized_text,omitempty"`
+}
+
+// ValidateConfigResponse reports whether a candidate intent config is usable
+type ValidateConfigResponse struct {
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"` // Every problem found, when Valid is false
+}
+
+// IntentSummary describes a supported intent for API clients
+type IntentSummary struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Variables   []string `json:"variables"`
+	Required    []string `json:"required"`
+	Priority    int      `json:"priority"`
+	Enabled     bool     `json:"enabled"`
+	// RequiredOneOf lists groups of variables where any one is required
+	RequiredOneOf [][]string `json:"required_one_of,omitempty"`
+}
+
+// IntentsResponse lists the intents supported by the active provider
+type IntentsResponse struct {
+	Domain  string          `json:"domain"`
+	Version string          `json:"version,omitempty"`
+	Intents []IntentSummary `json:"intents"`
+}
+
+// CompiledConfigResponse describes the patterns the enhanced local provider
+// is running with, per language
+type CompiledConfigResponse struct {
+	Provider      string                            `json:"provider"`
+	ConfigVersion uint64                            `json:"config_version"` // Bumped by every reload and intent toggle
+	Languages     map[string]CompiledLanguageConfig `json:"languages"`
+}
+
+// CompiledLanguageConfig is the compiled config of one language. Regexes are
+// listed by their source patterns.
+type CompiledLanguageConfig struct {
+	Domain        string              `json:"domain"`
+	Version       string              `json:"version,omitempty"`
+	IntentRegexes map[string][]string `json:"intent_regexes"`
+	EntityRegexes map[string][]string `json:"entity_regexes"`
+	Keywords      map[string][]string `json:"keywords"`
+	Phrases       map[string][]string `json:"phrases"`
+	Synonyms      map[string][]string `json:"synonyms"`      // Word -> words it matches
+	ExactPhrases  map[string]string   `json:"exact_phrases"` // Normalized phrase or example -> intent
+}
+
+// TokenEvent is the payload of a streamed "token" event
+type TokenEvent struct {
+	Token string `json:"token"`
+}
+
+// TaskEvent is the payload of a streamed "task" event
+type TaskEvent struct {
+	Task       string  `json:"task"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// EntityEvent is the payload of a streamed "entity" event
+type EntityEvent struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// FollowUpEvent is the payload of a streamed "follow_up" event
+type FollowUpEvent struct {
+	Question string `json:"question"`
 }
 
 // ContactIntent represents a specific contact-related intent
@@ -55,13 +235,92 @@ func (i *Intent) ToJSON() (string, error) {
 	return string(data), nil
 }
 
-// FromJSON creates an intent from JSON string
+// FromJSON creates an intent from JSON string. If the data is a JSON array of
+// intents, the first element is returned.
 func FromJSON(data string) (*Intent, error) {
-	var intent Intent
-	if err := json.Unmarshal([]byte(data), &intent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal intent: %w", err)
+	intents, err := FromJSONMulti(data)
+	if err != nil {
+		return nil, err
+	}
+	return intents[0], nil
+}
+
+// FromJSONMulti creates intents from a JSON string holding either a single
+// intent object or an array of intent objects
+func FromJSONMulti(data string) ([]*Intent, error) {
+	trimmed := strings.TrimSpace(data)
+
+	if !strings.HasPrefix(trimmed, "[") {
+		var intent Intent
+		if err := json.Unmarshal([]byte(trimmed), &intent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal intent: %w", err)
+		}
+		return []*Intent{&intent}, nil
+	}
+
+	var intents []*Intent
+	if err := json.Unmarshal([]byte(trimmed), &intents); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal intent array: %w", err)
+	}
+	if len(intents) == 0 {
+		return nil, fmt.Errorf("failed to unmarshal intent: empty array")
+	}
+	for i, intent := range intents {
+		if intent == nil {
+			return nil, fmt.Errorf("failed to unmarshal intent: element %d is null", i)
+		}
+	}
+
+	return intents, nil
+}
+
+// FromJSONLenient creates an intent from an LLM reply that may wrap the JSON
+// in a markdown code fence or surround it with prose. Data that parses as-is
+// is handled like FromJSON; otherwise the first balanced {...} object in the
+// data is parsed.
+func FromJSONLenient(data string) (*Intent, error) {
+	if intent, err := FromJSON(data); err == nil {
+		return intent, nil
+	}
+
+	object, ok := firstJSONObject(data)
+	if !ok {
+		return nil, fmt.Errorf("failed to unmarshal intent: no JSON object found")
+	}
+	return FromJSON(object)
+}
+
+// firstJSONObject returns the first balanced {...} span in data. Braces inside
+// JSON strings are ignored.
+func firstJSONObject(data string) (string, bool) {
+	start := strings.IndexByte(data, '{')
+	if start < 0 {
+		return "", false
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return data[start : i+1], true
+			}
+		}
 	}
-	return &intent, nil
+	return "", false
 }
 
 // NormalizeText cleans and normalizes input text for better processing
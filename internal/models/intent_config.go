@@ -2,52 +2,404 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // IntentConfig represents a configurable intent recognition system
 type IntentConfig struct {
-	Domain     string                   `json:"domain"`     // e.g., "personal_assistant", "customer_support"
-	Version    string                   `json:"version"`    // Config version
-	Intents    map[string]IntentPattern `json:"intents"`    // Intent definitions
-	Entities   map[string]EntityPattern `json:"entities"`   // Entity extraction patterns
-	Synonyms   map[string][]string      `json:"synonyms"`   // Word synonyms for better matching
-	Confidence map[string]float64       `json:"confidence"` // Confidence thresholds per intent
+	Domain            string                   `json:"domain" yaml:"domain"`                                             // e.g., "personal_assistant", "customer_support"
+	Version           string                   `json:"version" yaml:"version"`                                           // Config version
+	Intents           map[string]IntentPattern `json:"intents" yaml:"intents"`                                           // Intent definitions
+	Entities          map[string]EntityPattern `json:"entities" yaml:"entities"`                                         // Entity extraction patterns
+	Synonyms          map[string][]string      `json:"synonyms" yaml:"synonyms"`                                         // Word synonyms for better matching
+	SynonymDepth      int                      `json:"synonym_depth,omitempty" yaml:"synonym_depth,omitempty"`           // Synonym links followed when matching keywords (0 = 1, negative disables)
+	SynonymsFile      string                   `json:"synonyms_file,omitempty" yaml:"synonyms_file,omitempty"`           // JSON or YAML file of extra synonyms, relative to this config
+	Confidence        map[string]float64       `json:"confidence" yaml:"confidence"`                                     // Confidence thresholds per intent
+	DefaultConfidence float64                  `json:"default_confidence,omitempty" yaml:"default_confidence,omitempty"` // Threshold for intents not listed in Confidence (default 0.5)
+	Honorifics        []string                 `json:"honorifics,omitempty" yaml:"honorifics,omitempty"`                 // Titles stripped from names (default: Mr, Mrs, Ms, Dr, ...)
+	Negators          []string                 `json:"negators,omitempty" yaml:"negators,omitempty"`                     // Words that cancel the keywords just after them (default: not, don't, never, cancel, ...)
+	ExactMatch        ExactMatchConfig         `json:"exact_match" yaml:"exact_match"`                                   // Short-circuit on canned phrases/examples
+	Timezone          string                   `json:"timezone,omitempty" yaml:"timezone,omitempty"`                     // IANA zone for times without an explicit zone (default UTC)
+	DefaultRegion     string                   `json:"default_region,omitempty" yaml:"default_region,omitempty"`         // Region phone numbers without a country code are dialled from, e.g. "US"
+	StrictEntities    bool                     `json:"strict_entities,omitempty" yaml:"strict_entities,omitempty"`       // Drop extracted values that fail their type's format check
+	BuiltinEntities   bool                     `json:"builtin_entities,omitempty" yaml:"builtin_entities,omitempty"`     // Extract number and ordinal without defining them as entities
+	FuzzyThreshold    int                      `json:"fuzzy_threshold,omitempty" yaml:"fuzzy_threshold,omitempty"`       // Edits allowed for a misspelled keyword (0 = by length, negative disables)
+	StopWords         []string                 `json:"stop_words,omitempty" yaml:"stop_words,omitempty"`                 // Words ignored when matching, added to DefaultStopWords
+	ReplaceStopWords  bool                     `json:"replace_stop_words,omitempty" yaml:"replace_stop_words,omitempty"` // Use StopWords instead of the defaults, e.g. for non-English domains
+	Aliases           map[string]string        `json:"aliases,omitempty" yaml:"aliases,omitempty"`                       // Deprecated task names reported in place of renamed intents, keyed by intent
+	Abbreviations     map[string]string        `json:"abbreviations,omitempty" yaml:"abbreviations,omitempty"`           // Words written out before classification, e.g. "appt": "appointment"
+	Derived           map[string]string        `json:"derived,omitempty" yaml:"derived,omitempty"`                       // Vars built from other vars with a text/template, e.g. "full_contact": "{{.name}} <{{.email}}>"
+
+	// Inputs that match no intent are reported as FallbackIntent, e.g.
+	// "Clarify", with FallbackFollowUp as its questions, instead of UNKNOWN
+	FallbackIntent   string   `json:"fallback_intent,omitempty" yaml:"fallback_intent,omitempty"`
+	FallbackFollowUp []string `json:"fallback_follow_up,omitempty" yaml:"fallback_follow_up,omitempty"`
+
+	// AmbiguityMargin reports input as unrecognized when the two best intents
+	// score less than this apart (0 disables)
+	AmbiguityMargin float64 `json:"ambiguity_margin,omitempty" yaml:"ambiguity_margin,omitempty"`
+
+	// ScoringWeights replaces the default weights of score components for every intent
+	ScoringWeights *ScoringWeights `json:"scoring_weights,omitempty" yaml:"scoring_weights,omitempty"`
+
+	// MatchAccumulation is how several matching regexes or phrases of an
+	// intent score: first (default) or cumulative
+	MatchAccumulation string `json:"match_accumulation,omitempty" yaml:"match_accumulation,omitempty"`
+
+	// Tokenizer splits text into words for keyword scoring and word
+	// overlap: whitespace (default) or unicode
+	Tokenizer string `json:"tokenizer,omitempty" yaml:"tokenizer,omitempty"`
+
+	// Priority boost, see PriorityBoost. Unset fields take the defaults.
+	PriorityWeight     *float64 `json:"priority_weight,omitempty" yaml:"priority_weight,omitempty"`           // Added per point of priority (default 0.1)
+	MaxPriorityBoost   *float64 `json:"max_priority_boost,omitempty" yaml:"max_priority_boost,omitempty"`     // Largest boost any priority gets (default 0.5)
+	PriorityBoostRatio *float64 `json:"priority_boost_ratio,omitempty" yaml:"priority_boost_ratio,omitempty"` // Share of the threshold the rest of the score must reach first (default 0.5)
+
+	// Confidence calibration, off unless score_calibration is set
+	ScoreCalibration       string  `json:"score_calibration,omitempty" yaml:"score_calibration,omitempty"`             // none (default), softmax or sigmoid
+	CalibrationTemperature float64 `json:"calibration_temperature,omitempty" yaml:"calibration_temperature,omitempty"` // Spread of calibrated confidences; lower is more decisive (default 0.25)
+}
+
+// Score calibrations for IntentConfig.ScoreCalibration
+const (
+	CalibrationNone    = "none"    // Report the raw score, capped at 1
+	CalibrationSoftmax = "softmax" // Report the winner's share of all scores
+	CalibrationSigmoid = "sigmoid" // Report a logistic of the score's distance from its threshold
+)
+
+// Match accumulations for IntentConfig.MatchAccumulation
+const (
+	AccumulationFirst      = "first"      // The first matching regex or phrase scores its weight; more add nothing
+	AccumulationCumulative = "cumulative" // Each further distinct match adds half as much as the one before
+)
+
+// Tokenizers for IntentConfig.Tokenizer
+const (
+	TokenizerWhitespace = "whitespace" // Split at whitespace
+	TokenizerUnicode    = "unicode"    // Split at Unicode word boundaries, dropping punctuation
+)
+
+// DefaultCalibrationTemperature applies when a config doesn't set calibration_temperature
+const DefaultCalibrationTemperature = 0.25
+
+// Default scoring weights, used when neither the intent nor the config sets one
+const (
+	DefaultRegexWeight   = 0.8
+	DefaultPhraseWeight  = 0.6
+	DefaultKeywordWeight = 0.4
+	DefaultOverlapWeight = 0.2
+	DefaultLengthWeight  = 0.1
+)
+
+// Default priority boost settings, used when a config doesn't set them
+const (
+	DefaultPriorityWeight     = 0.1
+	DefaultMaxPriorityBoost   = 0.5
+	DefaultPriorityBoostRatio = 0.5
+)
+
+// PriorityBoost returns what intentName's priority adds to a score of base
+// from its other components: PriorityWeight per point, capped at
+// MaxPriorityBoost, and nothing unless base reaches PriorityBoostRatio of the
+// intent's confidence threshold. Priority settles close calls this way but
+// can't carry an intent the text barely matches.
+func (c *IntentConfig) PriorityBoost(intentName string, base float64) float64 {
+	weight, maxBoost, ratio := DefaultPriorityWeight, DefaultMaxPriorityBoost, DefaultPriorityBoostRatio
+	if c.PriorityWeight != nil {
+		weight = *c.PriorityWeight
+	}
+	if c.MaxPriorityBoost != nil {
+		maxBoost = *c.MaxPriorityBoost
+	}
+	if c.PriorityBoostRatio != nil {
+		ratio = *c.PriorityBoostRatio
+	}
+
+	if base < ratio*c.ConfidenceThreshold(intentName) {
+		return 0
+	}
+	return math.Min(float64(c.Intents[intentName].Priority)*weight, maxBoost)
 }
 
+// ScoringWeights sets how much each kind of match adds to an intent's score.
+// Unset weights fall back to the config's, then to the defaults.
+type ScoringWeights struct {
+	Regex   *float64 `json:"regex,omitempty" yaml:"regex,omitempty"`     // Added when an intent regex matches
+	Phrase  *float64 `json:"phrase,omitempty" yaml:"phrase,omitempty"`   // Added when a phrase appears in the text
+	Keyword *float64 `json:"keyword,omitempty" yaml:"keyword,omitempty"` // Per exact keyword match, averaged over the keywords; synonyms and misspellings score less
+	Overlap *float64 `json:"overlap,omitempty" yaml:"overlap,omitempty"` // Multiplies the share of the intent's words found in the text
+	Length  *float64 `json:"length,omitempty" yaml:"length,omitempty"`   // Added for texts over 20 characters
+}
+
+// Weights is a resolved set of scoring weights
+type Weights struct {
+	Regex, Phrase, Keyword, Overlap, Length float64
+}
+
+// ScoringWeightsFor resolves the weights used to score intentName: the
+// intent's own, then the config's, then the defaults
+func (c *IntentConfig) ScoringWeightsFor(intentName string) Weights {
+	weights := Weights{
+		Regex:   DefaultRegexWeight,
+		Phrase:  DefaultPhraseWeight,
+		Keyword: DefaultKeywordWeight,
+		Overlap: DefaultOverlapWeight,
+		Length:  DefaultLengthWeight,
+	}
+	weights.apply(c.ScoringWeights)
+	weights.apply(c.Intents[intentName].ScoringWeights)
+	return weights
+}
+
+// apply overrides the weights that overrides sets
+func (w *Weights) apply(overrides *ScoringWeights) {
+	if overrides == nil {
+		return
+	}
+	if overrides.Regex != nil {
+		w.Regex = *overrides.Regex
+	}
+	if overrides.Phrase != nil {
+		w.Phrase = *overrides.Phrase
+	}
+	if overrides.Keyword != nil {
+		w.Keyword = *overrides.Keyword
+	}
+	if overrides.Overlap != nil {
+		w.Overlap = *overrides.Overlap
+	}
+	if overrides.Length != nil {
+		w.Length = *overrides.Length
+	}
+}
+
+// validate reports negative weights, naming them after their JSON fields
+func (w *ScoringWeights) validate() []error {
+	if w == nil {
+		return nil
+	}
+	var errs []error
+	for _, field := range []struct {
+		name  string
+		value *float64
+	}{
+		{"regex", w.Regex}, {"phrase", w.Phrase}, {"keyword", w.Keyword}, {"overlap", w.Overlap}, {"length", w.Length},
+	} {
+		if field.value != nil && *field.value < 0 {
+			errs = append(errs, fmt.Errorf("scoring_weights.%s must not be negative, got %v", field.name, *field.value))
+		}
+	}
+	return errs
+}
+
+// ExactMatchConfig controls how inputs that equal a configured phrase or example
+// are classified. A match skips scoring and assigns Confidence to that intent.
+type ExactMatchConfig struct {
+	Disabled    bool    `json:"disabled,omitempty" yaml:"disabled,omitempty"`         // Turn exact-match short-circuiting off
+	MaxDistance int     `json:"max_distance,omitempty" yaml:"max_distance,omitempty"` // Edits allowed for a near-exact match (0 = exact only)
+	Confidence  float64 `json:"confidence,omitempty" yaml:"confidence,omitempty"`     // Confidence for a match (default 0.99)
+}
+
+// FallbackConfidenceThreshold applies when neither the intent nor the config sets a threshold
+const FallbackConfidenceThreshold = 0.5
+
+// ConfidenceThreshold returns the minimum score for intentName. A per-intent
+// threshold overrides DefaultConfidence, which overrides FallbackConfidenceThreshold.
+func (c *IntentConfig) ConfidenceThreshold(intentName string) float64 {
+	if threshold := c.Confidence[intentName]; threshold != 0 {
+		return threshold
+	}
+	if c.DefaultConfidence != 0 {
+		return c.DefaultConfidence
+	}
+	return FallbackConfidenceThreshold
+}
+
+// TaskName returns the task reported for intentName: its alias if it has
+// one, else the intent name itself
+func (c *IntentConfig) TaskName(intentName string) string {
+	if alias, exists := c.Aliases[intentName]; exists {
+		return alias
+	}
+	return intentName
+}
+
+// IntentName returns the intent a reported task stands for, undoing TaskName
+func (c *IntentConfig) IntentName(task string) string {
+	for intentName, alias := range c.Aliases {
+		if alias == task {
+			return intentName
+		}
+	}
+	return task
+}
+
+// DefaultHonorifics are the titles recognized before names when a config doesn't list its own
+var DefaultHonorifics = []string{"Mr", "Mrs", "Ms", "Miss", "Mx", "Dr", "Prof", "Sir"}
+
+// DefaultStopWords are the English words ignored when matching intents
+var DefaultStopWords = []string{
+	"the", "a", "an", "and", "or", "but",
+	"in", "on", "at", "to", "for", "of",
+	"with", "by", "from", "up", "about",
+	"into", "through", "during", "before", "after",
+	"above", "below", "between", "among",
+	"is", "are", "was", "were", "be", "been",
+	"have", "has", "had", "do", "does", "did",
+	"will", "would", "could", "should", "may", "might",
+}
+
+// DefaultNegators are the negating words recognized when a config doesn't list its own
+var DefaultNegators = []string{"not", "don't", "doesn't", "didn't", "won't", "never", "cancel"}
+
 // IntentPattern defines how to recognize a specific intent
 type IntentPattern struct {
-	Description string   `json:"description"` // Human-readable description
-	Keywords    []string `json:"keywords"`    // Primary keywords
-	Phrases     []string `json:"phrases"`     // Common phrases
-	Regex       []string `json:"regex"`       // Regex patterns
-	Priority    int      `json:"priority"`    // Higher priority = more specific
-	Variables   []string `json:"variables"`   // Expected variables to extract
-	Required    []string `json:"required"`    // Required variables (will prompt if missing)
-	Examples    []string `json:"examples"`    // Training examples
-	FollowUp    []string `json:"follow_up"`   // Follow-up questions for missing info
+	Description string   `json:"description" yaml:"description"` // Human-readable description
+	Keywords    []string `json:"keywords" yaml:"keywords"`       // Primary keywords
+	Phrases     []string `json:"phrases" yaml:"phrases"`         // Common phrases
+	Regex       []string `json:"regex" yaml:"regex"`             // Regex patterns
+	Priority    int      `json:"priority" yaml:"priority"`       // Higher priority = more specific
+	Variables   []string `json:"variables" yaml:"variables"`     // Expected variables to extract
+	Required    []string `json:"required" yaml:"required"`       // Required variables (will prompt if missing)
+	Examples    []string `json:"examples" yaml:"examples"`       // Training examples
+	FollowUp    []string `json:"follow_up" yaml:"follow_up"`     // Follow-up questions for missing info
+	// DisplayName is how follow-up questions refer to the intent, e.g.
+	// "event" in "When should this event be scheduled?". When empty the
+	// intent name is split into lowercase words.
+	DisplayName string `json:"display_name,omitempty" yaml:"display_name,omitempty"`
+	// RequiredOneOf lists groups of variables where any one is enough, e.g.
+	// [["email", "phone"]] for a contact reachable either way
+	RequiredOneOf [][]string `json:"required_one_of,omitempty" yaml:"required_one_of,omitempty"`
+	// Defaults fill variables that were not extracted. Precedence is
+	// extracted value > default > reported as missing.
+	Defaults map[string]interface{} `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	// Webhook receives a POST with the intent whenever this intent is detected
+	Webhook string `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	// Enabled set to false stops the intent from being classified (default true)
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// ScoringWeights overrides the config's scoring weights for this intent
+	ScoringWeights *ScoringWeights `json:"scoring_weights,omitempty" yaml:"scoring_weights,omitempty"`
+	// Entities limits extraction to these entities when the intent is
+	// detected; when empty every entity is attempted
+	Entities []string `json:"entities,omitempty" yaml:"entities,omitempty"`
+}
+
+// IsEnabled reports whether the intent takes part in classification
+func (p IntentPattern) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// AllowsEntity reports whether the entity is extracted for the intent
+func (p IntentPattern) AllowsEntity(entityName string) bool {
+	return len(p.Entities) == 0 || slices.Contains(p.Entities, entityName)
 }
 
 // EntityPattern defines how to extract specific entities
 type EntityPattern struct {
-	Type        string   `json:"type"`        // Entity type (name, email, phone, etc.)
-	Description string   `json:"description"` // Human-readable description
-	Regex       []string `json:"regex"`       // Regex patterns for extraction
-	Keywords    []string `json:"keywords"`    // Keywords that indicate this entity
-	Examples    []string `json:"examples"`    // Example values
+	Type        string   `json:"type" yaml:"type"`                                 // Entity type (name, email, phone, etc.)
+	Description string   `json:"description" yaml:"description"`                   // Human-readable description
+	Regex       []string `json:"regex" yaml:"regex"`                               // Regex patterns for extraction
+	Keywords    []string `json:"keywords" yaml:"keywords"`                         // Keywords that indicate this entity
+	Examples    []string `json:"examples" yaml:"examples"`                         // Example values
+	Extraction  string   `json:"extraction,omitempty" yaml:"extraction,omitempty"` // Extraction mode (default or "rest_of_input")
+	Multiple    bool     `json:"multiple,omitempty" yaml:"multiple,omitempty"`     // Capture every regex match; more than one is returned as a list
+	Resolve     string   `json:"resolve,omitempty" yaml:"resolve,omitempty"`       // "date" adds <name>_resolved as YYYY-MM-DD (date entities only)
+	Normalize   bool     `json:"normalize,omitempty" yaml:"normalize,omitempty"`   // Rewrite values with the normalizer registered for Type
+	DependsOn   []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"` // Entities that must also be extracted for this one to be kept
+	Sensitive   bool     `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`   // Mask the values in logged bodies (LOG_BODIES)
+
+	// AllowedValues, when set, are the only values kept for the entity, e.g.
+	// "low", "medium" and "high". Values match case-insensitively or through
+	// synonyms and are reported in their canonical spelling from this list.
+	AllowedValues []string `json:"allowed_values,omitempty" yaml:"allowed_values,omitempty"`
+
+	// How the value is found: the methods in ExtractionOrder are tried in
+	// turn (default regex, then keyword) until one yields a value. With
+	// MethodConfidence set, more trusted methods go first; unlisted ones
+	// count as 0 and ties keep ExtractionOrder.
+	ExtractionOrder  []string           `json:"extraction_order,omitempty" yaml:"extraction_order,omitempty"`
+	MethodConfidence map[string]float64 `json:"method_confidence,omitempty" yaml:"method_confidence,omitempty"`
 }
 
-// LoadIntentConfig loads intent configuration from a JSON file
-func LoadIntentConfig(filepath string) (*IntentConfig, error) {
-	data, err := os.ReadFile(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+// Entity extraction methods, for EntityPattern.ExtractionOrder and MethodConfidence
+const (
+	ExtractionMethodRegex   = "regex"   // The entity's regex patterns
+	ExtractionMethodKeyword = "keyword" // Heuristics around the entity's keywords and type
+)
+
+// ExtractionMethods returns the methods to find the entity's value with, in
+// the order they're tried
+func (e EntityPattern) ExtractionMethods() []string {
+	methods := []string{ExtractionMethodRegex, ExtractionMethodKeyword}
+	if len(e.ExtractionOrder) > 0 {
+		methods = append([]string(nil), e.ExtractionOrder...)
+	}
+	if len(e.MethodConfidence) > 0 {
+		sort.SliceStable(methods, func(i, j int) bool {
+			return e.MethodConfidence[methods[i]] > e.MethodConfidence[methods[j]]
+		})
 	}
+	return methods
+}
 
-	var config IntentConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+// validateExtractionMethods reports unknown or repeated methods in
+// extraction_order and method_confidence, and confidences outside [0, 1]
+func (e EntityPattern) validateExtractionMethods() []error {
+	known := func(method string) bool {
+		return method == ExtractionMethodRegex || method == ExtractionMethodKeyword
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for _, method := range e.ExtractionOrder {
+		switch {
+		case !known(method):
+			errs = append(errs, fmt.Errorf("unknown extraction_order method %q, want regex or keyword", method))
+		case seen[method]:
+			errs = append(errs, fmt.Errorf("extraction_order lists %q more than once", method))
+		}
+		seen[method] = true
+	}
+	for _, method := range sortedKeys(e.MethodConfidence) {
+		if !known(method) {
+			errs = append(errs, fmt.Errorf("unknown method_confidence method %q, want regex or keyword", method))
+		}
+		if confidence := e.MethodConfidence[method]; confidence < 0 || confidence > 1 {
+			errs = append(errs, fmt.Errorf("method_confidence.%s must be between 0 and 1, got %v", method, confidence))
+		}
+	}
+	return errs
+}
+
+// Entity extraction modes
+const (
+	// ExtractionDefault uses regex patterns followed by keyword heuristics
+	ExtractionDefault = ""
+	// ExtractionRestOfInput captures everything after the first trigger keyword verbatim
+	ExtractionRestOfInput = "rest_of_input"
+)
+
+// ResolveDate resolves relative dates such as "tomorrow" to calendar days
+const ResolveDate = "date"
+
+// LoadIntentConfig loads intent configuration from a YAML (.yaml/.yml) or JSON file.
+// Files with any other extension are parsed as JSON. A synonyms_file is read
+// and merged into Synonyms before the config is validated. An http(s) URL is
+// fetched with DefaultConfigFetchTimeout.
+func LoadIntentConfig(path string) (*IntentConfig, error) {
+	config, err := ReadIntentConfig(path)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate configuration
@@ -55,86 +407,370 @@ func LoadIntentConfig(filepath string) (*IntentConfig, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	return config, nil
+}
+
+// ReadIntentConfig reads a config file like LoadIntentConfig without
+// validating it
+func ReadIntentConfig(path string) (*IntentConfig, error) {
+	if IsConfigURL(path) {
+		return FetchIntentConfig(path, DefaultConfigFetchTimeout)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	format := ConfigFormatJSON
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		format = ConfigFormatYAML
+	}
+	config, err := ParseIntentConfig(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SynonymsFile != "" {
+		synonymsPath := config.SynonymsFile
+		if !filepath.IsAbs(synonymsPath) {
+			synonymsPath = filepath.Join(filepath.Dir(path), synonymsPath)
+		}
+		synonyms, err := loadSynonyms(synonymsPath)
+		if err != nil {
+			return nil, err
+		}
+		config.mergeSynonyms(synonyms)
+	}
+
+	return config, nil
+}
+
+// Config formats accepted by ParseIntentConfig
+const (
+	ConfigFormatJSON = "json"
+	ConfigFormatYAML = "yaml"
+)
+
+// ParseIntentConfig decodes a config in the given format without validating
+// it. A synonyms_file is not read, since it is relative to a config file.
+func ParseIntentConfig(data []byte, format string) (*IntentConfig, error) {
+	var config IntentConfig
+	switch format {
+	case ConfigFormatYAML:
+		// yaml.v3 errors include the offending line number
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML config file: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
 	return &config, nil
 }
 
-// Validate ensures the configuration is valid
+// LoadIntentConfigs loads one intent config per language. When path is a
+// directory, each .json, .yaml or .yml file in it is named after its language,
+// e.g. en.json and es.yaml. A single file is loaded as defaultLanguage. The
+// default language must always be present. A URL is fetched as a single file.
+func LoadIntentConfigs(path, defaultLanguage string) (map[string]*IntentConfig, error) {
+	if IsConfigURL(path) {
+		config, err := LoadIntentConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*IntentConfig{defaultLanguage: config}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if !info.IsDir() {
+		config, err := LoadIntentConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*IntentConfig{defaultLanguage: config}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+
+	paths := make(map[string]string)
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		language := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		if _, exists := paths[language]; exists {
+			return nil, fmt.Errorf("more than one config file for language %q in %s", language, path)
+		}
+		paths[language] = filepath.Join(path, entry.Name())
+	}
+	if _, exists := paths[defaultLanguage]; !exists {
+		return nil, fmt.Errorf("no config for default language %q in %s", defaultLanguage, path)
+	}
+
+	configs := make(map[string]*IntentConfig, len(paths))
+	for language, languagePath := range paths {
+		config, err := LoadIntentConfig(languagePath)
+		if err != nil {
+			return nil, fmt.Errorf("language %s: %w", language, err)
+		}
+		configs[language] = config
+	}
+	return configs, nil
+}
+
+// loadSynonyms reads a word -> synonyms map from a YAML (.yaml/.yml) or JSON file
+func loadSynonyms(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read synonyms file: %w", err)
+	}
+
+	var synonyms map[string][]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &synonyms)
+	default:
+		err = json.Unmarshal(data, &synonyms)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse synonyms file %s: %w", path, err)
+	}
+
+	return synonyms, nil
+}
+
+// mergeSynonyms adds synonyms loaded from a file to the inline ones. Inline
+// entries win: a word listed inline keeps its inline synonyms, and a synonym
+// already listed inline for another word is dropped from the file's entry.
+func (c *IntentConfig) mergeSynonyms(synonyms map[string][]string) {
+	if len(synonyms) == 0 {
+		return
+	}
+
+	inline := make(map[string]bool)
+	for _, words := range c.Synonyms {
+		for _, synonym := range words {
+			inline[synonym] = true
+		}
+	}
+
+	merged := make(map[string][]string, len(c.Synonyms)+len(synonyms))
+	for word, words := range synonyms {
+		if _, exists := c.Synonyms[word]; exists {
+			continue
+		}
+		var kept []string
+		for _, synonym := range words {
+			if !inline[synonym] {
+				kept = append(kept, synonym)
+			}
+		}
+		if len(kept) > 0 {
+			merged[word] = kept
+		}
+	}
+	for word, words := range c.Synonyms {
+		merged[word] = words
+	}
+	c.Synonyms = merged
+}
+
+// Validate ensures the configuration is valid. Every problem found is
+// reported, joined into one error.
 func (c *IntentConfig) Validate() error {
+	var errs []error
+
 	if c.Domain == "" {
-		return fmt.Errorf("domain is required")
+		errs = append(errs, fmt.Errorf("domain is required"))
 	}
 
 	if len(c.Intents) == 0 {
-		return fmt.Errorf("at least one intent must be defined")
+		errs = append(errs, fmt.Errorf("at least one intent must be defined"))
 	}
 
 	// Validate each intent
-	for intentName, intent := range c.Intents {
+	for _, intentName := range sortedKeys(c.Intents) {
+		intent := c.Intents[intentName]
 		if intent.Description == "" {
-			return fmt.Errorf("intent %s: description is required", intentName)
+			errs = append(errs, fmt.Errorf("intent %s: description is required", intentName))
 		}
 		if len(intent.Keywords) == 0 && len(intent.Phrases) == 0 && len(intent.Regex) == 0 {
-			return fmt.Errorf("intent %s: must have at least keywords, phrases, or regex", intentName)
-		}
-	}
-
-	return nil
-}
-
-// GetDefaultConfig returns a default configuration for personal assistant
-func GetDefaultConfig() *IntentConfig {
-	return &IntentConfig{
-		Domain:  "personal_assistant",
-		Version: "1.0.0",
-		Intents: map[string]IntentPattern{
-			"CREATE_CONTACT": {
-				Description: "Create a new contact",
-				Keywords:    []string{"create", "add", "new", "save"},
-				Phrases:     []string{"create contact", "add contact", "new contact", "save contact"},
-				Priority:    10,
-				Variables:   []string{"name", "email", "phone"},
-				Examples:    []string{"create a new contact named bob", "add contact alice with email alice@example.com"},
-			},
-			"FIND_CONTACT": {
-				Description: "Find or search for a contact",
-				Keywords:    []string{"find", "search", "look", "get"},
-				Phrases:     []string{"find contact", "search contact", "look up contact"},
-				Priority:    8,
-				Variables:   []string{"name"},
-				Examples:    []string{"find contact bob", "search for alice"},
-			},
-		},
-		Entities: map[string]EntityPattern{
-			"name": {
-				Type:        "name",
-				Description: "Person's name",
-				Regex:       []string{`(?i)(?:named\s+|name\s+is\s+|call(?:ed)?\s+)([A-Z][a-z]+(?:\s+[A-Z][a-z]+)*)`},
-				Keywords:    []string{"named", "name", "called"},
-			},
-			"email": {
-				Type:        "email",
-				Description: "Email address",
-				Regex:       []string{`(?i)([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})`},
-				Keywords:    []string{"email", "e-mail", "mail"},
-			},
-			"phone": {
-				Type:        "phone",
-				Description: "Phone number",
-				Regex:       []string{`(?i)(\+\d{1,3}[-.\s]?)?\(?\d{3}\)?[-.\s]?\d{3}[-.\s]?\d{4}`},
-				Keywords:    []string{"phone", "telephone", "mobile", "cell"},
-			},
-		},
-		Synonyms: map[string][]string{
-			"create": {"add", "new", "save", "store", "insert"},
-			"find":   {"search", "look", "locate", "get"},
-			"update": {"change", "modify", "edit", "alter"},
-			"delete": {"remove", "drop", "erase", "clear"},
-		},
-		Confidence: map[string]float64{
-			"CREATE_CONTACT": 0.7,
-			"FIND_CONTACT":   0.6,
-			"UPDATE_CONTACT": 0.6,
-			"DELETE_CONTACT": 0.6,
-		},
+			errs = append(errs, fmt.Errorf("intent %s: must have at least keywords, phrases, or regex", intentName))
+		}
+		for i, group := range intent.RequiredOneOf {
+			if len(group) == 0 {
+				errs = append(errs, fmt.Errorf("intent %s: required_one_of group %d is empty", intentName, i))
+			}
+		}
+		for _, err := range intent.ScoringWeights.validate() {
+			errs = append(errs, fmt.Errorf("intent %s: %w", intentName, err))
+		}
+		for _, entityName := range intent.Entities {
+			if _, exists := c.Entities[entityName]; !exists {
+				errs = append(errs, fmt.Errorf("intent %s: entities names unknown entity %q", intentName, entityName))
+			}
+		}
+	}
+
+	// Each alias must stand for exactly one intent
+	aliasedBy := make(map[string]string, len(c.Aliases))
+	for _, intentName := range sortedKeys(c.Aliases) {
+		alias := c.Aliases[intentName]
+		_, known := c.Intents[intentName]
+		_, taken := c.Intents[alias]
+		switch {
+		case !known:
+			errs = append(errs, fmt.Errorf("alias %q: unknown intent %s", alias, intentName))
+		case alias == "":
+			errs = append(errs, fmt.Errorf("intent %s: alias must not be empty", intentName))
+		case taken:
+			errs = append(errs, fmt.Errorf("intent %s: alias %q is the name of another intent", intentName, alias))
+		case aliasedBy[alias] != "":
+			errs = append(errs, fmt.Errorf("intent %s: alias %q is already used by intent %s", intentName, alias, aliasedBy[alias]))
+		default:
+			aliasedBy[alias] = intentName
+		}
+	}
+
+	for _, abbreviation := range sortedKeys(c.Abbreviations) {
+		switch {
+		case strings.TrimSpace(abbreviation) == "":
+			errs = append(errs, fmt.Errorf("abbreviations must not have an empty key"))
+		case strings.TrimSpace(c.Abbreviations[abbreviation]) == "":
+			errs = append(errs, fmt.Errorf("abbreviation %q must have an expansion", abbreviation))
+		}
+	}
+
+	// The fallback stands for "nothing matched", so it can't be an intent
+	// that matches
+	if _, exists := c.Intents[c.FallbackIntent]; exists {
+		errs = append(errs, fmt.Errorf("fallback_intent %s must not be the name of an intent", c.FallbackIntent))
+	}
+	if c.FallbackIntent == "" && len(c.FallbackFollowUp) > 0 {
+		errs = append(errs, fmt.Errorf("fallback_follow_up requires fallback_intent"))
+	}
+
+	errs = append(errs, c.ScoringWeights.validate()...)
+
+	if c.DefaultConfidence < 0 || c.DefaultConfidence > 1 {
+		errs = append(errs, fmt.Errorf("default_confidence must be between 0 and 1, got %v", c.DefaultConfidence))
+	}
+
+	for _, field := range []struct {
+		name  string
+		value *float64
+	}{
+		{"priority_weight", c.PriorityWeight}, {"max_priority_boost", c.MaxPriorityBoost}, {"priority_boost_ratio", c.PriorityBoostRatio},
+	} {
+		if field.value != nil && *field.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %v", field.name, *field.value))
+		}
+	}
+
+	if c.ExactMatch.MaxDistance < 0 {
+		errs = append(errs, fmt.Errorf("exact_match.max_distance must not be negative, got %d", c.ExactMatch.MaxDistance))
+	}
+	if c.ExactMatch.Confidence < 0 || c.ExactMatch.Confidence > 1 {
+		errs = append(errs, fmt.Errorf("exact_match.confidence must be between 0 and 1, got %v", c.ExactMatch.Confidence))
+	}
+
+	if c.AmbiguityMargin < 0 {
+		errs = append(errs, fmt.Errorf("ambiguity_margin must not be negative, got %v", c.AmbiguityMargin))
+	}
+
+	switch c.MatchAccumulation {
+	case "", AccumulationFirst, AccumulationCumulative:
+	default:
+		errs = append(errs, fmt.Errorf("unknown match_accumulation %q, want first or cumulative", c.MatchAccumulation))
+	}
+
+	switch c.Tokenizer {
+	case "", TokenizerWhitespace, TokenizerUnicode:
+	default:
+		errs = append(errs, fmt.Errorf("unknown tokenizer %q, want whitespace or unicode", c.Tokenizer))
+	}
+
+	switch c.ScoreCalibration {
+	case "", CalibrationNone, CalibrationSoftmax, CalibrationSigmoid:
+	default:
+		errs = append(errs, fmt.Errorf("unknown score_calibration %q, want none, softmax or sigmoid", c.ScoreCalibration))
+	}
+	if c.CalibrationTemperature < 0 {
+		errs = append(errs, fmt.Errorf("calibration_temperature must not be negative, got %v", c.CalibrationTemperature))
+	}
+
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err))
+		}
+	}
+
+	// Validate each entity
+	for _, entityName := range sortedKeys(c.Entities) {
+		entity := c.Entities[entityName]
+		switch entity.Extraction {
+		case ExtractionDefault:
+		case ExtractionRestOfInput:
+			if len(entity.Keywords) == 0 {
+				errs = append(errs, fmt.Errorf("entity %s: rest_of_input extraction requires at least one keyword", entityName))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("entity %s: unknown extraction mode %q", entityName, entity.Extraction))
+		}
+
+		switch entity.Resolve {
+		case "":
+		case ResolveDate:
+			if entity.Type != "date" {
+				errs = append(errs, fmt.Errorf("entity %s: resolve %q requires type \"date\", got %q", entityName, entity.Resolve, entity.Type))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("entity %s: unknown resolve mode %q", entityName, entity.Resolve))
+		}
+
+		for _, dependency := range entity.DependsOn {
+			if dependency == entityName {
+				errs = append(errs, fmt.Errorf("entity %s: depends_on names the entity itself", entityName))
+			} else if _, exists := c.Entities[dependency]; !exists {
+				errs = append(errs, fmt.Errorf("entity %s: depends_on names unknown entity %q", entityName, dependency))
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, value := range entity.AllowedValues {
+			folded := strings.ToLower(strings.TrimSpace(value))
+			if folded == "" {
+				errs = append(errs, fmt.Errorf("entity %s: allowed_values has an empty value", entityName))
+			} else if seen[folded] {
+				errs = append(errs, fmt.Errorf("entity %s: allowed_values lists %q more than once", entityName, value))
+			}
+			seen[folded] = true
+		}
+
+		for _, err := range entity.validateExtractionMethods() {
+			errs = append(errs, fmt.Errorf("entity %s: %w", entityName, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortedKeys returns a map's keys in order, so problems are reported the same
+// way every time
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
 }
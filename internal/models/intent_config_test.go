@@ -0,0 +1,583 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlTestConfig = `domain: test
+version: "1.0"
+intents:
+  CreateNote:
+    description: Create a note
+    keywords: [note, remember]
+    phrases:
+      - note that
+    priority: 8
+    variables: [content, tag]
+    required: [content]
+    follow_up:
+      - What should the note say?
+    defaults:
+      tag: inbox
+entities:
+  content:
+    type: text
+    keywords: [note that]
+    extraction: rest_of_input
+exact_match:
+  max_distance: 1
+timezone: Europe/Berlin
+`
+
+const jsonTestConfig = `{
+  "domain": "test",
+  "version": "1.0",
+  "intents": {
+    "CreateNote": {
+      "description": "Create a note",
+      "keywords": ["note", "remember"],
+      "phrases": ["note that"],
+      "priority": 8,
+      "variables": ["content", "tag"],
+      "required": ["content"],
+      "follow_up": ["What should the note say?"],
+      "defaults": {"tag": "inbox"}
+    }
+  },
+  "entities": {
+    "content": {
+      "type": "text",
+      "keywords": ["note that"],
+      "extraction": "rest_of_input"
+    }
+  },
+  "exact_match": {"max_distance": 1},
+  "timezone": "Europe/Berlin"
+}`
+
+// writeTestConfig writes content to a file with the given name in a temp dir
+func writeTestConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	return path
+}
+
+func TestLoadIntentConfig_YAMLMatchesJSON(t *testing.T) {
+	fromJSON, err := LoadIntentConfig(writeTestConfig(t, "config.json", jsonTestConfig))
+	if err != nil {
+		t.Fatalf("LoadIntentConfig(json) error = %v", err)
+	}
+
+	for _, name := range []string{"config.yaml", "config.YML"} {
+		fromYAML, err := LoadIntentConfig(writeTestConfig(t, name, yamlTestConfig))
+		if err != nil {
+			t.Fatalf("LoadIntentConfig(%s) error = %v", name, err)
+		}
+		if !reflect.DeepEqual(fromYAML, fromJSON) {
+			t.Errorf("%s = %+v, want %+v", name, fromYAML, fromJSON)
+		}
+	}
+}
+
+func TestLoadIntentConfig_UnknownExtensionIsJSON(t *testing.T) {
+	if _, err := LoadIntentConfig(writeTestConfig(t, "config.conf", jsonTestConfig)); err != nil {
+		t.Errorf("LoadIntentConfig(.conf with JSON) error = %v", err)
+	}
+	if _, err := LoadIntentConfig(writeTestConfig(t, "config.conf", yamlTestConfig)); err == nil {
+		t.Error("LoadIntentConfig(.conf with YAML) error = nil, want JSON parse error")
+	}
+}
+
+func TestLoadIntentConfig_YAMLErrors(t *testing.T) {
+	t.Run("malformed yaml reports line", func(t *testing.T) {
+		malformed := "domain: test\nintents:\n  CreateNote:\n    keywords: [note\n    priority: 8\n"
+		_, err := LoadIntentConfig(writeTestConfig(t, "bad.yaml", malformed))
+		if err == nil {
+			t.Fatal("LoadIntentConfig() error = nil, want parse error")
+		}
+		if !strings.Contains(err.Error(), "line ") {
+			t.Errorf("error = %q, want a line number", err)
+		}
+	})
+
+	t.Run("wrong type reports line", func(t *testing.T) {
+		wrongType := "domain: test\nintents:\n  CreateNote:\n    priority: high\n"
+		_, err := LoadIntentConfig(writeTestConfig(t, "bad.yml", wrongType))
+		if err == nil || !strings.Contains(err.Error(), "line 4") {
+			t.Errorf("error = %v, want a mention of line 4", err)
+		}
+	})
+
+	t.Run("validation runs for yaml", func(t *testing.T) {
+		invalid := strings.Replace(yamlTestConfig, "timezone: Europe/Berlin", "timezone: Mars/Olympus", 1)
+		_, err := LoadIntentConfig(writeTestConfig(t, "invalid.yaml", invalid))
+		if err == nil || !strings.Contains(err.Error(), "invalid config") {
+			t.Errorf("error = %v, want a validation error", err)
+		}
+	})
+}
+
+// synonymsTestConfig references a synonyms file and lists "add" inline
+const synonymsTestConfig = `{
+  "domain": "test",
+  "synonyms_file": %q,
+  "synonyms": {"add": ["insert"]},
+  "intents": {
+    "CreateNote": {"description": "Create a note", "keywords": ["note"]}
+  }
+}`
+
+func TestLoadIntentConfig_SynonymsFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "shared"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	files := map[string]string{
+		"shared/synonyms.json": `{"add": ["append"], "remove": ["delete", "insert"], "note": ["memo"]}`,
+		"shared/synonyms.yaml": "add: [append]\nremove: [delete, insert]\nnote: [memo]\n",
+		"shared/bad.json":      `{"note": "memo"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	// Inline synonyms win, including "insert" listed under another word
+	want := map[string][]string{
+		"add":    {"insert"},
+		"remove": {"delete"},
+		"note":   {"memo"},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{name: "relative json", file: "shared/synonyms.json"},
+		{name: "relative yaml", file: "shared/synonyms.yaml"},
+		{name: "absolute", file: filepath.Join(dir, "shared", "synonyms.json")},
+		{name: "missing", file: "shared/missing.json", wantErr: "failed to read synonyms file"},
+		{name: "malformed", file: "shared/bad.json", wantErr: "failed to parse synonyms file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(path, []byte(fmt.Sprintf(synonymsTestConfig, tt.file)), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			config, err := LoadIntentConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadIntentConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(config.Synonyms, want) {
+				t.Errorf("Synonyms = %v, want %v", config.Synonyms, want)
+			}
+		})
+	}
+}
+
+func TestLoadIntentConfigs(t *testing.T) {
+	// writeDir creates a directory holding the named files, each with the YAML test config
+	writeDir := func(t *testing.T, names ...string) string {
+		dir := t.TempDir()
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(yamlTestConfig), 0o644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+		return dir
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		configs, err := LoadIntentConfigs(writeDir(t, "en.yaml", "ES.yml", "README.md"), "en")
+		if err != nil {
+			t.Fatalf("LoadIntentConfigs() error = %v", err)
+		}
+		if len(configs) != 2 || configs["en"] == nil || configs["es"] == nil {
+			t.Errorf("languages = %v, want en and es", configs)
+		}
+	})
+
+	t.Run("single file is the default language", func(t *testing.T) {
+		dir := writeDir(t, "intents.yaml")
+		configs, err := LoadIntentConfigs(filepath.Join(dir, "intents.yaml"), "es")
+		if err != nil {
+			t.Fatalf("LoadIntentConfigs() error = %v", err)
+		}
+		if len(configs) != 1 || configs["es"] == nil {
+			t.Errorf("languages = %v, want only es", configs)
+		}
+	})
+
+	errorTests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "missing default", files: []string{"es.yaml"}, want: `no config for default language "en"`},
+		{name: "duplicate language", files: []string{"en.json", "en.yaml"}, want: `more than one config file for language "en"`},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadIntentConfigs(writeDir(t, tt.files...), "en")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadIntentConfigs() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntentConfig_ConfidenceThreshold(t *testing.T) {
+	tests := []struct {
+		name              string
+		perIntent         map[string]float64
+		defaultConfidence float64
+		want              float64
+	}{
+		{"hardcoded fallback", nil, 0, FallbackConfidenceThreshold},
+		{"domain default", nil, 0.7, 0.7},
+		{"per-intent overrides domain default", map[string]float64{"CreateNote": 0.9}, 0.7, 0.9},
+		{"other intents use domain default", map[string]float64{"DeleteNote": 0.9}, 0.3, 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &IntentConfig{Confidence: tt.perIntent, DefaultConfidence: tt.defaultConfidence}
+			if got := config.ConfidenceThreshold("CreateNote"); got != tt.want {
+				t.Errorf("ConfidenceThreshold() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntentConfig_ValidateDefaultConfidence(t *testing.T) {
+	for _, value := range []float64{-0.1, 1.5} {
+		config := GetDefaultConfig()
+		config.DefaultConfidence = value
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate() with default_confidence %v error = nil, want error", value)
+		}
+	}
+
+	config := GetDefaultConfig()
+	config.DefaultConfidence = 1
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() with default_confidence 1 error = %v", err)
+	}
+}
+
+func TestIntentConfig_ValidateExactMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		exactMatch ExactMatchConfig
+		wantErr    bool
+	}{
+		{name: "defaults"},
+		{name: "near-exact with full confidence", exactMatch: ExactMatchConfig{MaxDistance: 2, Confidence: 1}},
+		{name: "negative max_distance", exactMatch: ExactMatchConfig{MaxDistance: -1}, wantErr: true},
+		{name: "negative confidence", exactMatch: ExactMatchConfig{Confidence: -0.1}, wantErr: true},
+		{name: "confidence above 1", exactMatch: ExactMatchConfig{Confidence: 1.5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetDefaultConfig()
+			config.ExactMatch = tt.exactMatch
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntentConfig_ValidateReportsEveryProblem(t *testing.T) {
+	config := &IntentConfig{
+		Intents: map[string]IntentPattern{
+			"Broken": {RequiredOneOf: [][]string{{"email", "phone"}, {}}},
+		},
+		DefaultConfidence: 2,
+		ScoreCalibration:  "platt",
+		MatchAccumulation: "sum",
+		Tokenizer:         "icu",
+		AmbiguityMargin:   -0.1,
+		Abbreviations:     map[string]string{"appt": ""},
+		ExactMatch:        ExactMatchConfig{MaxDistance: -1, Confidence: 1.5},
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil, want problems")
+	}
+	for _, want := range []string{
+		"domain is required",
+		"intent Broken: description is required",
+		"intent Broken: must have at least keywords, phrases, or regex",
+		"intent Broken: required_one_of group 1 is empty",
+		"default_confidence must be between 0 and 1",
+		`unknown score_calibration "platt"`,
+		`unknown match_accumulation "sum"`,
+		`unknown tokenizer "icu"`,
+		"ambiguity_margin must not be negative",
+		"exact_match.max_distance must not be negative",
+		"exact_match.confidence must be between 0 and 1",
+		`abbreviation "appt" must have an expansion`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestIntentConfig_ValidateFallback(t *testing.T) {
+	config := GetDefaultConfig()
+	config.FallbackIntent = "Clarify"
+	config.FallbackFollowUp = []string{"Could you rephrase that?"}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() with fallback_intent Clarify error = %v", err)
+	}
+
+	config.FallbackIntent = "CREATE_CONTACT"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "must not be the name of an intent") {
+		t.Errorf("Validate() with an intent as fallback error = %v", err)
+	}
+
+	config.FallbackIntent = ""
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "fallback_follow_up requires fallback_intent") {
+		t.Errorf("Validate() with questions but no fallback error = %v", err)
+	}
+}
+
+func TestIntentConfig_ValidateResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		entity  EntityPattern
+		wantErr string
+	}{
+		{name: "date entity", entity: EntityPattern{Type: "date", Resolve: ResolveDate}},
+		{name: "other type", entity: EntityPattern{Type: "time", Resolve: ResolveDate}, wantErr: `entity when: resolve "date" requires type "date", got "time"`},
+		{name: "unknown mode", entity: EntityPattern{Type: "date", Resolve: "weekday"}, wantErr: `entity when: unknown resolve mode "weekday"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetDefaultConfig()
+			config.Entities["when"] = tt.entity
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntentConfig_ValidateAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		wantErr string
+	}{
+		{name: "alias", aliases: map[string]string{"CREATE_CONTACT": "ADD_CONTACT"}},
+		{name: "unknown intent", aliases: map[string]string{"MISSING": "OLD"}, wantErr: `alias "OLD": unknown intent MISSING`},
+		{name: "empty alias", aliases: map[string]string{"CREATE_CONTACT": ""}, wantErr: "intent CREATE_CONTACT: alias must not be empty"},
+		{name: "another intent's name", aliases: map[string]string{"CREATE_CONTACT": "FIND_CONTACT"}, wantErr: `intent CREATE_CONTACT: alias "FIND_CONTACT" is the name of another intent`},
+		{
+			name:    "shared alias",
+			aliases: map[string]string{"CREATE_CONTACT": "CONTACT", "FIND_CONTACT": "CONTACT"},
+			wantErr: `intent FIND_CONTACT: alias "CONTACT" is already used by intent CREATE_CONTACT`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetDefaultConfig()
+			config.Aliases = tt.aliases
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntentConfig_ScoringWeightsFor(t *testing.T) {
+	weight := func(value float64) *float64 { return &value }
+
+	config := GetDefaultConfig()
+	config.ScoringWeights = &ScoringWeights{Regex: weight(1.2), Keyword: weight(0.5)}
+	contact := config.Intents["CREATE_CONTACT"]
+	contact.ScoringWeights = &ScoringWeights{Regex: weight(0)}
+	config.Intents["CREATE_CONTACT"] = contact
+
+	if got, want := config.ScoringWeightsFor("CREATE_CONTACT"), (Weights{Regex: 0, Phrase: 0.6, Keyword: 0.5, Overlap: 0.2, Length: 0.1}); got != want {
+		t.Errorf("ScoringWeightsFor(CREATE_CONTACT) = %+v, want %+v", got, want)
+	}
+	if got, want := config.ScoringWeightsFor("FIND_CONTACT"), (Weights{Regex: 1.2, Phrase: 0.6, Keyword: 0.5, Overlap: 0.2, Length: 0.1}); got != want {
+		t.Errorf("ScoringWeightsFor(FIND_CONTACT) = %+v, want %+v", got, want)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	config.ScoringWeights.Phrase = weight(-1)
+	contact.ScoringWeights.Overlap = weight(-0.5)
+	err := config.Validate()
+	for _, want := range []string{
+		"scoring_weights.phrase must not be negative, got -1",
+		"intent CREATE_CONTACT: scoring_weights.overlap must not be negative, got -0.5",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestIntentConfig_PriorityBoost(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		weight   *float64
+		maxBoost *float64
+		ratio    *float64
+		priority int
+		base     float64
+		want     float64
+	}{
+		{name: "defaults", priority: 3, base: 0.3, want: 0.3},
+		{name: "capped", priority: 10, base: 0.3, want: DefaultMaxPriorityBoost},
+		{name: "base below the ratio of the threshold", priority: 3, base: 0.2, want: 0},
+		{name: "base exactly at the ratio", priority: 3, base: 0.25, want: 0.3},
+		{name: "custom weight", weight: value(0.05), priority: 3, base: 0.3, want: 0.15},
+		{name: "weight zero disables", weight: value(0), priority: 3, base: 0.3, want: 0},
+		{name: "custom cap", maxBoost: value(2), priority: 10, base: 0.3, want: 1},
+		{name: "ratio zero always boosts", ratio: value(0), priority: 3, base: 0, want: 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &IntentConfig{
+				Intents:            map[string]IntentPattern{"CreateNote": {Priority: tt.priority}},
+				Confidence:         map[string]float64{"CreateNote": 0.5},
+				PriorityWeight:     tt.weight,
+				MaxPriorityBoost:   tt.maxBoost,
+				PriorityBoostRatio: tt.ratio,
+			}
+			if got := config.PriorityBoost("CreateNote", tt.base); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("PriorityBoost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	config := GetDefaultConfig()
+	config.MaxPriorityBoost = value(-1)
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "max_priority_boost must not be negative, got -1") {
+		t.Errorf("Validate() error = %v, want max_priority_boost error", err)
+	}
+}
+
+func TestIntentConfig_ValidateExtractionMethods(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Entities["name"] = EntityPattern{
+		Type:             "name",
+		ExtractionOrder:  []string{"keyword", "keyword", "guess"},
+		MethodConfidence: map[string]float64{"regex": 1.5, "vibes": 0.5},
+	}
+
+	err := config.Validate()
+	for _, want := range []string{
+		`entity name: extraction_order lists "keyword" more than once`,
+		`entity name: unknown extraction_order method "guess", want regex or keyword`,
+		`entity name: method_confidence.regex must be between 0 and 1, got 1.5`,
+		`entity name: unknown method_confidence method "vibes", want regex or keyword`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	config.Entities["name"] = EntityPattern{
+		Type:             "name",
+		ExtractionOrder:  []string{"keyword", "regex"},
+		MethodConfidence: map[string]float64{"regex": 0.5},
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if got, want := config.Entities["name"].ExtractionMethods(), []string{"regex", "keyword"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractionMethods() = %v, want %v", got, want)
+	}
+}
+
+func TestIntentConfig_ValidateDependsOn(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Entities["time"] = EntityPattern{Type: "time", DependsOn: []string{"date", "place", "time"}}
+	config.Entities["date"] = EntityPattern{Type: "date"}
+
+	err := config.Validate()
+	for _, want := range []string{`entity time: depends_on names unknown entity "place"`, "entity time: depends_on names the entity itself"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %q", err, want)
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), `unknown entity "date"`) {
+		t.Errorf("Validate() error = %v, want defined dependencies accepted", err)
+	}
+}
+
+func TestIntentConfig_ValidateIntentEntities(t *testing.T) {
+	config := GetDefaultConfig()
+	contact := config.Intents["CreateContact"]
+	contact.Entities = []string{"name", "mood"}
+	config.Intents["CreateContact"] = contact
+
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), `intent CreateContact: entities names unknown entity "mood"`) {
+		t.Errorf("Validate() error = %v, want the unknown entity reported", err)
+	}
+}
+
+func TestIntentConfig_ValidateAllowedValues(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Entities["priority"] = EntityPattern{Type: "text", AllowedValues: []string{"low", "High", " ", "high"}}
+
+	err := config.Validate()
+	for _, want := range []string{"entity priority: allowed_values has an empty value", `entity priority: allowed_values lists "high" more than once`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package models
+
+import "testing"
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantTask string
+		wantErr  bool
+	}{
+		{
+			name:     "single object",
+			input:    `{"task": "CREATE_CONTACT", "vars": {"name": "bob"}}`,
+			wantTask: "CREATE_CONTACT",
+		},
+		{
+			name:     "array takes first element",
+			input:    ` [{"task": "FIND_CONTACT", "vars": {}}, {"task": "DELETE_CONTACT", "vars": {}}]`,
+			wantTask: "FIND_CONTACT",
+		},
+		{
+			name:    "empty array",
+			input:   `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			input:   `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := FromJSON(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && intent.Task != tt.wantTask {
+				t.Errorf("Task = %v, want %v", intent.Task, tt.wantTask)
+			}
+		})
+	}
+}
+
+func TestFromJSONMulti(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantTasks []string
+		wantErr   bool
+	}{
+		{
+			name:      "single object",
+			input:     `{"task": "CREATE_CONTACT", "vars": {}}`,
+			wantTasks: []string{"CREATE_CONTACT"},
+		},
+		{
+			name:      "array of intents",
+			input:     `[{"task": "CREATE_CONTACT", "vars": {}}, {"task": "CREATE_EVENT", "vars": {}}]`,
+			wantTasks: []string{"CREATE_CONTACT", "CREATE_EVENT"},
+		},
+		{
+			name:    "array with null element",
+			input:   `[{"task": "CREATE_CONTACT"}, null]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intents, err := FromJSONMulti(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromJSONMulti() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(intents) != len(tt.wantTasks) {
+				t.Fatalf("got %d intents, want %d", len(intents), len(tt.wantTasks))
+			}
+			for i, task := range tt.wantTasks {
+				if intents[i].Task != task {
+					t.Errorf("intents[%d].Task = %v, want %v", i, intents[i].Task, task)
+				}
+			}
+		})
+	}
+}
+
+func TestFromJSONLenient(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantTask string
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "plain object",
+			input:    `{"task": "CREATE_CONTACT", "vars": {"name": "bob"}}`,
+			wantTask: "CREATE_CONTACT",
+			wantName: "bob",
+		},
+		{
+			name:     "fenced block",
+			input:    "```json\n{\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"bob\"}}\n```",
+			wantTask: "CREATE_CONTACT",
+			wantName: "bob",
+		},
+		{
+			name:     "leading prose",
+			input:    "Here's the JSON:\n{\"task\": \"FIND_CONTACT\", \"vars\": {\"name\": \"alice\"}}",
+			wantTask: "FIND_CONTACT",
+			wantName: "alice",
+		},
+		{
+			name:     "trailing explanation",
+			input:    "{\"task\": \"DELETE_CONTACT\", \"vars\": {\"name\": \"carol\"}}\n\nI picked DELETE_CONTACT because the user said {remove}.",
+			wantTask: "DELETE_CONTACT",
+			wantName: "carol",
+		},
+		{
+			name:     "braces and quotes inside strings",
+			input:    "Sure! ```\n{\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"bob \\\"}{\\\" smith\"}}\n```",
+			wantTask: "CREATE_CONTACT",
+			wantName: `bob "}{" smith`,
+		},
+		{
+			name:     "array is still accepted",
+			input:    `[{"task": "FIND_CONTACT", "vars": {}}]`,
+			wantTask: "FIND_CONTACT",
+		},
+		{
+			name:    "no object",
+			input:   "I couldn't find an intent.",
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced object",
+			input:   "```json\n{\"task\": \"CREATE_CONTACT\", \"vars\": {\n```",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := FromJSONLenient(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromJSONLenient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if intent.Task != tt.wantTask {
+				t.Errorf("Task = %v, want %v", intent.Task, tt.wantTask)
+			}
+			if tt.wantName != "" && intent.Vars["name"] != tt.wantName {
+				t.Errorf("name = %v, want %v", intent.Vars["name"], tt.wantName)
+			}
+		})
+	}
+
+	// The strict parser still rejects wrapped output
+	if _, err := FromJSON("```json\n{\"task\": \"CREATE_CONTACT\"}\n```"); err == nil {
+		t.Error("FromJSON(fenced) error = nil, want strict parsing to fail")
+	}
+}
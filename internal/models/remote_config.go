@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultConfigFetchTimeout bounds fetching a config from a URL when no
+// timeout is given
+const DefaultConfigFetchTimeout = 10 * time.Second
+
+// maxConfigBytes caps the size of a fetched config
+const maxConfigBytes = 10 << 20
+
+// ErrConfigFetch is returned when a config URL can't be fetched, as opposed
+// to fetched but invalid
+var ErrConfigFetch = errors.New("failed to fetch config")
+
+// IsConfigURL reports whether a config path is an http:// or https:// URL
+func IsConfigURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// fetchedConfig is the last response received for a config URL
+type fetchedConfig struct {
+	etag         string
+	lastModified string
+	format       string
+	data         []byte
+}
+
+// configFetches caches fetched configs by URL, so an unchanged config is
+// revalidated with a conditional request instead of downloaded again
+var configFetches sync.Map
+
+// FetchIntentConfig downloads a config from an http(s) URL without validating
+// it. YAML is recognized by the Content-Type or a .yaml/.yml path; anything
+// else is parsed as JSON. A timeout of zero uses DefaultConfigFetchTimeout.
+func FetchIntentConfig(configURL string, timeout time.Duration) (*IntentConfig, error) {
+	if timeout <= 0 {
+		timeout = DefaultConfigFetchTimeout
+	}
+
+	request, err := http.NewRequest(http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigFetch, err)
+	}
+	cached, _ := configFetches.Load(configURL)
+	if previous, ok := cached.(*fetchedConfig); ok {
+		if previous.etag != "" {
+			request.Header.Set("If-None-Match", previous.etag)
+		}
+		if previous.lastModified != "" {
+			request.Header.Set("If-Modified-Since", previous.lastModified)
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigFetch, err)
+	}
+	defer response.Body.Close()
+
+	var fetched *fetchedConfig
+	switch {
+	case response.StatusCode == http.StatusNotModified && cached != nil:
+		fetched = cached.(*fetchedConfig)
+	case response.StatusCode == http.StatusOK:
+		data, err := io.ReadAll(io.LimitReader(response.Body, maxConfigBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrConfigFetch, err)
+		}
+		if len(data) > maxConfigBytes {
+			return nil, fmt.Errorf("%w: config is larger than %d bytes", ErrConfigFetch, maxConfigBytes)
+		}
+		fetched = &fetchedConfig{
+			etag:         response.Header.Get("ETag"),
+			lastModified: response.Header.Get("Last-Modified"),
+			format:       remoteConfigFormat(request.URL, response.Header.Get("Content-Type")),
+			data:         data,
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s returned %s", ErrConfigFetch, configURL, response.Status)
+	}
+
+	config, err := ParseIntentConfig(fetched.data, fetched.format)
+	if err != nil {
+		return nil, err
+	}
+	if config.SynonymsFile != "" {
+		return nil, fmt.Errorf("synonyms_file is not supported in a config loaded from a URL; list synonyms inline")
+	}
+	configFetches.Store(configURL, fetched)
+	return config, nil
+}
+
+// LoadIntentConfigURL fetches a config like FetchIntentConfig and validates
+// it like LoadIntentConfig
+func LoadIntentConfigURL(configURL string, timeout time.Duration) (*IntentConfig, error) {
+	config, err := FetchIntentConfig(configURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return config, nil
+}
+
+// remoteConfigFormat picks the format of a fetched config
+func remoteConfigFormat(configURL *url.URL, contentType string) string {
+	if strings.Contains(strings.ToLower(contentType), "yaml") {
+		return ConfigFormatYAML
+	}
+	if ext := strings.ToLower(path.Ext(configURL.Path)); ext == ".yaml" || ext == ".yml" {
+		return ConfigFormatYAML
+	}
+	return ConfigFormatJSON
+}
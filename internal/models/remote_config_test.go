@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveConfig serves body with the given content type, answering requests
+// that revalidate etag with 304. It counts full responses.
+func serveConfig(t *testing.T, contentType, body, etag string, served *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if served != nil {
+			served.Add(1)
+		}
+		w.Header().Set("Content-Type", contentType)
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoadIntentConfig_URL(t *testing.T) {
+	data, err := json.Marshal(GetDefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	yamlConfig := "domain: yaml_assistant\nintents:\n  FIND_CONTACT:\n    description: Find a contact\n    keywords: [find]\n"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		path        string
+		wantDomain  string
+	}{
+		{name: "JSON", contentType: "application/json", body: string(data), path: "/intents", wantDomain: "personal_assistant"},
+		{name: "YAML content type", contentType: "application/yaml", body: yamlConfig, path: "/intents", wantDomain: "yaml_assistant"},
+		{name: "YAML extension", contentType: "text/plain", body: yamlConfig, path: "/intents.yml", wantDomain: "yaml_assistant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveConfig(t, tt.contentType, tt.body, "", nil)
+
+			config, err := LoadIntentConfig(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("LoadIntentConfig() error = %v", err)
+			}
+			if config.Domain != tt.wantDomain {
+				t.Errorf("Domain = %q, want %q", config.Domain, tt.wantDomain)
+			}
+		})
+	}
+}
+
+func TestLoadIntentConfigs_URL(t *testing.T) {
+	server := serveConfig(t, "application/yaml", "domain: remote\nintents:\n  FIND_CONTACT:\n    description: Find a contact\n    keywords: [find]\n", "", nil)
+
+	configs, err := LoadIntentConfigs(server.URL+"/config", "es")
+	if err != nil {
+		t.Fatalf("LoadIntentConfigs() error = %v", err)
+	}
+	if len(configs) != 1 || configs["es"] == nil || configs["es"].Domain != "remote" {
+		t.Errorf("configs = %v, want the remote config as es", configs)
+	}
+}
+
+func TestFetchIntentConfig_RevalidatesCachedConfig(t *testing.T) {
+	var served atomic.Int32
+	server := serveConfig(t, "application/yaml", "domain: cached\nintents:\n  FIND_CONTACT:\n    description: Find a contact\n    keywords: [find]\n", `"v1"`, &served)
+
+	for i := 0; i < 3; i++ {
+		config, err := LoadIntentConfigURL(server.URL+"/cached", time.Second)
+		if err != nil {
+			t.Fatalf("LoadIntentConfigURL() #%d error = %v", i+1, err)
+		}
+		if config.Domain != "cached" {
+			t.Errorf("Domain #%d = %q, want cached", i+1, config.Domain)
+		}
+	}
+	if got := served.Load(); got != 1 {
+		t.Errorf("full responses = %d, want 1 (later fetches answered 304)", got)
+	}
+}
+
+func TestFetchIntentConfig_Errors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	tests := []struct {
+		name      string
+		url       string
+		wantFetch bool
+		wantErr   string
+	}{
+		{name: "not found", url: missing.URL + "/intents.json", wantFetch: true, wantErr: "404"},
+		{name: "timeout", url: slow.URL, wantFetch: true, wantErr: "Timeout"},
+		{name: "invalid config", url: serveConfig(t, "application/json", `{"intents": {"Broken": {}}}`, "", nil).URL, wantErr: "invalid config"},
+		{name: "malformed config", url: serveConfig(t, "application/json", `{"intents": `, "", nil).URL, wantErr: "failed to parse"},
+		{name: "synonyms file", url: serveConfig(t, "application/json", `{"synonyms_file": "synonyms.json"}`, "", nil).URL, wantErr: "synonyms_file is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadIntentConfigURL(tt.url, 100*time.Millisecond)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrConfigFetch) != tt.wantFetch {
+				t.Errorf("errors.Is(err, ErrConfigFetch) = %v, want %v", !tt.wantFetch, tt.wantFetch)
+			}
+		})
+	}
+}
+
+func TestGetDefaultConfig(t *testing.T) {
+	config := GetDefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("bundled default config is invalid: %v", err)
+	}
+	if _, exists := config.Intents["CREATE_CONTACT"]; !exists {
+		t.Errorf("Intents = %v, want CREATE_CONTACT", config.Intents)
+	}
+
+	delete(config.Intents, "CREATE_CONTACT")
+	if _, exists := GetDefaultConfig().Intents["CREATE_CONTACT"]; !exists {
+		t.Error("modifying one default config changed the next")
+	}
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDraft is the JSON Schema version IntentResponseSchema follows
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaDescriptions describes the types and fields in the response schema,
+// keyed by type name or "Type.json_name". Every field needs an entry;
+// TestIntentResponseSchema_Descriptions fails when one is missing.
+var schemaDescriptions = map[string]string{
+	"IntentResponse":                 "Response of the intent extraction endpoints",
+	"IntentResponse.success":         "Whether the text was processed",
+	"IntentResponse.intent":          "Extracted intent; empty when success is false",
+	"IntentResponse.session_id":      "Session to continue a multi-turn conversation with",
+	"IntentResponse.error":           "What went wrong, when success is false",
+	"IntentResponse.debug":           "Classification trace, only set with ?debug=true",
+	"IntentResponse.raw_text":        "Text as sent, only set with ?include_text=true",
+	"IntentResponse.normalized_text": "Text as the provider was given it, only set with ?include_text=true",
+
+	"Intent":                   "Intent and variables extracted from the text",
+	"Intent.task":              "Intent name, or UNKNOWN when the text wasn't recognized",
+	"Intent.vars":              "Extracted variables by name",
+	"Intent.confidence":        "Classification confidence between 0 and 1",
+	"Intent.missing":           "Required fields that weren't found",
+	"Intent.follow_up":         "Questions to ask for the missing fields",
+	"Intent.is_complete":       "Whether every required field is present",
+	"Intent.warnings":          "Problems found while validating the provider response",
+	"Intent.max_depth_reached": "Set when a session ran out of follow-up turns",
+	"Intent.alternatives":      "Best-scoring candidate intents, best first; only set when requested",
+	"Intent.spans":             "Where each entity's values were found in the text; only set when requested",
+
+	"IntentCandidate":            "Scored candidate intent",
+	"IntentCandidate.task":       "Intent name",
+	"IntentCandidate.confidence": "Classification confidence between 0 and 1",
+
+	"Span":       "Byte range [start, end) of an entity value in the original text",
+	"Span.start": "Offset of the first byte",
+	"Span.end":   "Offset just past the last byte",
+
+	"DebugTrace":                  "How the text was classified; entity values are masked as <entity>",
+	"DebugTrace.provider":         "Provider that classified the text",
+	"DebugTrace.language":         "Language the text was classified in",
+	"DebugTrace.normalized_text":  "Text after normalization",
+	"DebugTrace.tokens":           "Words scored, without stop words and negated words",
+	"DebugTrace.matched_keywords": "Keywords that matched, by task",
+	"DebugTrace.exact_match":      "Whether the task came from an exact phrase rather than the scores",
+	"DebugTrace.top_task":         "Classified task, or the best-scoring one when the text was UNKNOWN",
+	"DebugTrace.score":            "Raw score of top_task",
+	"DebugTrace.threshold":        "Confidence threshold top_task was held to",
+	"DebugTrace.entity_methods":   "How each entity's value was found: regex, keyword, rest_of_input or timezone",
+}
+
+// IntentResponseSchema returns a JSON Schema for IntentResponse, generated
+// from the struct definitions and their json tags. Fields tagged omitempty
+// are optional; struct fields are always required since encoding/json
+// writes them regardless.
+func IntentResponseSchema() map[string]interface{} {
+	builder := schemaBuilder{defs: map[string]interface{}{}}
+	root := builder.schemaFor(reflect.TypeOf(IntentResponse{}))
+	return map[string]interface{}{
+		"$schema": jsonSchemaDraft,
+		"title":   "IntentResponse",
+		"$ref":    root["$ref"],
+		"$defs":   builder.defs,
+	}
+}
+
+// schemaBuilder collects the definitions of the struct types it meets
+type schemaBuilder struct {
+	defs map[string]interface{}
+}
+
+// schemaFor returns the schema of a Go type. Structs become references to
+// an entry in defs.
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, exists := b.defs[t.Name()]; !exists {
+			b.defs[t.Name()] = nil // Placeholder, in case the type refers to itself
+			b.defs[t.Name()] = b.objectSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		// interface{} holds any value
+		return map[string]interface{}{}
+	}
+}
+
+// JSONFieldNames returns the names encoding/json writes v's fields under,
+// in declaration order. v must be a struct.
+func JSONFieldNames(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, encoded := jsonField(t.Field(i)); encoded {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonField returns the name and omitempty option of a struct field's json
+// tag; encoded is false for fields encoding/json skips
+func jsonField(field reflect.StructField) (name string, omitEmpty, encoded bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if !field.IsExported() || name == "-" {
+		return "", false, false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+options+",", ",omitempty,"), true
+}
+
+// objectSchema describes a struct's exported fields by their json names
+func (b *schemaBuilder) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, encoded := jsonField(field)
+		if !encoded {
+			continue
+		}
+
+		property := b.schemaFor(field.Type)
+		if kind := field.Type.Kind(); (kind == reflect.Map || kind == reflect.Slice) && !omitEmpty {
+			// A nil map or slice is written as null unless it's left out
+			property["type"] = []string{property["type"].(string), "null"}
+		}
+		if description, exists := schemaDescriptions[t.Name()+"."+name]; exists {
+			property["description"] = description
+		}
+		properties[name] = property
+
+		if !omitEmpty || field.Type.Kind() == reflect.Struct {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if description, exists := schemaDescriptions[t.Name()]; exists {
+		schema["description"] = description
+	}
+	return schema
+}
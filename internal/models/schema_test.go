@@ -0,0 +1,50 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntentResponseSchema_Descriptions(t *testing.T) {
+	defs := IntentResponseSchema()["$defs"].(map[string]interface{})
+	for _, name := range []string{"IntentResponse", "Intent", "IntentCandidate", "Span", "DebugTrace"} {
+		if _, exists := defs[name]; !exists {
+			t.Errorf("$defs has no %s", name)
+		}
+	}
+
+	for name, def := range defs {
+		def := def.(map[string]interface{})
+		if def["description"] == nil {
+			t.Errorf("%s has no description", name)
+		}
+		for field, property := range def["properties"].(map[string]interface{}) {
+			if property.(map[string]interface{})["description"] == nil {
+				t.Errorf("%s.%s has no description", name, field)
+			}
+		}
+	}
+}
+
+func TestIntentResponseSchema_Required(t *testing.T) {
+	defs := IntentResponseSchema()["$defs"].(map[string]interface{})
+	tests := []struct {
+		def  string
+		want []string
+	}{
+		// intent is omitempty, but encoding/json writes struct fields anyway
+		{"IntentResponse", []string{"success", "intent"}},
+		{"Intent", []string{"task", "vars"}},
+		{"Span", []string{"start", "end"}},
+		{"DebugTrace", []string{"provider", "score", "threshold"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.def, func(t *testing.T) {
+			got := defs[tt.def].(map[string]interface{})["required"]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("required = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"myllm/internal/models"
+	"strings"
+	"time"
 )
 
 // AIProvider defines the interface for different AI backends
@@ -17,14 +21,66 @@ type AIProvider interface {
 	IsAvailable() bool
 }
 
+// ConfigurableProvider is implemented by providers driven by an IntentConfig
+type ConfigurableProvider interface {
+	GetConfig() *models.IntentConfig
+}
+
+// ReloadableProvider is implemented by providers that can re-read their config at runtime
+type ReloadableProvider interface {
+	// Reload swaps in the config from disk, keeping the current one on error
+	Reload() error
+}
+
+// ToggleableProvider is implemented by providers that can disable intents at runtime
+type ToggleableProvider interface {
+	// SetIntentEnabled turns classification of an intent on or off
+	SetIntentEnabled(intentName string, enabled bool) error
+}
+
+// StreamingProvider is implemented by providers that can stream generated tokens
+type StreamingProvider interface {
+	// StreamIntent extracts intent like ExtractIntent, passing each generated
+	// token to onToken as it arrives. An onToken error aborts the call.
+	StreamIntent(ctx context.Context, text string, onToken func(token string) error) (*models.Intent, error)
+}
+
 // AIProviderConfig holds configuration for AI providers
 type AIProviderConfig struct {
-	ProviderType string  // "openai", "local", "ollama", etc.
-	Model        string  // Model name
-	Temperature  float64 // Temperature for generation
-	MaxTokens    int     // Maximum tokens to generate
-	BaseURL      string  // Base URL for API calls (for local providers)
-	APIKey       string  // API key if required
+	ProviderType            string        // "openai", "local", "ollama", etc.
+	Model                   string        // Model name
+	Temperature             float64       // Temperature for generation
+	MaxTokens               int           // Maximum tokens to generate
+	BaseURL                 string        // Base URL for API calls (Ollama, an OpenAI proxy or the Azure endpoint)
+	APIKey                  string        // API key if required
+	AnthropicAPIKey         string        // API key for the "claude" provider
+	OpenAIFunctionCalling   bool          // Have the "openai" provider answer through a function call
+	OllamaUseChat           bool          // Have the "ollama" provider call /api/chat with a system prompt
+	OllamaJSONFormat        bool          // Have the "ollama" provider constrain its output to valid JSON
+	AzureOpenAI             bool          // Have the "openai" provider call Azure OpenAI at BaseURL
+	AzureDeployment         string        // Azure deployment name (derived from Model if empty)
+	AzureAPIVersion         string        // Azure OpenAI API version
+	RequestTimeout          time.Duration // Deadline for each provider call (default 30s)
+	MaxRetries              int           // Retries for transient provider failures (0 disables)
+	RetryBackoff            time.Duration // Wait before the first retry, doubled each time (default 500ms)
+	HTTPMaxIdleConns        int           // Idle connections kept across all hosts (default 100)
+	HTTPMaxIdleConnsPerHost int           // Idle connections kept per host (default 32)
+	HTTPIdleConnTimeout     time.Duration // How long an idle connection stays pooled (default 90s)
+	PromptTemplate          string        // text/template source of the extraction prompt (DefaultPromptTemplate if empty)
+	KnownTasks              []string      // Tasks the prompt lists (the built-in contact tasks if empty)
+	Routing                 RoutingConfig // Rules for the "router" provider type
+	ProviderChain           []string      // Provider types the "chain" provider tries in order
+}
+
+// DefaultRequestTimeout applies when AIProviderConfig.RequestTimeout is unset
+const DefaultRequestTimeout = 30 * time.Second
+
+// requestTimeout returns the configured provider call deadline or the default
+func (c AIProviderConfig) requestTimeout() time.Duration {
+	if c.RequestTimeout <= 0 {
+		return DefaultRequestTimeout
+	}
+	return c.RequestTimeout
 }
 
 // AIProviderFactory creates AI providers based on configuration
@@ -44,6 +100,8 @@ func (f *AIProviderFactory) CreateProvider() (AIProvider, error) {
 	switch f.config.ProviderType {
 	case "openai":
 		return NewOpenAIProvider(f.config)
+	case "claude":
+		return NewAnthropicProvider(f.config)
 	case "ollama":
 		return NewOllamaProvider(f.config)
 	case "local":
@@ -51,11 +109,94 @@ func (f *AIProviderFactory) CreateProvider() (AIProvider, error) {
 	case "enhanced_local":
 		configPath := getEnv("INTENT_CONFIG_PATH", "")
 		return NewEnhancedLocalProvider(configPath)
+	case "router":
+		return f.createRouterProvider()
+	case "chain":
+		return f.createChainProvider()
 	default:
 		return NewOpenAIProvider(f.config) // Default fallback
 	}
 }
 
+// createRouterProvider builds a RouterProvider from the routing rules. A remote
+// provider that cannot be created is logged and all inputs are routed locally.
+func (f *AIProviderFactory) createRouterProvider() (AIProvider, error) {
+	rules := f.config.Routing
+	if rules.LocalProvider == "" {
+		rules.LocalProvider = "enhanced_local"
+	}
+	if rules.RemoteProvider == "" {
+		rules.RemoteProvider = "openai"
+	}
+	if rules.LocalProvider == "router" || rules.RemoteProvider == "router" {
+		return nil, fmt.Errorf("router cannot route to another router")
+	}
+
+	localConfig := f.config
+	localConfig.ProviderType = rules.LocalProvider
+	local, err := NewAIProviderFactory(localConfig).CreateProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local provider %s: %w", rules.LocalProvider, err)
+	}
+
+	remoteConfig := f.config
+	remoteConfig.ProviderType = rules.RemoteProvider
+	remote, err := NewAIProviderFactory(remoteConfig).CreateProvider()
+	if err != nil {
+		slog.Warn("Remote provider unavailable, routing all inputs locally", "remote", rules.RemoteProvider, "error", err)
+		remote = nil
+	}
+
+	return NewRouterProvider(local, remote, rules)
+}
+
+// createChainProvider builds a ChainProvider over ProviderChain. Providers
+// that cannot be created are logged and left out of the chain.
+func (f *AIProviderFactory) createChainProvider() (AIProvider, error) {
+	var types []string
+	var providers []AIProvider
+	for _, providerType := range f.config.ProviderChain {
+		providerType = strings.ToLower(strings.TrimSpace(providerType))
+		if providerType == "chain" {
+			return nil, fmt.Errorf("provider chain cannot contain another chain")
+		}
+
+		config := f.config
+		config.ProviderType = providerType
+		provider, err := NewAIProviderFactory(config).CreateProvider()
+		if err != nil {
+			slog.Warn("Leaving provider out of the chain", "provider_type", providerType, "error", err)
+			continue
+		}
+		types = append(types, providerType)
+		providers = append(providers, provider)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no provider in the chain %v could be created", f.config.ProviderChain)
+	}
+
+	return NewChainProvider(types, providers)
+}
+
+// providerTypeOf returns the provider type provider is created for, e.g.
+// "ollama", or its name for a provider the factory doesn't create directly
+func providerTypeOf(provider AIProvider) string {
+	switch provider.(type) {
+	case *OpenAIProvider:
+		return "openai"
+	case *AnthropicProvider:
+		return "claude"
+	case *OllamaProvider:
+		return "ollama"
+	case *LocalAIProvider:
+		return "local"
+	case *EnhancedLocalProvider:
+		return "enhanced_local"
+	default:
+		return provider.Name()
+	}
+}
+
 // GetAvailableProviders returns a list of available providers
 func (f *AIProviderFactory) GetAvailableProviders() []AIProvider {
 	var providers []AIProvider
@@ -65,6 +206,11 @@ func (f *AIProviderFactory) GetAvailableProviders() []AIProvider {
 		providers = append(providers, openai)
 	}
 
+	// Try Anthropic
+	if anthropic, err := NewAnthropicProvider(f.config); err == nil && anthropic.IsAvailable() {
+		providers = append(providers, anthropic)
+	}
+
 	// Try Ollama
 	if ollama, err := NewOllamaProvider(f.config); err == nil && ollama.IsAvailable() {
 		providers = append(providers, ollama)
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAIProviderConfig_RequestTimeout(t *testing.T) {
+	if got := (AIProviderConfig{}).requestTimeout(); got != DefaultRequestTimeout {
+		t.Errorf("requestTimeout() unset = %v, want %v", got, DefaultRequestTimeout)
+	}
+	if got := (AIProviderConfig{RequestTimeout: 90 * time.Second}).requestTimeout(); got != 90*time.Second {
+		t.Errorf("requestTimeout() = %v, want 90s", got)
+	}
+}
+
+func TestNewIntentService_RequestTimeoutFromEnv(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "local")
+	t.Setenv("AI_REQUEST_TIMEOUT", "45s")
+
+	service, err := NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+	if got := service.RequestTimeout(); got != 45*time.Second {
+		t.Errorf("RequestTimeout() = %v, want 45s", got)
+	}
+}
+
+// slowServer answers /api/tags immediately and everything else after delay
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-release:
+		}
+		w.Write([]byte(`{"response": "{\"task\": \"UNKNOWN\", \"vars\": {}}", "done": true}`))
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+func TestProviders_HonorRequestTimeout(t *testing.T) {
+	server := slowServer(t, 5*time.Second)
+	config := AIProviderConfig{
+		BaseURL:         server.URL,
+		AnthropicAPIKey: "test-key",
+		RequestTimeout:  50 * time.Millisecond,
+	}
+
+	ollama, err := NewOllamaProvider(config)
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error = %v", err)
+	}
+	anthropic, err := NewAnthropicProvider(config)
+	if err != nil {
+		t.Fatalf("NewAnthropicProvider() error = %v", err)
+	}
+	anthropic.(*AnthropicProvider).baseURL = server.URL
+
+	for _, provider := range []AIProvider{ollama, anthropic} {
+		t.Run(provider.Name(), func(t *testing.T) {
+			start := time.Now()
+			_, err := provider.ExtractIntent(context.Background(), "add contact alice")
+			if err == nil {
+				t.Fatal("ExtractIntent() error = nil, want timeout")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("ExtractIntent() took %v, want it cut off near 50ms", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"slices"
+	"strings"
+
+	"myllm/internal/models"
+)
+
+// canonicalValue returns the allowed value that value stands for: the one it
+// equals ignoring case, else one it is a synonym of
+func (p *EnhancedLocalProvider) canonicalValue(entity models.EntityPattern, value string) (string, bool) {
+	folded := strings.ToLower(strings.TrimSpace(value))
+	for _, allowed := range entity.AllowedValues {
+		if strings.ToLower(allowed) == folded {
+			return allowed, true
+		}
+	}
+	for _, allowed := range entity.AllowedValues {
+		if slices.Contains(p.getSynonyms(allowed), folded) {
+			return allowed, true
+		}
+	}
+	return "", false
+}
+
+// applyAllowedValues rewrites the values of entities with allowed_values to
+// their canonical spelling and drops the others. Entities left without
+// values are removed and returned, sorted, so they can be asked for again.
+func (p *EnhancedLocalProvider) applyAllowedValues(entities map[string][]string) []string {
+	var rejected []string
+	for _, name := range sortedKeys(entities) {
+		entity, exists := p.config.Entities[name]
+		if !exists || len(entity.AllowedValues) == 0 {
+			continue
+		}
+
+		var valid []string
+		for _, value := range entities[name] {
+			if canonical, ok := p.canonicalValue(entity, value); ok {
+				valid = appendUnique(valid, canonical)
+			}
+		}
+		if len(valid) > 0 {
+			entities[name] = valid
+		} else {
+			delete(entities, name)
+			rejected = append(rejected, name)
+		}
+	}
+	return rejected
+}
+
+// askForAllowedValues reports entities whose values were all rejected as
+// missing, with a question listing the allowed values
+func (p *EnhancedLocalProvider) askForAllowedValues(intent *models.Intent, intentName string, rejected []string) {
+	for _, name := range rejected {
+		if slices.Contains(intent.Missing, name) {
+			continue // Already asked for, with the options listed
+		}
+		intent.Missing = append(intent.Missing, name)
+		if question := p.generateFollowUpQuestion(intentName, name); question != "" {
+			intent.FollowUp = append(intent.FollowUp, question)
+		}
+		intent.IsComplete = false
+	}
+}
+
+// allowedValuesHint lists an entity's allowed values for a follow-up
+// question, e.g. " (low, medium or high)", or returns "" without any
+func (p *EnhancedLocalProvider) allowedValuesHint(field string) string {
+	values := p.config.Entities[field].AllowedValues
+	switch len(values) {
+	case 0:
+		return ""
+	case 1:
+		return " (" + values[0] + ")"
+	}
+	return " (" + strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1] + ")"
+}
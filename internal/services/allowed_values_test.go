@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// priorityConfig has a task intent whose priority must be low, medium or high
+func priorityConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"CreateTask": {
+				Description: "Create a task",
+				Keywords:    []string{"task", "create"},
+				Variables:   []string{"priority"},
+			},
+		},
+		Entities: map[string]models.EntityPattern{
+			"priority": {
+				Type:          "text",
+				Regex:         []string{`(?i)priority (\w+)`},
+				AllowedValues: []string{"low", "medium", "high"},
+			},
+		},
+		Synonyms: map[string][]string{"high": {"urgent"}},
+	}
+}
+
+func TestEnhancedLocalProvider_AllowedValues(t *testing.T) {
+	provider := newTestEnhancedProvider(t, priorityConfig())
+
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{name: "exact value", input: "create a task with priority low", want: "low"},
+		{name: "different case", input: "create a task with priority MEDIUM", want: "medium"},
+		{name: "synonym maps to canonical", input: "create a task with priority urgent", want: "high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["priority"]; got != tt.want {
+				t.Errorf("priority = %v, want %v", got, tt.want)
+			}
+			if !intent.IsComplete || len(intent.FollowUp) != 0 {
+				t.Errorf("intent = %+v, want it complete without follow-up", intent)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_AllowedValuesRejected(t *testing.T) {
+	provider := newTestEnhancedProvider(t, priorityConfig())
+
+	intent, err := provider.ExtractIntent(context.Background(), "create a task with priority whenever")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if value, exists := intent.Vars["priority"]; exists {
+		t.Errorf("priority = %v, want the invalid value dropped", value)
+	}
+	if intent.IsComplete || !slices.Equal(intent.Missing, []string{"priority"}) {
+		t.Errorf("missing = %v, complete = %v, want priority asked for again", intent.Missing, intent.IsComplete)
+	}
+	want := "What priority should this create task have (low, medium or high)?"
+	if !slices.Equal(intent.FollowUp, []string{want}) {
+		t.Errorf("follow_up = %q, want %q", intent.FollowUp, want)
+	}
+
+	if entities := provider.extractEntities("priority whenever", ""); entities["priority"] != nil {
+		t.Errorf("extractEntities() priority = %v, want it dropped", entities["priority"])
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"myllm/internal/models"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+const (
+	anthropicBaseURL      = "https://api.anthropic.com"
+	anthropicAPIVersion   = "2023-06-01"
+	anthropicDefaultModel = "claude-3-haiku-20240307" // Claude 3 Haiku
+)
+
+// AnthropicProvider implements AIProvider for Anthropic's Claude models
+type AnthropicProvider struct {
+	client  *http.Client
+	config  AIProviderConfig
+	baseURL string
+	prompt  *template.Template // Extraction prompt; the default if nil
+}
+
+// AnthropicRequest represents the request structure for the Messages API
+type AnthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+}
+
+// AnthropicMessage is a single conversation turn
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AnthropicResponse represents the response structure from the Messages API
+type AnthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// NewAnthropicProvider creates a new Anthropic provider
+func NewAnthropicProvider(config AIProviderConfig) (AIProvider, error) {
+	if config.AnthropicAPIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key is required")
+	}
+
+	prompt, err := parsePromptTemplate(config.PromptTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnthropicProvider{
+		client:  newHTTPClient(config),
+		config:  config,
+		baseURL: anthropicBaseURL,
+		prompt:  prompt,
+	}, nil
+}
+
+// ExtractIntent extracts intent using Claude
+func (p *AnthropicProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	prompt, err := buildPrompt(p.prompt, text, p.config.KnownTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	model := p.config.Model
+	if model == "" {
+		model = anthropicDefaultModel
+	}
+
+	maxTokens := p.config.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 1000 // Required by the Messages API
+	}
+
+	request := AnthropicRequest{
+		Model:       model,
+		MaxTokens:   maxTokens,
+		Temperature: p.config.Temperature,
+		System:      "You are an intent extraction assistant. Always respond with valid JSON only.",
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: prompt},
+			// Prefill the reply so the model answers with bare JSON
+			{Role: "assistant", Content: "{"},
+		},
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Anthropic request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.requestTimeout())
+	defer cancel()
+
+	// Retry transient failures such as 429s, overloaded errors and connection resets
+	var anthropicResp *AnthropicResponse
+	err = retryWithBackoff(ctx, p.config.MaxRetries, p.config.RetryBackoff, func() error {
+		var err error
+		anthropicResp, err = p.sendMessages(ctx, requestBody)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if anthropicResp.Error != nil {
+		return nil, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
+	}
+
+	var reply strings.Builder
+	for _, block := range anthropicResp.Content {
+		if block.Type == "text" {
+			reply.WriteString(block.Text)
+		}
+	}
+	if reply.Len() == 0 {
+		return nil, fmt.Errorf("no response from Anthropic")
+	}
+
+	// Parse AI response, restoring the prefilled opening brace
+	intent, err := models.FromJSONLenient("{" + reply.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Anthropic response: %w", err)
+	}
+
+	return intent, nil
+}
+
+// sendMessages makes a single call to the Messages API
+func (p *AnthropicProvider) sendMessages(ctx context.Context, requestBody []byte) (*AnthropicResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Anthropic request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.config.AnthropicAPIKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &providerStatusError{Provider: "Anthropic", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var anthropicResp AnthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&anthropicResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Anthropic response: %w", err)
+	}
+
+	return &anthropicResp, nil
+}
+
+// Name returns the provider name
+func (p *AnthropicProvider) Name() string {
+	return "Anthropic Claude"
+}
+
+// IsAvailable checks if Anthropic is available
+func (p *AnthropicProvider) IsAvailable() bool {
+	return p.config.AnthropicAPIKey != "" && p.client != nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestAnthropicProvider points an AnthropicProvider at a test server
+func newTestAnthropicProvider(t *testing.T, config AIProviderConfig, handler http.HandlerFunc) *AnthropicProvider {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	provider, err := NewAnthropicProvider(config)
+	if err != nil {
+		t.Fatalf("NewAnthropicProvider() error = %v", err)
+	}
+	anthropic := provider.(*AnthropicProvider)
+	anthropic.baseURL = server.URL
+	return anthropic
+}
+
+func TestAnthropicProvider_ExtractIntent(t *testing.T) {
+	var got AnthropicRequest
+	provider := newTestAnthropicProvider(t, AIProviderConfig{AnthropicAPIKey: "test-key", MaxTokens: 200}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if key := r.Header.Get("x-api-key"); key != "test-key" {
+			t.Errorf("x-api-key = %q, want test-key", key)
+		}
+		if version := r.Header.Get("anthropic-version"); version != anthropicAPIVersion {
+			t.Errorf("anthropic-version = %q, want %s", version, anthropicAPIVersion)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content": [{"type": "text", "text": "\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"alice\"}}"}], "stop_reason": "end_turn"}`))
+	})
+
+	intent, err := provider.ExtractIntent(context.Background(), "add contact alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CREATE_CONTACT" || intent.Vars["name"] != "alice" {
+		t.Errorf("intent = %+v, want CREATE_CONTACT for alice", intent)
+	}
+
+	if got.Model != anthropicDefaultModel {
+		t.Errorf("model = %q, want default %s", got.Model, anthropicDefaultModel)
+	}
+	if got.MaxTokens != 200 {
+		t.Errorf("max_tokens = %d, want 200", got.MaxTokens)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "user" || !strings.Contains(got.Messages[0].Content, "add contact alice") {
+		t.Errorf("messages = %+v, want the user prompt followed by the prefill", got.Messages)
+	}
+}
+
+func TestAnthropicProvider_ExtractIntentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"api error status", http.StatusUnauthorized, `{"type": "error", "error": {"type": "authentication_error", "message": "invalid x-api-key"}}`, "Anthropic API error 401"},
+		{"empty content", http.StatusOK, `{"content": []}`, "no response from Anthropic"},
+		{"invalid json", http.StatusOK, `{"content": [{"type": "text", "text": "not json"}]}`, "failed to parse Anthropic response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestAnthropicProvider(t, AIProviderConfig{AnthropicAPIKey: "test-key"}, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := provider.ExtractIntent(context.Background(), "add contact alice")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExtractIntent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAIProviderFactory_Claude(t *testing.T) {
+	if _, err := NewAIProviderFactory(AIProviderConfig{ProviderType: "claude"}).CreateProvider(); err == nil {
+		t.Error("CreateProvider() error = nil, want error without ANTHROPIC_API_KEY")
+	}
+
+	provider, err := NewAIProviderFactory(AIProviderConfig{ProviderType: "claude", AnthropicAPIKey: "test-key"}).CreateProvider()
+	if err != nil {
+		t.Fatalf("CreateProvider() error = %v", err)
+	}
+	if _, ok := provider.(*AnthropicProvider); !ok || !provider.IsAvailable() {
+		t.Errorf("CreateProvider() = %T (available %v), want an available AnthropicProvider", provider, provider.IsAvailable())
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"myllm/internal/logging"
+	"myllm/internal/models"
+)
+
+// cacheKeyPrefix namespaces cached intents in a shared Store
+const cacheKeyPrefix = "intent:"
+
+// IntentCache keeps recently extracted intents, as JSON, in a Store. Entries
+// expire after a TTL. Vars are stored with their Go types, so a hit returns
+// the same intent a miss did.
+type IntentCache struct {
+	store Store
+	ttl   time.Duration // Zero keeps entries until they are evicted
+}
+
+// NewIntentCache creates an in-memory cache of up to size intents kept for
+// ttl, dropping the least recently used one when full. It returns nil when
+// size is not positive.
+func NewIntentCache(size int, ttl time.Duration) *IntentCache {
+	if size <= 0 {
+		return nil
+	}
+	return NewStoreIntentCache(NewMemoryStore(size), ttl)
+}
+
+// NewStoreIntentCache creates a cache that keeps intents in store for ttl
+func NewStoreIntentCache(store Store, ttl time.Duration) *IntentCache {
+	return &IntentCache{store: store, ttl: ttl}
+}
+
+// Get returns a copy of the intent cached under key. A store error is logged
+// and counts as a miss.
+func (c *IntentCache) Get(ctx context.Context, key string) (*models.Intent, bool) {
+	encoded, err := c.store.Get(ctx, c.storeKey(key))
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to read the intent cache", "error", err)
+		return nil, false
+	}
+	if encoded == nil {
+		return nil, false
+	}
+	var cached cachedIntent
+	err = json.Unmarshal(encoded, &cached)
+	var intent *models.Intent
+	if err == nil {
+		intent, err = cached.decode()
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to decode a cached intent", "error", err)
+		return nil, false
+	}
+	return intent, true
+}
+
+// Add caches intent under key. A store error is logged and the intent isn't
+// cached.
+func (c *IntentCache) Add(ctx context.Context, key string, intent *models.Intent) {
+	cached, err := encodeCachedIntent(intent)
+	var encoded []byte
+	if err == nil {
+		encoded, err = json.Marshal(cached)
+	}
+	if err == nil {
+		err = c.store.Set(ctx, c.storeKey(key), encoded, c.ttl)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to write the intent cache", "error", err)
+	}
+}
+
+// Types of cached vars. Plain JSON would read ints back as float64 and
+// sub-intents as maps.
+const (
+	cachedVarString  = "string"
+	cachedVarStrings = "strings"
+	cachedVarInt     = "int"
+	cachedVarFloat   = "float"
+	cachedVarBool    = "bool"
+	cachedVarIntents = "intents"
+	cachedVarJSON    = "json" // Anything else, decoded as generic JSON
+)
+
+// cachedIntent is an intent as stored in the cache, with each var tagged
+// with its type
+type cachedIntent struct {
+	models.Intent
+	Vars map[string]cachedVar `json:"vars"`
+}
+
+// cachedVar is one var of a cached intent
+type cachedVar struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// encodeCachedIntent tags the vars of intent, and of its sub-intents, with
+// their types
+func encodeCachedIntent(intent *models.Intent) (*cachedIntent, error) {
+	if intent == nil {
+		return nil, nil
+	}
+	cached := &cachedIntent{Intent: *intent}
+	cached.Intent.Vars = nil
+	if intent.Vars != nil {
+		cached.Vars = make(map[string]cachedVar, len(intent.Vars))
+	}
+	for name, value := range intent.Vars {
+		varType := cachedVarJSON
+		switch v := value.(type) {
+		case string:
+			varType = cachedVarString
+		case []string:
+			varType = cachedVarStrings
+		case int:
+			varType = cachedVarInt
+		case float64:
+			varType = cachedVarFloat
+		case bool:
+			varType = cachedVarBool
+		case []*models.Intent:
+			varType = cachedVarIntents
+			subIntents := make([]*cachedIntent, len(v))
+			for i, sub := range v {
+				encoded, err := encodeCachedIntent(sub)
+				if err != nil {
+					return nil, err
+				}
+				subIntents[i] = encoded
+			}
+			value = subIntents
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode var %q: %w", name, err)
+		}
+		cached.Vars[name] = cachedVar{Type: varType, Value: encoded}
+	}
+	return cached, nil
+}
+
+// decode restores the intent with its vars' original types
+func (c *cachedIntent) decode() (*models.Intent, error) {
+	if c == nil {
+		return nil, nil
+	}
+	intent := c.Intent
+	if c.Vars != nil {
+		intent.Vars = make(map[string]interface{}, len(c.Vars))
+	}
+	for name, cached := range c.Vars {
+		var value interface{}
+		var err error
+		switch cached.Type {
+		case cachedVarString:
+			value, err = decodeCachedVar[string](cached.Value)
+		case cachedVarStrings:
+			value, err = decodeCachedVar[[]string](cached.Value)
+		case cachedVarInt:
+			value, err = decodeCachedVar[int](cached.Value)
+		case cachedVarFloat:
+			value, err = decodeCachedVar[float64](cached.Value)
+		case cachedVarBool:
+			value, err = decodeCachedVar[bool](cached.Value)
+		case cachedVarIntents:
+			var subIntents []*cachedIntent
+			if subIntents, err = decodeCachedVar[[]*cachedIntent](cached.Value); err == nil {
+				decoded := make([]*models.Intent, len(subIntents))
+				for i, sub := range subIntents {
+					if decoded[i], err = sub.decode(); err != nil {
+						break
+					}
+				}
+				value = decoded
+			}
+		case cachedVarJSON:
+			value, err = decodeCachedVar[interface{}](cached.Value)
+		default:
+			err = fmt.Errorf("unknown type %q", cached.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode var %q: %w", name, err)
+		}
+		intent.Vars[name] = value
+	}
+	return &intent, nil
+}
+
+// decodeCachedVar decodes a cached var's value as a T
+func decodeCachedVar[T any](encoded json.RawMessage) (T, error) {
+	var value T
+	err := json.Unmarshal(encoded, &value)
+	return value, err
+}
+
+// storeKey hashes a cache key, which holds the request text, into a short
+// key for the store
+func (c *IntentCache) storeKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return cacheKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// cacheKey identifies an extraction: the provider, the config version, the
+// request options that change the result, and the text
+func (s *IntentService) cacheKey(ctx context.Context, text string) string {
+	parts := []string{
+		s.GetAIProviderName(),
+		strconv.FormatUint(s.configVersion.Load(), 10),
+		strconv.FormatBool(alternativesRequested(ctx)),
+		strconv.FormatBool(spansRequested(ctx)),
+		languageFromContext(ctx),
+		regionFromContext(ctx),
+		forcedProvider(ctx),
+	}
+	// Truncated texts score their length bonus on the original length
+	if length, truncated := ctx.Value(textLengthKey{}).(int); truncated {
+		parts = append(parts, "truncated", strconv.Itoa(length))
+	}
+	// Without an explicit reference, relative dates resolve against the
+	// clock and may be up to the TTL old
+	if reference, ok := ctx.Value(dateReferenceKey{}).(dateReference); ok {
+		zone := ""
+		if reference.location != nil {
+			zone = reference.location.String()
+		}
+		parts = append(parts, reference.now.UTC().Format(time.RFC3339Nano), zone)
+	}
+	// Values keep the input's casing and spans its offsets, so only
+	// identical texts share an entry
+	parts = append(parts, text)
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+func TestIntentCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewIntentCache(2, 0)
+	cache.Add(ctx, "a", &models.Intent{Task: "A"})
+	cache.Add(ctx, "b", &models.Intent{Task: "B"})
+	cache.Get(ctx, "a") // "b" is now the least recently used
+	cache.Add(ctx, "c", &models.Intent{Task: "C"})
+
+	if _, hit := cache.Get(ctx, "b"); hit {
+		t.Error("Get(b) hit, want it evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, hit := cache.Get(ctx, key); !hit {
+			t.Errorf("Get(%s) missed, want it kept", key)
+		}
+	}
+	if got := cache.store.(*MemoryStore).Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestIntentCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	cache := NewIntentCache(10, time.Minute)
+	cache.store.(*MemoryStore).now = func() time.Time { return now }
+
+	cache.Add(ctx, "a", &models.Intent{Task: "A"})
+	now = now.Add(59 * time.Second)
+	if _, hit := cache.Get(ctx, "a"); !hit {
+		t.Error("Get() missed before the TTL")
+	}
+	now = now.Add(time.Second)
+	if _, hit := cache.Get(ctx, "a"); hit {
+		t.Error("Get() hit after the TTL")
+	}
+}
+
+func TestIntentCache_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	cache := NewIntentCache(1, 0)
+	cache.Add(ctx, "a", &models.Intent{Task: "A", Vars: map[string]interface{}{"name": "Bob"}})
+
+	first, _ := cache.Get(ctx, "a")
+	first.Vars["name"] = "Alice"
+	second, _ := cache.Get(ctx, "a")
+	if second.Vars["name"] != "Bob" {
+		t.Errorf("name = %v, want the cached value unchanged by callers", second.Vars["name"])
+	}
+}
+
+func TestIntentCache_Disabled(t *testing.T) {
+	if cache := NewIntentCache(0, time.Minute); cache != nil {
+		t.Errorf("NewIntentCache(0) = %v, want nil", cache)
+	}
+}
+
+func TestIntentCache_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	cache := NewIntentCache(8, time.Minute)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("%d", (worker+i)%16)
+				if _, hit := cache.Get(ctx, key); !hit {
+					cache.Add(ctx, key, &models.Intent{Task: key})
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if got := cache.store.(*MemoryStore).Len(); got > 8 {
+		t.Errorf("Len() = %d, want at most the size 8", got)
+	}
+}
+
+func TestIntentService_Cache(t *testing.T) {
+	stub := &stubProvider{name: "stub", intent: &models.Intent{Task: "CreateNote", Vars: map[string]interface{}{}}}
+	service := &IntentService{aiProvider: stub, cache: NewIntentCache(10, time.Minute)}
+
+	for _, text := range []string{"add a note", "add a note"} {
+		intent, err := service.ExtractIntent(context.Background(), text)
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		if intent.Task != "CreateNote" {
+			t.Errorf("Task = %s, want CreateNote", intent.Task)
+		}
+	}
+	if stub.calls != 1 {
+		t.Errorf("provider calls = %d, want 1 for the same text", stub.calls)
+	}
+
+	// Values keep their casing and spans their offsets, so other casing or
+	// spacing is extracted again
+	service.ExtractIntent(context.Background(), "  Add a NOTE ")
+	if stub.calls != 2 {
+		t.Errorf("provider calls = %d, want text with other casing to miss", stub.calls)
+	}
+
+	service.ExtractIntent(WithAlternatives(context.Background()), "add a note")
+	if stub.calls != 3 {
+		t.Errorf("provider calls = %d, want a request with other options to miss", stub.calls)
+	}
+}
+
+func TestIntentService_CacheHitMatchesMiss(t *testing.T) {
+	newIntent := func() *models.Intent {
+		return &models.Intent{Task: "CreateNote", Confidence: 0.9, Vars: map[string]interface{}{
+			"title":      "Groceries",
+			"tags":       []string{"home", "errands"},
+			"count":      2,
+			"confidence": 0.75,
+			"urgent":     true,
+			"extra":      map[string]interface{}{"source": "llm"},
+			subIntentsVar: []*models.Intent{
+				{Task: "SetReminder", Vars: map[string]interface{}{"duration_minutes": 30, "people": []string{"Bob"}}},
+			},
+		}}
+	}
+	redisStore, _ := newTestRedisStore(t)
+	stores := map[string]*IntentCache{
+		"memory": NewIntentCache(10, time.Minute),
+		"redis":  NewStoreIntentCache(redisStore, time.Minute),
+	}
+
+	for name, cache := range stores {
+		t.Run(name, func(t *testing.T) {
+			stub := &stubProvider{name: "stub", intent: newIntent()}
+			service := &IntentService{aiProvider: stub, cache: cache}
+
+			miss, err := service.ExtractIntent(context.Background(), "add a note")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			want := *miss
+			want.Vars = newIntent().Vars
+			hit, err := service.ExtractIntent(context.Background(), "add a note")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if stub.calls != 1 {
+				t.Fatalf("provider calls = %d, want the second answered from the cache", stub.calls)
+			}
+			if !reflect.DeepEqual(hit, miss) || !reflect.DeepEqual(hit, &want) {
+				t.Errorf("cache hit = %#v\nwant %#v", hit, miss)
+			}
+		})
+	}
+}
+
+func TestIntentService_CacheInvalidatedByConfigChange(t *testing.T) {
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, noteConfig()), cache: NewIntentCache(10, time.Minute)}
+
+	extract := func() string {
+		t.Helper()
+		intent, err := service.ExtractIntent(context.Background(), "make a note that the printer is broken")
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		return intent.Task
+	}
+
+	if task := extract(); task != "CreateNote" {
+		t.Fatalf("Task = %s, want CreateNote", task)
+	}
+	if err := service.SetIntentEnabled("CreateNote", false); err != nil {
+		t.Fatalf("SetIntentEnabled() error = %v", err)
+	}
+	if task := extract(); task == "CreateNote" {
+		t.Error("Task = CreateNote, want the cached result dropped after the intent was disabled")
+	}
+}
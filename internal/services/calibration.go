@@ -0,0 +1,48 @@
+package services
+
+import (
+	"math"
+
+	"myllm/internal/models"
+)
+
+// calibrateScores turns raw scores, best first, into confidences in [0, 1]
+// as the config's score_calibration asks. softmax gives each candidate its
+// share of all candidates' scores; sigmoid maps each score's distance from
+// its intent's threshold through a logistic, so a score right at the
+// threshold becomes 0.5. The order is kept. ok is false when the config
+// doesn't calibrate scores.
+func (p *EnhancedLocalProvider) calibrateScores(ranked []models.IntentCandidate) (calibrated []models.IntentCandidate, ok bool) {
+	temperature := p.config.CalibrationTemperature
+	if temperature == 0 {
+		temperature = models.DefaultCalibrationTemperature
+	}
+
+	switch p.config.ScoreCalibration {
+	case models.CalibrationSoftmax:
+		if len(ranked) == 0 {
+			return ranked, true
+		}
+		calibrated = make([]models.IntentCandidate, len(ranked))
+		var sum float64
+		for i, candidate := range ranked {
+			// Scaled from the best score so large scores can't overflow
+			weight := math.Exp((candidate.Confidence - ranked[0].Confidence) / temperature)
+			calibrated[i] = models.IntentCandidate{Task: candidate.Task, Confidence: weight}
+			sum += weight
+		}
+		for i := range calibrated {
+			calibrated[i].Confidence /= sum
+		}
+		return calibrated, true
+
+	case models.CalibrationSigmoid:
+		calibrated = make([]models.IntentCandidate, len(ranked))
+		for i, candidate := range ranked {
+			distance := candidate.Confidence - p.config.ConfidenceThreshold(candidate.Task)
+			calibrated[i] = models.IntentCandidate{Task: candidate.Task, Confidence: 1 / (1 + math.Exp(-distance/temperature))}
+		}
+		return calibrated, true
+	}
+	return ranked, false
+}
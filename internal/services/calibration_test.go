@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestEnhancedLocalProvider_ScoreCalibration(t *testing.T) {
+	extract := func(calibration string) *models.Intent {
+		t.Helper()
+		config := contactAndEventConfig()
+		config.ScoreCalibration = calibration
+		provider := newTestEnhancedProvider(t, config)
+
+		intent, err := provider.ExtractIntent(WithAlternatives(context.Background()), "add contact Bob for the meeting schedule")
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		if intent.Task != "CreateContact" {
+			t.Fatalf("Task = %s, want CreateContact whatever the calibration", intent.Task)
+		}
+		return intent
+	}
+
+	raw := extract(models.CalibrationNone)
+	for _, calibration := range []string{models.CalibrationSoftmax, models.CalibrationSigmoid} {
+		t.Run(calibration, func(t *testing.T) {
+			intent := extract(calibration)
+			if intent.Confidence == raw.Confidence {
+				t.Errorf("Confidence = %v, want it to differ from the raw %v", intent.Confidence, raw.Confidence)
+			}
+
+			var sum float64
+			for _, candidate := range intent.Alternatives {
+				if candidate.Confidence < 0 || candidate.Confidence > 1 {
+					t.Errorf("%s confidence = %v, want it in [0, 1]", candidate.Task, candidate.Confidence)
+				}
+				sum += candidate.Confidence
+			}
+			if calibration == models.CalibrationSoftmax && (sum < 0.999 || sum > 1.001) {
+				t.Errorf("softmax confidences sum to %v, want 1", sum)
+			}
+			if calibration == models.CalibrationSigmoid && intent.Confidence < 0.5 {
+				t.Errorf("Confidence = %v, want at least 0.5 above the threshold", intent.Confidence)
+			}
+		})
+	}
+}
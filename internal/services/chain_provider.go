@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"myllm/internal/logging"
+	"myllm/internal/metrics"
+	"myllm/internal/models"
+)
+
+// ErrUnknownProvider is returned when a request forces a provider that isn't configured
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// providerKey carries the provider type a request forces
+type providerKey struct{}
+
+// WithProvider returns a context asking for providerType, e.g. "ollama", to
+// answer instead of the configured provider or chain
+func WithProvider(ctx context.Context, providerType string) context.Context {
+	return context.WithValue(ctx, providerKey{}, strings.ToLower(strings.TrimSpace(providerType)))
+}
+
+// forcedProvider returns the provider type ctx asks for, or ""
+func forcedProvider(ctx context.Context) string {
+	providerType, _ := ctx.Value(providerKey{}).(string)
+	return providerType
+}
+
+// ChainProvider implements AIProvider by asking each of its providers in
+// turn until one answers
+type ChainProvider struct {
+	types     []string // Provider type of each provider, e.g. "openai"
+	providers []AIProvider
+}
+
+// NewChainProvider creates a chain over providers, tried in order. types
+// names each provider so requests can force one of them.
+func NewChainProvider(types []string, providers []AIProvider) (*ChainProvider, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("provider chain requires at least one provider")
+	}
+	if len(types) != len(providers) {
+		return nil, fmt.Errorf("provider chain has %d types for %d providers", len(types), len(providers))
+	}
+
+	return &ChainProvider{
+		types:     types,
+		providers: providers,
+	}, nil
+}
+
+// ExtractIntent asks the providers in order and returns the first answer.
+// A request that forces a provider type only asks that provider.
+func (p *ChainProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	if forced := forcedProvider(ctx); forced != "" {
+		for i, providerType := range p.types {
+			if providerType == forced {
+				return p.providers[i].ExtractIntent(ctx, text)
+			}
+		}
+		return nil, fmt.Errorf("%w: %s is not in the provider chain", ErrUnknownProvider, forced)
+	}
+
+	logger := logging.FromContext(ctx)
+	var errs []error
+	for i, provider := range p.providers {
+		intent, err := provider.ExtractIntent(ctx, text)
+		if err == nil {
+			if i > 0 {
+				// Each hop down the chain has its own series, so degraded
+				// primaries can be alerted on
+				metrics.ObserveFallback(p.types[0], p.types[i])
+				logger.Warn("Fallback provider answered", "intended", p.types[0], "used", p.types[i], "failed", i)
+			} else {
+				logger.Debug("Provider answered", "provider", provider.Name())
+			}
+			return intent, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", p.types[i], err))
+		// The remaining providers would fail the same way
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(p.providers)-1 {
+			logger.Warn("Provider failed, trying the next in the chain",
+				"provider", provider.Name(), "next", p.providers[i+1].Name(), "error", err)
+		}
+	}
+	return nil, fmt.Errorf("every provider in the chain failed: %w", errors.Join(errs...))
+}
+
+// Name returns the provider name
+func (p *ChainProvider) Name() string {
+	names := make([]string, len(p.providers))
+	for i, provider := range p.providers {
+		names[i] = provider.Name()
+	}
+	return fmt.Sprintf("Chain (%s)", strings.Join(names, ", "))
+}
+
+// IsAvailable checks if any provider in the chain can serve requests
+func (p *ChainProvider) IsAvailable() bool {
+	for _, provider := range p.providers {
+		if provider.IsAvailable() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"myllm/internal/metrics"
+	"myllm/internal/models"
+)
+
+func newTestChain(t *testing.T, providers ...*stubProvider) *ChainProvider {
+	t.Helper()
+
+	var types []string
+	var members []AIProvider
+	for _, provider := range providers {
+		types = append(types, provider.name)
+		members = append(members, provider)
+	}
+	chain, err := NewChainProvider(types, members)
+	if err != nil {
+		t.Fatalf("NewChainProvider() error = %v", err)
+	}
+	return chain
+}
+
+func TestChainProvider_FallsBack(t *testing.T) {
+	failing := &stubProvider{name: "openai", err: errors.New("connection refused")}
+	answering := &stubProvider{name: "ollama", intent: &models.Intent{Task: "CREATE_CONTACT", Vars: map[string]interface{}{}}}
+	unused := &stubProvider{name: "local"}
+	chain := newTestChain(t, failing, answering, unused)
+
+	intent, err := chain.ExtractIntent(context.Background(), "add contact Bob")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CREATE_CONTACT" {
+		t.Errorf("Task = %v, want the second provider's CREATE_CONTACT", intent.Task)
+	}
+	if failing.calls != 1 || answering.calls != 1 || unused.calls != 0 {
+		t.Errorf("calls = %d, %d, %d, want 1, 1, 0", failing.calls, answering.calls, unused.calls)
+	}
+}
+
+func TestChainProvider_CountsFallbacks(t *testing.T) {
+	answered := metrics.ProviderFallbacks.WithLabelValues("openai", "ollama")
+	before := testutil.ToFloat64(answered)
+
+	chain := newTestChain(t,
+		&stubProvider{name: "openai", err: errors.New("connection refused")},
+		&stubProvider{name: "ollama", intent: &models.Intent{Task: "CREATE_CONTACT", Vars: map[string]interface{}{}}},
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := chain.ExtractIntent(context.Background(), "add contact Bob"); err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+	}
+
+	if got := testutil.ToFloat64(answered) - before; got != 2 {
+		t.Errorf("openai -> ollama fallbacks = %v, want 2", got)
+	}
+}
+
+func TestIntentService_CountsStartupFallback(t *testing.T) {
+	answered := metrics.ProviderFallbacks.WithLabelValues("openai", "Local AI")
+	before := testutil.ToFloat64(answered)
+
+	stub := &stubProvider{name: "Local AI", intent: &models.Intent{Task: "CREATE_CONTACT", Vars: map[string]interface{}{}}}
+	service := &IntentService{aiProvider: stub, fallbackFrom: "openai"}
+	if _, err := service.ExtractIntent(context.Background(), "add contact Bob"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if got := testutil.ToFloat64(answered) - before; got != 1 {
+		t.Errorf("openai -> Local AI fallbacks = %v, want 1", got)
+	}
+
+	// Failed requests were not served by the fallback
+	stub.err = errors.New("failed")
+	service.ExtractIntent(context.Background(), "add contact Bob")
+	if got := testutil.ToFloat64(answered) - before; got != 1 {
+		t.Errorf("fallbacks after a failure = %v, want still 1", got)
+	}
+}
+
+func TestChainProvider_AllFail(t *testing.T) {
+	chain := newTestChain(t,
+		&stubProvider{name: "openai", err: errors.New("connection refused")},
+		&stubProvider{name: "ollama", err: ErrInvalidProviderResponse},
+	)
+
+	_, err := chain.ExtractIntent(context.Background(), "add contact Bob")
+	if err == nil || !strings.Contains(err.Error(), "openai: connection refused") {
+		t.Errorf("ExtractIntent() error = %v, want every provider's error", err)
+	}
+	if !errors.Is(err, ErrInvalidProviderResponse) {
+		t.Errorf("ExtractIntent() error = %v, want it to wrap ErrInvalidProviderResponse", err)
+	}
+}
+
+func TestChainProvider_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	next := &stubProvider{name: "local"}
+	chain := newTestChain(t, &stubProvider{name: "openai", err: context.Canceled}, next)
+
+	if _, err := chain.ExtractIntent(ctx, "add contact Bob"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExtractIntent() error = %v, want context.Canceled", err)
+	}
+	if next.calls != 0 {
+		t.Errorf("next provider calls = %d, want 0 once the request is cancelled", next.calls)
+	}
+}
+
+func TestChainProvider_ForcedProvider(t *testing.T) {
+	first := &stubProvider{name: "openai"}
+	second := &stubProvider{name: "local", err: errors.New("failed")}
+	chain := newTestChain(t, first, second)
+
+	// A forced provider doesn't fall back
+	if _, err := chain.ExtractIntent(WithProvider(context.Background(), "Local"), "add contact Bob"); err == nil {
+		t.Error("ExtractIntent() error = nil, want the forced provider's error")
+	}
+	if first.calls != 0 || second.calls != 1 {
+		t.Errorf("calls = %d, %d, want only the forced provider called", first.calls, second.calls)
+	}
+
+	if _, err := chain.ExtractIntent(WithProvider(context.Background(), "claude"), "add contact Bob"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("ExtractIntent() error = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestNewIntentService_ProviderChain(t *testing.T) {
+	// Ollama is up but fails every generation
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_PROVIDER_CHAIN", "ollama, local")
+	t.Setenv("AI_BASE_URL", server.URL)
+	t.Setenv("AI_MAX_RETRIES", "0")
+	t.Setenv("PATTERN_FAST_PATH", "false")
+	service, err := NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+	if name := service.GetAIProviderName(); !strings.HasPrefix(name, "Chain (Ollama") {
+		t.Fatalf("provider = %s, want the chain", name)
+	}
+
+	intent, err := service.ExtractIntent(context.Background(), "add a contact named Bob")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CREATE_CONTACT" {
+		t.Errorf("Task = %v, want CREATE_CONTACT from the local provider", intent.Task)
+	}
+
+	if _, err := service.ExtractIntent(WithProvider(context.Background(), "ollama"), "add a contact named Bob"); err == nil {
+		t.Error("ExtractIntent() forcing ollama error = nil, want Ollama's error")
+	}
+}
+
+func TestIntentService_ForcedProviderWithoutChain(t *testing.T) {
+	service := &IntentService{aiProvider: &stubProvider{name: "Local AI"}, providerType: "local"}
+
+	if _, err := service.ExtractIntent(WithProvider(context.Background(), "local"), "add contact Bob"); err != nil {
+		t.Errorf("ExtractIntent() forcing the configured provider error = %v", err)
+	}
+	if _, err := service.ExtractIntent(WithProvider(context.Background(), "ollama"), "add contact Bob"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("ExtractIntent() error = %v, want ErrUnknownProvider", err)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrClassifierNotSupported is returned when the active provider doesn't
+// classify through a Classifier
+var ErrClassifierNotSupported = errors.New("custom classifiers not supported")
+
+// Classifier picks the intent of normalized text. The enhanced local provider
+// scores intents by their regexes, phrases and keywords unless another
+// Classifier, such as an ML model, is set. Only classification is replaced:
+// entities, follow-up questions and the rest of the pipeline stay the same.
+// Intents the config doesn't define get no entity filtering or follow-ups.
+type Classifier interface {
+	Classify(ctx context.Context, text string) (IntentResult, error)
+}
+
+// SetClassifier makes the provider classify text with classifier, or with
+// its scoring again when classifier is nil. Call it before the provider
+// serves requests.
+func (p *EnhancedLocalProvider) SetClassifier(classifier Classifier) {
+	p.classifier = classifier
+}
+
+// ScoringClassifier returns the provider's rule-based scoring as a
+// Classifier, for custom classifiers that fall back to it, e.g. when their
+// own confidence is low
+func (p *EnhancedLocalProvider) ScoringClassifier() Classifier {
+	return scoringClassifier{provider: p}
+}
+
+// scoringClassifier classifies with the live config of a provider, in the
+// request's language
+type scoringClassifier struct {
+	provider *EnhancedLocalProvider
+}
+
+// Classify scores text against every intent of the provider's config
+func (c scoringClassifier) Classify(ctx context.Context, text string) (IntentResult, error) {
+	snapshot := c.provider.snapshot()
+	return snapshot.forLanguage(snapshot.selectLanguage(ctx, text)).classifyIntent(ctx, text)
+}
+
+// classify picks the intent of normalized text with the configured
+// Classifier, or by scoring without one. p is a snapshot.
+func (p *EnhancedLocalProvider) classify(ctx context.Context, text string) (IntentResult, error) {
+	if p.classifier != nil {
+		return p.classifier.Classify(ctx, text)
+	}
+	return p.classifyIntent(ctx, text)
+}
+
+// SetClassifier replaces the classification step of the active provider
+func (s *IntentService) SetClassifier(classifier Classifier) error {
+	enhanced, ok := s.aiProvider.(*EnhancedLocalProvider)
+	if !ok {
+		return fmt.Errorf("%w by provider %s", ErrClassifierNotSupported, s.GetAIProviderName())
+	}
+	enhanced.SetClassifier(classifier)
+	return nil
+}
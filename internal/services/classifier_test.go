@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stubClassifier always picks the same intent, recording the texts it saw
+type stubClassifier struct {
+	result IntentResult
+	texts  []string
+}
+
+func (c *stubClassifier) Classify(ctx context.Context, text string) (IntentResult, error) {
+	c.texts = append(c.texts, text)
+	return c.result, nil
+}
+
+func TestEnhancedLocalProvider_SetClassifier(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactConfig())
+	classifier := &stubClassifier{result: IntentResult{Intent: "CreateContact", Confidence: 0.42}}
+	provider.SetClassifier(classifier)
+
+	// Nothing in the text points at CreateContact, but the email is still extracted
+	intent, err := provider.ExtractIntent(context.Background(), "Ping bob@example.com")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CreateContact" || intent.Confidence != 0.42 {
+		t.Errorf("task = %s (%.2f), want the classifier's CreateContact (0.42)", intent.Task, intent.Confidence)
+	}
+	if intent.Vars["email"] != "bob@example.com" {
+		t.Errorf("email = %v, want entities extracted as before", intent.Vars["email"])
+	}
+	if len(classifier.texts) == 0 || classifier.texts[0] != "ping bob@example.com" {
+		t.Errorf("classifier saw %q, want the normalized text", classifier.texts)
+	}
+
+	// Back to scoring
+	provider.SetClassifier(nil)
+	if intent, err = provider.ExtractIntent(context.Background(), "Ping bob@example.com"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task == "CreateContact" {
+		t.Errorf("task = %s, want scoring to decide without the classifier", intent.Task)
+	}
+}
+
+func TestEnhancedLocalProvider_ScoringClassifier(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactConfig())
+	provider.SetClassifier(&stubClassifier{result: IntentResult{Intent: "UNKNOWN"}})
+
+	// The scoring classifier ignores the custom one it can serve as fallback for
+	result, err := provider.ScoringClassifier().Classify(context.Background(), "create contact alice")
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if result.Intent != "CreateContact" {
+		t.Errorf("Classify() = %s, want CreateContact", result.Intent)
+	}
+}
+
+func TestIntentService_SetClassifier(t *testing.T) {
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, contactConfig())}
+	if err := service.SetClassifier(&stubClassifier{}); err != nil {
+		t.Errorf("SetClassifier() error = %v", err)
+	}
+
+	service = &IntentService{aiProvider: &stubProvider{name: "stub"}}
+	if err := service.SetClassifier(&stubClassifier{}); !errors.Is(err, ErrClassifierNotSupported) {
+		t.Errorf("SetClassifier() error = %v, want ErrClassifierNotSupported", err)
+	}
+}
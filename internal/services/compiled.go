@@ -0,0 +1,60 @@
+package services
+
+import (
+	"regexp"
+
+	"myllm/internal/models"
+)
+
+// CompiledConfig describes the compiled config of the enhanced local
+// provider, if that is the active provider
+func (s *IntentService) CompiledConfig() (*models.CompiledConfigResponse, bool) {
+	enhanced, ok := s.aiProvider.(*EnhancedLocalProvider)
+	if !ok {
+		return nil, false
+	}
+	return &models.CompiledConfigResponse{
+		Provider:      enhanced.Name(),
+		ConfigVersion: s.configVersion.Load(),
+		Languages:     enhanced.CompiledConfigs(),
+	}, true
+}
+
+// CompiledConfigs describes the live compiled config of every language,
+// keyed by language
+func (p *EnhancedLocalProvider) CompiledConfigs() map[string]models.CompiledLanguageConfig {
+	current := p.snapshot()
+	configs := current.allConfigs()
+	described := make(map[string]models.CompiledLanguageConfig)
+	for language, compiled := range current.allCompiled() {
+		described[language] = compiled.describe(configs[language])
+	}
+	return described
+}
+
+// describe lists the compiled patterns of config, giving regexes by their
+// source
+func (c *CompiledConfig) describe(config *models.IntentConfig) models.CompiledLanguageConfig {
+	return models.CompiledLanguageConfig{
+		Domain:        config.Domain,
+		Version:       config.Version,
+		IntentRegexes: regexSources(c.IntentRegexes),
+		EntityRegexes: regexSources(c.EntityRegexes),
+		Keywords:      c.KeywordMap,
+		Phrases:       c.PhraseMap,
+		Synonyms:      c.SynonymSets,
+		ExactPhrases:  c.ExactPhrases,
+	}
+}
+
+// regexSources returns the source pattern of each regex, keyed like regexes
+func regexSources(regexes map[string][]*regexp.Regexp) map[string][]string {
+	sources := make(map[string][]string, len(regexes))
+	for name, compiled := range regexes {
+		sources[name] = make([]string, len(compiled))
+		for i, re := range compiled {
+			sources[name][i] = re.String()
+		}
+	}
+	return sources
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"regexp"
+
+	"myllm/internal/models"
+)
+
+// subIntentsVar holds the intents of a composite utterance
+const subIntentsVar = "sub_intents"
+
+// conjunctionRegex matches the coordinating conjunctions a composite utterance
+// is split on. They must stand alone between spaces so "sandy@and.com" and a
+// trailing "then" are left intact.
+var conjunctionRegex = regexp.MustCompile(`(?i),?\s+(?:and\s+then|and\s+also|and|then|also)\s+`)
+
+// textSegment is a span of the original text classified as one intent
+type textSegment struct {
+	start, end int
+	task       string
+}
+
+// splitSegments returns the spans of text between coordinating conjunctions
+func splitSegments(text string) []textSegment {
+	var segments []textSegment
+	start := 0
+	for _, match := range conjunctionRegex.FindAllStringIndex(text, -1) {
+		segments = append(segments, textSegment{start: start, end: match[0]})
+		start = match[1]
+	}
+	return append(segments, textSegment{start: start, end: len(text)})
+}
+
+// extractSubIntents extracts one intent per part of a composite utterance
+// such as "create a contact for Bob and schedule a meeting tomorrow". A
+// segment that isn't recognised on its own merit, or is recognised as the
+// same intent as the one before it, stays with the segment before it, so
+// "add Bob and Alice" or "add Bob and add his email" remain one intent.
+// Returns nil unless the text holds at least two intents.
+func (p *EnhancedLocalProvider) extractSubIntents(ctx context.Context, text string) ([]*models.Intent, error) {
+	var groups []textSegment
+	for _, segment := range splitSegments(text) {
+		task, err := p.segmentTask(ctx, text[segment.start:segment.end])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(groups) > 0 {
+			last := &groups[len(groups)-1]
+			if task == "UNKNOWN" || task == last.task || last.task == "UNKNOWN" {
+				last.end = segment.end
+				if last.task == "UNKNOWN" {
+					last.task = task
+				}
+				continue
+			}
+		}
+		segment.task = task
+		groups = append(groups, segment)
+	}
+	if len(groups) < 2 {
+		return nil, nil
+	}
+
+	intents := make([]*models.Intent, 0, len(groups))
+	for _, group := range groups {
+		intent, err := p.extractIntent(ctx, text[group.start:group.end])
+		if err != nil {
+			return nil, err
+		}
+		intent.Alternatives = nil
+		// Spans are relative to the part; make them point into the whole text
+		for _, spans := range intent.Spans {
+			for i := range spans {
+				spans[i].Start += group.start
+				spans[i].End += group.start
+			}
+		}
+		intents = append(intents, intent)
+	}
+	return intents, nil
+}
+
+// segmentTask classifies a segment, returning UNKNOWN unless one of the
+// winning intent's regexes, phrases or keywords appears in it: the priority
+// boost alone can lift an intent over its threshold on any short text.
+func (p *EnhancedLocalProvider) segmentTask(ctx context.Context, segment string) (string, error) {
+	normalized := p.normalizeText(segment)
+	result, err := p.classify(ctx, normalized)
+	if err != nil {
+		return "", err
+	}
+	if result.Intent == "UNKNOWN" || !p.matchesIntent(normalized, result.Intent) {
+		return "UNKNOWN", nil
+	}
+	return result.Intent, nil
+}
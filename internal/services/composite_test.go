@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// contactAndEventConfig combines contactConfig and eventConfig
+func contactAndEventConfig() *models.IntentConfig {
+	config := contactConfig()
+	events := eventConfig()
+	for name, intent := range events.Intents {
+		config.Intents[name] = intent
+	}
+	for name, entity := range events.Entities {
+		config.Entities[name] = entity
+	}
+	return config
+}
+
+func TestSplitSegments(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"add contact Bob", []string{"add contact Bob"}},
+		{"add contact Bob and schedule a meeting", []string{"add contact Bob", "schedule a meeting"}},
+		{"add contact Bob, then schedule a meeting and also email him", []string{"add contact Bob", "schedule a meeting", "email him"}},
+		{"add contact Bob AND THEN schedule a meeting", []string{"add contact Bob", "schedule a meeting"}},
+		{"email sandy@and.com then", []string{"email sandy@and.com then"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			segments := splitSegments(tt.text)
+			if len(segments) != len(tt.want) {
+				t.Fatalf("splitSegments() = %d segments, want %d", len(segments), len(tt.want))
+			}
+			for i, segment := range segments {
+				if got := tt.text[segment.start:segment.end]; got != tt.want[i] {
+					t.Errorf("segment %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_SubIntents(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactAndEventConfig())
+
+	intent, err := provider.ExtractIntent(context.Background(), `add contact Bob and schedule a meeting "Intro" tomorrow`)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+
+	subIntents, ok := intent.Vars["sub_intents"].([]*models.Intent)
+	if !ok || len(subIntents) != 2 {
+		t.Fatalf("sub_intents = %#v, want two intents", intent.Vars["sub_intents"])
+	}
+	if subIntents[0].Task != "CreateContact" || subIntents[0].Vars["name"] != "Bob" {
+		t.Errorf("sub_intents[0] = %s %v, want CreateContact for Bob", subIntents[0].Task, subIntents[0].Vars)
+	}
+	if subIntents[1].Task != "CreateEvent" || subIntents[1].Vars["title"] != "Intro" || subIntents[1].Vars["date"] != "tomorrow" {
+		t.Errorf("sub_intents[1] = %s %v, want CreateEvent Intro tomorrow", subIntents[1].Task, subIntents[1].Vars)
+	}
+	if _, nested := subIntents[1].Vars["sub_intents"]; nested {
+		t.Error("sub-intent has sub_intents of its own")
+	}
+}
+
+func TestEnhancedLocalProvider_SingleIntentHasNoSubIntents(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactAndEventConfig())
+
+	for _, text := range []string{
+		"add contact Bob",
+		"add contact Bob and Alice",
+		"add contact Bob and add his email bob@example.com",
+		"hello and add contact Bob",
+	} {
+		t.Run(text, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), text)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "CreateContact" {
+				t.Errorf("Task = %s, want CreateContact", intent.Task)
+			}
+			if subIntents, exists := intent.Vars["sub_intents"]; exists {
+				t.Errorf("sub_intents = %v, want none for a single intent", subIntents)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import "myllm/internal/models"
+
+// ValidateConfig checks a candidate intent config the way the enhanced local
+// provider loads one: Validate, then compiling its regexes. Every problem
+// found is returned; nil means the config can be deployed.
+func ValidateConfig(config *models.IntentConfig) []error {
+	problems := splitErrors(config.Validate())
+	if _, err := compileConfig(config); err != nil {
+		problems = append(problems, splitErrors(err)...)
+	}
+	return problems
+}
+
+// splitErrors returns the errors joined in err, or err on its own
+func splitErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
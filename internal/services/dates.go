@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// resolvedSuffix names the var holding an entity's resolved value, e.g. date_resolved
+const resolvedSuffix = "_resolved"
+
+var (
+	nextWeekdayRegex = regexp.MustCompile(`^next (sunday|monday|tuesday|wednesday|thursday|friday|saturday)$`)
+	inDaysRegex      = regexp.MustCompile(`^in (\d{1,3}) days?$`)
+)
+
+// weekdays maps lowercase day names to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// dateReferenceKey carries the reference time and zone a request resolves dates against
+type dateReferenceKey struct{}
+
+// dateReference is the "now" relative dates are resolved from
+type dateReference struct {
+	now      time.Time      // Zero for the provider's clock
+	location *time.Location // Nil for the config's time zone
+}
+
+// WithDateReference returns a context resolving relative dates such as
+// "tomorrow" against now in the zone tz, an IANA name or "UTC+02:00". A zero
+// now uses the current time and an empty tz the config's time zone.
+func WithDateReference(ctx context.Context, now time.Time, tz string) (context.Context, error) {
+	reference := dateReference{now: now}
+	if tz != "" {
+		loc, err := loadTimezone(tz)
+		if err != nil {
+			return ctx, fmt.Errorf("invalid time zone %q: %w", tz, err)
+		}
+		reference.location = loc
+	}
+	return context.WithValue(ctx, dateReferenceKey{}, reference), nil
+}
+
+// dateReference returns the time relative dates are resolved from, in the
+// zone they are resolved in: the request's, else the provider's clock and
+// the config's time zone
+func (p *EnhancedLocalProvider) dateReference(ctx context.Context) time.Time {
+	reference, _ := ctx.Value(dateReferenceKey{}).(dateReference)
+
+	now := reference.now
+	if now.IsZero() {
+		now = p.currentTime()
+	}
+
+	loc := reference.location
+	if loc == nil {
+		var err error
+		if loc, err = loadTimezone(p.config.Timezone); err != nil {
+			loc = time.UTC
+		}
+	}
+	return now.In(loc)
+}
+
+// resolveDates adds <name>_resolved (YYYY-MM-DD) for each entity configured
+// with resolve: date whose value is a date reference resolveDate understands
+func (p *EnhancedLocalProvider) resolveDates(ctx context.Context, intent *models.Intent) {
+	var now time.Time
+	for entityName, entity := range p.config.Entities {
+		if entity.Resolve != models.ResolveDate {
+			continue
+		}
+		if now.IsZero() {
+			now = p.dateReference(ctx)
+		}
+
+		switch value := intent.Vars[entityName].(type) {
+		case string:
+			if date, ok := resolveDate(value, now); ok {
+				intent.Vars[entityName+resolvedSuffix] = date.Format(time.DateOnly)
+			}
+		case []string:
+			// Keep the lists parallel, leaving values that can't be resolved empty
+			resolved := make([]string, len(value))
+			found := false
+			for i, raw := range value {
+				if date, ok := resolveDate(raw, now); ok {
+					resolved[i] = date.Format(time.DateOnly)
+					found = true
+				}
+			}
+			if found {
+				intent.Vars[entityName+resolvedSuffix] = resolved
+			}
+		}
+	}
+}
+
+// resolveDate turns a date reference into midnight of that day in now's
+// location: "today" (or empty), "tomorrow", "yesterday", "next week", "last
+// week", "next monday", "in 3 days" or a numeric "12/25/2024"
+func resolveDate(date string, now time.Time) (time.Time, bool) {
+	year, month, day := now.Date()
+
+	date = strings.Join(strings.Fields(strings.ToLower(date)), " ")
+	switch date {
+	case "", "today":
+	case "tomorrow":
+		day++
+	case "yesterday":
+		day--
+	case "next week":
+		day += 7
+	case "last week":
+		day -= 7
+	default:
+		if matches := nextWeekdayRegex.FindStringSubmatch(date); matches != nil {
+			// The next one after today, so "next monday" on a Monday is a week away
+			ahead := (int(weekdays[matches[1]]) - int(now.Weekday()) + 7) % 7
+			if ahead == 0 {
+				ahead = 7
+			}
+			day += ahead
+		} else if matches := inDaysRegex.FindStringSubmatch(date); matches != nil {
+			days, _ := strconv.Atoi(matches[1])
+			day += days
+		} else if matches := numericDateRegex.FindStringSubmatch(date); matches != nil {
+			m, _ := strconv.Atoi(matches[1])
+			d, _ := strconv.Atoi(matches[2])
+			y, _ := strconv.Atoi(matches[3])
+			year, month, day = y, time.Month(m), d
+		} else {
+			return time.Time{}, false
+		}
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), true
+}
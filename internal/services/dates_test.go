@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+func TestResolveDate(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, 1, 17, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		date   string
+		want   string
+		wantOK bool
+	}{
+		{"today", "2024-01-17", true},
+		{"", "2024-01-17", true},
+		{"Tomorrow", "2024-01-18", true},
+		{"yesterday", "2024-01-16", true},
+		{"next week", "2024-01-24", true},
+		{"next monday", "2024-01-22", true},
+		{"next  Wednesday", "2024-01-24", true},
+		{"next thursday", "2024-01-18", true},
+		{"in 3 days", "2024-01-20", true},
+		{"in 1 day", "2024-01-18", true},
+		{"in 20 days", "2024-02-06", true},
+		{"12/25/2024", "2024-12-25", true},
+		{"someday", "", false},
+		{"next month", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got, ok := resolveDate(tt.date, now)
+			if ok != tt.wantOK {
+				t.Fatalf("resolveDate(%q) ok = %v, want %v", tt.date, ok, tt.wantOK)
+			}
+			if ok && got.Format(time.DateOnly) != tt.want {
+				t.Errorf("resolveDate(%q) = %s, want %s", tt.date, got.Format(time.DateOnly), tt.want)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_ResolveDates(t *testing.T) {
+	config := eventConfig()
+	date := config.Entities["date"]
+	date.Regex = []string{`(?i)\b(today|tomorrow|yesterday|next\s+\w+day|in\s+\d+\s+days?)\b`}
+	date.Resolve = models.ResolveDate
+	config.Entities["date"] = date
+	provider := newTestEnhancedProvider(t, config)
+	provider.now = func() time.Time { return time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC) }
+
+	// Late evening in UTC is already the next morning in Tokyo
+	tokyo, err := WithDateReference(context.Background(), time.Time{}, "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("WithDateReference() error = %v", err)
+	}
+	reference, err := WithDateReference(context.Background(), time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("WithDateReference() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		text string
+		want interface{}
+	}{
+		{"server time", context.Background(), `schedule event "Sync" tomorrow`, "2024-01-16"},
+		{"weekday", context.Background(), `schedule event "Sync" next friday`, "2024-01-19"},
+		{"days ahead", context.Background(), `schedule event "Sync" in 3 days`, "2024-01-18"},
+		{"request time zone", tokyo, `schedule event "Sync" tomorrow`, "2024-01-17"},
+		{"request reference time", reference, `schedule event "Sync" today`, "2024-03-01"},
+		{"nothing to resolve", context.Background(), `schedule event "Sync"`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(tt.ctx, tt.text)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["date_resolved"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("date_resolved = %v, want %v (date = %v)", got, tt.want, intent.Vars["date"])
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_ResolveDatesIsOptIn(t *testing.T) {
+	provider := newTestEnhancedProvider(t, eventConfig())
+
+	intent, err := provider.ExtractIntent(context.Background(), `schedule event "Sync" tomorrow`)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if got, exists := intent.Vars["date_resolved"]; exists {
+		t.Errorf("date_resolved = %v, want it only for entities with resolve: date", got)
+	}
+}
+
+func TestWithDateReference_InvalidTimezone(t *testing.T) {
+	if _, err := WithDateReference(context.Background(), time.Time{}, "Mars/Olympus"); err == nil {
+		t.Error("WithDateReference() error = nil, want an invalid time zone error")
+	}
+}
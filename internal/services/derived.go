@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// compileDerived parses the config's derived templates. Referring to a var
+// that wasn't extracted is an error when a template runs, which addDerivedVars
+// turns into an empty value.
+func compileDerived(derived map[string]string) (map[string]*template.Template, []error) {
+	templates := make(map[string]*template.Template, len(derived))
+	var errs []error
+	for name, text := range derived {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("invalid derived var: name is empty"))
+			continue
+		}
+		tmpl, err := template.New("derived." + name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid derived template for %s: %w", name, err))
+			continue
+		}
+		templates[name] = tmpl
+	}
+	return templates, errs
+}
+
+// addDerivedVars sets each derived var from the vars extracted so far.
+// Templates see the vars as they were before any derived var was added, and
+// one that refers to a missing var yields an empty value.
+func (p *EnhancedLocalProvider) addDerivedVars(vars map[string]interface{}) {
+	if len(p.compiled.DerivedTemplates) == 0 {
+		return
+	}
+
+	inputs := make(map[string]interface{}, len(vars))
+	for key, value := range vars {
+		if !isEmptyVar(value) {
+			inputs[key] = value
+		}
+	}
+	for name, tmpl := range p.compiled.DerivedTemplates {
+		var value strings.Builder
+		if err := tmpl.Execute(&value, inputs); err != nil {
+			vars[name] = ""
+			continue
+		}
+		vars[name] = value.String()
+	}
+}
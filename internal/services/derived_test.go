@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEnhancedLocalProvider_Derived(t *testing.T) {
+	config := contactConfig()
+	config.Derived = map[string]string{
+		"full_contact": "{{.name}} <{{.email}}>",
+		"greeting":     "Hello {{.name}}",
+	}
+	provider := newTestEnhancedProvider(t, config)
+
+	tests := []struct {
+		name            string
+		input           string
+		wantFullContact string
+		wantGreeting    string
+	}{
+		{
+			name:            "both inputs extracted",
+			input:           `add contact "Alice Brown" alice@example.com`,
+			wantFullContact: "Alice Brown <alice@example.com>",
+			wantGreeting:    "Hello Alice Brown",
+		},
+		{
+			name:         "email missing",
+			input:        `add contact "Alice Brown"`,
+			wantGreeting: "Hello Alice Brown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["full_contact"]; got != tt.wantFullContact {
+				t.Errorf("full_contact = %q, want %q", got, tt.wantFullContact)
+			}
+			if got := intent.Vars["greeting"]; got != tt.wantGreeting {
+				t.Errorf("greeting = %q, want %q", got, tt.wantGreeting)
+			}
+		})
+	}
+}
+
+func TestIntentService_DerivedAfterFollowUp(t *testing.T) {
+	// A reply that supplies the missing input fills in the derived var
+	config := contactConfig()
+	contact := config.Intents["CreateContact"]
+	contact.Required = []string{"name", "email"}
+	config.Intents["CreateContact"] = contact
+	config.Derived = map[string]string{"full_contact": "{{.name}} <{{.email}}>"}
+	service := &IntentService{
+		aiProvider:       newTestEnhancedProvider(t, config),
+		sessions:         NewMemorySessionStore(0),
+		maxFollowUpDepth: DefaultMaxFollowUpDepth,
+	}
+	conversation := Conversation{SessionID: "derived"}
+
+	if _, err := service.ExtractIntentWithContext(context.Background(), `add contact "Alice Brown"`, conversation); err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	intent, err := service.ExtractIntentWithContext(context.Background(), "alice@example.com", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if got, want := intent.Vars["full_contact"], fmt.Sprintf("%v <alice@example.com>", intent.Vars["name"]); got != want {
+		t.Errorf("full_contact = %q, want %q from the merged vars", got, want)
+	}
+}
+
+func TestCompileConfig_InvalidDerived(t *testing.T) {
+	config := contactConfig()
+	config.Derived = map[string]string{"full_contact": "{{.name"}
+
+	if _, err := compileConfig(config); err == nil || !strings.Contains(err.Error(), "invalid derived template for full_contact") {
+		t.Errorf("compileConfig() error = %v, want the derived template reported", err)
+	}
+}
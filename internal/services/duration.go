@@ -0,0 +1,181 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// durationVar is the variable filled by the built-in duration entity
+const durationVar = "duration"
+
+// durationTriggers are the words a duration follows, as in "for 30 minutes"
+var durationTriggers = map[string]bool{"for": true, "lasting": true}
+
+// durationUnits are the length in minutes of each unit word
+var durationUnits = map[string]float64{
+	"m": 1, "min": 1, "mins": 1, "minute": 1, "minutes": 1,
+	"h": 60, "hr": 60, "hrs": 60, "hour": 60, "hours": 60,
+}
+
+var (
+	// Durations written as one word: "2h", "90min", "1.5hrs", "1h30m"
+	compactDurationRegex = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)(?:h|hrs?|hours?))?(?:(\d+)(?:m|mins?|minutes?))?$`)
+	decimalRegex         = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+)
+
+// parseDuration finds the first duration after "for" or "lasting" in text,
+// such as "30 minutes", "an hour and a half" or "2h". It returns the duration
+// as written and its length in whole minutes.
+func parseDuration(text string) (raw string, minutes int, ok bool) {
+	words := strings.Fields(text)
+	for i, word := range words {
+		if !durationTriggers[strings.ToLower(strings.Trim(word, ".,!?;:"))] {
+			continue
+		}
+		total, length := readDuration(words[i+1:])
+		if length == 0 || total <= 0 {
+			continue
+		}
+		raw = strings.TrimRight(strings.Join(words[i+1:i+1+length], " "), ".,!?;:")
+		return raw, int(math.Round(total)), true
+	}
+	return "", 0, false
+}
+
+// readDuration reads the duration at the start of words, adding up parts
+// such as "1 hour and 30 minutes". It returns the total in minutes and how
+// many words it used, 0 when words don't start with a duration.
+func readDuration(words []string) (minutes float64, length int) {
+	for length < len(words) {
+		part, n := readDurationPart(words[length:])
+		if n == 0 {
+			break
+		}
+		minutes += part
+		length += n
+
+		// "1 hour and 30 minutes"
+		if length+1 < len(words) && durationWord(words[length]) == "and" {
+			if _, next := readDurationPart(words[length+1:]); next > 0 {
+				length++
+			}
+		}
+	}
+	return minutes, length
+}
+
+// readDurationPart reads one amount and unit, such as "30 minutes", "2h",
+// "half an hour" or "two and a half hours", from the start of words
+func readDurationPart(words []string) (minutes float64, length int) {
+	first := durationWord(words[0])
+	if matches := compactDurationRegex.FindStringSubmatch(first); matches != nil && (matches[1] != "" || matches[2] != "") {
+		hours, _ := strconv.ParseFloat(matches[1], 64)
+		mins, _ := strconv.Atoi(matches[2])
+		return hours*60 + float64(mins), 1
+	}
+
+	// "half an hour", "half hour"
+	if first == "half" {
+		rest := words[1:]
+		if len(rest) > 0 && (durationWord(rest[0]) == "a" || durationWord(rest[0]) == "an") {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			if unit, exists := durationUnits[durationWord(rest[0])]; exists {
+				return unit / 2, len(words) - len(rest) + 1
+			}
+		}
+		return 0, 0
+	}
+
+	amount, length := readDurationAmount(words)
+	if length == 0 {
+		return 0, 0
+	}
+	half := hasHalf(words[length:]) // "two and a half hours"
+	if half {
+		amount += 0.5
+		length += 3
+	}
+	if length >= len(words) {
+		return 0, 0
+	}
+	unit, exists := durationUnits[durationWord(words[length])]
+	if !exists {
+		return 0, 0
+	}
+	length++
+	if !half && hasHalf(words[length:]) { // "an hour and a half"
+		amount += 0.5
+		length += 3
+	}
+	return amount * unit, length
+}
+
+// readDurationAmount reads the number starting a duration: digits such as
+// "1.5", "a" or "an" for one, or spelled-out words such as "forty five"
+func readDurationAmount(words []string) (amount float64, length int) {
+	first := durationWord(words[0])
+	if first == "a" || first == "an" {
+		return 1, 1
+	}
+	if decimalRegex.MatchString(first) {
+		amount, _ = strconv.ParseFloat(first, 64)
+		return amount, 1
+	}
+	// "twenty-five" is read like "twenty five"
+	if parts := strings.Split(first, "-"); len(parts) > 1 {
+		if value, isOrdinal, n := parseNumber(parts); n == len(parts) && !isOrdinal {
+			return float64(value), 1
+		}
+		return 0, 0
+	}
+
+	lowered := make([]string, len(words))
+	for i, word := range words {
+		lowered[i] = durationWord(word)
+	}
+	value, isOrdinal, n := parseNumber(lowered)
+	if n == 0 || isOrdinal {
+		return 0, 0
+	}
+	return float64(value), n
+}
+
+// hasHalf reports whether words start with "and a half"
+func hasHalf(words []string) bool {
+	return len(words) >= 3 && durationWord(words[0]) == "and" &&
+		durationWord(words[1]) == "a" && durationWord(words[2]) == "half"
+}
+
+// durationWord lowercases word and drops trailing punctuation
+func durationWord(word string) string {
+	return strings.ToLower(strings.TrimRight(word, ".,!?;:"))
+}
+
+// addDurations sets <entity>_minutes for every extracted duration entity and,
+// when the config enables builtin_entities and doesn't define a duration
+// entity, extracts duration itself
+func (p *EnhancedLocalProvider) addDurations(text string, entities map[string][]string, vars map[string]interface{}) {
+	if _, defined := p.config.Entities[durationVar]; !defined && p.config.BuiltinEntities {
+		if raw, _, ok := parseDuration(text); ok {
+			entities[durationVar] = []string{raw}
+		}
+	}
+
+	for name, values := range entities {
+		entity := p.config.Entities[name]
+		if name != durationVar && entity.Type != durationVar {
+			continue
+		}
+		if len(values) != 1 {
+			continue
+		}
+		// The value is re-read on its own, so a "for" is put in front
+		if _, total, ok := parseDuration("for " + values[0]); ok {
+			vars[name+"_minutes"] = total
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		text        string
+		wantRaw     string
+		wantMinutes int
+		wantOK      bool
+	}{
+		{text: "meeting for 30 minutes", wantRaw: "30 minutes", wantMinutes: 30, wantOK: true},
+		{text: "block time for 1 hour", wantRaw: "1 hour", wantMinutes: 60, wantOK: true},
+		{text: "call lasting an hour and a half", wantRaw: "an hour and a half", wantMinutes: 90, wantOK: true},
+		{text: "workshop for two and a half hours", wantRaw: "two and a half hours", wantMinutes: 150, wantOK: true},
+		{text: "review for 1.5 hours tomorrow", wantRaw: "1.5 hours", wantMinutes: 90, wantOK: true},
+		{text: "focus for 0.25 hours", wantRaw: "0.25 hours", wantMinutes: 15, wantOK: true},
+		{text: "standup for half an hour", wantRaw: "half an hour", wantMinutes: 30, wantOK: true},
+		{text: "sync for 2h", wantRaw: "2h", wantMinutes: 120, wantOK: true},
+		{text: "sync for 1h30m.", wantRaw: "1h30m", wantMinutes: 90, wantOK: true},
+		{text: "lunch for 1 hour and 15 minutes", wantRaw: "1 hour and 15 minutes", wantMinutes: 75, wantOK: true},
+		{text: "lunch for 1 hr 15 mins with Bob", wantRaw: "1 hr 15 mins", wantMinutes: 75, wantOK: true},
+		{text: "run for forty-five minutes", wantRaw: "forty-five minutes", wantMinutes: 45, wantOK: true},
+		{text: "schedule a meeting for Bob for 20 min", wantRaw: "20 min", wantMinutes: 20, wantOK: true},
+		{text: "remind me in 30 minutes"},
+		{text: "schedule a meeting for tomorrow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			raw, minutes, ok := parseDuration(tt.text)
+			if raw != tt.wantRaw || minutes != tt.wantMinutes || ok != tt.wantOK {
+				t.Errorf("parseDuration() = %q, %d, %v, want %q, %d, %v", raw, minutes, ok, tt.wantRaw, tt.wantMinutes, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_Duration(t *testing.T) {
+	tests := []struct {
+		name        string
+		entity      bool // Define duration as an entity rather than using the built-in one
+		builtin     bool
+		input       string
+		wantRaw     interface{}
+		wantMinutes interface{}
+	}{
+		{name: "entity", entity: true, input: `schedule a meeting "Review" today for an hour and a half`, wantRaw: "an hour and a half", wantMinutes: 90},
+		{name: "builtin", builtin: true, input: `schedule a meeting "Review" today for 45 minutes`, wantRaw: "45 minutes", wantMinutes: 45},
+		{name: "builtin digits aren't numbers", builtin: true, input: `schedule a meeting "Review" today for 2 hours`, wantRaw: "2 hours", wantMinutes: 120},
+		{name: "disabled", input: `schedule a meeting "Review" today for 45 minutes`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := eventConfig()
+			config.BuiltinEntities = tt.builtin
+			if tt.entity {
+				config.Entities["duration"] = models.EntityPattern{Type: "duration"}
+			}
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["duration"]; got != tt.wantRaw {
+				t.Errorf("Vars[duration] = %v, want %v", got, tt.wantRaw)
+			}
+			if got := intent.Vars["duration_minutes"]; got != tt.wantMinutes {
+				t.Errorf("Vars[duration_minutes] = %v, want %v", got, tt.wantMinutes)
+			}
+			if _, exists := intent.Vars["number"]; exists {
+				t.Errorf("Vars[number] = %v, want the duration's digits left out", intent.Vars["number"])
+			}
+			if wantComplete := tt.wantRaw != nil; intent.IsComplete != wantComplete {
+				t.Errorf("IsComplete = %v, want %v (missing %v)", intent.IsComplete, wantComplete, intent.Missing)
+			}
+		})
+	}
+}
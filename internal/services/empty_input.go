@@ -0,0 +1,36 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"myllm/internal/models"
+)
+
+// reasonVar explains why an intent is UNKNOWN without classifying the text
+const reasonVar = "reason"
+
+// ReasonEmptyInput is the reason var of intents for texts with nothing to
+// classify, such as "..." or whitespace
+const ReasonEmptyInput = "empty_input"
+
+// hasContent reports whether text has a letter or digit left to classify
+func hasContent(text string) bool {
+	return strings.ContainsFunc(text, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r)
+	})
+}
+
+// emptyInputIntent is the intent of a text that normalizes to nothing
+func emptyInputIntent() *models.Intent {
+	return &models.Intent{
+		Task: "UNKNOWN",
+		Vars: map[string]interface{}{reasonVar: ReasonEmptyInput},
+	}
+}
+
+// isEmptyInput reports whether intent was answered without classification
+// because its text was empty
+func isEmptyInput(intent *models.Intent) bool {
+	return intent.Task == "UNKNOWN" && intent.Vars[reasonVar] == ReasonEmptyInput
+}
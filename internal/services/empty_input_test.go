@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestIntentService_EmptyInput(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "whitespace only", text: "   \t\n "},
+		{name: "punctuation only", text: "..."},
+		{name: "punctuation and whitespace", text: " ?! -- "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &stubProvider{name: "stub"}
+			service := &IntentService{aiProvider: provider}
+
+			intent, err := service.ExtractIntent(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "UNKNOWN" || intent.Vars["reason"] != ReasonEmptyInput {
+				t.Errorf("intent = %+v, want UNKNOWN with reason %s", intent, ReasonEmptyInput)
+			}
+			if provider.calls != 0 {
+				t.Errorf("provider called %d times, want the pipeline skipped", provider.calls)
+			}
+		})
+	}
+
+	// Text with a letter or digit is classified as usual
+	provider := &stubProvider{name: "stub"}
+	service := &IntentService{aiProvider: provider}
+	for _, text := range []string{"42", "ok!"} {
+		intent, err := service.ExtractIntent(context.Background(), text)
+		if err != nil {
+			t.Fatalf("ExtractIntent(%q) error = %v", text, err)
+		}
+		if intent.Vars["reason"] != nil {
+			t.Errorf("ExtractIntent(%q) reason = %v, want the text classified", text, intent.Vars["reason"])
+		}
+	}
+	if provider.calls != 2 {
+		t.Errorf("provider called %d times, want 2", provider.calls)
+	}
+}
+
+func TestIntentService_EmptyInputKeepsSession(t *testing.T) {
+	service := newSessionTestService(t, eventConfig(), 2)
+	conversation := Conversation{SessionID: "session-1"}
+	ctx := context.Background()
+
+	first, err := service.ExtractIntentWithContext(ctx, `schedule a meeting "Standup"`, conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+
+	// "..." neither answers the follow-up nor uses up one of the two turns allowed
+	intent, err := service.ExtractIntentWithContext(ctx, "...", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Vars["reason"] != ReasonEmptyInput {
+		t.Errorf("intent = %+v, want the empty input reported", intent)
+	}
+
+	session, err := service.sessions.Get(ctx, "session-1")
+	if err != nil || session == nil {
+		t.Fatalf("Get() = %v, %v, want the session kept", session, err)
+	}
+	if session.Intent.Task != first.Task || !slices.Equal(session.Intent.Missing, first.Missing) {
+		t.Errorf("session intent = %+v, want it unchanged", session.Intent)
+	}
+
+	intent, err = service.ExtractIntentWithContext(ctx, "tomorrow at 3pm", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != first.Task || intent.MaxDepthReached {
+		t.Errorf("intent = %+v, want the follow-up answered within the depth cap", intent)
+	}
+}
@@ -2,160 +2,556 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 
 	"myllm/internal/models"
 )
 
 // EnhancedLocalProvider implements AIProvider with configurable intent recognition
 type EnhancedLocalProvider struct {
-	config     *models.IntentConfig
+	// current is the config set being served. Requests classify with a
+	// snapshot of it, so they never lock and a swap can't change the config
+	// under them.
+	current    atomic.Pointer[configSet]
+	swapMu     sync.Mutex           // Serializes Reload and SetIntentEnabled
+	config     *models.IntentConfig // Config of the language served; set on snapshots
 	compiled   *CompiledConfig
 	configPath string
+	now        func() time.Time // Clock used to resolve relative dates (time.Now if nil)
+	classifier Classifier       // Picks the intent instead of scoring, if set
+
+	// Per-language configs keyed by language, including the default, on a
+	// snapshot. Nil for a provider built around a single config.
+	languageConfigs map[string]*models.IntentConfig
+	languages       map[string]*CompiledConfig
+	defaultLanguage string
+	detectLanguage  bool // Pick the language from the text when a request names none
+
+	// legacyConfidenceInVars also copies the confidence into Vars["confidence"]
+	// for clients that haven't moved to the top-level field yet
+	legacyConfidenceInVars bool
+}
+
+// configSet is the per-language configs of a provider and their compiled
+// patterns, keyed by language. It is never modified once stored; swaps
+// replace it as a whole.
+type configSet struct {
+	configs  map[string]*models.IntentConfig
+	compiled map[string]*CompiledConfig
 }
 
 // CompiledConfig holds pre-compiled patterns for performance
 type CompiledConfig struct {
-	IntentRegexes map[string][]*regexp.Regexp
-	EntityRegexes map[string][]*regexp.Regexp
-	KeywordMap    map[string][]string
-	PhraseMap     map[string][]string
-	SynonymMap    map[string]string
+	IntentRegexes      map[string][]*regexp.Regexp
+	EntityRegexes      map[string][]*regexp.Regexp
+	KeywordMap         map[string][]string
+	PhraseMap          map[string][]string
+	SynonymSets        map[string][]string           // Lowercase word -> words it matches, see compileSynonyms
+	RestOfInputRegexes map[string]*regexp.Regexp     // Trigger keywords for rest-of-input entities
+	HonorificRegex     *regexp.Regexp                // Matches an honorific followed by a name
+	HonorificMap       map[string]string             // Lowercase honorific -> configured spelling
+	ExactPhrases       map[string]string             // Normalized phrase/example -> intent
+	Negators           [][]string                    // Negators as normalized word sequences
+	FuzzyDistances     map[string][]int              // Edits allowed per keyword, parallel to KeywordMap (0 = exact only)
+	StopWords          map[string]bool               // Case-folded stop words
+	Tokenizer          Tokenizer                     // Splits text into words; whitespace if nil
+	DerivedTemplates   map[string]*template.Template // Derived var -> template building it
+	Vocabulary         map[string]bool               // Case-folded words the config is written in, for language detection
+
+	// Parsed follow_up questions per intent
+	FollowUpTemplates map[string][]followUpTemplate
 }
 
-// NewEnhancedLocalProvider creates a new enhanced local AI provider
+// NewEnhancedLocalProvider creates a new enhanced local AI provider. configPath
+// is a config file, a directory of per-language files such as en.json and
+// es.json, or an http(s) URL; without one the built-in default config is used.
 func NewEnhancedLocalProvider(configPath string) (AIProvider, error) {
-	var config *models.IntentConfig
+	defaultLanguage := normalizeLanguage(getEnv("INTENT_DEFAULT_LANGUAGE", DefaultLanguage))
+
+	var configs map[string]*models.IntentConfig
 	var err error
 
 	// Try to load from file, fallback to default
 	if configPath != "" {
-		fmt.Printf("Loading intent configuration from: %s\n", configPath)
-		config, err = models.LoadIntentConfig(configPath)
+		configs, err = loadIntentConfigs(configPath, defaultLanguage)
+		if errors.Is(err, models.ErrConfigFetch) && getBoolEnv("INTENT_CONFIG_FALLBACK", false) {
+			slog.Warn("Failed to fetch intent configuration, using the default configuration until a reload succeeds",
+				"path", configPath, "error", err)
+			configs, err = map[string]*models.IntentConfig{defaultLanguage: models.GetDefaultConfig()}, nil
+		}
 		if err != nil {
-			fmt.Printf("Failed to load config from %s: %v\n", configPath, err)
 			return nil, fmt.Errorf("failed to load config from %s: %w", configPath, err)
 		}
-		fmt.Printf("Successfully loaded configuration with domain: %s\n", config.Domain)
+		for language, config := range configs {
+			slog.Info("Loaded intent configuration", "path", configPath, "language", language, "domain", config.Domain)
+		}
 	} else {
-		fmt.Printf("No config path provided, using default configuration\n")
-		config = models.GetDefaultConfig()
-		fmt.Printf("Using default configuration with domain: %s\n", config.Domain)
+		configs = map[string]*models.IntentConfig{defaultLanguage: models.GetDefaultConfig()}
+		slog.Info("No config path provided, using default intent configuration", "domain", configs[defaultLanguage].Domain)
 	}
 
-	// Log available intents
-	fmt.Printf("Available intents (%d):\n", len(config.Intents))
-	for intentName, intent := range config.Intents {
-		fmt.Printf("  - %s: %s (priority: %d, required: %v)\n",
-			intentName, intent.Description, intent.Priority, intent.Required)
-	}
-
-	// Log available entities
-	fmt.Printf("Available entities (%d):\n", len(config.Entities))
-	for entityName, entity := range config.Entities {
-		fmt.Printf("  - %s: %s\n", entityName, entity.Description)
+	// Log available intents and entities
+	for language, config := range configs {
+		for intentName, intent := range config.Intents {
+			slog.Debug("Available intent", "language", language, "intent", intentName, "description", intent.Description,
+				"priority", intent.Priority, "required", intent.Required)
+		}
+		for entityName, entity := range config.Entities {
+			slog.Debug("Available entity", "language", language, "entity", entityName, "description", entity.Description)
+		}
 	}
 
 	// Compile patterns for performance
-	compiled, err := compileConfig(config)
+	start := time.Now()
+	languages, err := compileLanguages(configs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compile config: %w", err)
+		return nil, err
 	}
+	slog.Info("Compiled intent configuration", "languages", len(languages), "duration_ms", float64(time.Since(start).Microseconds())/1000)
 
-	fmt.Printf("Configuration compilation completed successfully\n")
+	provider := &EnhancedLocalProvider{
+		configPath:             configPath,
+		defaultLanguage:        defaultLanguage,
+		detectLanguage:         getBoolEnv("INTENT_DETECT_LANGUAGE", false),
+		legacyConfidenceInVars: getBoolEnv("LEGACY_CONFIDENCE_IN_VARS", false),
+	}
+	provider.setLanguages(configs, languages)
+	return provider, nil
+}
 
-	return &EnhancedLocalProvider{
-		config:     config,
-		compiled:   compiled,
-		configPath: configPath,
-	}, nil
+// loadIntentConfigs loads configs like models.LoadIntentConfigs, fetching a
+// URL within INTENT_CONFIG_TIMEOUT
+func loadIntentConfigs(path, defaultLanguage string) (map[string]*models.IntentConfig, error) {
+	if !models.IsConfigURL(path) {
+		return models.LoadIntentConfigs(path, defaultLanguage)
+	}
+	config, err := models.LoadIntentConfigURL(path, getDurationEnv("INTENT_CONFIG_TIMEOUT", models.DefaultConfigFetchTimeout))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*models.IntentConfig{defaultLanguage: config}, nil
 }
 
-// compileConfig pre-compiles all regex patterns for performance
+// compileConfig pre-compiles all regex patterns for performance. Every
+// invalid regex, and every one over REGEX_MAX_LENGTH or REGEX_MAX_PATTERNS,
+// is reported, joined into one error.
 func compileConfig(config *models.IntentConfig) (*CompiledConfig, error) {
+	var errs []error
+	limits := currentRegexLimits()
 	compiled := &CompiledConfig{
-		IntentRegexes: make(map[string][]*regexp.Regexp),
-		EntityRegexes: make(map[string][]*regexp.Regexp),
-		KeywordMap:    make(map[string][]string),
-		PhraseMap:     make(map[string][]string),
-		SynonymMap:    make(map[string]string),
+		IntentRegexes:      make(map[string][]*regexp.Regexp),
+		EntityRegexes:      make(map[string][]*regexp.Regexp),
+		KeywordMap:         make(map[string][]string),
+		PhraseMap:          make(map[string][]string),
+		RestOfInputRegexes: make(map[string]*regexp.Regexp),
+		HonorificMap:       make(map[string]string),
+		ExactPhrases:       make(map[string]string),
+		FuzzyDistances:     make(map[string][]int),
+		FollowUpTemplates:  make(map[string][]followUpTemplate),
 	}
 
 	// Compile intent regexes
 	for intentName, intent := range config.Intents {
 		var regexes []*regexp.Regexp
-		for _, pattern := range intent.Regex {
+		patterns, limitErrs := limits.check("intent "+intentName, intent.Regex)
+		errs = append(errs, limitErrs...)
+		for _, pattern := range patterns {
 			re, err := regexp.Compile(pattern)
 			if err != nil {
-				return nil, fmt.Errorf("invalid regex for intent %s: %w", intentName, err)
+				errs = append(errs, fmt.Errorf("invalid regex for intent %s: %w", intentName, err))
+				continue
 			}
 			regexes = append(regexes, re)
 		}
 		compiled.IntentRegexes[intentName] = regexes
 		compiled.KeywordMap[intentName] = intent.Keywords
 		compiled.PhraseMap[intentName] = intent.Phrases
+		compiled.FuzzyDistances[intentName] = compileFuzzyDistances(intent.Keywords, config.FuzzyThreshold)
+
+		followUps, followUpErrs := compileFollowUps(intentName, intent.FollowUp)
+		compiled.FollowUpTemplates[intentName] = followUps
+		errs = append(errs, followUpErrs...)
 	}
 
 	// Compile entity regexes
 	for entityName, entity := range config.Entities {
 		var regexes []*regexp.Regexp
-		for _, pattern := range entity.Regex {
+		patterns, limitErrs := limits.check("entity "+entityName, entity.Regex)
+		errs = append(errs, limitErrs...)
+		for _, pattern := range patterns {
 			re, err := regexp.Compile(pattern)
 			if err != nil {
-				return nil, fmt.Errorf("invalid regex for entity %s: %w", entityName, err)
+				errs = append(errs, fmt.Errorf("invalid regex for entity %s: %w", entityName, err))
+				continue
+			}
+			// The value must be unambiguous: a named group, or the only group
+			if re.SubexpIndex(entityValueGroup) < 0 && re.NumSubexp() != 1 {
+				errs = append(errs, fmt.Errorf("invalid regex for entity %s: %q needs a (?P<%s>...) group or exactly one capturing group, found %d",
+					entityName, pattern, entityValueGroup, re.NumSubexp()))
+				continue
 			}
 			regexes = append(regexes, re)
 		}
 		compiled.EntityRegexes[entityName] = regexes
+
+		if entity.Extraction == models.ExtractionRestOfInput {
+			compiled.RestOfInputRegexes[entityName] = compileRestOfInputRegex(entity.Keywords)
+		}
+	}
+
+	derived, derivedErrs := compileDerived(config.Derived)
+	compiled.DerivedTemplates = derived
+	errs = append(errs, derivedErrs...)
+
+	tokenizer, err := newTokenizer(config.Tokenizer)
+	if err != nil {
+		errs = append(errs, err)
 	}
+	compiled.Tokenizer = tokenizer
 
-	// Build synonym map
-	for word, synonyms := range config.Synonyms {
-		for _, synonym := range synonyms {
-			compiled.SynonymMap[synonym] = word
+	if region := normalizeRegion(config.DefaultRegion); region != "" {
+		if _, exists := phoneRegions[region]; !exists {
+			errs = append(errs, fmt.Errorf("unsupported default_region %q (supported: %s)", config.DefaultRegion, strings.Join(PhoneRegions(), ", ")))
 		}
 	}
 
+	if len(errs) > 0 {
+		// Map order is random; sort so the report is stable
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return nil, errors.Join(errs...)
+	}
+
+	// Expand synonyms once so keyword scoring is a lookup
+	compiled.SynonymSets = compileSynonyms(config.Synonyms, config.SynonymDepth)
+
+	// Index phrases and examples for exact-match classification. When the same
+	// text belongs to several intents, the higher priority (then name) wins.
+	// Disabled intents are left out so they can't shadow an enabled one.
+	for intentName, intent := range config.Intents {
+		if !intent.IsEnabled() {
+			continue
+		}
+		candidates := append(append([]string{}, intent.Phrases...), intent.Examples...)
+		for _, candidate := range candidates {
+			key := normalizeForMatching(candidate)
+			if key == "" {
+				continue
+			}
+			if existing, exists := compiled.ExactPhrases[key]; exists {
+				existingPriority := config.Intents[existing].Priority
+				if existingPriority > intent.Priority || (existingPriority == intent.Priority && existing < intentName) {
+					continue
+				}
+			}
+			compiled.ExactPhrases[key] = intentName
+		}
+	}
+
+	// Compile honorific detection
+	honorifics := config.Honorifics
+	if honorifics == nil {
+		honorifics = models.DefaultHonorifics
+	}
+	var alternatives []string
+	for _, honorific := range honorifics {
+		honorific = strings.TrimSuffix(strings.TrimSpace(honorific), ".")
+		if honorific == "" {
+			continue
+		}
+		compiled.HonorificMap[strings.ToLower(honorific)] = honorific
+		alternatives = append(alternatives, regexp.QuoteMeta(honorific))
+	}
+	if len(alternatives) > 0 {
+		compiled.HonorificRegex = regexp.MustCompile(`(?i)\b(` + strings.Join(alternatives, "|") + `)\.?\s+`)
+	}
+
+	// Compile negation detection
+	negators := config.Negators
+	if negators == nil {
+		negators = models.DefaultNegators
+	}
+	compiled.Negators = compileNegators(negators)
+
+	// Compile stop words
+	compiled.StopWords = compileStopWords(config)
+	compiled.Vocabulary = compileVocabulary(config)
+
 	return compiled, nil
 }
 
-// ExtractIntent extracts intent using enhanced local processing
+// compileRestOfInputRegex builds a regex that matches the first trigger keyword
+// and captures everything after it. Longer triggers are tried first so that
+// "note that" wins over "note" at the same position.
+func compileRestOfInputRegex(keywords []string) *regexp.Regexp {
+	triggers := make([]string, len(keywords))
+	copy(triggers, keywords)
+	sort.SliceStable(triggers, func(i, j int) bool {
+		return len(triggers[i]) > len(triggers[j])
+	})
+
+	alternatives := make([]string, len(triggers))
+	for i, trigger := range triggers {
+		alternatives[i] = regexp.QuoteMeta(trigger)
+	}
+
+	return regexp.MustCompile(`(?is)\b(?:` + strings.Join(alternatives, "|") + `)\b(.*)`)
+}
+
+// ExtractIntent extracts intent using enhanced local processing, with the
+// config of the requested or detected language
 func (p *EnhancedLocalProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	snapshot := p.snapshot()
+	provider := snapshot.forLanguage(snapshot.selectLanguage(ctx, text))
+	intent, err := provider.extractIntent(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// "create a contact for Bob and schedule a meeting" asks for two things
+	subIntents, err := provider.extractSubIntents(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if subIntents != nil {
+		intent.Vars[subIntentsVar] = subIntents
+	} else if intent.Task == "UNKNOWN" && provider.config.FallbackIntent != "" {
+		// Route unrecognized input to the configured fallback, such as a
+		// task that asks the user to rephrase
+		intent.Task = provider.config.FallbackIntent
+		intent.FollowUp = append([]string(nil), provider.config.FallbackFollowUp...)
+		intent.IsComplete = false
+	}
+	return intent, nil
+}
+
+// extractIntent classifies text with p's config. p is a snapshot.
+func (p *EnhancedLocalProvider) extractIntent(ctx context.Context, text string) (*models.Intent, error) {
 	normalizedText := p.normalizeText(text)
 
 	// Get intent with confidence score
-	intentResult := p.classifyIntent(normalizedText)
+	intentResult, err := p.classify(ctx, normalizedText)
+	if err != nil {
+		return nil, err
+	}
 
-	// Extract entities
-	entities := p.extractEntities(text)
+	// Extract entities, locating them before normalization rewrites them
+	entities, err := p.findEntities(ctx, text, intentResult.Intent)
+	if err != nil {
+		return nil, err
+	}
+	var spans map[string][]models.Span
+	if spansRequested(ctx) {
+		spans = entitySpans(text, entities)
+	}
+	p.normalizeEntities(entities)
 
 	// Build the intent structure
 	result := &models.Intent{
-		Task: intentResult.Intent,
-		Vars: make(map[string]interface{}),
+		Task:       intentResult.Intent,
+		Vars:       make(map[string]interface{}),
+		Confidence: intentResult.Confidence,
+	}
+
+	// Durations such as "for 30 minutes", also in minutes. They are found
+	// first so their digits aren't read as numbers.
+	p.addDurations(text, entities, result.Vars)
+
+	// Counts and positions such as "3 tasks" and "the second contact"
+	p.addBuiltinNumbers(text, entities, result.Vars)
+
+	// Reject malformed values such as "a@b" so they are asked for again
+	if p.config.StrictEntities {
+		p.validateEntities(entities)
 	}
 
-	// Map extracted entities to variables
-	for entityType, value := range entities {
-		result.Vars[entityType] = value
+	// Map values such as "urgent" to an entity's allowed values, rejecting
+	// the ones that map to none
+	rejected := p.applyAllowedValues(entities)
+
+	// Write phone numbers in E.164 form, dropping ones that aren't valid
+	// for the region so they are asked for again
+	var phoneCountries map[string][]string
+	if region := p.phoneRegion(ctx); region != "" {
+		phoneCountries = p.parsePhoneEntities(region, entities)
 	}
 
-	// Add confidence score
-	result.Vars["confidence"] = intentResult.Confidence
+	// Drop entities such as a time without the date it belongs to, once
+	// every other check has had its chance to drop the date
+	p.dropUnmetDependencies(entities)
+
+	// Map extracted entities to variables. Lists are only used when a
+	// multi-value entity matched more than once.
+	for entityType, values := range entities {
+		if len(values) == 1 {
+			result.Vars[entityType] = values[0]
+		} else {
+			result.Vars[entityType] = values
+		}
+	}
+	// Values dropped above have no span
+	for entityName := range spans {
+		if _, exists := entities[entityName]; !exists {
+			delete(spans, entityName)
+		}
+	}
+	if spans != nil {
+		result.Spans = spans
+	}
+	for entityName, countries := range phoneCountries {
+		if _, exists := entities[entityName]; !exists {
+			continue
+		}
+		if len(countries) == 1 {
+			result.Vars[entityName+"_country"] = countries[0]
+		} else {
+			result.Vars[entityName+"_country"] = countries
+		}
+	}
+
+	// Combine date, time and time zone into an absolute timestamp, and turn
+	// relative dates into calendar days where configured
+	p.resolveTimestamp(ctx, result)
+	p.resolveDates(ctx, result)
+
+	// Deprecated: kept for one release behind LEGACY_CONFIDENCE_IN_VARS
+	if p.legacyConfidenceInVars {
+		result.Vars["confidence"] = intentResult.Confidence
+	}
 
 	// Check for missing required fields and generate follow-up questions
 	if intentResult.Intent != "UNKNOWN" {
 		p.addMissingFieldsAndFollowUp(result, intentResult.Intent)
+		p.askForAllowedValues(result, intentResult.Intent, rejected)
+	}
+
+	alternatives := 0
+	if alternativesRequested(ctx) {
+		alternatives = maxAlternatives
+	} else if intentResult.Ambiguous {
+		// The near-tied intents are listed so the caller can ask which was meant
+		alternatives = 2
+	}
+	if alternatives > 0 {
+		if result.Alternatives, err = p.topAlternatives(ctx, normalizedText, intentResult, alternatives); err != nil {
+			return nil, err
+		}
+	}
+
+	// Renamed intents are still reported under their old names
+	result.Task = p.taskName(result.Task)
+	for i := range result.Alternatives {
+		result.Alternatives[i].Task = p.taskName(result.Alternatives[i].Task)
 	}
 
 	return result, nil
 }
 
-// addMissingFieldsAndFollowUp checks for missing required fields and adds follow-up questions
+// loggedAliases records the aliases whose deprecation has been logged
+var loggedAliases sync.Map
+
+// taskName returns the task reported for intentName, logging a deprecation
+// warning the first time each alias is used
+func (p *EnhancedLocalProvider) taskName(intentName string) string {
+	task := p.config.TaskName(intentName)
+	if task != intentName {
+		if _, logged := loggedAliases.LoadOrStore(intentName+"\x00"+task, true); !logged {
+			slog.Warn("Reporting intent under its deprecated alias", "intent", intentName, "alias", task)
+		}
+	}
+	return task
+}
+
+// maxAlternatives is the number of candidate intents returned on request
+const maxAlternatives = 3
+
+// topAlternatives returns up to n candidate intents, best first. The winner
+// leads the list; the rest follow by score. UNKNOWN is only returned when no
+// intent scored at all.
+func (p *EnhancedLocalProvider) topAlternatives(ctx context.Context, text string, result IntentResult, n int) ([]models.IntentCandidate, error) {
+	var alternatives []models.IntentCandidate
+	if result.Intent != "UNKNOWN" {
+		alternatives = append(alternatives, models.IntentCandidate{Task: result.Intent, Confidence: result.Confidence})
+	}
+
+	ranked := result.Ranked
+	if ranked == nil {
+		// Exact matches skip scoring
+		var err error
+		if ranked, err = p.rankIntents(ctx, text); err != nil {
+			return nil, err
+		}
+	}
+	for _, candidate := range ranked {
+		if len(alternatives) >= n {
+			break
+		}
+		if candidate.Task == result.Intent {
+			continue
+		}
+		candidate.Confidence = math.Min(candidate.Confidence, 1.0)
+		alternatives = append(alternatives, candidate)
+	}
+
+	if len(alternatives) == 0 {
+		return []models.IntentCandidate{{Task: "UNKNOWN", Confidence: 0}}, nil
+	}
+	return alternatives, nil
+}
+
+// resolveTimestamp sets Vars["datetime"] (RFC 3339) when a time was extracted,
+// using the extracted time zone or the request's or config's default zone
+func (p *EnhancedLocalProvider) resolveTimestamp(ctx context.Context, intent *models.Intent) {
+	clock, _ := intent.Vars["time"].(string)
+	if clock == "" {
+		return
+	}
+
+	now := p.dateReference(ctx)
+	loc := now.Location()
+	if zone, _ := intent.Vars["timezone"].(string); zone != "" {
+		var err error
+		if loc, err = loadTimezone(zone); err != nil {
+			return
+		}
+	}
+
+	date, _ := intent.Vars["date"].(string)
+	if timestamp, ok := resolveDateTime(date, clock, loc, now); ok {
+		intent.Vars["datetime"] = timestamp.Format(time.RFC3339)
+	}
+}
+
+// currentTime returns the provider's notion of now
+func (p *EnhancedLocalProvider) currentTime() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// completeIntent applies defaults and follow-ups to an intent built outside
+// ExtractIntent, such as a structured command
+func (p *EnhancedLocalProvider) completeIntent(intent *models.Intent, intentName string) {
+	p.snapshot().addMissingFieldsAndFollowUp(intent, intentName)
+}
+
+// addMissingFieldsAndFollowUp fills defaults and derived vars, then checks for
+// missing required fields and adds follow-up questions
 func (p *EnhancedLocalProvider) addMissingFieldsAndFollowUp(intent *models.Intent, intentName string) {
 	intentPattern, exists := p.config.Intents[intentName]
 	if !exists {
@@ -165,6 +561,16 @@ func (p *EnhancedLocalProvider) addMissingFieldsAndFollowUp(intent *models.Inten
 	var missing []string
 	var followUp []string
 
+	// Fill defaults for fields that were not extracted
+	for field, defaultValue := range intentPattern.Defaults {
+		if value, exists := intent.Vars[field]; !exists || value == "" {
+			intent.Vars[field] = defaultValue
+		}
+	}
+
+	// Build derived vars from the extracted ones and the defaults
+	p.addDerivedVars(intent.Vars)
+
 	// Check which required fields are missing
 	for _, requiredField := range intentPattern.Required {
 		if value, exists := intent.Vars[requiredField]; !exists || value == "" {
@@ -174,12 +580,35 @@ func (p *EnhancedLocalProvider) addMissingFieldsAndFollowUp(intent *models.Inten
 
 	// Generate follow-up questions for missing fields
 	for _, field := range missing {
-		question := p.generateFollowUpQuestion(intentName, field, intentPattern.FollowUp)
+		question := p.generateFollowUpQuestion(intentName, field)
 		if question != "" {
 			followUp = append(followUp, question)
 		}
 	}
 
+	// A one-of group is missing when none of its fields has a value; all of
+	// them are reported, with one question for the group
+	for _, group := range intentPattern.RequiredOneOf {
+		satisfied := false
+		for _, field := range group {
+			if value, exists := intent.Vars[field]; exists && value != "" {
+				satisfied = true
+				break
+			}
+		}
+		if satisfied || len(group) == 0 {
+			continue
+		}
+		for _, field := range group {
+			if !slices.Contains(missing, field) {
+				missing = append(missing, field)
+			}
+		}
+		if question := p.groupFollowUpQuestion(intentName, group); question != "" {
+			followUp = append(followUp, question)
+		}
+	}
+
 	// Update intent with missing fields and follow-up questions
 	intent.Missing = missing
 	intent.FollowUp = followUp
@@ -187,15 +616,23 @@ func (p *EnhancedLocalProvider) addMissingFieldsAndFollowUp(intent *models.Inten
 }
 
 // generateFollowUpQuestion generates a follow-up question for a missing field
-func (p *EnhancedLocalProvider) generateFollowUpQuestion(intentName, field string, customFollowUp []string) string {
+func (p *EnhancedLocalProvider) generateFollowUpQuestion(intentName, field string) string {
 	// Try to use custom follow-up questions first
-	for _, question := range customFollowUp {
-		if strings.Contains(strings.ToLower(question), strings.ToLower(field)) {
-			return question
-		}
+	if question, ok := p.customFollowUp(intentName, field); ok {
+		return question
 	}
 
-	// Generate default questions based on field type
+	// Default questions for fields with allowed values list them
+	question := p.defaultFollowUpQuestion(intentName, field)
+	if question != "" {
+		question = strings.TrimSuffix(question, "?") + p.allowedValuesHint(field) + "?"
+	}
+	return question
+}
+
+// defaultFollowUpQuestion generates a question for a missing field based on
+// its name
+func (p *EnhancedLocalProvider) defaultFollowUpQuestion(intentName, field string) string {
 	switch field {
 	case "title":
 		// Use a more natural intent name for the question
@@ -231,123 +668,228 @@ func (p *EnhancedLocalProvider) generateFollowUpQuestion(intentName, field strin
 	}
 }
 
-// getIntentDisplayName converts intent names to user-friendly display names
+// getIntentDisplayName returns the intent's configured display_name, or the
+// intent name split into lowercase words ("CreateEvent" -> "create event")
 func (p *EnhancedLocalProvider) getIntentDisplayName(intentName string) string {
-	switch intentName {
-	case "CreateEvent":
-		return "event"
-	case "CreateTask":
-		return "task"
-	case "CreateContact":
-		return "contact"
-	case "CreateNote":
-		return "note"
-	case "Weather":
-		return "weather"
-	case "Time":
-		return "time"
-	case "Calculator":
-		return "calculation"
-	default:
-		// Convert camelCase to lowercase with spaces
-		result := ""
-		for i, char := range intentName {
-			if i > 0 && unicode.IsUpper(char) {
-				result += " "
-			}
-			result += string(unicode.ToLower(char))
+	if intent, exists := p.config.Intents[intentName]; exists && intent.DisplayName != "" {
+		return intent.DisplayName
+	}
+
+	// Convert camelCase to lowercase with spaces
+	result := ""
+	for i, char := range intentName {
+		if i > 0 && unicode.IsUpper(char) {
+			result += " "
 		}
-		return result
+		result += string(unicode.ToLower(char))
 	}
+	return result
 }
 
 // IntentResult holds intent classification with confidence
 type IntentResult struct {
 	Intent     string
 	Confidence float64
+	Ranked     []models.IntentCandidate // Scores best first, uncapped or calibrated (nil for exact matches)
+	Ambiguous  bool                     // The two best intents scored within the config's ambiguity margin
 }
 
-// classifyIntent determines the intent with confidence scoring
-func (p *EnhancedLocalProvider) classifyIntent(text string) IntentResult {
+// classifyIntent determines the intent with confidence scoring. It stops
+// with ctx's error when ctx is done before every intent is scored.
+func (p *EnhancedLocalProvider) classifyIntent(ctx context.Context, text string) (IntentResult, error) {
+	// Canned phrases are classified deterministically
+	if intentName, ok := p.matchExactPhrase(text); ok {
+		confidence := p.config.ExactMatch.Confidence
+		if confidence == 0 {
+			confidence = 0.99
+		}
+		return IntentResult{
+			Intent:     intentName,
+			Confidence: confidence,
+		}, nil
+	}
+
 	var bestIntent string = "UNKNOWN"
 	var bestScore float64 = 0.0
 
-	// Score each intent
-	intentScores := make(map[string]float64)
-
-	for intentName, intent := range p.config.Intents {
-		score := p.calculateIntentScore(text, intentName, intent)
-		intentScores[intentName] = score
-
-		// Apply priority boost
-		priorityBoost := float64(intent.Priority) * 0.1
-		score += priorityBoost
-
-		if score > bestScore {
-			bestScore = score
-			bestIntent = intentName
-		}
+	ranked, err := p.rankIntents(ctx, text)
+	if err != nil {
+		return IntentResult{}, err
+	}
+	if len(ranked) > 0 {
+		bestIntent = ranked[0].Task
+		bestScore = ranked[0].Confidence
 	}
 
 	// Check confidence threshold
-	threshold := p.config.Confidence[bestIntent]
-	if threshold == 0 {
-		threshold = 0.5 // Default threshold
+	if bestScore < p.config.ConfidenceThreshold(bestIntent) {
+		bestIntent = "UNKNOWN"
+		bestScore = 0.0
 	}
 
-	if bestScore < threshold {
+	// A near tie is a coin flip, so neither intent is reported
+	ambiguous := false
+	if margin := p.config.AmbiguityMargin; margin > 0 && bestIntent != "UNKNOWN" && len(ranked) > 1 && ranked[0].Confidence-ranked[1].Confidence < margin {
 		bestIntent = "UNKNOWN"
 		bestScore = 0.0
+		ambiguous = true
+	}
+
+	// Thresholds apply to raw scores; only the reported confidences change
+	confidence := math.Min(bestScore, 1.0)
+	if calibrated, ok := p.calibrateScores(ranked); ok {
+		ranked = calibrated
+		if bestIntent != "UNKNOWN" {
+			confidence = ranked[0].Confidence
+		}
 	}
 
 	return IntentResult{
 		Intent:     bestIntent,
-		Confidence: math.Min(bestScore, 1.0),
+		Confidence: confidence,
+		Ranked:     ranked,
+		Ambiguous:  ambiguous,
+	}, nil
+}
+
+// rankIntents scores every intent, including the priority boost, and returns
+// those with a positive score, best first. Ties are broken by priority, then
+// by intent name so the winner is deterministic. Scores are not capped. ctx
+// is checked before each intent, so large configs stop promptly when the
+// request is cancelled.
+func (p *EnhancedLocalProvider) rankIntents(ctx context.Context, text string) ([]models.IntentCandidate, error) {
+	// Negated words ("don't create a contact") don't count towards any intent
+	scoringText, negated := p.stripNegated(text)
+
+	var ranked []models.IntentCandidate
+	for intentName, intent := range p.config.Intents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !intent.IsEnabled() {
+			continue
+		}
+
+		// With negation present, only intents matched outside the negated words
+		// are ranked, so the priority boost can't carry an intent on its own
+		if negated && !p.matchesIntent(scoringText, intentName) {
+			continue
+		}
+
+		score := p.scoreIntent(scoringText, textLength(ctx, scoringText), intentName, intent).Total()
+		if score > 0 {
+			ranked = append(ranked, models.IntentCandidate{Task: intentName, Confidence: score})
+		}
 	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		// Capped boosts can leave intents of different priorities level
+		if a, b := p.config.Intents[ranked[i].Task].Priority, p.config.Intents[ranked[j].Task].Priority; a != b {
+			return a > b
+		}
+		return ranked[i].Task < ranked[j].Task
+	})
+	return ranked, nil
 }
 
-// calculateIntentScore calculates a confidence score for an intent
-func (p *EnhancedLocalProvider) calculateIntentScore(text, intentName string, intent models.IntentPattern) float64 {
-	score := 0.0
+// matchExactPhrase finds the intent whose phrase or example equals the text, or is
+// within ExactMatch.MaxDistance edits of it
+func (p *EnhancedLocalProvider) matchExactPhrase(text string) (string, bool) {
+	if p.config.ExactMatch.Disabled {
+		return "", false
+	}
+
+	key := normalizeForMatching(text)
+	if intentName, exists := p.compiled.ExactPhrases[key]; exists {
+		return intentName, true
+	}
+
+	maxDistance := p.config.ExactMatch.MaxDistance
+	if maxDistance <= 0 {
+		return "", false
+	}
+
+	bestIntent := ""
+	bestPhrase := ""
+	bestDistance := maxDistance + 1
+	for phrase, intentName := range p.compiled.ExactPhrases {
+		// Skip phrases whose length alone rules them out
+		if abs(len(phrase)-len(key)) > maxDistance {
+			continue
+		}
+		distance := levenshtein(key, phrase)
+		if distance < bestDistance || (distance == bestDistance && phrase < bestPhrase) {
+			bestIntent = intentName
+			bestPhrase = phrase
+			bestDistance = distance
+		}
+	}
+
+	return bestIntent, bestIntent != ""
+}
+
+// calculateIntentScore breaks down the score of an intent for text. The
+// total used for ranking is the sum of the components.
+func (p *EnhancedLocalProvider) calculateIntentScore(text, intentName string, intent models.IntentPattern) models.ScoreBreakdown {
+	return p.scoreIntent(text, len(text), intentName, intent)
+}
+
+// scoreIntent is calculateIntentScore for a text that was length bytes long
+// before it was truncated
+func (p *EnhancedLocalProvider) scoreIntent(text string, length int, intentName string, intent models.IntentPattern) models.ScoreBreakdown {
+	var breakdown models.ScoreBreakdown
+	weights := p.config.ScoringWeightsFor(intentName)
+
+	cumulative := p.config.MatchAccumulation == models.AccumulationCumulative
 
 	// 1. Regex matching (highest weight)
-	for _, re := range p.compiled.IntentRegexes[intentName] {
+	regexHits := 0
+	for i, re := range p.compiled.IntentRegexes[intentName] {
 		if re.MatchString(text) {
-			score += 0.8
-			break
+			if regexHits == 0 {
+				breakdown.RegexHit = intent.Regex[i]
+			}
+			regexHits++
+			if !cumulative {
+				break
+			}
 		}
 	}
+	breakdown.Regex = accumulatedScore(weights.Regex, regexHits)
 
 	// 2. Exact phrase matching (high weight)
 	textLower := strings.ToLower(text)
+	matchedPhrases := make(map[string]bool)
 	for _, phrase := range p.compiled.PhraseMap[intentName] {
-		if strings.Contains(textLower, strings.ToLower(phrase)) {
-			score += 0.6
+		phraseLower := strings.ToLower(phrase)
+		if matchedPhrases[phraseLower] || !strings.Contains(textLower, phraseLower) {
+			continue
+		}
+		if len(matchedPhrases) == 0 {
+			breakdown.PhraseHit = phrase
+		}
+		matchedPhrases[phraseLower] = true
+		if !cumulative {
 			break
 		}
 	}
+	breakdown.Phrase = accumulatedScore(weights.Phrase, len(matchedPhrases))
 
-	// 3. Keyword matching with fuzzy scoring
+	// 3. Keyword matching with synonym and typo-tolerant scoring
 	keywords := p.compiled.KeywordMap[intentName]
+	fuzzyDistances := p.compiled.FuzzyDistances[intentName]
+	textWords := p.tokenize(text)
 	keywordScore := 0.0
-	matchedKeywords := 0
 
-	for _, keyword := range keywords {
-		// Exact match
-		if strings.Contains(textLower, strings.ToLower(keyword)) {
-			keywordScore += 0.4
-			matchedKeywords++
-		} else {
-			// Fuzzy match using synonym expansion
-			synonyms := p.getSynonyms(keyword)
-			for _, synonym := range synonyms {
-				if strings.Contains(textLower, strings.ToLower(synonym)) {
-					keywordScore += 0.3
-					matchedKeywords++
-					break
-				}
-			}
-		}
+	for i, keyword := range keywords {
+		match := p.matchKeyword(textLower, textWords, keyword, fuzzyDistances, i)
+		// Synonyms and misspellings keep their share of an exact match's weight
+		match.Score *= weights.Keyword / models.DefaultKeywordWeight
+		keywordScore += match.Score
+		breakdown.Keywords = append(breakdown.Keywords, match)
 	}
 
 	// Normalize keyword score
@@ -355,103 +897,292 @@ func (p *EnhancedLocalProvider) calculateIntentScore(text, intentName string, in
 		keywordScore = keywordScore / float64(len(keywords))
 	}
 
-	score += keywordScore
+	breakdown.Keyword = keywordScore
 
 	// 4. Word overlap scoring
-	textWords := p.tokenize(text)
 	intentWords := p.getIntentWords(intent)
 	overlap := p.calculateWordOverlap(textWords, intentWords)
-	score += overlap * 0.2
+	breakdown.WordOverlap = overlap * weights.Overlap
 
 	// 5. Length bonus (longer, more specific queries get higher scores)
-	if len(text) > 20 {
-		score += 0.1
+	if length > 20 {
+		breakdown.LengthBonus = weights.Length
 	}
 
+	// 6. Priority boost
+	breakdown.PriorityBoost = p.config.PriorityBoost(intentName, breakdown.Total())
+
+	return breakdown
+}
+
+// accumulatedScore is what hits matching regexes or phrases of a component
+// worth weight add up to: the weight for the first and half the previous
+// increment for each further one, so the total stays below twice the weight
+func accumulatedScore(weight float64, hits int) float64 {
+	score, increment := 0.0, weight
+	for i := 0; i < hits; i++ {
+		score += increment
+		increment /= 2
+	}
 	return score
 }
 
-// extractEntities extracts entities using configurable patterns
-func (p *EnhancedLocalProvider) extractEntities(text string) map[string]string {
-	entities := make(map[string]string)
+// matchKeyword scores one keyword against the lowercased text and its tokens:
+// 0.4 for an exact match, 0.3 for a synonym and less for a misspelling
+func (p *EnhancedLocalProvider) matchKeyword(textLower string, textWords []string, keyword string, fuzzyDistances []int, i int) models.KeywordMatch {
+	match := models.KeywordMatch{Keyword: keyword}
+	keywordLower := strings.ToLower(keyword)
+
+	// Exact match
+	if strings.Contains(textLower, keywordLower) {
+		match.Match = models.KeywordMatchExact
+		match.Score = 0.4
+		return match
+	}
 
-	// Extract name first (can be quoted)
-	for entityName, entity := range p.config.Entities {
-		if entityName == "name" {
-			// Try regex patterns first
-			for _, re := range p.compiled.EntityRegexes[entityName] {
-				matches := re.FindStringSubmatch(text)
-				if len(matches) > 1 {
-					entities[entityName] = matches[1]
-					break
-				}
-			}
+	// Fuzzy match using synonym expansion
+	for _, synonym := range p.getSynonyms(keyword) {
+		if strings.Contains(textLower, strings.ToLower(synonym)) {
+			match.Match = models.KeywordMatchSynonym
+			match.Via = synonym
+			match.Score = 0.3
+			return match
+		}
+	}
 
-			// If no regex match, try keyword-based extraction
-			if entities[entityName] == "" {
-				value := p.extractEntityByKeywords(text, entityName, entity)
-				if value != "" {
-					entities[entityName] = value
-				}
-			}
+	// Misspellings such as "contcat" for "contact"
+	if i < len(fuzzyDistances) && fuzzyDistances[i] > 0 {
+		if token, ok := fuzzyMatch(textWords, keywordLower, fuzzyDistances[i]); ok {
+			match.Match = models.KeywordMatchFuzzy
+			match.Via = token
+			match.Score = fuzzyKeywordScore
 		}
 	}
+	return match
+}
 
-	// Extract title (can be quoted, but don't override name)
-	for entityName, entity := range p.config.Entities {
-		if entityName == "title" {
-			// Try regex patterns first
-			for _, re := range p.compiled.EntityRegexes[entityName] {
-				matches := re.FindStringSubmatch(text)
-				if len(matches) > 1 {
-					entities[entityName] = matches[1]
-					break
-				}
-			}
+// extractEntities extracts entities using configurable patterns, only those
+// listed in the intent's entities when it lists any
+func (p *EnhancedLocalProvider) extractEntities(text, intentName string) map[string][]string {
+	// A background context is never cancelled, so there's no error
+	entities, _ := p.findEntities(context.Background(), text, intentName)
+	p.normalizeEntities(entities)
+	p.applyAllowedValues(entities)
+	p.dropUnmetDependencies(entities)
+	return entities
+}
 
-			// If no regex match, try keyword-based extraction
-			if entities[entityName] == "" {
-				value := p.extractEntityByKeywords(text, entityName, entity)
-				if value != "" {
-					entities[entityName] = value
-				}
+// findEntities extracts entity values as they appear in text, before
+// normalization, skipping entities the intent doesn't list. It stops with
+// ctx's error when ctx is done between entities.
+func (p *EnhancedLocalProvider) findEntities(ctx context.Context, text, intentName string) (map[string][]string, error) {
+	entities, _, err := p.locateEntities(ctx, text, intentName)
+	return entities, err
+}
+
+// locateEntities is findEntities, also reporting the extraction method that
+// found each entity's values
+func (p *EnhancedLocalProvider) locateEntities(ctx context.Context, text, intentName string) (map[string][]string, map[string]string, error) {
+	entities := make(map[string][]string)
+	methods := make(map[string]string)
+
+	// An unknown intent, UNKNOWN included, attempts every entity
+	allowed := func(entityName string) bool {
+		intent, exists := p.config.Intents[intentName]
+		return !exists || intent.AllowsEntity(entityName)
+	}
+
+	// Extract name first (can be quoted), with any honorific captured separately
+	if entity, exists := p.config.Entities["name"]; exists && allowed("name") {
+		nameText, honorific := p.stripHonorifics(text)
+		if values, method := p.extractEntityValues(nameText, "name", entity); len(values) > 0 {
+			entities["name"] = values
+			methods["name"] = method
+			if honorific != "" {
+				entities["honorific"] = []string{honorific}
 			}
 		}
 	}
 
+	// Extract title (can be quoted, but don't override name)
+	if entity, exists := p.config.Entities["title"]; exists && allowed("title") {
+		values, method := p.extractEntityValues(text, "title", entity)
+		values = p.dropHonorificNames(values, entities["name"])
+		if len(values) > 0 {
+			entities["title"] = values
+			methods["title"] = method
+		}
+	}
+
 	// Extract other entities
 	for entityName, entity := range p.config.Entities {
-		if entityName == "name" || entityName == "title" {
-			continue // Already processed
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if entityName == "name" || entityName == "title" || !allowed(entityName) {
+			continue // Already processed, or not wanted for the intent
 		}
 
-		// Try regex patterns first
-		for _, re := range p.compiled.EntityRegexes[entityName] {
-			matches := re.FindStringSubmatch(text)
-			if len(matches) > 1 {
-				entities[entityName] = matches[1]
-				break
+		if values, method := p.extractEntityValues(text, entityName, entity); len(values) > 0 {
+			entities[entityName] = values
+			methods[entityName] = method
+		}
+	}
+
+	return entities, methods, nil
+}
+
+// extractEntityValues returns the values found for an entity and the method
+// that found them. The entity's extraction methods are tried in order until
+// one finds a value. Multi-value entities collect every regex match, in
+// pattern order and without duplicates; other entities yield at most one
+// value.
+func (p *EnhancedLocalProvider) extractEntityValues(text, entityName string, entity models.EntityPattern) ([]string, string) {
+	if entity.Extraction == models.ExtractionRestOfInput {
+		return nonEmpty(p.extractRestOfInput(text, entityName)), models.ExtractionRestOfInput
+	}
+
+	// Time zones use the built-in recognizer so values are normalized
+	if entity.Type == "timezone" {
+		zone, _ := parseTimezone(text)
+		return nonEmpty(zone), "timezone"
+	}
+
+	for _, method := range entity.ExtractionMethods() {
+		var values []string
+		switch method {
+		case models.ExtractionMethodRegex:
+			values = p.extractByRegex(text, entityName, entity.Multiple)
+		case models.ExtractionMethodKeyword:
+			values = nonEmpty(p.extractEntityByKeywords(text, entityName, entity))
+		}
+		if len(values) > 0 {
+			return values, method
+		}
+	}
+	return nil, ""
+}
+
+// extractByRegex returns the value captured by the entity's first matching
+// regex, or with multiple set every value its regexes capture
+func (p *EnhancedLocalProvider) extractByRegex(text, entityName string, multiple bool) []string {
+	var values []string
+	for _, re := range p.compiled.EntityRegexes[entityName] {
+		if !multiple {
+			if matches := re.FindStringSubmatch(text); matches != nil {
+				return nonEmpty(entityValue(re, matches))
 			}
+			continue
+		}
+		for _, matches := range re.FindAllStringSubmatch(text, -1) {
+			if value := entityValue(re, matches); value != "" {
+				values = appendUnique(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// nonEmpty returns value as a one-value list, or nil when it's empty
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// entityValueGroup names the capture group holding an entity's value
+const entityValueGroup = "value"
+
+// entityValue returns the value captured by an entity regex match: the
+// "value" group if the regex has one, otherwise group 1
+func entityValue(re *regexp.Regexp, matches []string) string {
+	index := re.SubexpIndex(entityValueGroup)
+	if index < 0 {
+		index = 1
+	}
+	if index >= len(matches) {
+		return ""
+	}
+	return matches[index]
+}
+
+// appendUnique appends value unless values already holds it, ignoring case
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if strings.EqualFold(existing, value) {
+			return values
 		}
+	}
+	return append(values, value)
+}
 
-		// If no regex match, try keyword-based extraction
-		if entities[entityName] == "" {
-			value := p.extractEntityByKeywords(text, entityName, entity)
-			if value != "" {
-				entities[entityName] = value
+// dropHonorificNames drops titles that are one of names after an honorific,
+// such as "Dr Alice Brown" read as a title after "named"
+func (p *EnhancedLocalProvider) dropHonorificNames(titles, names []string) []string {
+	var kept []string
+	for _, title := range titles {
+		stripped, honorific := p.stripHonorifics(title)
+		isName := false
+		for _, name := range names {
+			if honorific != "" && strings.EqualFold(strings.TrimSpace(stripped), name) {
+				isName = true
+				break
 			}
 		}
+		if !isName {
+			kept = append(kept, title)
+		}
 	}
+	return kept
+}
 
-	return entities
+// stripHonorifics removes honorifics such as "Dr" or "Mrs." from text so that
+// name extraction sees only the name, returning the first honorific found
+func (p *EnhancedLocalProvider) stripHonorifics(text string) (string, string) {
+	re := p.compiled.HonorificRegex
+	if re == nil {
+		return text, ""
+	}
+
+	matches := re.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return text, ""
+	}
+
+	return re.ReplaceAllString(text, ""), p.compiled.HonorificMap[strings.ToLower(matches[1])]
+}
+
+// extractRestOfInput returns the text following the entity's first trigger keyword,
+// preserving punctuation and casing
+func (p *EnhancedLocalProvider) extractRestOfInput(text, entityName string) string {
+	re := p.compiled.RestOfInputRegexes[entityName]
+	if re == nil {
+		return ""
+	}
+
+	matches := re.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return ""
+	}
+
+	// Drop separators between the trigger and the content, e.g. "note: buy milk"
+	return strings.TrimLeft(strings.TrimSpace(matches[1]), ":,- ")
 }
 
 // extractEntityByKeywords extracts entities using keyword context
 func (p *EnhancedLocalProvider) extractEntityByKeywords(text, entityName string, entity models.EntityPattern) string {
 	words := strings.Fields(text)
 
-	// Only use keyword-based extraction for specific entity types that have clear patterns
-	switch entityName {
+	// Only use keyword-based extraction for specific entity types that have
+	// clear patterns. Entities typed as locations or durations, such as
+	// "venue", are found like "location" or "duration".
+	kind := entityName
+	switch entity.Type {
+	case "location", durationVar:
+		kind = entity.Type
+	}
+	switch kind {
 	case "name":
 		// First try to extract names in quotes (most reliable)
 		quotePattern := regexp.MustCompile(`"([^"]+)"`)
@@ -539,17 +1270,24 @@ func (p *EnhancedLocalProvider) extractEntityByKeywords(text, entityName string,
 			}
 		}
 
+	case durationVar:
+		// Look for durations like "for 30 minutes", "lasting an hour and a half"
+		if raw, _, ok := parseDuration(text); ok {
+			return raw
+		}
+
 	case "location":
-		// Look for location patterns like "in New York", "at the office"
+		// Quoted locations such as at "the main office" are taken as written
+		if matches := quotedLocationRegex.FindStringSubmatch(text); matches != nil {
+			return matches[1]
+		}
+
+		// Look for location patterns like "in New York City", "at Central Park"
 		for i, word := range words {
 			wordLower := strings.ToLower(word)
-			if wordLower == "in" || wordLower == "at" {
-				if i+1 < len(words) {
-					nextWord := strings.Trim(words[i+1], ".,!?;:")
-					// Check if it looks like a location (starts with capital letter, not a common word)
-					if len(nextWord) > 0 && unicode.IsUpper(rune(nextWord[0])) && !p.isStopWord(strings.ToLower(nextWord)) {
-						return nextWord
-					}
+			if (wordLower == "in" || wordLower == "at") && i+1 < len(words) {
+				if location := p.capitalizedRun(words[i+1:]); location != "" {
+					return location
 				}
 			}
 		}
@@ -601,6 +1339,31 @@ func (p *EnhancedLocalProvider) extractEntityByKeywords(text, entityName string,
 	return ""
 }
 
+// quotedLocationRegex finds a quoted location after "in" or "at"
+var quotedLocationRegex = regexp.MustCompile(`(?i)\b(?:in|at)\s+"([^"]+)"`)
+
+// capitalizedRun joins the capitalized words at the start of words, such as
+// "New York City", stopping at a stop word, a lowercase word, a date word or
+// punctuation
+func (p *EnhancedLocalProvider) capitalizedRun(words []string) string {
+	var run []string
+	for _, word := range words {
+		trimmed := strings.TrimRight(word, ".,!?;:")
+		first, _ := utf8.DecodeRuneInString(trimmed)
+		lower := strings.ToLower(trimmed)
+		if trimmed == "" || !unicode.IsUpper(first) || p.isStopWord(lower) ||
+			lower == "today" || lower == "tomorrow" || lower == "yesterday" {
+			break
+		}
+		run = append(run, trimmed)
+		// Punctuation ends the location, as in "in Paris, at noon"
+		if trimmed != word {
+			break
+		}
+	}
+	return strings.Join(run, " ")
+}
+
 // normalizeText performs advanced text normalization
 func (p *EnhancedLocalProvider) normalizeText(text string) string {
 	// Convert to lowercase
@@ -623,10 +1386,9 @@ func (p *EnhancedLocalProvider) normalizeText(text string) string {
 	return normalized
 }
 
-// tokenize splits text into meaningful tokens
+// tokenize splits text into meaningful tokens with the config's tokenizer
 func (p *EnhancedLocalProvider) tokenize(text string) []string {
-	// Simple tokenization - can be enhanced with NLP libraries
-	words := strings.Fields(strings.ToLower(text))
+	words := p.tokenizer().Tokenize(strings.ToLower(norm.NFC.String(text)))
 	var tokens []string
 
 	for _, word := range words {
@@ -639,28 +1401,23 @@ func (p *EnhancedLocalProvider) tokenize(text string) []string {
 	return tokens
 }
 
-// isStopWord checks if a word is a common stop word
-func (p *EnhancedLocalProvider) isStopWord(word string) bool {
-	stopWords := map[string]bool{
-		"the": true, "a": true, "an": true, "and": true, "or": true, "but": true,
-		"in": true, "on": true, "at": true, "to": true, "for": true, "of": true,
-		"with": true, "by": true, "from": true, "up": true, "about": true,
-		"into": true, "through": true, "during": true, "before": true, "after": true,
-		"above": true, "below": true, "between": true, "among": true,
-		"is": true, "are": true, "was": true, "were": true, "be": true, "been": true,
-		"have": true, "has": true, "had": true, "do": true, "does": true, "did": true,
-		"will": true, "would": true, "could": true, "should": true, "may": true, "might": true,
+// tokenizer returns the config's tokenizer
+func (p *EnhancedLocalProvider) tokenizer() Tokenizer {
+	if p.compiled.Tokenizer == nil {
+		return WhitespaceTokenizer{}
 	}
+	return p.compiled.Tokenizer
+}
 
-	return stopWords[word]
+// isStopWord checks if a word is one of the config's stop words, ignoring
+// case and Unicode normalization differences
+func (p *EnhancedLocalProvider) isStopWord(word string) bool {
+	return p.compiled.StopWords[foldWord(word)]
 }
 
-// getSynonyms returns synonyms for a word
+// getSynonyms returns the words that match word as synonyms
 func (p *EnhancedLocalProvider) getSynonyms(word string) []string {
-	if synonyms, exists := p.config.Synonyms[word]; exists {
-		return synonyms
-	}
-	return []string{}
+	return p.compiled.SynonymSets[strings.ToLower(word)]
 }
 
 // getIntentWords gets all words associated with an intent
@@ -668,9 +1425,9 @@ func (p *EnhancedLocalProvider) getIntentWords(intent models.IntentPattern) []st
 	var words []string
 	words = append(words, intent.Keywords...)
 
-	// Add words from phrases
+	// Add words from phrases, split like the text they are compared with
 	for _, phrase := range intent.Phrases {
-		phraseWords := strings.Fields(strings.ToLower(phrase))
+		phraseWords := p.tokenizer().Tokenize(strings.ToLower(norm.NFC.String(phrase)))
 		words = append(words, phraseWords...)
 	}
 
@@ -698,10 +1455,53 @@ func (p *EnhancedLocalProvider) calculateWordOverlap(textWords, intentWords []st
 	return float64(overlap) / float64(len(intentWords))
 }
 
+// normalizeForMatching lowercases text, drops punctuation and collapses whitespace
+func normalizeForMatching(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) && r != '@' && r != '\'' {
+			return ' '
+		}
+		return unicode.ToLower(r)
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
 // Name returns the provider name
 func (p *EnhancedLocalProvider) Name() string {
 	if p.configPath != "" {
-		return fmt.Sprintf("Enhanced Local AI (%s)", p.config.Domain)
+		return fmt.Sprintf("Enhanced Local AI (%s)", p.GetConfig().Domain)
 	}
 	return "Enhanced Local AI (Default)"
 }
@@ -711,7 +1511,79 @@ func (p *EnhancedLocalProvider) IsAvailable() bool {
 	return true // Always available
 }
 
-// GetConfig returns the current configuration
+// GetConfig returns the current configuration. The returned config is never
+// modified; a reload replaces it with a new one.
 func (p *EnhancedLocalProvider) GetConfig() *models.IntentConfig {
-	return p.config
+	return p.snapshot().config
+}
+
+// Reload re-reads the config file, directory or URL the provider was created
+// with, recompiles it and swaps it in. If loading, validation or compilation
+// fails for any language, the current configs keep serving and the error is
+// returned.
+func (p *EnhancedLocalProvider) Reload() error {
+	if p.configPath == "" {
+		return fmt.Errorf("%w: no config path set, using the built-in default config", ErrReloadNotSupported)
+	}
+
+	configs, err := loadIntentConfigs(p.configPath, p.defaultLanguage)
+	if err != nil {
+		return err
+	}
+
+	languages, err := compileLanguages(configs)
+	if err != nil {
+		return err
+	}
+
+	p.swapMu.Lock()
+	p.setLanguages(configs, languages)
+	p.swapMu.Unlock()
+
+	for language, config := range configs {
+		slog.Info("Reloaded intent configuration", "path", p.configPath, "language", language, "domain", config.Domain, "intents", len(config.Intents))
+	}
+	return nil
+}
+
+// SetIntentEnabled turns classification of an intent on or off in every
+// language that defines it. The change is applied to copies of the configs,
+// so configs returned by GetConfig stay unchanged, and lasts until the next
+// reload.
+func (p *EnhancedLocalProvider) SetIntentEnabled(intentName string, enabled bool) error {
+	// Held from read to swap, so concurrent toggles don't undo each other
+	p.swapMu.Lock()
+	defer p.swapMu.Unlock()
+
+	found := false
+	configs := make(map[string]*models.IntentConfig)
+	for language, current := range p.snapshot().allConfigs() {
+		intent, exists := current.Intents[intentName]
+		if !exists {
+			configs[language] = current
+			continue
+		}
+		found = true
+		intent.Enabled = &enabled
+
+		config := *current
+		config.Intents = make(map[string]models.IntentPattern, len(current.Intents))
+		for name, pattern := range current.Intents {
+			config.Intents[name] = pattern
+		}
+		config.Intents[intentName] = intent
+		configs[language] = &config
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrUnknownIntent, intentName)
+	}
+
+	languages, err := compileLanguages(configs)
+	if err != nil {
+		return err
+	}
+	p.setLanguages(configs, languages)
+
+	slog.Info("Toggled intent", "intent", intentName, "enabled", enabled)
+	return nil
 }
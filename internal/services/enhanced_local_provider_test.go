@@ -0,0 +1,1460 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// newTestEnhancedProvider builds an EnhancedLocalProvider directly from an in-memory config
+func newTestEnhancedProvider(t *testing.T, config *models.IntentConfig) *EnhancedLocalProvider {
+	t.Helper()
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+
+	compiled, err := compileConfig(config)
+	if err != nil {
+		t.Fatalf("compileConfig() error = %v", err)
+	}
+
+	return &EnhancedLocalProvider{
+		config:   config,
+		compiled: compiled,
+	}
+}
+
+// noteConfig returns a minimal config with a note intent and a rest-of-input content entity
+// firstValue returns the first extracted value, or "" when there is none
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func noteConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"CreateNote": {
+				Description: "Create a note",
+				Keywords:    []string{"note", "remember"},
+				Phrases:     []string{"note that", "make a note"},
+				Priority:    8,
+				Variables:   []string{"content"},
+				Required:    []string{"content"},
+			},
+		},
+		Entities: map[string]models.EntityPattern{
+			"content": {
+				Type:        "text",
+				Description: "Free-form note content",
+				Keywords:    []string{"note", "note that", "write down"},
+				Extraction:  models.ExtractionRestOfInput,
+			},
+		},
+	}
+}
+
+func TestEnhancedLocalProvider_RestOfInputExtraction(t *testing.T) {
+	provider := newTestEnhancedProvider(t, noteConfig())
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "longest trigger wins and punctuation is preserved",
+			input:    "note that remember to buy milk, eggs, and bread",
+			expected: "remember to buy milk, eggs, and bread",
+		},
+		{
+			name:     "casing is preserved",
+			input:    "Please write down Call Alice about the Q3 report!",
+			expected: "Call Alice about the Q3 report!",
+		},
+		{
+			name:     "separator after trigger is dropped",
+			input:    "note: pick up the dry cleaning",
+			expected: "pick up the dry cleaning",
+		},
+		{
+			name:     "trigger must be a whole word",
+			input:    "notebook shopping list",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities := provider.extractEntities(tt.input, "")
+			if got := firstValue(entities["content"]); got != tt.expected {
+				t.Errorf("content = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_RestOfInputSatisfiesRequired(t *testing.T) {
+	provider := newTestEnhancedProvider(t, noteConfig())
+
+	intent, err := provider.ExtractIntent(context.Background(), "note that remember to buy milk, eggs, and bread")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+
+	if intent.Task != "CreateNote" {
+		t.Fatalf("Task = %v, want CreateNote", intent.Task)
+	}
+	if !intent.IsComplete {
+		t.Errorf("IsComplete = false, missing %v", intent.Missing)
+	}
+}
+
+func TestIntentService_RestOfInputKeepsCasing(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+	t.Setenv("INTENT_CONFIG_PATH", "../../configs/personal_assistant.json")
+	service, err := NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	intent, err := service.ExtractIntent(context.Background(), "Note that Remember to buy Milk, eggs, and bread")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CreateNote" {
+		t.Errorf("Task = %v, want CreateNote", intent.Task)
+	}
+	if got := intent.Vars["content"]; got != "Remember to buy Milk, eggs, and bread" {
+		t.Errorf("content = %q, want the text after the trigger as typed", got)
+	}
+	if _, exists := intent.Vars["location"]; exists {
+		t.Errorf("location = %q, want none inside the note", intent.Vars["location"])
+	}
+}
+
+func TestIntentConfig_ValidateRestOfInputRequiresKeywords(t *testing.T) {
+	config := noteConfig()
+	config.Entities["content"] = models.EntityPattern{
+		Type:       "text",
+		Extraction: models.ExtractionRestOfInput,
+	}
+
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() error = nil, want error for rest_of_input entity without keywords")
+	}
+}
+
+// eventConfig returns a minimal config with an event intent and title/date/time entities
+func eventConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"CreateEvent": {
+				Description: "Create a calendar event",
+				Keywords:    []string{"schedule", "event", "meeting"},
+				Phrases:     []string{"schedule event", "schedule a meeting"},
+				Priority:    9,
+				Variables:   []string{"title", "date", "time", "duration"},
+				Required:    []string{"title", "date", "duration"},
+			},
+		},
+		Entities: map[string]models.EntityPattern{
+			"title": {
+				Type:  "title",
+				Regex: []string{`"([^"]+)"`},
+			},
+			"date": {
+				Type:  "date",
+				Regex: []string{`(?i)\b(today|tomorrow|yesterday)\b`},
+			},
+			"time": {
+				Type:  "time",
+				Regex: []string{`(?i)(\d{1,2}(?::\d{2})?\s*(?:am|pm))`},
+			},
+		},
+	}
+}
+
+func TestEnhancedLocalProvider_DefaultsSatisfyRequired(t *testing.T) {
+	config := eventConfig()
+	event := config.Intents["CreateEvent"]
+	event.Defaults = map[string]interface{}{"duration": "30m", "date": "today"}
+	config.Intents["CreateEvent"] = event
+	provider := newTestEnhancedProvider(t, config)
+
+	tests := []struct {
+		name        string
+		input       string
+		wantVars    map[string]interface{}
+		wantMissing []string
+	}{
+		{
+			name:     "defaults fill missing fields",
+			input:    `schedule event "Standup"`,
+			wantVars: map[string]interface{}{"title": "Standup", "date": "today", "duration": "30m"},
+		},
+		{
+			name:     "extracted value beats default",
+			input:    `schedule event "Standup" tomorrow`,
+			wantVars: map[string]interface{}{"title": "Standup", "date": "tomorrow", "duration": "30m"},
+		},
+		{
+			name:        "fields without defaults are still missing",
+			input:       "schedule a meeting tomorrow",
+			wantVars:    map[string]interface{}{"date": "tomorrow", "duration": "30m"},
+			wantMissing: []string{"title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "CreateEvent" {
+				t.Fatalf("Task = %v, want CreateEvent", intent.Task)
+			}
+			for key, want := range tt.wantVars {
+				if got := intent.Vars[key]; got != want {
+					t.Errorf("Vars[%s] = %v, want %v", key, got, want)
+				}
+			}
+			if len(intent.Missing) != len(tt.wantMissing) {
+				t.Fatalf("Missing = %v, want %v", intent.Missing, tt.wantMissing)
+			}
+			for i, field := range tt.wantMissing {
+				if intent.Missing[i] != field {
+					t.Errorf("Missing[%d] = %v, want %v", i, intent.Missing[i], field)
+				}
+			}
+			if len(intent.FollowUp) != len(tt.wantMissing) {
+				t.Errorf("FollowUp = %v, want %d question(s)", intent.FollowUp, len(tt.wantMissing))
+			}
+			if intent.IsComplete != (len(tt.wantMissing) == 0) {
+				t.Errorf("IsComplete = %v", intent.IsComplete)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_RequiredOneOf(t *testing.T) {
+	config := phoneContactConfig("")
+	createContact := config.Intents["CreateContact"]
+	createContact.DisplayName = "contact"
+	createContact.Required = []string{"name"}
+	createContact.RequiredOneOf = [][]string{{"email", "phone"}}
+	config.Intents["CreateContact"] = createContact
+	provider := newTestEnhancedProvider(t, config)
+
+	tests := []struct {
+		name         string
+		input        string
+		wantMissing  []string
+		wantFollowUp []string
+	}{
+		{name: "just an email", input: "add contact named Alice, email alice@example.com"},
+		{name: "just a phone", input: "add contact named Alice, phone (415) 555-2671"},
+		{
+			name:         "neither",
+			input:        "add contact named Alice",
+			wantMissing:  []string{"email", "phone"},
+			wantFollowUp: []string{"What email or phone should I use for this contact?"},
+		},
+		{
+			name:         "neither, with the name missing too",
+			input:        "add contact",
+			wantMissing:  []string{"name", "email", "phone"},
+			wantFollowUp: []string{"What's the name?", "What email or phone should I use for this contact?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "CreateContact" {
+				t.Fatalf("Task = %v, want CreateContact", intent.Task)
+			}
+			if !reflect.DeepEqual(intent.Missing, tt.wantMissing) || !reflect.DeepEqual(intent.FollowUp, tt.wantFollowUp) {
+				t.Errorf("Missing = %v, FollowUp = %q, want %v, %q", intent.Missing, intent.FollowUp, tt.wantMissing, tt.wantFollowUp)
+			}
+			if intent.IsComplete != (len(tt.wantMissing) == 0) {
+				t.Errorf("IsComplete = %v, want %v", intent.IsComplete, len(tt.wantMissing) == 0)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_RequiredOneOfFollowUp(t *testing.T) {
+	config := phoneContactConfig("")
+	createContact := config.Intents["CreateContact"]
+	createContact.Required = []string{"name"}
+	createContact.RequiredOneOf = [][]string{{"email", "phone"}}
+	createContact.FollowUp = []string{"What's their email?", "How can I reach them, by email or phone?"}
+	config.Intents["CreateContact"] = createContact
+	provider := newTestEnhancedProvider(t, config)
+
+	intent, err := provider.ExtractIntent(context.Background(), "add contact named Alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	// The question naming the whole group wins over the one for email alone
+	if want := []string{"How can I reach them, by email or phone?"}; !reflect.DeepEqual(intent.FollowUp, want) {
+		t.Errorf("FollowUp = %q, want %q", intent.FollowUp, want)
+	}
+}
+
+// contactConfig returns a minimal config with a contact intent and the shipped name patterns
+func contactConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"CreateContact": {
+				Description: "Create a contact",
+				Keywords:    []string{"create", "add", "contact"},
+				Phrases:     []string{"add contact", "create contact"},
+				Priority:    10,
+				Variables:   []string{"name", "email", "phone"},
+				Required:    []string{"name"},
+			},
+		},
+		Entities: map[string]models.EntityPattern{
+			"name": {
+				Type: "name",
+				Regex: []string{
+					`(?i)"([^"]+)"`,
+					`(?i)(?:named\s+|name\s+is\s+|call(?:ed)?\s+)([A-Z][a-z]+(?:\s+[A-Z][a-z]+)*)`,
+					`(?i)(?:contact|person)\s+([A-Z][a-z]+(?:\s+[A-Z][a-z]+)*)`,
+				},
+				Keywords: []string{"named", "name", "contact"},
+			},
+			"email": {
+				Type:  "email",
+				Regex: []string{`(?i)([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})`},
+			},
+		},
+	}
+}
+
+func TestEnhancedLocalProvider_Honorifics(t *testing.T) {
+	tests := []struct {
+		name          string
+		honorifics    []string
+		input         string
+		wantName      string
+		wantHonorific string
+	}{
+		{"title before full name", nil, "contact Dr Alice Brown", "Alice Brown", "Dr"},
+		{"title with period", nil, "add contact named Mrs. Smith", "Smith", "Mrs"},
+		{"lowercase title uses configured spelling", nil, "contact prof Jane Doe", "Jane Doe", "Prof"},
+		{"no title", nil, "contact Alice Brown", "Alice Brown", ""},
+		{"custom list", []string{"Rev", "Capt"}, "contact Capt Jack Sparrow", "Jack Sparrow", "Capt"},
+		{"custom list replaces defaults", []string{"Rev"}, "contact Dr Alice", "Dr Alice", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := contactConfig()
+			config.Honorifics = tt.honorifics
+			provider := newTestEnhancedProvider(t, config)
+
+			entities := provider.extractEntities(tt.input, "")
+			if got := firstValue(entities["name"]); got != tt.wantName {
+				t.Errorf("name = %q, want %q", got, tt.wantName)
+			}
+			if got := firstValue(entities["honorific"]); got != tt.wantHonorific {
+				t.Errorf("honorific = %q, want %q", got, tt.wantHonorific)
+			}
+		})
+	}
+}
+
+func TestIntentService_HonorificNameKeepsCasing(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+	t.Setenv("INTENT_CONFIG_PATH", "../../configs/personal_assistant.json")
+	service, err := NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
+
+	intent, err := service.ExtractIntent(context.Background(), "add contact named Dr Alice Brown")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Vars["name"] != "Alice Brown" || intent.Vars["honorific"] != "Dr" {
+		t.Errorf("name, honorific = %q, %q, want Alice Brown and Dr", intent.Vars["name"], intent.Vars["honorific"])
+	}
+	if title, exists := intent.Vars["title"]; exists {
+		t.Errorf("title = %q, want none for the contact's name", title)
+	}
+}
+
+func TestEnhancedLocalProvider_ExactMatchShortCircuit(t *testing.T) {
+	newConfig := func(exact models.ExactMatchConfig) *models.IntentConfig {
+		config := contactConfig()
+		contact := config.Intents["CreateContact"]
+		contact.Examples = []string{"save this person"}
+		contact.Priority = 0
+		config.Intents["CreateContact"] = contact
+		config.Intents["DeleteContact"] = models.IntentPattern{
+			Description: "Delete a contact",
+			Keywords:    []string{"delete", "remove", "contact"},
+			Phrases:     []string{"remove contact", "forget them"},
+		}
+		config.ExactMatch = exact
+		return config
+	}
+
+	tests := []struct {
+		name           string
+		exact          models.ExactMatchConfig
+		input          string
+		wantIntent     string
+		wantConfidence float64 // 0 means the input was scored normally
+	}{
+		{"exact phrase", models.ExactMatchConfig{}, "Forget them!", "DeleteContact", 0.99},
+		{"exact example", models.ExactMatchConfig{}, "save this person", "CreateContact", 0.99},
+		{"custom confidence", models.ExactMatchConfig{Confidence: 0.95}, "forget them", "DeleteContact", 0.95},
+		{"near-exact within distance", models.ExactMatchConfig{MaxDistance: 2}, "forgt thm", "DeleteContact", 0.99},
+		{"near-exact not allowed by default", models.ExactMatchConfig{}, "forgt thm", "UNKNOWN", 0},
+		{"near-exact beyond distance", models.ExactMatchConfig{MaxDistance: 1}, "forgt thm", "UNKNOWN", 0},
+		{"disabled", models.ExactMatchConfig{Disabled: true}, "forget them", "DeleteContact", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, newConfig(tt.exact))
+
+			result, err := provider.classifyIntent(context.Background(), provider.normalizeText(tt.input))
+			if err != nil {
+				t.Fatalf("classifyIntent() error = %v", err)
+			}
+			if result.Intent != tt.wantIntent {
+				t.Errorf("Intent = %v, want %v", result.Intent, tt.wantIntent)
+			}
+			if tt.wantConfidence != 0 && result.Confidence != tt.wantConfidence {
+				t.Errorf("Confidence = %v, want %v", result.Confidence, tt.wantConfidence)
+			}
+			if tt.wantConfidence == 0 && (result.Confidence == 0.99 || result.Confidence == 0.95) {
+				t.Errorf("Confidence = %v, want a scored confidence", result.Confidence)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"contact", "contact", 0},
+		{"contact", "contcat", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_ConfidenceIsTopLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		legacy     bool
+		wantInVars bool
+	}{
+		{"default", false, false},
+		{"legacy flag", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, contactConfig())
+			provider.legacyConfidenceInVars = tt.legacy
+
+			intent, err := provider.ExtractIntent(context.Background(), "add contact named Alice")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Confidence <= 0 || intent.Confidence > 1 {
+				t.Errorf("Confidence = %v, want a score in (0, 1]", intent.Confidence)
+			}
+
+			value, inVars := intent.Vars["confidence"]
+			if inVars != tt.wantInVars {
+				t.Errorf("Vars has confidence = %v, want %v", inVars, tt.wantInVars)
+			}
+			if inVars && value != intent.Confidence {
+				t.Errorf("Vars[confidence] = %v, want %v", value, intent.Confidence)
+			}
+		})
+	}
+}
+
+func TestNewEnhancedLocalProvider_LegacyConfidenceFlag(t *testing.T) {
+	originalGetEnv := getEnvVar
+	defer func() { getEnvVar = originalGetEnv }()
+	getEnvVar = func(key string) string {
+		if key == "LEGACY_CONFIDENCE_IN_VARS" {
+			return "true"
+		}
+		return ""
+	}
+
+	provider, err := NewEnhancedLocalProvider("")
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+	if !provider.(*EnhancedLocalProvider).legacyConfidenceInVars {
+		t.Error("legacyConfidenceInVars = false, want true")
+	}
+}
+
+func TestNewEnhancedLocalProvider_ConfigURL(t *testing.T) {
+	var available atomic.Bool
+	available.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			http.Error(w, "config service down", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(noteConfig())
+	}))
+	defer server.Close()
+
+	provider, err := NewEnhancedLocalProvider(server.URL + "/intents")
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+	intent, err := provider.ExtractIntent(context.Background(), "create a note about groceries")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CreateNote" {
+		t.Errorf("Task = %q, want CreateNote from the fetched config", intent.Task)
+	}
+
+	// A failed reload keeps the fetched config
+	available.Store(false)
+	enhanced := provider.(*EnhancedLocalProvider)
+	if err := enhanced.Reload(); !errors.Is(err, models.ErrConfigFetch) {
+		t.Errorf("Reload() error = %v, want ErrConfigFetch", err)
+	}
+	if _, exists := enhanced.GetConfig().Intents["CreateNote"]; !exists {
+		t.Error("failed reload replaced the fetched config")
+	}
+}
+
+func TestNewEnhancedLocalProvider_ConfigURLFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "config service down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	originalGetEnv := getEnvVar
+	defer func() { getEnvVar = originalGetEnv }()
+	fallback := "false"
+	getEnvVar = func(key string) string {
+		if key == "INTENT_CONFIG_FALLBACK" {
+			return fallback
+		}
+		return ""
+	}
+
+	if _, err := NewEnhancedLocalProvider(server.URL); !errors.Is(err, models.ErrConfigFetch) {
+		t.Fatalf("NewEnhancedLocalProvider() without fallback error = %v, want ErrConfigFetch", err)
+	}
+
+	fallback = "true"
+	provider, err := NewEnhancedLocalProvider(server.URL)
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() with fallback error = %v", err)
+	}
+	if _, exists := provider.(*EnhancedLocalProvider).GetConfig().Intents["CREATE_CONTACT"]; !exists {
+		t.Error("fallback did not use the default config")
+	}
+}
+
+// writeConfigFile writes config as JSON to path
+func writeConfigFile(t *testing.T, path string, config interface{}) {
+	t.Helper()
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func TestEnhancedLocalProvider_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "intents.json")
+	writeConfigFile(t, path, contactConfig())
+
+	provider, err := NewEnhancedLocalProvider(path)
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+	enhanced := provider.(*EnhancedLocalProvider)
+
+	extractTask := func(text string) string {
+		t.Helper()
+		intent, err := enhanced.ExtractIntent(context.Background(), text)
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		return intent.Task
+	}
+
+	if task := extractTask("note that buy milk"); task == "CreateNote" {
+		t.Fatalf("Task = %v before reload, want the old config's result", task)
+	}
+
+	writeConfigFile(t, path, noteConfig())
+	if err := enhanced.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if task := extractTask("note that buy milk"); task != "CreateNote" {
+		t.Errorf("Task = %v after reload, want CreateNote", task)
+	}
+
+	// An invalid config is rejected and the current one keeps serving
+	if err := os.WriteFile(path, []byte(`{"domain": "broken", "intents": {}}`), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := enhanced.Reload(); err == nil {
+		t.Fatal("Reload() error = nil, want validation error")
+	}
+	if domain := enhanced.GetConfig().Domain; domain != "test" {
+		t.Errorf("Domain = %v after failed reload, want test", domain)
+	}
+	if task := extractTask("note that buy milk"); task != "CreateNote" {
+		t.Errorf("Task = %v after failed reload, want CreateNote", task)
+	}
+}
+
+func TestEnhancedLocalProvider_ReloadDuringExtraction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "intents.json")
+	writeConfigFile(t, path, contactConfig())
+
+	provider, err := NewEnhancedLocalProvider(path)
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+	enhanced := provider.(*EnhancedLocalProvider)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := enhanced.ExtractIntent(context.Background(), "add contact named Alice"); err != nil {
+					t.Errorf("ExtractIntent() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := enhanced.Reload(); err != nil {
+			t.Fatalf("Reload() error = %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+// TestEnhancedLocalProvider_SwapDuringExtraction swaps between two configs
+// while requests run. Run it with -race; every request must see one config
+// or the other as a whole.
+func TestEnhancedLocalProvider_SwapDuringExtraction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "intents.json")
+	writeConfigFile(t, path, noteConfig())
+
+	provider, err := NewEnhancedLocalProvider(path)
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+	enhanced := provider.(*EnhancedLocalProvider)
+
+	const text = "note that buy milk"
+	configs := []*models.IntentConfig{noteConfig(), contactConfig()}
+	want := map[string]bool{}
+	for _, config := range configs {
+		writeConfigFile(t, path, config)
+		if err := enhanced.Reload(); err != nil {
+			t.Fatalf("Reload() error = %v", err)
+		}
+		intent, err := enhanced.ExtractIntent(context.Background(), text)
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		want[intent.Task] = true
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				intent, err := enhanced.ExtractIntent(context.Background(), text)
+				if err != nil {
+					t.Errorf("ExtractIntent() error = %v", err)
+					return
+				}
+				if !want[intent.Task] {
+					t.Errorf("Task = %v, want one of %v", intent.Task, want)
+					return
+				}
+				enhanced.Explain(text)
+				enhanced.Trace(context.Background(), text)
+				enhanced.GetConfig()
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		writeConfigFile(t, path, configs[i%len(configs)])
+		if err := enhanced.Reload(); err != nil {
+			t.Errorf("Reload() error = %v", err)
+			break
+		}
+		_ = enhanced.SetIntentEnabled("CreateNote", true)
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestEnhancedLocalProvider_ReloadWithoutConfigPath(t *testing.T) {
+	provider, err := NewEnhancedLocalProvider("")
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+
+	if err := provider.(*EnhancedLocalProvider).Reload(); !errors.Is(err, ErrReloadNotSupported) {
+		t.Errorf("Reload() error = %v, want ErrReloadNotSupported", err)
+	}
+}
+
+func TestEnhancedLocalProvider_DefaultConfidenceThreshold(t *testing.T) {
+	config := contactConfig()
+	contact := config.Intents["CreateContact"]
+	contact.Priority = 0
+	config.Intents["CreateContact"] = contact
+	provider := newTestEnhancedProvider(t, config)
+
+	// A weak match that scores around 0.2
+	input := provider.normalizeText("contact Alice")
+
+	tests := []struct {
+		name              string
+		perIntent         map[string]float64
+		defaultConfidence float64
+		want              string
+	}{
+		{"hardcoded 0.5 fallback rejects", nil, 0, "UNKNOWN"},
+		{"domain default accepts", nil, 0.1, "CreateContact"},
+		{"per-intent threshold overrides domain default", map[string]float64{"CreateContact": 0.5}, 0.1, "UNKNOWN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Confidence = tt.perIntent
+			config.DefaultConfidence = tt.defaultConfidence
+			if result, _ := provider.classifyIntent(context.Background(), input); result.Intent != tt.want {
+				t.Errorf("Intent = %v (confidence %v), want %v", result.Intent, result.Confidence, tt.want)
+			}
+		})
+	}
+}
+
+// taskConfig returns a config with one intent per keyword, all at the same priority
+func taskConfig(keywords ...string) *models.IntentConfig {
+	config := &models.IntentConfig{Domain: "test", Intents: map[string]models.IntentPattern{}}
+	for _, keyword := range keywords {
+		config.Intents[keyword] = models.IntentPattern{
+			Description: keyword,
+			Keywords:    []string{keyword, "task"},
+		}
+	}
+	return config
+}
+
+func TestEnhancedLocalProvider_Alternatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *models.IntentConfig
+		input  string
+		want   []string
+	}{
+		{
+			name:   "top three with the winner first",
+			config: taskConfig("archive", "backup", "copy", "delete"),
+			input:  "archive task",
+			want:   []string{"archive", "backup", "copy"},
+		},
+		{
+			name:   "ties are ordered by name",
+			config: taskConfig("delete", "copy", "backup", "archive"),
+			input:  "task",
+			want:   []string{"archive", "backup", "copy"},
+		},
+		{
+			name:   "fewer than three intents",
+			config: taskConfig("archive", "backup"),
+			input:  "backup task",
+			want:   []string{"backup", "archive"},
+		},
+		{
+			name:   "only unknown when nothing scores",
+			config: taskConfig("archive", "backup"),
+			input:  "hello there",
+			want:   []string{"UNKNOWN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, tt.config)
+			ctx := WithAlternatives(context.Background())
+
+			intent, err := provider.ExtractIntent(ctx, tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+
+			var got []string
+			for i, candidate := range intent.Alternatives {
+				got = append(got, candidate.Task)
+				if i > 0 && candidate.Confidence > intent.Alternatives[i-1].Confidence {
+					t.Errorf("Alternatives not sorted descending: %+v", intent.Alternatives)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Alternatives = %v, want %v", got, tt.want)
+			}
+			if intent.Task != "UNKNOWN" && intent.Alternatives[0].Confidence != intent.Confidence {
+				t.Errorf("first alternative confidence = %v, want the winner's %v", intent.Alternatives[0].Confidence, intent.Confidence)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_AlternativesBelowThreshold(t *testing.T) {
+	config := taskConfig("archive", "backup")
+	config.DefaultConfidence = 1
+	provider := newTestEnhancedProvider(t, config)
+
+	intent, err := provider.ExtractIntent(WithAlternatives(context.Background()), "archive task")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "UNKNOWN" {
+		t.Fatalf("Task = %v, want UNKNOWN below the threshold", intent.Task)
+	}
+	if len(intent.Alternatives) != 2 || intent.Alternatives[0].Task != "archive" {
+		t.Errorf("Alternatives = %+v, want the scored intents without UNKNOWN", intent.Alternatives)
+	}
+}
+
+func TestEnhancedLocalProvider_AlternativesOptIn(t *testing.T) {
+	provider := newTestEnhancedProvider(t, taskConfig("archive", "backup"))
+
+	intent, err := provider.ExtractIntent(context.Background(), "archive task")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Alternatives != nil {
+		t.Errorf("Alternatives = %+v, want none unless requested", intent.Alternatives)
+	}
+}
+
+func TestEnhancedLocalProvider_MultipleEntityValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		multiple bool
+		strict   bool
+		input    string
+		want     interface{}
+	}{
+		{name: "two values", multiple: true, input: "add contact alice@a.com and bob@b.com", want: []string{"alice@a.com", "bob@b.com"}},
+		{name: "one value stays a string", multiple: true, input: "add contact alice@a.com", want: "alice@a.com"},
+		{name: "duplicates collapse", multiple: true, input: "add alice@a.com, ALICE@a.com and alice@a.com", want: "alice@a.com"},
+		{name: "single-value entity keeps the first", input: "add contact alice@a.com and bob@b.com", want: "alice@a.com"},
+		{name: "strict drops invalid values", multiple: true, strict: true, input: "add x@y.c and bob@b.com", want: "bob@b.com"},
+		{name: "strict dedups after normalizing", multiple: true, strict: true, input: "add bob@B.com and bob@b.com", want: "bob@b.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := contactConfig()
+			email := config.Entities["email"]
+			email.Multiple = tt.multiple
+			config.Entities["email"] = email
+			config.StrictEntities = tt.strict
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["email"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("email = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_DisabledIntentNeverWins(t *testing.T) {
+	disabled := false
+	config := contactConfig()
+	config.Intents["DeleteContact"] = models.IntentPattern{
+		Description: "Delete a contact",
+		Keywords:    []string{"delete", "contact"},
+		Phrases:     []string{"delete contact"},
+		Regex:       []string{`(?i)^delete contact`},
+		Examples:    []string{"delete contact"},
+		Priority:    20,
+		Enabled:     &disabled,
+	}
+	provider := newTestEnhancedProvider(t, config)
+
+	// An exact example, phrase, regex and every keyword all match
+	for _, input := range []string{"delete contact", "delete contact Alice please"} {
+		intent, err := provider.ExtractIntent(WithAlternatives(context.Background()), input)
+		if err != nil {
+			t.Fatalf("ExtractIntent(%q) error = %v", input, err)
+		}
+		if intent.Task == "DeleteContact" {
+			t.Errorf("ExtractIntent(%q) = DeleteContact, want a disabled intent never to win", input)
+		}
+		for _, candidate := range intent.Alternatives {
+			if candidate.Task == "DeleteContact" {
+				t.Errorf("ExtractIntent(%q) alternatives = %+v, want the disabled intent unscored", input, intent.Alternatives)
+			}
+		}
+	}
+
+	before := provider.GetConfig()
+	if err := provider.SetIntentEnabled("DeleteContact", true); err != nil {
+		t.Fatalf("SetIntentEnabled() error = %v", err)
+	}
+	if before.Intents["DeleteContact"].IsEnabled() {
+		t.Error("SetIntentEnabled() modified a config returned earlier by GetConfig")
+	}
+	intent, err := provider.ExtractIntent(context.Background(), "delete contact")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "DeleteContact" {
+		t.Errorf("Task = %s after enabling, want DeleteContact", intent.Task)
+	}
+
+	if err := provider.SetIntentEnabled("Missing", false); !errors.Is(err, ErrUnknownIntent) {
+		t.Errorf("SetIntentEnabled(unknown) error = %v, want ErrUnknownIntent", err)
+	}
+}
+
+func TestEnhancedLocalProvider_EntityRegexGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		multiple bool
+		input    string
+		want     interface{}
+	}{
+		{name: "single group", pattern: `(?i)order\s+#?(\d+)`, input: "track order #1234", want: "1234"},
+		{name: "named group after context groups", pattern: `(?i)(order|ticket)\s+(#)?(?P<value>\d+)`, input: "track order #1234", want: "1234"},
+		{name: "named group before a context group", pattern: `(?P<value>\d+)\s+(please|now)`, input: "track 1234 please", want: "1234"},
+		{name: "named group with multiple values", pattern: `(order|ticket)\s+(?P<value>\d+)`, multiple: true, input: "track order 12 and ticket 34", want: []string{"12", "34"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.IntentConfig{
+				Domain: "test",
+				Intents: map[string]models.IntentPattern{
+					"TrackOrder": {Description: "Track an order", Keywords: []string{"track"}, Priority: 5, Variables: []string{"order_id"}},
+				},
+				Entities: map[string]models.EntityPattern{
+					"order_id": {Type: "id", Regex: []string{tt.pattern}, Multiple: tt.multiple},
+				},
+			}
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["order_id"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order_id = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileConfig_EntityRegexGroups(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: `(\d+)`},
+		{pattern: `(?:order\s+)(\d+)`},
+		{pattern: `(order|ticket)\s+(?P<value>\d+)`},
+		{pattern: `\d+`, wantErr: true},
+		{pattern: `(order|ticket)\s+(\d+)`, wantErr: true},
+		{pattern: `(?P<id>\d+)\s+(now)`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		config := &models.IntentConfig{
+			Domain:   "test",
+			Entities: map[string]models.EntityPattern{"order_id": {Regex: []string{tt.pattern}}},
+		}
+		_, err := compileConfig(config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("compileConfig(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_Aliases(t *testing.T) {
+	config := contactConfig()
+	config.Aliases = map[string]string{"CreateContact": "CREATE_CONTACT"}
+	provider := newTestEnhancedProvider(t, config)
+
+	intent, err := provider.ExtractIntent(WithAlternatives(context.Background()), "add contact Bob")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CREATE_CONTACT" {
+		t.Errorf("Task = %s, want the alias CREATE_CONTACT", intent.Task)
+	}
+	if intent.Vars["name"] != "Bob" || !intent.IsComplete {
+		t.Errorf("intent = %+v, want it extracted with the CreateContact definition", intent)
+	}
+	if len(intent.Alternatives) == 0 || intent.Alternatives[0].Task != "CREATE_CONTACT" {
+		t.Errorf("Alternatives = %v, want the alias listed", intent.Alternatives)
+	}
+}
+
+func TestEnhancedLocalProvider_ScoringWeights(t *testing.T) {
+	weight := func(value float64) *float64 { return &value }
+
+	config := contactConfig()
+	config.ScoringWeights = &models.ScoringWeights{Phrase: weight(0.3), Keyword: weight(0.8)}
+	contact := config.Intents["CreateContact"]
+	contact.Regex = []string{`(?i)^add contact`}
+	contact.ScoringWeights = &models.ScoringWeights{Regex: weight(2)}
+	config.Intents["CreateContact"] = contact
+	provider := newTestEnhancedProvider(t, config)
+
+	breakdown := provider.calculateIntentScore("add contact Bob", "CreateContact", contact)
+	if breakdown.Regex != 2 {
+		t.Errorf("Regex = %v, want the intent's weight 2", breakdown.Regex)
+	}
+	if breakdown.Phrase != 0.3 {
+		t.Errorf("Phrase = %v, want the config's weight 0.3", breakdown.Phrase)
+	}
+	// "add" and "contact" match exactly, "create" doesn't
+	if want := 2 * 0.8 / 3; math.Abs(breakdown.Keyword-want) > 1e-9 {
+		t.Errorf("Keyword = %v, want %v from the config's keyword weight", breakdown.Keyword, want)
+	}
+}
+
+func TestEnhancedLocalProvider_MatchAccumulation(t *testing.T) {
+	tests := []struct {
+		name         string
+		accumulation string
+		text         string
+		wantRegex    float64
+		wantPhrase   float64
+	}{
+		{"first, one hit", "", "add contact Bob", 0.8, 0.6},
+		{"first, several hits", models.AccumulationFirst, "add contact Bob, create contact Bob, new contact Bob", 0.8, 0.6},
+		{"cumulative, one hit", models.AccumulationCumulative, "add contact Bob", 0.8, 0.6},
+		{"cumulative, two hits", models.AccumulationCumulative, "add contact Bob, create contact Bob", 0.8 + 0.4, 0.6 + 0.3},
+		{"cumulative, three hits", models.AccumulationCumulative, "add contact Bob, create contact Bob, new contact Bob", 0.8 + 0.4 + 0.2, 0.6 + 0.3 + 0.15},
+		{"cumulative, repeated hit", models.AccumulationCumulative, "add contact Bob, add contact Ann", 0.8, 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := contactConfig()
+			config.MatchAccumulation = tt.accumulation
+			contact := config.Intents["CreateContact"]
+			contact.Phrases = []string{"add contact", "create contact", "new contact", "Add Contact"}
+			contact.Regex = []string{`(?i)\badd contact`, `(?i)\bcreate contact`, `(?i)\bnew contact`}
+			config.Intents["CreateContact"] = contact
+			provider := newTestEnhancedProvider(t, config)
+
+			breakdown := provider.calculateIntentScore(tt.text, "CreateContact", contact)
+			if math.Abs(breakdown.Regex-tt.wantRegex) > 1e-9 {
+				t.Errorf("Regex = %v, want %v", breakdown.Regex, tt.wantRegex)
+			}
+			if math.Abs(breakdown.Phrase-tt.wantPhrase) > 1e-9 {
+				t.Errorf("Phrase = %v, want %v", breakdown.Phrase, tt.wantPhrase)
+			}
+			if breakdown.RegexHit != `(?i)\badd contact` || breakdown.PhraseHit != "add contact" {
+				t.Errorf("hits = %q, %q, want the first regex and phrase", breakdown.RegexHit, breakdown.PhraseHit)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_Cancelled(t *testing.T) {
+	config := &models.IntentConfig{Domain: "test", Intents: map[string]models.IntentPattern{}, Entities: map[string]models.EntityPattern{}}
+	for i := 0; i < 2000; i++ {
+		name := fmt.Sprintf("Intent%d", i)
+		config.Intents[name] = models.IntentPattern{
+			Description: name,
+			Keywords:    []string{fmt.Sprintf("word%d", i), "contact"},
+			Regex:       []string{fmt.Sprintf(`(?i)\bword%d\b`, i)},
+		}
+		config.Entities[fmt.Sprintf("entity%d", i)] = models.EntityPattern{Regex: []string{fmt.Sprintf(`(?i)field%d (\w+)`, i)}}
+	}
+	provider := newTestEnhancedProvider(t, config)
+	text := "add word1999 contact with field1999 value"
+
+	start := time.Now()
+	if _, err := provider.ExtractIntent(context.Background(), text); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	full := time.Since(start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	intent, err := provider.ExtractIntent(ctx, text)
+	if !errors.Is(err, context.Canceled) || intent != nil {
+		t.Fatalf("ExtractIntent() = %v, %v, want context.Canceled", intent, err)
+	}
+	if elapsed := time.Since(start); elapsed >= full {
+		t.Errorf("cancelled ExtractIntent() took %v, want less than the %v of a full extraction", elapsed, full)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := provider.ExtractIntent(ctx, text); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExtractIntent() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLocalAIProvider_Cancelled(t *testing.T) {
+	provider, err := NewLocalAIProvider(AIProviderConfig{})
+	if err != nil {
+		t.Fatalf("NewLocalAIProvider() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if intent, err := provider.ExtractIntent(ctx, "create a contact named Bob"); !errors.Is(err, context.Canceled) || intent != nil {
+		t.Errorf("ExtractIntent() = %v, %v, want context.Canceled", intent, err)
+	}
+}
+
+func TestEnhancedLocalProvider_AmbiguityMargin(t *testing.T) {
+	tests := []struct {
+		name             string
+		margin           float64
+		fallback         string
+		input            string
+		wantTask         string
+		wantAlternatives []string
+	}{
+		{name: "near tie", margin: 0.05, input: "archive or backup the task", wantTask: "UNKNOWN", wantAlternatives: []string{"backup", "archive"}},
+		{name: "near tie with fallback", margin: 0.05, fallback: "Clarify", input: "archive or backup the task", wantTask: "Clarify", wantAlternatives: []string{"backup", "archive"}},
+		{name: "outside the margin", margin: 0.03, input: "archive or backup the task", wantTask: "backup"},
+		{name: "clear winner", margin: 0.05, input: "archive the task", wantTask: "archive"},
+		{name: "no margin", input: "archive or backup the task", wantTask: "backup"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := taskConfig("archive", "backup")
+			// backup scores 0.04 more than archive when both match
+			keyword := 0.44
+			backup := config.Intents["backup"]
+			backup.ScoringWeights = &models.ScoringWeights{Keyword: &keyword}
+			config.Intents["backup"] = backup
+			config.DefaultConfidence = 0.1
+			config.AmbiguityMargin = tt.margin
+			config.FallbackIntent = tt.fallback
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.wantTask {
+				t.Errorf("Task = %v, want %v", intent.Task, tt.wantTask)
+			}
+			var alternatives []string
+			for _, candidate := range intent.Alternatives {
+				alternatives = append(alternatives, candidate.Task)
+			}
+			if !reflect.DeepEqual(alternatives, tt.wantAlternatives) {
+				t.Errorf("Alternatives = %v, want %v", intent.Alternatives, tt.wantAlternatives)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_PriorityBoost(t *testing.T) {
+	always := 0.0
+
+	t.Run("breaks a near tie", func(t *testing.T) {
+		config := taskConfig("archive", "backup")
+		// backup scores 0.04 more than archive before the boost
+		keyword := 0.44
+		backup := config.Intents["backup"]
+		backup.ScoringWeights = &models.ScoringWeights{Keyword: &keyword}
+		backup.Priority = 1
+		config.Intents["backup"] = backup
+		archive := config.Intents["archive"]
+		archive.Priority = 2
+		config.Intents["archive"] = archive
+		config.DefaultConfidence = 0.1
+
+		provider := newTestEnhancedProvider(t, config)
+		intent, err := provider.ExtractIntent(context.Background(), "archive or backup the task")
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		if intent.Task != "archive" {
+			t.Errorf("Task = %v, want the higher priority archive", intent.Task)
+		}
+	})
+
+	t.Run("doesn't rescue a weak match", func(t *testing.T) {
+		config := &models.IntentConfig{
+			Domain: "test",
+			Intents: map[string]models.IntentPattern{
+				"archive": {
+					Description: "archive",
+					Keywords:    []string{"archive", "task", "folder", "old", "files"},
+					Priority:    10,
+				},
+			},
+		}
+
+		provider := newTestEnhancedProvider(t, config)
+		intent, err := provider.ExtractIntent(context.Background(), "task")
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		if intent.Task != "UNKNOWN" {
+			t.Errorf("Task = %v, want UNKNOWN for one keyword in five", intent.Task)
+		}
+
+		// Boosting regardless of the base score lets priority carry it
+		config.PriorityBoostRatio = &always
+		provider = newTestEnhancedProvider(t, config)
+		intent, err = provider.ExtractIntent(context.Background(), "task")
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		if intent.Task != "archive" {
+			t.Errorf("Task = %v with priority_boost_ratio 0, want archive", intent.Task)
+		}
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		config := taskConfig("archive")
+		archive := config.Intents["archive"]
+		archive.Priority = 10
+		config.Intents["archive"] = archive
+
+		provider := newTestEnhancedProvider(t, config)
+		explanation := provider.Explain("archive the task")
+		if boost := explanation.Intents[0].Breakdown.PriorityBoost; boost != models.DefaultMaxPriorityBoost {
+			t.Errorf("PriorityBoost = %v, want the cap %v", boost, models.DefaultMaxPriorityBoost)
+		}
+	})
+}
+
+func TestEnhancedLocalProvider_ExtractionOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		order      []string
+		confidence map[string]float64
+		input      string
+		wantName   string
+		wantMethod string
+	}{
+		{name: "regex first by default", input: "Please add contact named Alice", wantName: "Please", wantMethod: "regex"},
+		{name: "keyword first", order: []string{"keyword", "regex"}, input: "Please add contact named Alice", wantName: "Alice", wantMethod: "keyword"},
+		{name: "more trusted method first", confidence: map[string]float64{"regex": 0.4, "keyword": 0.8}, input: "Please add contact named Alice", wantName: "Alice", wantMethod: "keyword"},
+		{name: "confidence outranks order", order: []string{"keyword", "regex"}, confidence: map[string]float64{"regex": 0.9}, input: "Please add contact named Alice", wantName: "Please", wantMethod: "regex"},
+		{name: "falls back when the first method finds nothing", order: []string{"keyword", "regex"}, input: "Please add Alice", wantName: "Please", wantMethod: "regex"},
+		{name: "regex only", order: []string{"regex"}, input: "add contact named alice", wantName: "", wantMethod: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := contactConfig()
+			// Any capitalized word, so it grabs "Please" as readily as a name
+			config.Entities["name"] = models.EntityPattern{
+				Type:             "name",
+				Regex:            []string{`\b([A-Z][a-z]+)\b`},
+				ExtractionOrder:  tt.order,
+				MethodConfidence: tt.confidence,
+			}
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if name, _ := intent.Vars["name"].(string); name != tt.wantName {
+				t.Errorf("Vars[name] = %v, want %q", intent.Vars["name"], tt.wantName)
+			}
+			if method := provider.Trace(context.Background(), tt.input).EntityMethods["name"]; method != tt.wantMethod {
+				t.Errorf("EntityMethods[name] = %q, want %q", method, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_Locations(t *testing.T) {
+	config := eventConfig()
+	config.Entities["location"] = models.EntityPattern{Type: "location", Keywords: []string{"in", "at"}}
+	// Any entity typed as a location is found the same way
+	config.Entities["venue"] = models.EntityPattern{Type: "location", Keywords: []string{"in", "at"}}
+	provider := newTestEnhancedProvider(t, config)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"multi-word", "schedule a meeting in New York City tomorrow", "New York City"},
+		{"ends at punctuation", "schedule a meeting in San Francisco, at 3pm", "San Francisco"},
+		{"ends at a date word", "schedule a meeting in Paris Tomorrow", "Paris"},
+		{"skips non-locations", "schedule a meeting at 3pm in Los Angeles", "Los Angeles"},
+		{"quoted", `schedule a meeting at "the main office" tomorrow`, "the main office"},
+		{"lowercase", "schedule a meeting at the office", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities := provider.extractEntities(tt.input, "")
+			for _, entityName := range []string{"location", "venue"} {
+				got := ""
+				if values := entities[entityName]; len(values) > 0 {
+					got = values[0]
+				}
+				if got != tt.want {
+					t.Errorf("%s = %q, want %q", entityName, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIntentService_CapitalizedLocation(t *testing.T) {
+	config := eventConfig()
+	config.Entities["location"] = models.EntityPattern{Type: "location", Keywords: []string{"in", "at"}}
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, config)}
+
+	// Without a location regex only the capitalized words can find it, so the
+	// service must hand the provider the text with its casing
+	intent, err := service.ExtractIntent(context.Background(), "Schedule a meeting in New York City tomorrow")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if got := intent.Vars["location"]; got != "New York City" {
+		t.Errorf("location = %q, want New York City", got)
+	}
+}
+
+func TestEnhancedLocalProvider_IntentEntities(t *testing.T) {
+	config := &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"Weather": {
+				Description: "Get the weather",
+				Keywords:    []string{"weather", "forecast"},
+				Phrases:     []string{"weather in"},
+				Entities:    []string{"location"},
+			},
+			"CreateTask": {
+				Description: "Create a task",
+				Keywords:    []string{"task", "todo"},
+				Phrases:     []string{"add a task"},
+			},
+		},
+		Entities: map[string]models.EntityPattern{
+			"location": {Type: "location", Regex: []string{`(?i)\bin\s+([A-Z][a-z]+)`}},
+			"priority": {Type: "text", Regex: []string{`(?i)\b(high|low|urgent)\b`}},
+		},
+	}
+	provider := newTestEnhancedProvider(t, config)
+
+	tests := []struct {
+		name         string
+		input        string
+		wantTask     string
+		wantPriority interface{}
+	}{
+		{name: "priority not in the whitelist", input: "what's the weather in Paris, high winds?", wantTask: "Weather"},
+		{name: "intent without a whitelist", input: "add a task with high priority in Paris", wantTask: "CreateTask", wantPriority: "high"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.wantTask {
+				t.Fatalf("Task = %s, want %s", intent.Task, tt.wantTask)
+			}
+			if got := intent.Vars["priority"]; got != tt.wantPriority {
+				t.Errorf("priority = %v, want %v", got, tt.wantPriority)
+			}
+			if got := intent.Vars["location"]; got != "Paris" {
+				t.Errorf("location = %v, want Paris", got)
+			}
+		})
+	}
+
+	if entities := provider.extractEntities("weather in Paris, high winds", "Weather"); entities["priority"] != nil || entities["location"] == nil {
+		t.Errorf("extractEntities() for Weather = %v, want only location", entities)
+	}
+}
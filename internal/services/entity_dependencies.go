@@ -0,0 +1,26 @@
+package services
+
+// dropUnmetDependencies removes entities whose depends_on names an entity
+// that wasn't extracted. Dropping one can leave another's dependency unmet,
+// so it repeats until nothing changes. It returns the dropped entities.
+func (p *EnhancedLocalProvider) dropUnmetDependencies(entities map[string][]string) []string {
+	var dropped []string
+	for changed := true; changed; {
+		changed = false
+		for name := range entities {
+			entity, exists := p.config.Entities[name]
+			if !exists {
+				continue
+			}
+			for _, dependency := range entity.DependsOn {
+				if _, found := entities[dependency]; !found {
+					delete(entities, name)
+					dropped = append(dropped, name)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// dependentTimeConfig is eventConfig with time kept only alongside a date
+func dependentTimeConfig() *models.IntentConfig {
+	config := eventConfig()
+	timeEntity := config.Entities["time"]
+	timeEntity.DependsOn = []string{"date"}
+	config.Entities["time"] = timeEntity
+	return config
+}
+
+func TestEnhancedLocalProvider_DependsOn(t *testing.T) {
+	provider := newTestEnhancedProvider(t, dependentTimeConfig())
+
+	tests := []struct {
+		name     string
+		input    string
+		wantTime interface{}
+	}{
+		{name: "time with a date", input: `schedule a meeting "Standup" tomorrow at 3pm`, wantTime: "3pm"},
+		{name: "time without a date", input: `schedule a meeting "Standup" at 3pm`, wantTime: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["time"]; got != tt.wantTime {
+				t.Errorf("time = %v, want %v", got, tt.wantTime)
+			}
+			if intent.Vars["title"] != "Standup" {
+				t.Errorf("title = %v, want entities without dependencies kept", intent.Vars["title"])
+			}
+		})
+	}
+
+	if entities := provider.extractEntities("at 3pm", ""); entities["time"] != nil {
+		t.Errorf("extractEntities() time = %v, want it dropped without a date", entities["time"])
+	}
+}
+
+func TestEnhancedLocalProvider_DependsOnChain(t *testing.T) {
+	// The reminder needs time, which needs date: no date drops both
+	config := dependentTimeConfig()
+	config.Entities["reminder"] = models.EntityPattern{
+		Type:      "text",
+		Regex:     []string{`(?i)remind me (\d+ minutes) before`},
+		DependsOn: []string{"time"},
+	}
+	provider := newTestEnhancedProvider(t, config)
+
+	entities := provider.extractEntities("meeting at 3pm, remind me 10 minutes before", "")
+	if entities["time"] != nil || entities["reminder"] != nil {
+		t.Errorf("entities = %v, want time and the reminder depending on it dropped", entities)
+	}
+
+	entities = provider.extractEntities("meeting tomorrow at 3pm, remind me 10 minutes before", "")
+	if len(entities["reminder"]) != 1 || entities["reminder"][0] != "10 minutes" {
+		t.Errorf("reminder = %v, want it kept once its chain is met", entities["reminder"])
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EntityNormalizer rewrites an extracted entity value into a canonical form
+type EntityNormalizer interface {
+	Normalize(raw string) (string, error)
+}
+
+// EntityNormalizerFunc adapts a function to EntityNormalizer
+type EntityNormalizerFunc func(raw string) (string, error)
+
+// Normalize calls f(raw)
+func (f EntityNormalizerFunc) Normalize(raw string) (string, error) {
+	return f(raw)
+}
+
+var (
+	entityNormalizersMu sync.RWMutex
+	entityNormalizers   = map[string]EntityNormalizer{
+		"email": EntityNormalizerFunc(normalizeEmail),
+		"phone": EntityNormalizerFunc(normalizePhone),
+		"name":  EntityNormalizerFunc(normalizeName),
+	}
+)
+
+// RegisterEntityNormalizer sets the normalizer used for entities of the given
+// type that set normalize: true, replacing any existing one
+func RegisterEntityNormalizer(entityType string, normalizer EntityNormalizer) {
+	entityNormalizersMu.Lock()
+	defer entityNormalizersMu.Unlock()
+
+	entityNormalizers[entityType] = normalizer
+}
+
+// lookupEntityNormalizer returns the normalizer registered for an entity type
+func lookupEntityNormalizer(entityType string) (EntityNormalizer, bool) {
+	entityNormalizersMu.RLock()
+	defer entityNormalizersMu.RUnlock()
+
+	normalizer, exists := entityNormalizers[entityType]
+	return normalizer, exists
+}
+
+// normalizeEntities rewrites the values of entities that opted in with
+// normalize: true. Types without a normalizer pass through unchanged, as do
+// values their normalizer rejects; strict_entities decides whether those are
+// kept.
+func (p *EnhancedLocalProvider) normalizeEntities(entities map[string][]string) {
+	for name, values := range entities {
+		entity, exists := p.config.Entities[name]
+		if !exists || !entity.Normalize {
+			continue
+		}
+		normalizer, exists := lookupEntityNormalizer(entity.Type)
+		if !exists {
+			continue
+		}
+
+		var normalized []string
+		for _, value := range values {
+			if result, err := normalizer.Normalize(value); err == nil {
+				value = result
+			} else {
+				slog.Debug("Entity value left as extracted", "entity", name, "type", entity.Type, "error", err)
+			}
+			normalized = appendUnique(normalized, value)
+		}
+		entities[name] = normalized
+	}
+}
+
+// defaultPhoneCountryCode is assumed for 10-digit numbers without one, the
+// North American format
+const defaultPhoneCountryCode = "1"
+
+// normalizePhone formats a phone number as E.164: "(555) 123-4567" becomes
+// "+15551234567". Numbers without a country code must be North American.
+func normalizePhone(raw string) (string, error) {
+	number, ok := validatePhone(raw)
+	if !ok {
+		return "", fmt.Errorf("not a phone number: %q", raw)
+	}
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		return number, nil
+	case len(number) == 10:
+		return "+" + defaultPhoneCountryCode + number, nil
+	case len(number) == 11 && strings.HasPrefix(number, defaultPhoneCountryCode):
+		return "+" + number, nil
+	}
+	return "", fmt.Errorf("phone number %q has no country code", raw)
+}
+
+// normalizeEmail lowercases an email address
+func normalizeEmail(raw string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	if !strings.Contains(email, "@") {
+		return "", fmt.Errorf("not an email address: %q", raw)
+	}
+	return email, nil
+}
+
+// normalizeName title-cases each word of a name written in a single case, so
+// "alice SMITH" becomes "Alice Smith". Words that already mix cases, such as
+// "McDonald", are kept as written.
+func normalizeName(raw string) (string, error) {
+	words := strings.Fields(raw)
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty name")
+	}
+
+	for i, word := range words {
+		if word != strings.ToLower(word) && word != strings.ToUpper(word) {
+			continue
+		}
+		// Capitalize each part of hyphenated names such as "jean-luc"
+		parts := strings.Split(strings.ToLower(word), "-")
+		for j, part := range parts {
+			if first, size := utf8.DecodeRuneInString(part); size > 0 {
+				parts[j] = string(unicode.ToTitle(first)) + part[size:]
+			}
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+	return strings.Join(words, " "), nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "(555) 123-4567", want: "+15551234567"},
+		{raw: "555.123.4567", want: "+15551234567"},
+		{raw: "1-555-123-4567", want: "+15551234567"},
+		{raw: "+44 20 7946 0958", want: "+442079460958"},
+		{raw: "0044 20 7946 0958", want: "+442079460958"},
+		{raw: "123-4567", wantErr: true},
+		{raw: "call me", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := normalizePhone(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizePhone(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizePhone(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"alice smith":     "Alice Smith",
+		"ALICE SMITH":     "Alice Smith",
+		"jean-luc picard": "Jean-Luc Picard",
+		"ronald McDonald": "Ronald McDonald",
+		"élodie":          "Élodie",
+	}
+
+	for raw, want := range tests {
+		if got, err := normalizeName(raw); err != nil || got != want {
+			t.Errorf("normalizeName(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_NormalizeEntities(t *testing.T) {
+	config := contactConfig()
+	config.Entities["name"] = withNormalize(config.Entities["name"])
+	config.Entities["email"] = withNormalize(config.Entities["email"])
+	config.Entities["phone"] = withNormalize(models.EntityPattern{
+		Type:  "phone",
+		Regex: []string{`(\(?\d{3}\)?[\s.-]?\d{3}[\s.-]?\d{4})`},
+	})
+	config.Entities["sku"] = withNormalize(models.EntityPattern{
+		Type:  "sku",
+		Regex: []string{`(?i)\b(sku-\w+)\b`},
+	})
+	provider := newTestEnhancedProvider(t, config)
+
+	intent, err := provider.ExtractIntent(context.Background(), `add contact "alice smith" Alice.Smith@Example.COM (555) 123-4567 sku-ab12`)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+
+	want := map[string]string{
+		"name":  "Alice Smith",
+		"email": "alice.smith@example.com",
+		"phone": "+15551234567",
+		"sku":   "sku-ab12", // No normalizer registered for the type
+	}
+	for field, value := range want {
+		if got := intent.Vars[field]; got != value {
+			t.Errorf("%s = %v, want %v", field, got, value)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_NormalizeIsOptIn(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactConfig())
+
+	intent, err := provider.ExtractIntent(context.Background(), "add contact named Alice with email Alice@Example.com")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if got := intent.Vars["email"]; got != "Alice@Example.com" {
+		t.Errorf("email = %v, want it unchanged without normalize", got)
+	}
+}
+
+func TestRegisterEntityNormalizer(t *testing.T) {
+	original, hadOriginal := lookupEntityNormalizer("sku")
+	t.Cleanup(func() {
+		entityNormalizersMu.Lock()
+		defer entityNormalizersMu.Unlock()
+		if hadOriginal {
+			entityNormalizers["sku"] = original
+		} else {
+			delete(entityNormalizers, "sku")
+		}
+	})
+
+	RegisterEntityNormalizer("sku", EntityNormalizerFunc(func(raw string) (string, error) {
+		return strings.ToUpper(raw), nil
+	}))
+
+	config := noteConfig()
+	config.Entities["sku"] = models.EntityPattern{
+		Type:      "sku",
+		Regex:     []string{`(?i)\b(sku-\w+)\b`},
+		Normalize: true,
+	}
+	provider := newTestEnhancedProvider(t, config)
+
+	intent, err := provider.ExtractIntent(context.Background(), "note that sku-ab12 is out of stock")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Vars["sku"] != "SKU-AB12" {
+		t.Errorf("sku = %v, want SKU-AB12", intent.Vars["sku"])
+	}
+}
+
+// withNormalize returns the entity with normalize: true
+func withNormalize(entity models.EntityPattern) models.EntityPattern {
+	entity.Normalize = true
+	return entity
+}
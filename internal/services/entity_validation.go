@@ -0,0 +1,141 @@
+package services
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// EntityValidator checks an extracted entity value. It returns the value in
+// normalized form and whether it is valid.
+type EntityValidator func(value string) (string, bool)
+
+var (
+	entityValidatorsMu sync.RWMutex
+	entityValidators   = map[string]EntityValidator{
+		"email": validateEmail,
+		"phone": validatePhone,
+	}
+)
+
+// RegisterEntityValidator sets the validator used for entities of the given
+// type when a config enables strict_entities, replacing any existing one
+func RegisterEntityValidator(entityType string, validator EntityValidator) {
+	entityValidatorsMu.Lock()
+	defer entityValidatorsMu.Unlock()
+
+	entityValidators[entityType] = validator
+}
+
+// lookupEntityValidator returns the validator registered for an entity type
+func lookupEntityValidator(entityType string) (EntityValidator, bool) {
+	entityValidatorsMu.RLock()
+	defer entityValidatorsMu.RUnlock()
+
+	validator, exists := entityValidators[entityType]
+	return validator, exists
+}
+
+// validateEntities normalizes extracted values and drops those that fail their
+// type's validator. Entities left without values are removed, so required ones
+// are reported as missing.
+func (p *EnhancedLocalProvider) validateEntities(entities map[string][]string) {
+	for name, values := range entities {
+		entity, exists := p.config.Entities[name]
+		if !exists {
+			continue
+		}
+		validator, exists := lookupEntityValidator(entity.Type)
+		if !exists {
+			continue
+		}
+
+		var valid []string
+		for _, value := range values {
+			if normalized, ok := validator(value); ok {
+				valid = appendUnique(valid, normalized)
+			}
+		}
+		if len(valid) > 0 {
+			entities[name] = valid
+		} else {
+			delete(entities, name)
+		}
+	}
+}
+
+var emailDomainLabelRegex = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$`)
+
+// validateEmail accepts a bare address with a dotted domain and a
+// two-letter-or-longer top-level domain, and lowercases the domain
+func validateEmail(value string) (string, bool) {
+	if len(value) > 254 {
+		return "", false
+	}
+
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Name != "" || address.Address != value {
+		return "", false
+	}
+
+	at := strings.LastIndex(value, "@")
+	local, domain := value[:at], strings.ToLower(value[at+1:])
+	if strings.HasPrefix(local, "\"") {
+		return "", false // Quoted local parts are legal but never intended here
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", false
+	}
+	for _, label := range labels {
+		if !emailDomainLabelRegex.MatchString(label) {
+			return "", false
+		}
+	}
+	if tld := labels[len(labels)-1]; len(tld) < 2 || strings.Trim(tld, "0123456789") == "" {
+		return "", false
+	}
+
+	return local + "@" + domain, true
+}
+
+// validatePhone accepts 7 to 15 digits, optionally grouped with spaces, dots,
+// dashes or parentheses. Numbers with an international prefix ("+" or "00")
+// are normalized to E.164 ("+15551234567"); others to their digits.
+func validatePhone(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	international := false
+	switch {
+	case strings.HasPrefix(value, "+"):
+		international = true
+		value = value[1:]
+	case strings.HasPrefix(value, "00"):
+		international = true
+		value = value[2:]
+	}
+
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+
+	number := digits.String()
+	if len(number) < 7 || len(number) > 15 {
+		return "", false
+	}
+	if international {
+		if number[0] == '0' {
+			return "", false // Country codes never start with 0
+		}
+		return "+" + number, true
+	}
+	return number, true
+}
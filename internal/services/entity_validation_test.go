@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"alice@example.com", "alice@example.com", true},
+		{"Alice.Smith+work@Mail.Example.co.uk", "Alice.Smith+work@mail.example.co.uk", true},
+		{"a@b", "", false},
+		{"a.b@c", "", false},
+		{"alice@example.c", "", false},
+		{"alice@-example.com", "", false},
+		{"alice@example..com", "", false},
+		{"alice@example.123", "", false},
+		{"alice..smith@example.com", "", false},
+		{"Alice <alice@example.com>", "", false},
+		{`"alice"@example.com`, "", false},
+		{"alice@", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := validateEmail(tt.input)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("validateEmail(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidatePhone(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"555-1234", "5551234", true},
+		{"(555) 123-4567", "5551234567", true},
+		{"+1 555.123.4567", "+15551234567", true},
+		{"0044 20 7946 0958", "+442079460958", true},
+		{"12-34", "", false},
+		{"+0 555 123 4567", "", false},
+		{"555-CALL-NOW", "", false},
+		{"1234567890123456", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := validatePhone(tt.input)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("validatePhone(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_StrictEntities(t *testing.T) {
+	newProvider := func(strict bool) *EnhancedLocalProvider {
+		config := contactConfig()
+		contact := config.Intents["CreateContact"]
+		contact.Required = []string{"name", "email"}
+		config.Intents["CreateContact"] = contact
+		config.StrictEntities = strict
+		return newTestEnhancedProvider(t, config)
+	}
+
+	// The keyword fallback captures anything with "@" and "."
+	const input = "add contact named Alice email a.b@c"
+
+	lenient, err := newProvider(false).ExtractIntent(context.Background(), input)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if lenient.Vars["email"] != "a.b@c" {
+		t.Fatalf("lenient email = %v, want the raw capture", lenient.Vars["email"])
+	}
+
+	strict, err := newProvider(true).ExtractIntent(context.Background(), input)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if _, exists := strict.Vars["email"]; exists {
+		t.Errorf("strict email = %v, want it dropped", strict.Vars["email"])
+	}
+	if len(strict.Missing) != 1 || strict.Missing[0] != "email" || len(strict.FollowUp) != 1 {
+		t.Errorf("Missing = %v, FollowUp = %v, want a follow-up for email", strict.Missing, strict.FollowUp)
+	}
+
+	valid, err := newProvider(true).ExtractIntent(context.Background(), "add contact named Alice email alice@Example.com")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if valid.Vars["email"] != "alice@example.com" || !valid.IsComplete {
+		t.Errorf("email = %v (complete %v), want the normalized address", valid.Vars["email"], valid.IsComplete)
+	}
+}
+
+func TestRegisterEntityValidator(t *testing.T) {
+	original, hadOriginal := lookupEntityValidator("sku")
+	t.Cleanup(func() {
+		entityValidatorsMu.Lock()
+		defer entityValidatorsMu.Unlock()
+		if hadOriginal {
+			entityValidators["sku"] = original
+		} else {
+			delete(entityValidators, "sku")
+		}
+	})
+
+	RegisterEntityValidator("sku", func(value string) (string, bool) {
+		return strings.ToUpper(value), strings.HasPrefix(strings.ToLower(value), "sku-")
+	})
+
+	config := noteConfig()
+	config.StrictEntities = true
+	config.Entities["sku"] = models.EntityPattern{
+		Type:  "sku",
+		Regex: []string{`(?i)\b(sku-\w+|item-\w+)\b`},
+	}
+	provider := newTestEnhancedProvider(t, config)
+
+	intent, err := provider.ExtractIntent(context.Background(), "note that sku-ab12 is out of stock")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Vars["sku"] != "SKU-AB12" {
+		t.Errorf("sku = %v, want the normalized SKU-AB12", intent.Vars["sku"])
+	}
+
+	intent, err = provider.ExtractIntent(context.Background(), "note that item-ab12 is out of stock")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if _, exists := intent.Vars["sku"]; exists {
+		t.Errorf("sku = %v, want it rejected by the custom validator", intent.Vars["sku"])
+	}
+}
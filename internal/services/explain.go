@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"myllm/internal/models"
+)
+
+// ErrExplainNotSupported is returned when the active provider has no scores to explain
+var ErrExplainNotSupported = errors.New("score explanation not supported")
+
+// Explain classifies text like ExtractIntent and reports how every intent
+// scored, including disabled intents and intents dropped because their words
+// were negated. Intents are listed best first. Nothing else is extracted.
+func (p *EnhancedLocalProvider) Explain(text string) *models.ExplainResponse {
+	return p.snapshot().explain(text)
+}
+
+// explain builds the breakdown for text with p's config. p is a snapshot.
+func (p *EnhancedLocalProvider) explain(text string) *models.ExplainResponse {
+	normalizedText := p.normalizeText(text)
+	scoringText, negated := p.stripNegated(normalizedText)
+	_, exactMatch := p.matchExactPhrase(normalizedText)
+	// A background context is never cancelled, so there's no error
+	result, _ := p.classify(context.Background(), normalizedText)
+
+	response := &models.ExplainResponse{
+		Text:           text,
+		NormalizedText: normalizedText,
+		ScoredText:     scoringText,
+		Task:           result.Intent,
+		Confidence:     result.Confidence,
+		ExactMatch:     exactMatch,
+		Intents:        make([]models.IntentExplanation, 0, len(p.config.Intents)),
+	}
+
+	for intentName, intent := range p.config.Intents {
+		breakdown := p.calculateIntentScore(scoringText, intentName, intent)
+		explanation := models.IntentExplanation{
+			Task:      intentName,
+			Score:     breakdown.Total(),
+			Threshold: p.config.ConfidenceThreshold(intentName),
+			Breakdown: breakdown,
+		}
+		switch {
+		case !intent.IsEnabled():
+			explanation.Skipped = "disabled"
+		case negated && !p.matchesIntent(scoringText, intentName):
+			explanation.Skipped = "negated"
+		default:
+			explanation.AboveThreshold = explanation.Score >= explanation.Threshold
+		}
+		response.Intents = append(response.Intents, explanation)
+	}
+
+	sort.Slice(response.Intents, func(i, j int) bool {
+		a, b := response.Intents[i], response.Intents[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Task < b.Task
+	})
+	return response
+}
+
+// Trace classifies text like ExtractIntent, in the same language, and reports
+// the words that were scored, the keywords each intent matched and the
+// winning score
+func (p *EnhancedLocalProvider) Trace(ctx context.Context, text string) *models.DebugTrace {
+	snapshot := p.snapshot()
+	language := snapshot.selectLanguage(ctx, text)
+	return snapshot.forLanguage(language).trace(language, text)
+}
+
+// trace builds the debug trace for text with p's config. p is a snapshot.
+func (p *EnhancedLocalProvider) trace(language, text string) *models.DebugTrace {
+	normalizedText := p.normalizeText(text)
+	scoringText, _ := p.stripNegated(normalizedText)
+	_, exactMatch := p.matchExactPhrase(normalizedText)
+	// A background context is never cancelled, so there's no error
+	result, _ := p.classify(context.Background(), normalizedText)
+
+	entities, methods, _ := p.locateEntities(context.Background(), text, result.Intent)
+	p.normalizeEntities(entities)
+	for _, name := range p.applyAllowedValues(entities) {
+		delete(methods, name)
+	}
+	for _, name := range p.dropUnmetDependencies(entities) {
+		delete(methods, name)
+	}
+	mask := p.entityMask(entities)
+	trace := &models.DebugTrace{
+		Language:       language,
+		NormalizedText: mask.Replace(normalizedText),
+		Tokens:         p.tokenize(mask.Replace(scoringText)),
+		ExactMatch:     exactMatch,
+	}
+	if len(methods) > 0 {
+		trace.EntityMethods = methods
+	}
+
+	// The winner, or the intent that came closest when nothing passed
+	top, topScore := result.Intent, 0.0
+	for _, intentName := range sortedKeys(p.config.Intents) {
+		intent := p.config.Intents[intentName]
+		if !intent.IsEnabled() {
+			continue
+		}
+		breakdown := p.calculateIntentScore(scoringText, intentName, intent)
+		for _, keyword := range breakdown.Keywords {
+			if keyword.Match == "" {
+				continue
+			}
+			if trace.MatchedKeywords == nil {
+				trace.MatchedKeywords = make(map[string][]string)
+			}
+			task := p.taskName(intentName)
+			trace.MatchedKeywords[task] = append(trace.MatchedKeywords[task], keyword.Keyword)
+		}
+		if score := breakdown.Total(); intentName == result.Intent || (result.Intent == "UNKNOWN" && score > topScore) {
+			top, topScore = intentName, score
+		}
+	}
+	if top != "UNKNOWN" {
+		trace.TopTask = p.taskName(top)
+		trace.Score = topScore
+		trace.Threshold = p.config.ConfidenceThreshold(top)
+	}
+	return trace
+}
+
+// entityMask returns a replacer that writes the values of the extracted
+// entities as "<entity>" in normalized forms of the text
+func (p *EnhancedLocalProvider) entityMask(entities map[string][]string) *strings.Replacer {
+	type mask struct{ value, entity string }
+	var masks []mask
+	for name, values := range entities {
+		for _, value := range values {
+			if value := p.normalizeText(value); value != "" {
+				masks = append(masks, mask{value, name})
+			}
+		}
+	}
+
+	// The replacer tries values in order; longer ones go first so a value
+	// inside another doesn't split it
+	sort.Slice(masks, func(i, j int) bool { return len(masks[i].value) > len(masks[j].value) })
+	replacements := make([]string, 0, 2*len(masks))
+	for _, m := range masks {
+		replacements = append(replacements, m.value, "<"+m.entity+">")
+	}
+	return strings.NewReplacer(replacements...)
+}
+
+// Trace returns the debug trace for text from the active provider. Providers
+// that don't score intents get a trace naming only the provider.
+func (s *IntentService) Trace(ctx context.Context, text string) *models.DebugTrace {
+	trace := &models.DebugTrace{}
+	if enhanced, ok := s.aiProvider.(*EnhancedLocalProvider); ok {
+		trace = enhanced.Trace(ctx, s.preprocess(text))
+	}
+	trace.Provider = s.GetAIProviderName()
+	return trace
+}
+
+// Explain returns the scoring breakdown for text from the active provider.
+// Only the enhanced local provider scores intents, so others return
+// ErrExplainNotSupported. It is a dry run: no webhooks, sessions or metrics.
+func (s *IntentService) Explain(text string) (*models.ExplainResponse, error) {
+	enhanced, ok := s.aiProvider.(*EnhancedLocalProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w by provider %s", ErrExplainNotSupported, s.GetAIProviderName())
+	}
+	return enhanced.Explain(s.preprocess(text)), nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// explainConfig exercises every scoring signal
+func explainConfig() *models.IntentConfig {
+	disabled := false
+	return &models.IntentConfig{
+		Domain:   "test",
+		Synonyms: map[string][]string{"contact": {"person"}},
+		Intents: map[string]models.IntentPattern{
+			"CreateContact": {
+				Description: "Create a contact",
+				Keywords:    []string{"create", "contact", "new"},
+				Phrases:     []string{"create a"},
+				Regex:       []string{`(?i)^create\b`},
+				Priority:    2,
+			},
+			"DeleteContact": {
+				Description: "Delete a contact",
+				Keywords:    []string{"delete", "contact"},
+				Priority:    1,
+			},
+			"ArchiveContact": {
+				Description: "Archive a contact",
+				Keywords:    []string{"archive", "contact"},
+				Enabled:     &disabled,
+			},
+		},
+	}
+}
+
+// approxEqual compares scores built from float sums
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestEnhancedLocalProvider_Explain(t *testing.T) {
+	provider := newTestEnhancedProvider(t, explainConfig())
+
+	explanation := provider.Explain("Create a contct named Alice")
+	if explanation.Task != "CreateContact" || explanation.ExactMatch {
+		t.Fatalf("Task = %s, ExactMatch = %v, want scored CreateContact", explanation.Task, explanation.ExactMatch)
+	}
+	if len(explanation.Intents) != 3 || explanation.Intents[0].Task != "CreateContact" {
+		t.Fatalf("Intents = %+v, want all three with CreateContact first", explanation.Intents)
+	}
+
+	create := explanation.Intents[0]
+	breakdown := create.Breakdown
+	if breakdown.Regex != 0.8 || breakdown.RegexHit != `(?i)^create\b` {
+		t.Errorf("regex = %v %q, want 0.8 from the pattern", breakdown.Regex, breakdown.RegexHit)
+	}
+	if breakdown.Phrase != 0.6 || breakdown.PhraseHit != "create a" {
+		t.Errorf("phrase = %v %q, want 0.6 from \"create a\"", breakdown.Phrase, breakdown.PhraseHit)
+	}
+	wantKeywords := []models.KeywordMatch{
+		{Keyword: "create", Match: models.KeywordMatchExact, Score: 0.4},
+		{Keyword: "contact", Match: models.KeywordMatchFuzzy, Via: "contct", Score: fuzzyKeywordScore},
+		{Keyword: "new"},
+	}
+	if !reflect.DeepEqual(breakdown.Keywords, wantKeywords) {
+		t.Errorf("keywords = %+v, want %+v", breakdown.Keywords, wantKeywords)
+	}
+	if !approxEqual(breakdown.Keyword, (0.4+fuzzyKeywordScore)/3) {
+		t.Errorf("keyword = %v, want the average over three keywords", breakdown.Keyword)
+	}
+	if breakdown.LengthBonus != 0.1 || !approxEqual(breakdown.PriorityBoost, 0.2) {
+		t.Errorf("length bonus = %v, priority boost = %v, want 0.1 and 0.2", breakdown.LengthBonus, breakdown.PriorityBoost)
+	}
+	if !approxEqual(create.Score, breakdown.Total()) || !create.AboveThreshold || create.Threshold != models.FallbackConfidenceThreshold {
+		t.Errorf("score = %v above %v = %v, want the breakdown total above the fallback threshold", create.Score, create.Threshold, create.AboveThreshold)
+	}
+
+	// The scalar path ranks with the same totals
+	ranked, err := provider.rankIntents(context.Background(), explanation.ScoredText)
+	if err != nil {
+		t.Fatalf("rankIntents() error = %v", err)
+	}
+	if len(ranked) == 0 || !approxEqual(ranked[0].Confidence, create.Score) {
+		t.Errorf("rankIntents() = %+v, want CreateContact scored %v", ranked, create.Score)
+	}
+
+	for _, intent := range explanation.Intents[1:] {
+		if intent.Task == "ArchiveContact" && intent.Skipped != "disabled" {
+			t.Errorf("ArchiveContact skipped = %q, want disabled", intent.Skipped)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_ExplainSynonymAndNegation(t *testing.T) {
+	provider := newTestEnhancedProvider(t, explainConfig())
+
+	explanation := provider.Explain("delete the person")
+	if explanation.Task != "DeleteContact" {
+		t.Fatalf("Task = %s, want DeleteContact", explanation.Task)
+	}
+	want := models.KeywordMatch{Keyword: "contact", Match: models.KeywordMatchSynonym, Via: "person", Score: 0.3}
+	if got := explanation.Intents[0].Breakdown.Keywords[1]; got != want {
+		t.Errorf("contact keyword = %+v, want %+v", got, want)
+	}
+
+	explanation = provider.Explain("don't create contacts")
+	if explanation.ScoredText == explanation.NormalizedText || explanation.Task != "UNKNOWN" {
+		t.Fatalf("ScoredText = %q, Task = %s, want the negated words removed and no intent", explanation.ScoredText, explanation.Task)
+	}
+	skipped := map[string]string{}
+	for _, intent := range explanation.Intents {
+		skipped[intent.Task] = intent.Skipped
+	}
+	wantSkipped := map[string]string{"CreateContact": "negated", "DeleteContact": "negated", "ArchiveContact": "disabled"}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("skipped = %v, want %v", skipped, wantSkipped)
+	}
+}
+
+func TestIntentService_ExplainNotSupported(t *testing.T) {
+	local, err := NewLocalAIProvider(AIProviderConfig{})
+	if err != nil {
+		t.Fatalf("NewLocalAIProvider() error = %v", err)
+	}
+	service := &IntentService{aiProvider: local}
+
+	if _, err := service.Explain("add contact"); !errors.Is(err, ErrExplainNotSupported) {
+		t.Errorf("Explain() error = %v, want ErrExplainNotSupported", err)
+	}
+
+	// Explaining must not change what ExtractIntent returns
+	service = &IntentService{aiProvider: newTestEnhancedProvider(t, explainConfig())}
+	if _, err := service.Explain("create a contact"); err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	intent, err := service.ExtractIntent(context.Background(), "create a contact")
+	if err != nil || intent.Task != "CreateContact" {
+		t.Errorf("ExtractIntent() = %+v, %v, want CreateContact", intent, err)
+	}
+}
+
+func TestEnhancedLocalProvider_TraceUnknown(t *testing.T) {
+	provider := newTestEnhancedProvider(t, explainConfig())
+
+	trace := provider.Trace(context.Background(), "that contact")
+	if intent, _ := provider.ExtractIntent(context.Background(), "that contact"); intent.Task != "UNKNOWN" {
+		t.Fatalf("Task = %s, want UNKNOWN for the trace to explain", intent.Task)
+	}
+	// The closest intent is reported with the threshold it missed
+	if trace.TopTask != "DeleteContact" || trace.Score <= 0 || trace.Score >= trace.Threshold {
+		t.Errorf("trace = %+v, want DeleteContact below its threshold", trace)
+	}
+	if _, exists := trace.MatchedKeywords["ArchiveContact"]; exists {
+		t.Errorf("matched_keywords = %v, want disabled intents left out", trace.MatchedKeywords)
+	}
+}
+
+func TestIntentService_TraceWithoutScores(t *testing.T) {
+	local, err := NewLocalAIProvider(AIProviderConfig{})
+	if err != nil {
+		t.Fatalf("NewLocalAIProvider() error = %v", err)
+	}
+	service := &IntentService{aiProvider: local}
+
+	trace := service.Trace(context.Background(), "add contact")
+	if trace.Provider != local.Name() || trace.NormalizedText != "" || trace.TopTask != "" {
+		t.Errorf("trace = %+v, want only the provider name", trace)
+	}
+}
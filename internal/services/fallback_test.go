@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestEnhancedLocalProvider_FallbackIntent(t *testing.T) {
+	tests := []struct {
+		name         string
+		fallback     string
+		followUp     []string
+		wantTask     string
+		wantFollowUp []string
+	}{
+		{name: "unconfigured", wantTask: "UNKNOWN"},
+		{
+			name:         "configured",
+			fallback:     "Clarify",
+			followUp:     []string{"Sorry, I didn't get that. Do you want to add a note?"},
+			wantTask:     "Clarify",
+			wantFollowUp: []string{"Sorry, I didn't get that. Do you want to add a note?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := noteConfig()
+			// Without a priority boost, unrelated text scores nothing
+			createNote := config.Intents["CreateNote"]
+			createNote.Priority = 0
+			config.Intents["CreateNote"] = createNote
+			config.FallbackIntent = tt.fallback
+			config.FallbackFollowUp = tt.followUp
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), "what's the capital of peru")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.wantTask || !reflect.DeepEqual(intent.FollowUp, tt.wantFollowUp) || intent.IsComplete {
+				t.Errorf("intent = %s %q complete %v, want %s %q incomplete", intent.Task, intent.FollowUp, intent.IsComplete, tt.wantTask, tt.wantFollowUp)
+			}
+
+			// Recognized input is unaffected
+			intent, err = provider.ExtractIntent(context.Background(), "make a note that the fallback works")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "CreateNote" {
+				t.Errorf("Task = %s, want CreateNote", intent.Task)
+			}
+		})
+	}
+}
+
+func TestIntentService_FallbackAnswersFollowUp(t *testing.T) {
+	config := eventConfig()
+	createEvent := config.Intents["CreateEvent"]
+	createEvent.Priority = 0
+	config.Intents["CreateEvent"] = createEvent
+	config.FallbackIntent = "Clarify"
+	config.FallbackFollowUp = []string{"Could you rephrase that?"}
+	service := newSessionTestService(t, config, DefaultMaxFollowUpDepth)
+	conversation := Conversation{SessionID: "session-1"}
+	ctx := context.Background()
+
+	intent, err := service.ExtractIntentWithContext(ctx, `schedule a meeting "Standup" tomorrow`, conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" {
+		t.Fatalf("first turn = %s, want CreateEvent", intent.Task)
+	}
+	if fresh, _ := service.ExtractIntent(ctx, "30 minutes"); fresh.Task != "Clarify" {
+		t.Fatalf("reply on its own = %s, want the fallback", fresh.Task)
+	}
+
+	// A reply that matches no intent still continues the pending one
+	intent, err = service.ExtractIntentWithContext(ctx, "30 minutes", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" || intent.Vars["duration"] != "30 minutes" {
+		t.Errorf("second turn = %s %v, want CreateEvent with the duration", intent.Task, intent.Vars)
+	}
+}
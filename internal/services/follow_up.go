@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// followUpData is what follow_up templates can refer to
+type followUpData struct {
+	Field       string // Missing field, e.g. "email"
+	Intent      string // Intent name, e.g. "CreateContact"
+	DisplayName string // Intent as written in a sentence, e.g. "contact"
+}
+
+// followUpTemplate is a parsed follow_up question
+type followUpTemplate struct {
+	literal  string // The question with no variables filled in, for matching field names
+	template *template.Template
+	generic  bool // Refers to {{.Field}}, so it can ask for any field
+}
+
+// compileFollowUps parses an intent's follow_up questions as text/template
+// templates and runs each once, so a bad action or an unknown variable is
+// reported when the config is loaded rather than when the question is asked
+func compileFollowUps(intentName string, questions []string) ([]followUpTemplate, []error) {
+	var templates []followUpTemplate
+	var errs []error
+	for i, question := range questions {
+		var literal strings.Builder
+		tmpl, err := template.New(fmt.Sprintf("%s.follow_up[%d]", intentName, i)).Parse(question)
+		if err == nil {
+			err = tmpl.Execute(&literal, followUpData{})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid follow_up template for intent %s: %w", intentName, err))
+			continue
+		}
+		templates = append(templates, followUpTemplate{
+			literal:  strings.ToLower(literal.String()),
+			template: tmpl,
+			generic:  refersToField(tmpl.Tree.Root),
+		})
+	}
+	return templates, errs
+}
+
+// refersToField reports whether a template tree uses .Field
+func refersToField(node parse.Node) bool {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return false
+		}
+		for _, child := range node.Nodes {
+			if refersToField(child) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return refersToField(node.Pipe)
+	case *parse.PipeNode:
+		if node == nil {
+			return false
+		}
+		for _, command := range node.Cmds {
+			for _, arg := range command.Args {
+				if refersToField(arg) {
+					return true
+				}
+			}
+		}
+	case *parse.FieldNode:
+		return len(node.Ident) > 0 && node.Ident[0] == "Field"
+	case *parse.IfNode:
+		return refersToField(node.Pipe) || refersToField(node.List) || refersToField(node.ElseList)
+	case *parse.RangeNode:
+		return refersToField(node.Pipe) || refersToField(node.List) || refersToField(node.ElseList)
+	case *parse.WithNode:
+		return refersToField(node.Pipe) || refersToField(node.List) || refersToField(node.ElseList)
+	}
+	return false
+}
+
+// customFollowUp renders the intent's follow_up question for field: the first
+// one that mentions the field by name outside its variables, else the first
+// that refers to {{.Field}}. It returns false when neither exists.
+func (p *EnhancedLocalProvider) customFollowUp(intentName, field string) (string, bool) {
+	templates := p.compiled.FollowUpTemplates[intentName]
+	chosen := -1
+	for i, question := range templates {
+		if strings.Contains(question.literal, strings.ToLower(field)) {
+			chosen = i
+			break
+		}
+		if chosen < 0 && question.generic {
+			chosen = i
+		}
+	}
+	if chosen < 0 {
+		return "", false
+	}
+
+	return p.renderFollowUp(templates[chosen], intentName, field)
+}
+
+// renderFollowUp fills in a follow_up template for field
+func (p *EnhancedLocalProvider) renderFollowUp(question followUpTemplate, intentName, field string) (string, bool) {
+	var rendered strings.Builder
+	data := followUpData{Field: field, Intent: intentName, DisplayName: p.getIntentDisplayName(intentName)}
+	if err := question.template.Execute(&rendered, data); err != nil {
+		return "", false
+	}
+	return rendered.String(), true
+}
+
+// groupFollowUpQuestion asks for any one field of a required_one_of group.
+// A follow_up question naming every field of the group is preferred, then
+// one using {{.Field}}, which is given the fields joined with "or".
+func (p *EnhancedLocalProvider) groupFollowUpQuestion(intentName string, group []string) string {
+	if len(group) == 1 {
+		return p.generateFollowUpQuestion(intentName, group[0])
+	}
+	fields := strings.Join(group[:len(group)-1], ", ") + " or " + group[len(group)-1]
+
+	for _, question := range p.compiled.FollowUpTemplates[intentName] {
+		mentionsAll := true
+		for _, field := range group {
+			if !strings.Contains(question.literal, strings.ToLower(field)) {
+				mentionsAll = false
+				break
+			}
+		}
+		if !mentionsAll {
+			continue
+		}
+		if rendered, ok := p.renderFollowUp(question, intentName, fields); ok {
+			return rendered
+		}
+	}
+	if question, ok := p.customFollowUp(intentName, fields); ok {
+		return question
+	}
+	return "What " + fields + " should I use for this " + p.getIntentDisplayName(intentName) + "?"
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestEnhancedLocalProvider_FollowUpTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		followUp []string
+		want     []string
+	}{
+		{
+			name:     "field template",
+			followUp: []string{"Which email should the {{.DisplayName}} have?", "What's the {{.Field}} for this {{.Intent}}?"},
+			want:     []string{"Which email should the contact have?", "What's the phone for this CreateContact?"},
+		},
+		{
+			name:     "plain question",
+			followUp: []string{"What email should I save?"},
+			want:     []string{"What email should I save?", "What's the phone number?"},
+		},
+		{
+			name:     "variables don't count as field names",
+			followUp: []string{"Tell me about the {{.DisplayName}}"},
+			want:     []string{"What's the email address?", "What's the phone number?"},
+		},
+		{
+			name: "no templates",
+			want: []string{"What's the email address?", "What's the phone number?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := contactConfig()
+			createContact := config.Intents["CreateContact"]
+			createContact.DisplayName = "contact"
+			createContact.Required = []string{"name", "email", "phone"}
+			createContact.FollowUp = tt.followUp
+			config.Intents["CreateContact"] = createContact
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), "add contact named Alice")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if !reflect.DeepEqual(intent.FollowUp, tt.want) {
+				t.Errorf("FollowUp = %q, want %q", intent.FollowUp, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_FollowUpDisplayName(t *testing.T) {
+	tests := []struct {
+		name        string
+		displayName string
+		want        []string
+	}{
+		{
+			name:        "configured",
+			displayName: "trip",
+			want:        []string{"What destination should I use for this trip?", "When should this trip be scheduled?"},
+		},
+		{
+			name: "split from the intent name",
+			want: []string{"What destination should I use for this book flight?", "When should this book flight be scheduled?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, &models.IntentConfig{
+				Domain: "travel",
+				Intents: map[string]models.IntentPattern{
+					"BookFlight": {
+						Description: "Book a flight",
+						DisplayName: tt.displayName,
+						Keywords:    []string{"book", "flight"},
+						Phrases:     []string{"book a flight"},
+						Required:    []string{"destination", "date"},
+					},
+				},
+			})
+
+			intent, err := provider.ExtractIntent(context.Background(), "book a flight")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "BookFlight" {
+				t.Fatalf("Task = %v, want BookFlight", intent.Task)
+			}
+			if !reflect.DeepEqual(intent.FollowUp, tt.want) {
+				t.Errorf("FollowUp = %q, want %q", intent.FollowUp, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileConfig_FollowUpTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		followUp string
+		wantErr  string
+	}{
+		{name: "syntax", followUp: "What's the {{.Field", wantErr: "unclosed action"},
+		{name: "unknown variable", followUp: "What's the {{.Entity}}?", wantErr: "can't evaluate field Entity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := contactConfig()
+			createContact := config.Intents["CreateContact"]
+			createContact.FollowUp = []string{tt.followUp}
+			config.Intents["CreateContact"] = createContact
+
+			_, err := compileConfig(config)
+			if err == nil || !strings.Contains(err.Error(), "invalid follow_up template for intent CreateContact") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compileConfig() error = %v, want an invalid follow_up template error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Fuzzy keyword matching limits
+const (
+	// minFuzzyLength is the shortest word that takes part in fuzzy matching.
+	// Shorter words are mostly stop words and would be one edit from many keywords.
+	minFuzzyLength = 4
+	// fuzzyKeywordScore is added for a keyword matched only within its edit
+	// distance, below the 0.4 for an exact match and 0.3 for a synonym
+	fuzzyKeywordScore = 0.2
+)
+
+// compileFuzzyDistances returns, for each keyword, the number of edits a word
+// may be from it and still match. Zero means the keyword only matches exactly:
+// it is shorter than minFuzzyLength, has several words, or fuzzy matching is
+// disabled by a negative threshold. A positive threshold applies to every
+// keyword; otherwise keywords of up to 5 characters allow 1 edit and longer
+// ones 2.
+func compileFuzzyDistances(keywords []string, threshold int) []int {
+	distances := make([]int, len(keywords))
+	if threshold < 0 {
+		return distances
+	}
+
+	for i, keyword := range keywords {
+		length := utf8.RuneCountInString(keyword)
+		if length < minFuzzyLength || strings.ContainsAny(keyword, " \t") {
+			continue
+		}
+		switch {
+		case threshold > 0:
+			distances[i] = threshold
+		case length <= 5:
+			distances[i] = 1
+		default:
+			distances[i] = 2
+		}
+	}
+	return distances
+}
+
+// fuzzyMatch returns the first token within maxDistance edits of the
+// lowercase keyword. Tokens shorter than minFuzzyLength never match.
+func fuzzyMatch(tokens []string, keyword string, maxDistance int) (string, bool) {
+	keywordLength := utf8.RuneCountInString(keyword)
+	for _, token := range tokens {
+		tokenLength := utf8.RuneCountInString(token)
+		// Skip tokens whose length alone rules them out
+		if tokenLength < minFuzzyLength || abs(tokenLength-keywordLength) > maxDistance {
+			continue
+		}
+		if levenshtein(token, keyword) <= maxDistance {
+			return token, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// fuzzyConfig has no priorities, so intents are only ranked by what matched
+func fuzzyConfig(threshold int) *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain:            "test",
+		DefaultConfidence: 0.1,
+		FuzzyThreshold:    threshold,
+		Intents: map[string]models.IntentPattern{
+			"CreateContact": {Description: "Create a contact", Keywords: []string{"create", "contact"}},
+			"DeleteContact": {Description: "Delete a contact", Keywords: []string{"delete", "contact"}},
+			"CreateNote":    {Description: "Create a note", Keywords: []string{"note", "add"}},
+		},
+	}
+}
+
+func TestCompileFuzzyDistances(t *testing.T) {
+	keywords := []string{"add", "note", "email", "contact", "add contact"}
+
+	tests := []struct {
+		name      string
+		threshold int
+		want      []int
+	}{
+		{name: "by length", threshold: 0, want: []int{0, 1, 1, 2, 0}},
+		{name: "fixed", threshold: 3, want: []int{0, 3, 3, 3, 0}},
+		{name: "disabled", threshold: -1, want: []int{0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compileFuzzyDistances(keywords, tt.threshold); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compileFuzzyDistances() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_FuzzyKeywords(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		input     string
+		want      string
+	}{
+		{name: "typos", input: "creat contcat", want: "CreateContact"},
+		{name: "one typo", input: "delet the contact", want: "DeleteContact"},
+		{name: "too many edits", input: "crt cntt", want: "UNKNOWN"},
+		{name: "short words don't match", input: "not ad", want: "UNKNOWN"},
+		{name: "disabled", threshold: -1, input: "creat contcat", want: "UNKNOWN"},
+		{name: "raised threshold", threshold: 3, input: "crete cntact", want: "CreateContact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, fuzzyConfig(tt.threshold))
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.want {
+				t.Errorf("Task = %s, want %s", intent.Task, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_FuzzyScoresBelowExact(t *testing.T) {
+	config := fuzzyConfig(0)
+	provider := newTestEnhancedProvider(t, config)
+	intent := config.Intents["CreateContact"]
+
+	exact := provider.calculateIntentScore("create contact", "CreateContact", intent).Total()
+	fuzzy := provider.calculateIntentScore("creat contcat", "CreateContact", intent).Total()
+	if fuzzy <= 0 || fuzzy >= exact {
+		t.Errorf("fuzzy score = %v, exact score = %v, want 0 < fuzzy < exact", fuzzy, exact)
+	}
+}
+
+func BenchmarkCalculateIntentScore(b *testing.B) {
+	config := models.GetDefaultConfig()
+	benchmarks := []struct {
+		name      string
+		threshold int
+		input     string
+	}{
+		{name: "exact", input: "create a contact named john with email john@example.com"},
+		{name: "typos", input: "creat a contcat named john with emial john@example.com"},
+		{name: "typos without fuzzy", threshold: -1, input: "creat a contcat named john with emial john@example.com"},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			config.FuzzyThreshold = bm.threshold
+			compiled, err := compileConfig(config)
+			if err != nil {
+				b.Fatalf("compileConfig() error = %v", err)
+			}
+			provider := &EnhancedLocalProvider{config: config, compiled: compiled}
+			text := provider.normalizeText(bm.input)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for intentName, intent := range config.Intents {
+					provider.calculateIntentScore(text, intentName, intent)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFuzzyMatch(b *testing.B) {
+	tokens := []string{"creat", "contcat", "named", "john", "emial", "john@example.com"}
+	for i := 0; i < b.N; i++ {
+		fuzzyMatch(tokens, "telephone", 2)
+	}
+}
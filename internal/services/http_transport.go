@@ -0,0 +1,59 @@
+package services
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Connection pool defaults for the HTTP providers. Go's stock transport keeps
+// only 2 idle connections per host, so concurrent calls to one provider
+// endpoint otherwise keep opening and closing connections.
+const (
+	DefaultHTTPMaxIdleConns        = 100
+	DefaultHTTPMaxIdleConnsPerHost = 32
+	DefaultHTTPIdleConnTimeout     = 90 * time.Second
+	DefaultHTTPKeepAlive           = 30 * time.Second
+)
+
+// httpPoolSettings returns the configured pool sizes, falling back to the
+// defaults for unset values
+func (c AIProviderConfig) httpPoolSettings() (maxIdle, maxIdlePerHost int, idleTimeout time.Duration) {
+	maxIdle, maxIdlePerHost, idleTimeout = c.HTTPMaxIdleConns, c.HTTPMaxIdleConnsPerHost, c.HTTPIdleConnTimeout
+	if maxIdle <= 0 {
+		maxIdle = DefaultHTTPMaxIdleConns
+	}
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = DefaultHTTPMaxIdleConnsPerHost
+	}
+	if maxIdlePerHost > maxIdle {
+		maxIdlePerHost = maxIdle
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultHTTPIdleConnTimeout
+	}
+	return maxIdle, maxIdlePerHost, idleTimeout
+}
+
+// newHTTPTransport builds the pooled transport shared by every call a
+// provider instance makes
+func newHTTPTransport(config AIProviderConfig) *http.Transport {
+	maxIdle, maxIdlePerHost, idleTimeout := config.httpPoolSettings()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: DefaultHTTPKeepAlive,
+	}).DialContext
+	transport.MaxIdleConns = maxIdle
+	transport.MaxIdleConnsPerHost = maxIdlePerHost
+	transport.IdleConnTimeout = idleTimeout
+	return transport
+}
+
+// newHTTPClient returns the client a provider instance keeps for its lifetime
+func newHTTPClient(config AIProviderConfig) *http.Client {
+	return &http.Client{
+		Timeout:   config.requestTimeout(),
+		Transport: newHTTPTransport(config),
+	}
+}
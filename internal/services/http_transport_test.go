@@ -0,0 +1,97 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAIProviderConfig_HTTPPoolSettings(t *testing.T) {
+	maxIdle, perHost, idle := AIProviderConfig{}.httpPoolSettings()
+	if maxIdle != DefaultHTTPMaxIdleConns || perHost != DefaultHTTPMaxIdleConnsPerHost || idle != DefaultHTTPIdleConnTimeout {
+		t.Errorf("defaults = %d, %d, %v", maxIdle, perHost, idle)
+	}
+
+	maxIdle, perHost, idle = AIProviderConfig{
+		HTTPMaxIdleConns:        8,
+		HTTPMaxIdleConnsPerHost: 16,
+		HTTPIdleConnTimeout:     time.Minute,
+	}.httpPoolSettings()
+	if maxIdle != 8 || perHost != 8 || idle != time.Minute {
+		t.Errorf("settings = %d, %d, %v, want per-host capped at 8", maxIdle, perHost, idle)
+	}
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	transport := newHTTPTransport(AIProviderConfig{HTTPMaxIdleConnsPerHost: 20, HTTPIdleConnTimeout: time.Minute})
+
+	if transport.MaxIdleConnsPerHost != 20 || transport.MaxIdleConns != DefaultHTTPMaxIdleConns {
+		t.Errorf("pool = %d/%d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Errorf("IdleConnTimeout = %v, want the configured 1m", transport.IdleConnTimeout)
+	}
+}
+
+func TestOllamaProvider_ReusesPooledClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(AIProviderConfig{BaseURL: server.URL, HTTPMaxIdleConnsPerHost: 12})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error = %v", err)
+	}
+	transport, ok := provider.(*OllamaProvider).client.Transport.(*http.Transport)
+	if !ok || transport.MaxIdleConnsPerHost != 12 {
+		t.Errorf("client transport = %#v, want pooled transport with 12 idle conns per host", provider.(*OllamaProvider).client.Transport)
+	}
+}
+
+// benchmarkConcurrentCalls sends parallel requests through transport to a
+// local TLS server, the way concurrent intent requests reach one HTTPS
+// provider endpoint
+func benchmarkConcurrentCalls(b *testing.B, transport *http.Transport) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond) // model latency keeps calls in flight together
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"response": "{\"intent\": \"greeting\"}", "done": true}`))
+	}))
+	defer server.Close()
+
+	transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+	transport.ForceAttemptHTTP2 = false
+	client := &http.Client{Transport: transport}
+	defer client.CloseIdleConnections()
+
+	b.ReportAllocs()
+	b.SetParallelism(16)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	})
+}
+
+// BenchmarkHTTPClient compares Go's stock transport (2 idle connections per
+// host) with the pooled provider transport under concurrent calls:
+//
+//	go test ./internal/services -run '^$' -bench BenchmarkHTTPClient
+func BenchmarkHTTPClient(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		benchmarkConcurrentCalls(b, http.DefaultTransport.(*http.Transport).Clone())
+	})
+	b.Run("pooled", func(b *testing.B) {
+		benchmarkConcurrentCalls(b, newHTTPTransport(AIProviderConfig{}))
+	})
+}
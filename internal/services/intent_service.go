@@ -2,93 +2,601 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"myllm/internal/logging"
+	"myllm/internal/metrics"
 	"myllm/internal/models"
 )
 
+// ErrReloadNotSupported is returned when the active provider has no config file to reload
+var ErrReloadNotSupported = errors.New("config reload not supported")
+
+// ErrToggleNotSupported is returned when the active provider can't enable or disable intents
+var ErrToggleNotSupported = errors.New("intent toggling not supported")
+
+// ErrUnknownIntent is returned when an intent name isn't in the active config
+var ErrUnknownIntent = errors.New("unknown intent")
+
+// ErrNoProvider is returned when the service has no AI provider to ask
+var ErrNoProvider = errors.New("no AI provider available")
+
+// alternativesKey marks a context whose request asked for candidate intents
+type alternativesKey struct{}
+
+// WithAlternatives returns a context asking providers to fill Intent.Alternatives
+func WithAlternatives(ctx context.Context) context.Context {
+	return context.WithValue(ctx, alternativesKey{}, true)
+}
+
+// alternativesRequested reports whether ctx asks for candidate intents
+func alternativesRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(alternativesKey{}).(bool)
+	return requested
+}
+
+// spansKey marks a context whose request asked for entity spans
+type spansKey struct{}
+
+// WithSpans returns a context asking providers to fill Intent.Spans
+func WithSpans(ctx context.Context) context.Context {
+	return context.WithValue(ctx, spansKey{}, true)
+}
+
+// spansRequested reports whether ctx asks for entity spans
+func spansRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(spansKey{}).(bool)
+	return requested
+}
+
 // IntentService handles intent recognition logic
 type IntentService struct {
-	aiProvider AIProvider
-	patterns   map[string]*regexp.Regexp
+	aiProvider      AIProvider
+	providerType    string // Type aiProvider was created as, empty for a fallback provider
+	fallbackFrom    string // Provider type that failed at startup when aiProvider is a fallback
+	patterns        map[string]*regexp.Regexp
+	patternFastPath bool // Answer regex matches directly without calling the provider
+	webhooks        *WebhookDispatcher
+
+	completedWebhook      string          // Receives every complete intent (WEBHOOK_URL)
+	completedWebhookTasks map[string]bool // Tasks sent to completedWebhook; every task if empty
+
+	responseValidation string               // "off", "warn" or "reject"
+	taskValidation     string               // "off", "unknown" or "flag"
+	schema             *models.IntentConfig // Intent config used to validate provider responses
+	intentConfig       *models.IntentConfig // INTENT_CONFIG_PATH config of a provider that isn't config-driven
+
+	shadow *ShadowRunner // Candidate config classified alongside the active provider
+
+	sessions         SessionStore // Conversation state for multi-turn requests
+	maxFollowUpDepth int          // Follow-up answers allowed per intent (<= 0 for no cap)
+
+	requestTimeout time.Duration // Deadline for each provider call
+
+	maxTextLength int    // Characters of text classified (<= 0 for no cap)
+	textOverflow  string // "truncate" or "reject" for longer texts
+
+	preprocessors atomic.Pointer[[]TextPreprocessor] // Run on request text before it is normalized
+
+	cache         *IntentCache  // Recent extractions; nil when CACHE_SIZE is 0
+	configVersion atomic.Uint64 // Bumped when the config changes, so cached results are not reused
 }
 
-// NewIntentService creates a new intent service instance
-func NewIntentService() *IntentService {
+// NewIntentService creates a new intent service instance. It fails when
+// neither the configured provider nor any fallback can be created.
+func NewIntentService() (*IntentService, error) {
 	// Create AI provider configuration
 	config := AIProviderConfig{
-		ProviderType: getEnv("AI_PROVIDER", "openai"),
-		Model:        getEnv("AI_MODEL", ""),
-		Temperature:  getFloatEnvVar("AI_TEMPERATURE", 0.1),
-		MaxTokens:    getIntEnvVar("AI_MAX_TOKENS", 1000),
-		BaseURL:      getEnv("AI_BASE_URL", ""),
-		APIKey:       getEnv("OPENAI_API_KEY", ""),
+		ProviderType:            getEnv("AI_PROVIDER", "openai"),
+		Model:                   getEnv("AI_MODEL", ""),
+		Temperature:             getFloatEnvVar("AI_TEMPERATURE", 0.1),
+		MaxTokens:               getIntEnvVar("AI_MAX_TOKENS", 1000),
+		BaseURL:                 getEnv("AI_BASE_URL", ""),
+		APIKey:                  getEnv("OPENAI_API_KEY", ""),
+		AnthropicAPIKey:         getEnv("ANTHROPIC_API_KEY", ""),
+		OpenAIFunctionCalling:   getBoolEnv("AI_OPENAI_FUNCTION_CALLING", false),
+		OllamaUseChat:           getBoolEnv("OLLAMA_USE_CHAT", false),
+		OllamaJSONFormat:        getBoolEnv("OLLAMA_JSON_FORMAT", true),
+		AzureOpenAI:             getBoolEnv("AZURE_OPENAI", false),
+		AzureDeployment:         getEnv("AZURE_OPENAI_DEPLOYMENT", ""),
+		AzureAPIVersion:         getEnv("AZURE_OPENAI_API_VERSION", DefaultAzureAPIVersion),
+		RequestTimeout:          getDurationEnv("AI_REQUEST_TIMEOUT", DefaultRequestTimeout),
+		MaxRetries:              getIntEnvVar("AI_MAX_RETRIES", DefaultMaxRetries),
+		RetryBackoff:            getDurationEnv("AI_RETRY_BACKOFF", DefaultRetryBackoff),
+		HTTPMaxIdleConns:        getIntEnvVar("AI_HTTP_MAX_IDLE_CONNS", DefaultHTTPMaxIdleConns),
+		HTTPMaxIdleConnsPerHost: getIntEnvVar("AI_HTTP_MAX_IDLE_CONNS_PER_HOST", DefaultHTTPMaxIdleConnsPerHost),
+		HTTPIdleConnTimeout:     getDurationEnv("AI_HTTP_IDLE_CONN_TIMEOUT", DefaultHTTPIdleConnTimeout),
+		Routing: RoutingConfig{
+			LocalProvider:  getEnv("ROUTER_LOCAL_PROVIDER", "enhanced_local"),
+			RemoteProvider: getEnv("ROUTER_REMOTE_PROVIDER", "openai"),
+			MaxLocalChars:  getIntEnvVar("ROUTER_MAX_LOCAL_CHARS", 80),
+			MaxLocalTokens: getIntEnvVar("ROUTER_MAX_LOCAL_TOKENS", 12),
+			LocalKeywords:  getListEnv("ROUTER_LOCAL_KEYWORDS", nil),
+			RemoteKeywords: getListEnv("ROUTER_REMOTE_KEYWORDS", nil),
+		},
+		ProviderChain: getListEnv("AI_PROVIDER_CHAIN", nil),
+	}
+	// A provider chain replaces the single configured provider
+	if len(config.ProviderChain) > 0 {
+		config.ProviderType = "chain"
 	}
 
-	fmt.Printf("Creating IntentService with AI provider type: %s\n", config.ProviderType)
-	fmt.Printf("Environment variables:\n")
-	fmt.Printf("  - AI_PROVIDER: %s\n", getEnv("AI_PROVIDER", "not set"))
-	fmt.Printf("  - INTENT_CONFIG_PATH: %s\n", getEnv("INTENT_CONFIG_PATH", "not set"))
+	// A broken prompt template fails startup instead of every LLM provider
+	promptTemplate, err := loadPromptTemplate()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parsePromptTemplate(promptTemplate); err != nil {
+		return nil, err
+	}
+	config.PromptTemplate = promptTemplate
+
+	// Providers that aren't config-driven still use the intent config, when
+	// there is one, for the tasks LLMs are prompted with and the sensitive
+	// entities masked in logged bodies
+	var intentConfig *models.IntentConfig
+	if config.ProviderType != "enhanced_local" && getEnv("INTENT_CONFIG_PATH", "") != "" {
+		intentConfig = loadValidationSchema()
+		config.KnownTasks = configTasks(intentConfig)
+	}
+
+	slog.Debug("Creating IntentService", "provider_type", config.ProviderType,
+		"intent_config_path", getEnv("INTENT_CONFIG_PATH", "not set"))
 
 	// Create AI provider factory
 	factory := NewAIProviderFactory(config)
 
 	// Try to create the configured provider
 	aiProvider, err := factory.CreateProvider()
+	providerType, fallbackFrom := config.ProviderType, ""
 	if err != nil {
-		fmt.Printf("Failed to create configured provider: %v\n", err)
+		providerType, fallbackFrom = "", config.ProviderType
+		slog.Warn("Failed to create configured provider", "provider_type", config.ProviderType, "error", err)
 		// Fallback to available providers
 		availableProviders := factory.GetAvailableProviders()
 		if len(availableProviders) > 0 {
 			aiProvider = availableProviders[0]
-			fmt.Printf("Using fallback provider: %s\n", aiProvider.Name())
+			slog.Info("Using fallback provider", "provider", aiProvider.Name())
 		} else {
 			// Last resort: create local provider
-			aiProvider, _ = NewLocalAIProvider(config)
-			fmt.Printf("Using last resort local provider: %s\n", aiProvider.Name())
+			var fallbackErr error
+			aiProvider, fallbackErr = NewLocalAIProvider(config)
+			if fallbackErr != nil || aiProvider == nil {
+				return nil, fmt.Errorf("%w: %s provider failed (%v) and the local fallback failed (%v)",
+					ErrNoProvider, config.ProviderType, err, fallbackErr)
+			}
+			slog.Info("Using last resort local provider", "provider", aiProvider.Name())
 		}
 	} else {
-		fmt.Printf("Successfully created configured provider: %s\n", aiProvider.Name())
+		slog.Info("Created configured provider", "provider", aiProvider.Name())
 	}
 
 	// Initialize pattern matching for common intents
-	patterns := map[string]*regexp.Regexp{
-		"create_contact": regexp.MustCompile(`(?i)(create|add|new)\s+(?:contact|person)\s+(?:named\s+)?([a-zA-Z]+)(?:\s+with\s+email\s+([^\s]+))?`),
-		"find_contact":   regexp.MustCompile(`(?i)(find|search|look\s+for)\s+(?:contact\s+)?([a-zA-Z]+)`),
-		"update_contact": regexp.MustCompile(`(?i)(update|change|modify)\s+(?:contact\s+)?([a-zA-Z]+)`),
-		"delete_contact": regexp.MustCompile(`(?i)(delete|remove|drop)\s+(?:contact\s+)?([a-zA-Z]+)`),
+	patterns := defaultPatterns()
+	patternFastPath := getBoolEnv("PATTERN_FAST_PATH", false)
+
+	slog.Debug("Initialized pattern-based intents", "patterns", len(patterns), "fast_path", patternFastPath)
+
+	webhooks := NewWebhookDispatcher(WebhookConfig{
+		Secret:     getEnv("WEBHOOK_SECRET", ""),
+		MaxRetries: getIntEnvVar("WEBHOOK_MAX_RETRIES", 3),
+		Timeout:    getDurationEnv("WEBHOOK_TIMEOUT", 5*time.Second),
+	})
+
+	// Responses from providers that aren't config-driven can be validated
+	// against the intent config
+	responseValidation := getEnv("RESPONSE_VALIDATION", ResponseValidationOff)
+	switch responseValidation {
+	case ResponseValidationOff, ResponseValidationWarn, ResponseValidationReject:
+	default:
+		slog.Warn("Unknown RESPONSE_VALIDATION, validation disabled", "value", responseValidation)
+		responseValidation = ResponseValidationOff
+	}
+	taskValidation := getEnv("VALIDATE_LLM_TASK", TaskValidationOff)
+	switch taskValidation {
+	case TaskValidationOff, TaskValidationUnknown, TaskValidationFlag:
+	default:
+		slog.Warn("Unknown VALIDATE_LLM_TASK, task validation disabled", "value", taskValidation)
+		taskValidation = TaskValidationOff
+	}
+	var schema *models.IntentConfig
+	if responseValidation != ResponseValidationOff || taskValidation != TaskValidationOff {
+		schema = intentConfig
+		if schema == nil {
+			schema = loadValidationSchema()
+		}
+		slog.Info("Provider response validation enabled", "mode", responseValidation,
+			"task_mode", taskValidation, "domain", schema.Domain)
+	}
+
+	// Optionally classify every request with a candidate config as well
+	var shadow *ShadowRunner
+	if shadowPath := getEnv("SHADOW_INTENT_CONFIG_PATH", ""); shadowPath != "" {
+		shadowProvider, err := NewEnhancedLocalProvider(shadowPath)
+		if err != nil {
+			slog.Warn("Shadow mode disabled, failed to load config", "path", shadowPath, "error", err)
+		} else {
+			shadow = NewShadowRunner(shadowProvider)
+			slog.Info("Shadow mode enabled", "path", shadowPath)
+		}
+	}
+
+	// Optionally forward complete intents to one downstream service
+	completedWebhook := getEnv("WEBHOOK_URL", "")
+	webhookTasks := getListEnv("WEBHOOK_TASKS", nil)
+	completedWebhookTasks := make(map[string]bool, len(webhookTasks))
+	for _, task := range webhookTasks {
+		completedWebhookTasks[task] = true
+	}
+	if completedWebhook != "" {
+		slog.Info("Completed intent webhook enabled", "url", completedWebhook, "tasks", webhookTasks)
+	}
+
+	textOverflow := getEnv("TEXT_OVERFLOW", TextOverflowTruncate)
+	switch textOverflow {
+	case TextOverflowTruncate, TextOverflowReject:
+	default:
+		slog.Warn("Unknown TEXT_OVERFLOW, truncating", "value", textOverflow)
+		textOverflow = TextOverflowTruncate
+	}
+
+	// Sessions and cached results live in memory unless a shared store is
+	// configured
+	sessionTTL := getDurationEnv("SESSION_TTL", DefaultSessionTTL)
+	sessions := SessionStore(NewMemorySessionStore(sessionTTL))
+	cacheSize, cacheTTL := getIntEnvVar("CACHE_SIZE", 0), getDurationEnv("CACHE_TTL", 5*time.Minute)
+	cache := NewIntentCache(cacheSize, cacheTTL)
+	storeKind := getEnv("SESSION_STORE", StoreMemory)
+	if storeKind != StoreMemory {
+		store, err := newStore(storeKind, getEnv("REDIS_URL", DefaultRedisURL))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the session store: %w", err)
+		}
+		sessions = NewKeyValueSessionStore(store, sessionTTL)
+		if cache != nil {
+			cache = NewStoreIntentCache(store, cacheTTL)
+		}
+		slog.Info("Using shared session store", "store", storeKind)
+	}
+	if cache != nil {
+		slog.Info("Intent cache enabled", "size", cacheSize, "ttl", cacheTTL, "store", storeKind)
+	}
+
+	service := &IntentService{
+		aiProvider:            aiProvider,
+		providerType:          providerType,
+		fallbackFrom:          fallbackFrom,
+		patterns:              patterns,
+		patternFastPath:       patternFastPath,
+		webhooks:              webhooks,
+		completedWebhook:      completedWebhook,
+		completedWebhookTasks: completedWebhookTasks,
+		responseValidation:    responseValidation,
+		taskValidation:        taskValidation,
+		schema:                schema,
+		intentConfig:          intentConfig,
+		shadow:                shadow,
+		sessions:              sessions,
+		maxFollowUpDepth:      getIntEnvVar("SESSION_MAX_DEPTH", DefaultMaxFollowUpDepth),
+		requestTimeout:        config.requestTimeout(),
+		maxTextLength:         getIntEnvVar("MAX_TEXT_LENGTH", 0),
+		textOverflow:          textOverflow,
+		cache:                 cache,
+	}
+	if configurable, ok := aiProvider.(ConfigurableProvider); ok {
+		service.applyConfigAbbreviations(configurable.GetConfig())
 	}
+	return service, nil
+}
 
-	fmt.Printf("Initialized IntentService with %d pattern-based intents\n", len(patterns))
+// SetSessionStore replaces the in-memory session store, e.g. with a shared one
+// when running several instances
+func (s *IntentService) SetSessionStore(store SessionStore) {
+	s.sessions = store
+}
+
+// patternMatchConfidence is reported for intents answered by the pattern fast
+// path. The patterns only match whole commands that name a contact, so a match
+// leaves little doubt.
+const patternMatchConfidence = 0.95
 
-	return &IntentService{
-		aiProvider: aiProvider,
-		patterns:   patterns,
+// defaultPatterns returns the precompiled patterns for common contact phrasings.
+// Each pattern must match the whole normalized text and name a contact, so
+// sentences that merely contain a verb like "find" or "drop" go to the provider.
+func defaultPatterns() map[string]*regexp.Regexp {
+	return map[string]*regexp.Regexp{
+		"create_contact": regexp.MustCompile(`(?i)^(create|add|new)\s+(?:a\s+)?(?:new\s+)?contact\s+(?:named\s+)?([a-zA-Z]+)(?:\s+with\s+email\s+([^\s]+))?$`),
+		"find_contact":   regexp.MustCompile(`(?i)^(find|search\s+for|look\s+for|look\s+up)\s+(?:the\s+)?contact\s+(?:named\s+)?([a-zA-Z]+)$`),
+		"update_contact": regexp.MustCompile(`(?i)^(update|change|modify)\s+(?:the\s+)?contact\s+(?:named\s+)?([a-zA-Z]+)$`),
+		"delete_contact": regexp.MustCompile(`(?i)^(delete|remove|drop)\s+(?:the\s+)?contact\s+(?:named\s+)?([a-zA-Z]+)$`),
 	}
 }
 
 // ExtractIntent processes natural language and extracts structured intent
 func (s *IntentService) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
-	normalizedText := models.NormalizeText(text)
+	return s.ExtractIntentWithContext(ctx, text, Conversation{})
+}
+
+// extractIntent runs the extraction pipeline, or answers from the cache, and
+// records its metrics. When onToken is set and the provider supports it,
+// generated tokens are streamed to onToken. Streamed extractions and
+// structured commands, whose values keep their case, bypass the cache. Text
+// over MAX_TEXT_LENGTH is truncated or rejected first, and text without a
+// letter or digit once normalized is UNKNOWN without asking the provider.
+func (s *IntentService) extractIntent(ctx context.Context, text string, onToken func(string) error) (*models.Intent, error) {
+	start := time.Now()
+
+	ctx, text, truncated, err := s.limitTextLength(ctx, text)
+	if err != nil {
+		metrics.ObserveExtraction(s.GetAIProviderName(), "", time.Since(start), err)
+		return nil, err
+	}
+
+	var intent *models.Intent
+	if s.cache != nil && onToken == nil && !isStructuredCommand(text) {
+		key := s.cacheKey(ctx, text)
+		cached, hit := s.cache.Get(ctx, key)
+		metrics.ObserveCacheLookup(hit)
+		if hit {
+			intent = cached
+		} else if intent, err = s.runPipeline(ctx, text, onToken); err == nil {
+			s.cache.Add(ctx, key, intent)
+		}
+	} else {
+		intent, err = s.runPipeline(ctx, text, onToken)
+	}
+
+	task := ""
+	if intent != nil {
+		task = intent.Task
+		if truncated {
+			if intent.Vars == nil {
+				intent.Vars = make(map[string]interface{})
+			}
+			intent.Vars[truncatedVar] = true
+		}
+	}
+	metrics.ObserveExtraction(s.GetAIProviderName(), task, time.Since(start), err)
+	return intent, err
+}
+
+// NormalizedText returns text the way it is classified: cut to
+// MAX_TEXT_LENGTH, preprocessed and normalized. The enhanced local provider
+// normalizes further, dropping most punctuation, and that is included when
+// it is the provider.
+func (s *IntentService) NormalizedText(text string) string {
+	if s.maxTextLength > 0 {
+		text = firstCharacters(text, s.maxTextLength)
+	}
+	normalized := models.NormalizeText(s.preprocess(text))
+	if enhanced, ok := s.aiProvider.(*EnhancedLocalProvider); ok {
+		normalized = enhanced.normalizeText(normalized)
+	}
+	return normalized
+}
+
+// runPipeline tries structured commands and the pattern fast path before
+// asking the provider
+func (s *IntentService) runPipeline(ctx context.Context, text string, onToken func(string) error) (*models.Intent, error) {
+	// Structured commands ("create-event: title=Standup, time=9am") skip fuzzy
+	// scoring entirely. They are parsed from the raw text to keep value casing.
+	if intent := s.extractStructuredCommand(text); intent != nil {
+		return intent, nil
+	}
+
+	// Providers get the text with its casing and spacing, so values keep
+	// the user's casing and spans point into the request text. Patterns and
+	// the empty-input check work on the normalized form.
+	preprocessedText := s.preprocess(text)
+	normalizedText := models.NormalizeText(preprocessedText)
+
+	// Whitespace or punctuation alone, such as "...", has nothing to classify
+	if !hasContent(normalizedText) {
+		return emptyInputIntent(), nil
+	}
+
+	// Fast path: common phrasings are answered from precompiled patterns without
+	// calling the provider. Skipped for the enhanced local provider, which has
+	// its own configured patterns and intent names, and when the request forces
+	// a provider.
+	if s.patternFastPath && !s.usesEnhancedLocalProvider() && forcedProvider(ctx) == "" {
+		if intent := s.extractWithPatterns(normalizedText); intent != nil {
+			return intent, nil
+		}
+	}
+
+	var reportToShadow func(*models.Intent)
+	if s.shadow != nil {
+		reportToShadow = s.shadow.Start(ctx, preprocessedText)
+	}
+
+	intent, err := s.callProvider(ctx, preprocessedText, onToken)
+	if reportToShadow != nil {
+		reportToShadow(intent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.validateTask(ctx, intent)
+	if err := s.validateResponse(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Debug("Extracted intent", "provider", s.aiProvider.Name(),
+		"task", intent.Task, "confidence", intent.Confidence)
+	return intent, nil
+}
+
+// callProvider asks the provider for an intent, streaming tokens when
+// possible. Answers from a startup fallback provider are counted.
+func (s *IntentService) callProvider(ctx context.Context, text string, onToken func(string) error) (*models.Intent, error) {
+	if s.aiProvider == nil {
+		return nil, ErrNoProvider
+	}
+	// Only a chain can switch providers; otherwise a request may only force
+	// the provider already configured
+	if forced := forcedProvider(ctx); forced != "" && forced != s.providerType {
+		if _, isChain := s.aiProvider.(*ChainProvider); !isChain {
+			return nil, fmt.Errorf("%w: %s is not configured", ErrUnknownProvider, forced)
+		}
+	}
+	var intent *models.Intent
+	var err error
+	if streaming, ok := s.aiProvider.(StreamingProvider); ok && onToken != nil {
+		intent, err = streaming.StreamIntent(ctx, text, onToken)
+	} else {
+		intent, err = s.aiProvider.ExtractIntent(ctx, text)
+	}
+
+	// A provider that stood in for the configured one at startup answers
+	// every request; each is counted so the degradation can be alerted on
+	if err == nil && s.fallbackFrom != "" {
+		used := providerTypeOf(s.aiProvider)
+		metrics.ObserveFallback(s.fallbackFrom, used)
+		logging.FromContext(ctx).Warn("Request answered by a fallback provider", "intended", s.fallbackFrom, "used", used)
+	}
+	return intent, err
+}
+
+// validateResponse applies the configured validation mode to a provider response.
+// Config-driven providers are trusted since they only produce configured intents.
+func (s *IntentService) validateResponse(ctx context.Context, intent *models.Intent) error {
+	if s.schema == nil || s.responseValidation == ResponseValidationOff {
+		return nil
+	}
+	if _, ok := s.aiProvider.(ConfigurableProvider); ok {
+		return nil
+	}
+
+	problems := validateProviderResponse(intent, s.schema)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if s.responseValidation == ResponseValidationReject {
+		return fmt.Errorf("%w from %s: %s", ErrInvalidProviderResponse, s.GetAIProviderName(), strings.Join(problems, "; "))
+	}
 
-	// Skip pattern matching if using enhanced local provider for better accuracy
-	providerName := s.GetAIProviderName()
-	fmt.Printf("DEBUG: Provider name: %s\n", providerName)
-	fmt.Printf("DEBUG: Contains 'Enhanced Local': %v\n", strings.Contains(providerName, "Enhanced Local"))
+	logging.FromContext(ctx).Warn("Provider response validation warnings", "provider", s.GetAIProviderName(), "problems", problems)
+	intent.Warnings = append(intent.Warnings, problems...)
+	return nil
+}
 
-	// Temporarily disable pattern matching to force AI provider usage
-	fmt.Printf("DEBUG: Using AI provider for extraction\n")
-	return s.aiProvider.ExtractIntent(ctx, normalizedText)
+// loadValidationSchema loads the intent config from INTENT_CONFIG_PATH, falling
+// back to the default config. For a directory of per-language configs the
+// default language's config is used.
+func loadValidationSchema() *models.IntentConfig {
+	if configPath := getEnv("INTENT_CONFIG_PATH", ""); configPath != "" {
+		defaultLanguage := normalizeLanguage(getEnv("INTENT_DEFAULT_LANGUAGE", DefaultLanguage))
+		configs, err := loadIntentConfigs(configPath, defaultLanguage)
+		if err == nil {
+			return configs[defaultLanguage]
+		}
+		slog.Warn("Failed to load validation schema, using default config", "path", configPath, "error", err)
+	}
+	return models.GetDefaultConfig()
+}
+
+// dispatchWebhook fires the webhook configured for the detected intent, if
+// any, and the WEBHOOK_URL webhook for complete intents of the selected tasks.
+// Delivery happens in the background and never delays the response.
+func (s *IntentService) dispatchWebhook(intent *models.Intent) {
+	if s.webhooks == nil || intent == nil || intent.Task == "UNKNOWN" {
+		return
+	}
+
+	if s.completedWebhook != "" && intent.IsComplete &&
+		(len(s.completedWebhookTasks) == 0 || s.completedWebhookTasks[intent.Task]) {
+		s.webhooks.Dispatch(s.completedWebhook, WebhookEventIntentCompleted, intent)
+	}
+
+	configurable, ok := s.aiProvider.(ConfigurableProvider)
+	if !ok {
+		return
+	}
+
+	config := configurable.GetConfig()
+	pattern, exists := config.Intents[config.IntentName(intent.Task)]
+	if !exists || pattern.Webhook == "" {
+		return
+	}
+
+	s.webhooks.Dispatch(pattern.Webhook, WebhookEventIntentDetected, intent)
+}
+
+// extractStructuredCommand builds an intent from `command: key=value, ...` input.
+// The command must name an enabled intent of the active provider's config or,
+// for providers that aren't config-driven, of INTENT_CONFIG_PATH or the
+// validation schema. Otherwise, and when there is no config at all, nil is
+// returned and the input is treated as natural language.
+func (s *IntentService) extractStructuredCommand(text string) *models.Intent {
+	command, values, ok := parseStructuredCommand(text)
+	if !ok {
+		return nil
+	}
+
+	config := s.knownIntentConfig()
+	if config == nil {
+		return nil
+	}
+
+	task := ""
+	for intentName, pattern := range config.Intents {
+		// Commands may name a renamed intent by its alias
+		if pattern.IsEnabled() && (canonicalCommandName(intentName) == canonicalCommandName(command) ||
+			canonicalCommandName(config.TaskName(intentName)) == canonicalCommandName(command)) {
+			task = intentName
+			break
+		}
+	}
+	if task == "" {
+		return nil
+	}
+
+	intent := &models.Intent{
+		Task:       task,
+		Vars:       make(map[string]interface{}, len(values)),
+		Confidence: 1.0,
+	}
+	for key, value := range values {
+		intent.Vars[key] = value
+	}
+
+	if enhanced, ok := s.aiProvider.(*EnhancedLocalProvider); ok {
+		enhanced.completeIntent(intent, task)
+	}
+	intent.Task = config.TaskName(task)
+
+	return intent
+}
+
+// usesEnhancedLocalProvider reports whether the configured provider is the enhanced local provider
+func (s *IntentService) usesEnhancedLocalProvider() bool {
+	_, ok := s.aiProvider.(*EnhancedLocalProvider)
+	return ok
 }
 
 // extractWithPatterns uses regex patterns to extract intent
 func (s *IntentService) extractWithPatterns(text string) *models.Intent {
-	for intentType, pattern := range s.patterns {
-		matches := pattern.FindStringSubmatch(text)
+	// Check patterns in a fixed order so overlapping matches are deterministic
+	intentTypes := make([]string, 0, len(s.patterns))
+	for intentType := range s.patterns {
+		intentTypes = append(intentTypes, intentType)
+	}
+	sort.Strings(intentTypes)
+
+	for _, intentType := range intentTypes {
+		matches := s.patterns[intentType].FindStringSubmatch(text)
 		if len(matches) > 0 {
 			return s.buildIntentFromMatches(intentType, matches)
 		}
@@ -96,11 +604,13 @@ func (s *IntentService) extractWithPatterns(text string) *models.Intent {
 	return nil
 }
 
-// buildIntentFromMatches constructs intent from regex matches
+// buildIntentFromMatches constructs intent from regex matches. Every pattern
+// requires the contact's name; the other vars are optional.
 func (s *IntentService) buildIntentFromMatches(intentType string, matches []string) *models.Intent {
 	intent := &models.Intent{
-		Task: strings.ToUpper(intentType),
-		Vars: make(map[string]interface{}),
+		Task:       strings.ToUpper(intentType),
+		Vars:       make(map[string]interface{}),
+		Confidence: patternMatchConfidence,
 	}
 
 	switch intentType {
@@ -121,9 +631,89 @@ func (s *IntentService) buildIntentFromMatches(intentType string, matches []stri
 		}
 	}
 
+	if isEmptyVar(intent.Vars["name"]) {
+		intent.Missing = []string{"name"}
+	}
+	intent.IsComplete = len(intent.Missing) == 0
 	return intent
 }
 
+// ShadowStats returns shadow classification stats, or nil when shadow mode is off
+func (s *IntentService) ShadowStats() *ShadowStats {
+	if s.shadow == nil {
+		return nil
+	}
+	stats := s.shadow.Stats()
+	return &stats
+}
+
+// ReloadConfig re-reads the active provider's intent config and returns the new
+// config. On failure the previous config stays active.
+func (s *IntentService) ReloadConfig() (*models.IntentConfig, error) {
+	reloadable, ok := s.aiProvider.(ReloadableProvider)
+	if !ok {
+		return nil, fmt.Errorf("%w by provider %s", ErrReloadNotSupported, s.GetAIProviderName())
+	}
+	if err := reloadable.Reload(); err != nil {
+		return nil, err
+	}
+	s.configVersion.Add(1)
+
+	configurable, ok := s.aiProvider.(ConfigurableProvider)
+	if !ok {
+		return nil, nil
+	}
+	config := configurable.GetConfig()
+	s.applyConfigAbbreviations(config)
+	return config, nil
+}
+
+// SetIntentEnabled enables or disables an intent of the active provider until
+// the next config reload
+func (s *IntentService) SetIntentEnabled(intentName string, enabled bool) error {
+	toggleable, ok := s.aiProvider.(ToggleableProvider)
+	if !ok {
+		return fmt.Errorf("%w by provider %s", ErrToggleNotSupported, s.GetAIProviderName())
+	}
+	if err := toggleable.SetIntentEnabled(intentName, enabled); err != nil {
+		return err
+	}
+	s.configVersion.Add(1)
+	return nil
+}
+
+// RequestTimeout returns the deadline applied to each provider call
+func (s *IntentService) RequestTimeout() time.Duration {
+	if s.requestTimeout <= 0 {
+		return DefaultRequestTimeout
+	}
+	return s.requestTimeout
+}
+
+// GetIntentConfig returns the active provider's intent config, or false for
+// providers that aren't config-driven
+func (s *IntentService) GetIntentConfig() (*models.IntentConfig, bool) {
+	configurable, ok := s.aiProvider.(ConfigurableProvider)
+	if !ok {
+		return nil, false
+	}
+	return configurable.GetConfig(), true
+}
+
+// knownIntentConfig returns the active provider's intent config or, for
+// providers that aren't config-driven, INTENT_CONFIG_PATH or the validation
+// schema. It is nil when there is none.
+func (s *IntentService) knownIntentConfig() *models.IntentConfig {
+	config, ok := s.GetIntentConfig()
+	if !ok || config == nil {
+		config = s.intentConfig
+	}
+	if config == nil {
+		config = s.schema
+	}
+	return config
+}
+
 // GetAIProviderName returns the name of the current AI provider
 func (s *IntentService) GetAIProviderName() string {
 	if s.aiProvider != nil {
@@ -132,6 +722,23 @@ func (s *IntentService) GetAIProviderName() string {
 	return "None"
 }
 
+// ProviderAvailable reports whether the AI provider can serve requests. It
+// gives up and reports false once ctx is done, since checks such as
+// Ollama's make a network call.
+func (s *IntentService) ProviderAvailable(ctx context.Context) bool {
+	if s.aiProvider == nil {
+		return false
+	}
+	available := make(chan bool, 1)
+	go func() { available <- s.aiProvider.IsAvailable() }()
+	select {
+	case ok := <-available:
+		return ok
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // getEnvVar is a wrapper for os.Getenv to make testing easier
 var getEnvVar = os.Getenv
 
@@ -168,3 +775,39 @@ func getFloatEnv(key string, fallback float64) float64 {
 	}
 	return fallback
 }
+
+// getBoolEnv gets boolean environment variable with fallback
+func getBoolEnv(key string, fallback bool) bool {
+	if value := getEnvVar(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return fallback
+}
+
+// getDurationEnv gets duration environment variable with fallback
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	if value := getEnvVar(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return fallback
+}
+
+// getListEnv gets a comma-separated list environment variable with fallback
+func getListEnv(key string, fallback []string) []string {
+	value := getEnvVar(key)
+	if value == "" {
+		return fallback
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,6 +7,29 @@ import (
 	"myllm/internal/models"
 )
 
+// stubProvider is an AIProvider that returns a fixed intent and counts calls
+type stubProvider struct {
+	name   string
+	intent *models.Intent
+	err    error
+	calls  int
+}
+
+func (p *stubProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.intent != nil {
+		return p.intent, nil
+	}
+	return &models.Intent{Task: "UNKNOWN", Vars: map[string]interface{}{}}, nil
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) IsAvailable() bool { return true }
+
 func TestIntentService_ExtractIntent_PatternMatching(t *testing.T) {
 	// Mock environment variables for testing
 	originalGetEnv := getEnvVar
@@ -23,6 +46,8 @@ func TestIntentService_ExtractIntent_PatternMatching(t *testing.T) {
 			return ""
 		case "OPENAI_API_KEY":
 			return "test-key"
+		case "PATTERN_FAST_PATH":
+			return "true"
 		default:
 			return ""
 		}
@@ -42,7 +67,10 @@ func TestIntentService_ExtractIntent_PatternMatching(t *testing.T) {
 		getFloatEnvVar = originalGetFloatEnv
 	}()
 
-	service := NewIntentService()
+	service, err := NewIntentService()
+	if err != nil {
+		t.Fatalf("NewIntentService() error = %v", err)
+	}
 
 	tests := []struct {
 		name     string
@@ -205,3 +233,68 @@ func TestIntent_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestIntentService_PatternFastPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		fastPath  bool
+		input     string
+		wantTask  string
+		wantCalls int
+	}{
+		{"matching input skips provider", true, "delete contact mike", "DELETE_CONTACT", 0},
+		{"create with email skips provider", true, "Add contact John with email john@example.com", "CREATE_CONTACT", 0},
+		{"non-matching input uses provider", true, "what's the weather like", "UNKNOWN", 1},
+		{"disabled fast path uses provider", false, "delete contact mike", "UNKNOWN", 1},
+		{"verb inside a sentence uses provider", true, "find a good restaurant nearby", "UNKNOWN", 1},
+		{"negated verb uses provider", true, "I don't want to delete anything", "UNKNOWN", 1},
+		{"update without contact uses provider", true, "update me on the weather", "UNKNOWN", 1},
+		{"drop without contact uses provider", true, "drop me off at the station", "UNKNOWN", 1},
+		{"trailing words use provider", true, "delete contact mike tomorrow", "UNKNOWN", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &stubProvider{name: "stub"}
+			service := &IntentService{
+				aiProvider:      provider,
+				patterns:        defaultPatterns(),
+				patternFastPath: tt.fastPath,
+			}
+
+			intent, err := service.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.wantTask {
+				t.Errorf("Task = %v, want %v", intent.Task, tt.wantTask)
+			}
+			if provider.calls != tt.wantCalls {
+				t.Errorf("provider calls = %d, want %d", provider.calls, tt.wantCalls)
+			}
+			if tt.wantCalls == 0 && (intent.Confidence != patternMatchConfidence || !intent.IsComplete || len(intent.Missing) != 0) {
+				t.Errorf("fast-path intent = %+v, want confidence %v and complete", intent, patternMatchConfidence)
+			}
+		})
+	}
+}
+
+func TestIntentService_PatternFastPathSkippedForEnhancedLocal(t *testing.T) {
+	provider := newTestEnhancedProvider(t, models.GetDefaultConfig())
+	service := &IntentService{
+		aiProvider:      provider,
+		patterns:        defaultPatterns(),
+		patternFastPath: true,
+	}
+
+	intent, err := service.ExtractIntent(context.Background(), "find contact john")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "FIND_CONTACT" {
+		t.Errorf("Task = %v, want FIND_CONTACT from the enhanced provider's config", intent.Task)
+	}
+	if intent.Confidence == 0 {
+		t.Error("expected the enhanced provider to handle the request")
+	}
+}
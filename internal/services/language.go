@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"myllm/internal/logging"
+	"myllm/internal/models"
+)
+
+// DefaultLanguage is the language of a single-file intent config
+const DefaultLanguage = "en"
+
+// languageKey carries the language a request asked for
+type languageKey struct{}
+
+// WithLanguage returns a context asking providers to classify text in lang,
+// e.g. "es" or "es-MX". An empty lang leaves the choice to the provider.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, normalizeLanguage(lang))
+}
+
+// languageFromContext returns the requested language, or "" if none was set
+func languageFromContext(ctx context.Context) string {
+	lang, _ := ctx.Value(languageKey{}).(string)
+	return lang
+}
+
+// normalizeLanguage lowercases a language tag and writes "es_MX" as "es-mx"
+func normalizeLanguage(lang string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "_", "-")
+}
+
+// compileVocabulary collects the case-folded words a config is written in:
+// its keywords, phrases, examples, synonyms, negators and own stop words.
+// The built-in stop words are left out since they are English for every
+// config.
+func compileVocabulary(config *models.IntentConfig) map[string]bool {
+	var texts []string
+	for _, intent := range config.Intents {
+		texts = append(texts, intent.Keywords...)
+		texts = append(texts, intent.Phrases...)
+		texts = append(texts, intent.Examples...)
+	}
+	for word, synonyms := range config.Synonyms {
+		texts = append(texts, word)
+		texts = append(texts, synonyms...)
+	}
+	texts = append(texts, config.Negators...)
+	texts = append(texts, config.StopWords...)
+
+	vocabulary := make(map[string]bool)
+	for _, text := range texts {
+		for _, word := range strings.Fields(normalizeForMatching(text)) {
+			vocabulary[foldWord(word)] = true
+		}
+	}
+	return vocabulary
+}
+
+// compileLanguages compiles the config of every language
+func compileLanguages(configs map[string]*models.IntentConfig) (map[string]*CompiledConfig, error) {
+	languages := make(map[string]*CompiledConfig, len(configs))
+	for language, config := range configs {
+		compiled, err := compileConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile config for language %s: %w", language, err)
+		}
+		languages[language] = compiled
+	}
+	return languages, nil
+}
+
+// setLanguages swaps in a new set of per-language configs. Requests already
+// running keep their snapshot of the old set.
+func (p *EnhancedLocalProvider) setLanguages(configs map[string]*models.IntentConfig, languages map[string]*CompiledConfig) {
+	p.current.Store(&configSet{configs: configs, compiled: languages})
+}
+
+// snapshot returns a provider that serves the current configs, in the
+// default language, for as long as it's used. A provider built around a
+// single config is its own snapshot.
+func (p *EnhancedLocalProvider) snapshot() *EnhancedLocalProvider {
+	set := p.current.Load()
+	if set == nil {
+		return p
+	}
+	return &EnhancedLocalProvider{
+		config:                 set.configs[p.defaultLanguage],
+		compiled:               set.compiled[p.defaultLanguage],
+		configPath:             p.configPath,
+		now:                    p.now,
+		classifier:             p.classifier,
+		languageConfigs:        set.configs,
+		languages:              set.compiled,
+		defaultLanguage:        p.defaultLanguage,
+		detectLanguage:         p.detectLanguage,
+		legacyConfidenceInVars: p.legacyConfidenceInVars,
+	}
+}
+
+// allCompiled returns the compiled config of every language, keyed by language
+func (p *EnhancedLocalProvider) allCompiled() map[string]*CompiledConfig {
+	if p.languages == nil {
+		return map[string]*CompiledConfig{p.defaultLanguage: p.compiled}
+	}
+	return p.languages
+}
+
+// allConfigs returns the config of every language, keyed by language
+func (p *EnhancedLocalProvider) allConfigs() map[string]*models.IntentConfig {
+	if p.languageConfigs == nil {
+		return map[string]*models.IntentConfig{p.defaultLanguage: p.config}
+	}
+	return p.languageConfigs
+}
+
+// selectLanguage picks the language to classify text in: the requested one,
+// falling back from "es-mx" to "es", then the detected one when detection is
+// on, then the default
+func (p *EnhancedLocalProvider) selectLanguage(ctx context.Context, text string) string {
+	if len(p.languages) < 2 {
+		return p.defaultLanguage
+	}
+
+	if lang := languageFromContext(ctx); lang != "" {
+		if _, exists := p.languages[lang]; exists {
+			return lang
+		}
+		if base, _, found := strings.Cut(lang, "-"); found {
+			if _, exists := p.languages[base]; exists {
+				return base
+			}
+		}
+		logging.FromContext(ctx).Debug("Unknown language, using the default", "lang", lang, "default", p.defaultLanguage)
+		return p.defaultLanguage
+	}
+
+	if p.detectLanguage {
+		return p.detectTextLanguage(text)
+	}
+	return p.defaultLanguage
+}
+
+// detectTextLanguage returns the language whose vocabulary covers the most
+// words of text. The default language wins ties, including texts that no
+// config knows a word of.
+func (p *EnhancedLocalProvider) detectTextLanguage(text string) string {
+	var words []string
+	for _, word := range strings.Fields(normalizeForMatching(text)) {
+		words = append(words, foldWord(word))
+	}
+
+	hits := func(language string) int {
+		count := 0
+		for _, word := range words {
+			if p.languages[language].Vocabulary[word] {
+				count++
+			}
+		}
+		return count
+	}
+
+	languages := make([]string, 0, len(p.languages))
+	for language := range p.languages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	best, bestHits := p.defaultLanguage, hits(p.defaultLanguage)
+	for _, language := range languages {
+		if count := hits(language); count > bestHits {
+			best, bestHits = language, count
+		}
+	}
+	return best
+}
+
+// forLanguage returns a provider that classifies with the given language's
+// config. The default language is served by p itself. p is a snapshot.
+func (p *EnhancedLocalProvider) forLanguage(language string) *EnhancedLocalProvider {
+	if language == p.defaultLanguage || p.languages[language] == nil {
+		return p
+	}
+	return &EnhancedLocalProvider{
+		config:                 p.languageConfigs[language],
+		compiled:               p.languages[language],
+		defaultLanguage:        language,
+		configPath:             p.configPath,
+		now:                    p.now,
+		classifier:             p.classifier,
+		legacyConfidenceInVars: p.legacyConfidenceInVars,
+	}
+}
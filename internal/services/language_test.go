@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// spanishNoteConfig is noteConfig written in Spanish, under its own intent
+// name so tests can tell which config classified a text
+func spanishNoteConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"CrearNota": {
+				Description: "Crear una nota",
+				Keywords:    []string{"nota", "apunta"},
+				Phrases:     []string{"toma nota", "apunta que"},
+				Priority:    8,
+				Variables:   []string{"content"},
+				Required:    []string{"content"},
+			},
+		},
+		Entities: map[string]models.EntityPattern{
+			"content": {
+				Type:        "text",
+				Description: "Contenido de la nota",
+				Keywords:    []string{"nota que", "apunta que", "apunta"},
+				Extraction:  models.ExtractionRestOfInput,
+			},
+		},
+		StopWords:        []string{"el", "la", "que", "de", "un", "una"},
+		ReplaceStopWords: true,
+	}
+}
+
+// newLanguageTestProvider writes an English and a Spanish config to a
+// directory and loads it the way INTENT_CONFIG_PATH would
+func newLanguageTestProvider(t *testing.T) *EnhancedLocalProvider {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, config := range map[string]*models.IntentConfig{"en.json": noteConfig(), "es.json": spanishNoteConfig()} {
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	provider, err := NewEnhancedLocalProvider(dir)
+	if err != nil {
+		t.Fatalf("NewEnhancedLocalProvider() error = %v", err)
+	}
+	return provider.(*EnhancedLocalProvider)
+}
+
+func TestEnhancedLocalProvider_LanguageSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		detect      bool
+		lang        string
+		text        string
+		wantSpanish bool
+	}{
+		{name: "requested language", lang: "es", text: "apunta que hay que comprar pan", wantSpanish: true},
+		{name: "region falls back to language", lang: "es-MX", text: "apunta que hay que comprar pan", wantSpanish: true},
+		{name: "unknown language uses the default", lang: "fr", text: "apunta que hay que comprar pan"},
+		{name: "no language uses the default", text: "apunta que hay que comprar pan"},
+		{name: "detected Spanish", detect: true, text: "apunta que hay que comprar pan", wantSpanish: true},
+		{name: "detected English", detect: true, text: "make a note that the printer is broken"},
+		{name: "requested language wins over detection", detect: true, lang: "en", text: "apunta que hay que comprar pan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newLanguageTestProvider(t)
+			provider.detectLanguage = tt.detect
+
+			ctx := context.Background()
+			if tt.lang != "" {
+				ctx = WithLanguage(ctx, tt.lang)
+			}
+			intent, err := provider.ExtractIntent(ctx, tt.text)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if gotSpanish := intent.Task == "CrearNota"; gotSpanish != tt.wantSpanish {
+				t.Errorf("Task = %s, classified in Spanish = %v, want %v", intent.Task, gotSpanish, tt.wantSpanish)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_LanguageExtractsWithItsEntities(t *testing.T) {
+	provider := newLanguageTestProvider(t)
+
+	intent, err := provider.ExtractIntent(WithLanguage(context.Background(), "es"), "apunta que hay que comprar pan")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if got := intent.Vars["content"]; got != "hay que comprar pan" {
+		t.Errorf("content = %q, want the text after the Spanish trigger", got)
+	}
+}
+
+func TestEnhancedLocalProvider_ToggleIntentInOtherLanguage(t *testing.T) {
+	provider := newLanguageTestProvider(t)
+
+	if err := provider.SetIntentEnabled("CrearNota", false); err != nil {
+		t.Fatalf("SetIntentEnabled() error = %v, want the Spanish intent to be found", err)
+	}
+
+	intent, err := provider.ExtractIntent(WithLanguage(context.Background(), "es"), "apunta que hay que comprar pan")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task == "CrearNota" {
+		t.Errorf("Task = %s, want the disabled intent skipped", intent.Task)
+	}
+	if _, exists := provider.GetConfig().Intents["CreateNote"]; !exists {
+		t.Error("default language config lost its intents after the toggle")
+	}
+}
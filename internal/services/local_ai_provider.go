@@ -41,9 +41,15 @@ func (p *LocalAIProvider) ExtractIntent(ctx context.Context, text string) (*mode
 	normalizedText := strings.ToLower(strings.TrimSpace(text))
 
 	// Determine intent based on keywords
-	intent := p.classifyIntent(normalizedText)
+	intent, err := p.classifyIntent(ctx, normalizedText)
+	if err != nil {
+		return nil, err
+	}
 
 	// Extract entities
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	entities := p.extractEntities(text)
 
 	// Build the intent structure
@@ -74,14 +80,18 @@ func (p *LocalAIProvider) ExtractIntent(ctx context.Context, text string) (*mode
 	return result, nil
 }
 
-// classifyIntent determines the intent based on keywords
-func (p *LocalAIProvider) classifyIntent(text string) string {
+// classifyIntent determines the intent based on keywords, stopping with
+// ctx's error when ctx is done between intents
+func (p *LocalAIProvider) classifyIntent(ctx context.Context, text string) (string, error) {
 	text = strings.ToLower(text)
 
 	// Count keyword matches for each intent
 	intentScores := make(map[string]int)
 
 	for intent, keywords := range p.intentKeywords {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 		score := 0
 		for _, keyword := range keywords {
 			if strings.Contains(text, keyword) {
@@ -102,7 +112,7 @@ func (p *LocalAIProvider) classifyIntent(text string) string {
 		}
 	}
 
-	return bestIntent
+	return bestIntent, nil
 }
 
 // extractEntities extracts named entities from text
@@ -151,7 +161,7 @@ func (p *LocalAIProvider) isCommonWord(word string) bool {
 		"remove": true, "modify": true, "change": true, "edit": true, "save": true, "store": true,
 	}
 
-	return commonWords[strings.ToLower(word)]
+	return commonWords[foldWord(word)]
 }
 
 // Name returns the provider name
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// negationWindow is the number of words after a negator that it cancels
+const negationWindow = 3
+
+// compileNegators splits each negator into the words it becomes after text
+// normalization, e.g. "don't" -> ["don", "t"]
+func compileNegators(negators []string) [][]string {
+	var compiled [][]string
+	for _, negator := range negators {
+		words := strings.FieldsFunc(strings.ToLower(negator), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) > 0 {
+			compiled = append(compiled, words)
+		}
+	}
+	return compiled
+}
+
+// stripNegated removes the negationWindow words after each negator from
+// normalized text, keeping the negators themselves. It reports whether any
+// words were removed.
+func (p *EnhancedLocalProvider) stripNegated(text string) (string, bool) {
+	words := strings.Fields(text)
+	negated := make([]bool, len(words))
+	found := false
+
+	for i := range words {
+		for _, negator := range p.compiled.Negators {
+			if !hasWordsAt(words, i, negator) {
+				continue
+			}
+			end := i + len(negator)
+			for j := end; j < end+negationWindow && j < len(words); j++ {
+				negated[j] = true
+				found = true
+			}
+			break
+		}
+	}
+	if !found {
+		return text, false
+	}
+
+	kept := make([]string, 0, len(words))
+	for i, word := range words {
+		if !negated[i] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " "), true
+}
+
+// hasWordsAt reports whether words continues with sequence at index i
+func hasWordsAt(words []string, i int, sequence []string) bool {
+	if i+len(sequence) > len(words) {
+		return false
+	}
+	for j, word := range sequence {
+		if words[i+j] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesIntent reports whether text matches any of the intent's regexes,
+// phrases, keywords, keyword synonyms or misspelled keywords
+func (p *EnhancedLocalProvider) matchesIntent(text, intentName string) bool {
+	for _, re := range p.compiled.IntentRegexes[intentName] {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+
+	textLower := strings.ToLower(text)
+	for _, phrase := range p.compiled.PhraseMap[intentName] {
+		if strings.Contains(textLower, strings.ToLower(phrase)) {
+			return true
+		}
+	}
+	fuzzyDistances := p.compiled.FuzzyDistances[intentName]
+	textWords := p.tokenize(text)
+	for i, keyword := range p.compiled.KeywordMap[intentName] {
+		if p.matchKeyword(textLower, textWords, keyword, fuzzyDistances, i).Match != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func negationConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"CreateContact": {
+				Description: "Create a contact",
+				Keywords:    []string{"create", "add", "contact"},
+				Priority:    10,
+			},
+			"DeleteContact": {
+				Description: "Delete a contact",
+				Keywords:    []string{"delete", "remove"},
+				Priority:    9,
+			},
+		},
+		ExactMatch: models.ExactMatchConfig{Disabled: true},
+	}
+}
+
+func TestEnhancedLocalProvider_Negation(t *testing.T) {
+	provider := newTestEnhancedProvider(t, negationConfig())
+
+	tests := []struct {
+		input   string
+		want    string
+		notWant string
+	}{
+		{input: "create a contact", want: "CreateContact"},
+		{input: "don't create a contact", notWant: "CreateContact"},
+		{input: "Don’t create a contact", notWant: "CreateContact"},
+		{input: "I do not want to delete anything", notWant: "DeleteContact"},
+		{input: "never remove Bob", want: "UNKNOWN"},
+		// Keywords outside the window still count
+		{input: "don't delete it, create a contact instead", want: "CreateContact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if tt.want != "" && intent.Task != tt.want {
+				t.Errorf("Task = %q, want %q", intent.Task, tt.want)
+			}
+			if tt.notWant != "" && intent.Task == tt.notWant {
+				t.Errorf("Task = %q, want anything else", intent.Task)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_ConfiguredNegators(t *testing.T) {
+	tests := []struct {
+		name     string
+		negators []string
+		input    string
+		want     string
+	}{
+		{name: "custom negator", negators: []string{"skip"}, input: "skip adding a contact", want: "UNKNOWN"},
+		{name: "custom list replaces defaults", negators: []string{"skip"}, input: "don't create a contact", want: "CreateContact"},
+		{name: "empty list disables negation", negators: []string{}, input: "don't create a contact", want: "CreateContact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := negationConfig()
+			config.Negators = tt.negators
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.want {
+				t.Errorf("Task = %q, want %q", intent.Task, tt.want)
+			}
+		})
+	}
+}
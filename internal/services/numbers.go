@@ -0,0 +1,169 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Variables filled by the built-in number entities
+const (
+	numberVar  = "number"
+	ordinalVar = "ordinal"
+)
+
+// numberWords are the spelled-out cardinals below a hundred that aren't
+// compounds such as "twenty one"
+var numberWords = map[string]int{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17,
+	"eighteen": 18, "nineteen": 19, "twenty": 20, "thirty": 30, "forty": 40,
+	"fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+// ordinalWords are the spelled-out ordinals below a hundred that aren't
+// compounds such as "twenty first"
+var ordinalWords = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6,
+	"seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10, "eleventh": 11,
+	"twelfth": 12, "thirteenth": 13, "fourteenth": 14, "fifteenth": 15,
+	"sixteenth": 16, "seventeenth": 17, "eighteenth": 18, "nineteenth": 19,
+	"twentieth": 20, "thirtieth": 30, "fortieth": 40, "fiftieth": 50,
+	"sixtieth": 60, "seventieth": 70, "eightieth": 80, "ninetieth": 90,
+	"hundredth": 100,
+}
+
+// Numbers written with digits: "3" and "3rd", but not "3pm", "10:30" or "2.5"
+var (
+	cardinalDigitsRegex = regexp.MustCompile(`^\d{1,9}$`)
+	ordinalDigitsRegex  = regexp.MustCompile(`^(\d{1,9})(?:st|nd|rd|th)$`)
+)
+
+// numberWordSplitter separates words, keeping hyphens and colons inside
+// them so "twenty-one" and "10:30" stay whole
+var numberWordSplitter = strings.NewReplacer(",", " ", "!", " ", "?", " ", ";", " ", "(", " ", ")", " ", "\"", " ")
+
+// extractNumbers returns the first cardinal and the first ordinal number in
+// text, e.g. 3 in "create 3 tasks" and 2 in "the second contact"
+func extractNumbers(text string) (number int, hasNumber bool, ordinal int, hasOrdinal bool) {
+	var words []string
+	for _, word := range strings.Fields(numberWordSplitter.Replace(strings.ToLower(text))) {
+		word = strings.TrimRight(word, ".")
+		// "twenty-one" is read like "twenty one"
+		words = append(words, strings.Split(word, "-")...)
+	}
+
+	for i := 0; i < len(words); {
+		value, isOrdinal, length := parseNumber(words[i:])
+		if length == 0 {
+			i++
+			continue
+		}
+		if isOrdinal && !hasOrdinal {
+			ordinal, hasOrdinal = value, true
+		} else if !isOrdinal && !hasNumber {
+			number, hasNumber = value, true
+		}
+		if hasNumber && hasOrdinal {
+			break
+		}
+		i += length
+	}
+	return number, hasNumber, ordinal, hasOrdinal
+}
+
+// parseNumber reads the number at the start of words, such as "7", "4th",
+// "two hundred fifty" or "twenty first". It returns how many words it used,
+// 0 when words don't start with a number.
+func parseNumber(words []string) (value int, isOrdinal bool, length int) {
+	if digits := ordinalDigitsRegex.FindStringSubmatch(words[0]); digits != nil {
+		value, _ = strconv.Atoi(digits[1])
+		return value, true, 1
+	}
+	if cardinalDigitsRegex.MatchString(words[0]) {
+		value, _ = strconv.Atoi(words[0])
+		return value, false, 1
+	}
+
+	// Spelled out: a run of number words, hundreds and thousands, which
+	// ends early at an ordinal
+	total, current := 0, 0
+	for length < len(words) {
+		word := words[length]
+		if ordinal, exists := ordinalWords[word]; exists {
+			if ordinal == 100 {
+				return total + max(current, 1)*100, true, length + 1
+			}
+			if !joinsNumber(current, ordinal) {
+				break
+			}
+			return total + current + ordinal, true, length + 1
+		}
+		if word == "hundred" && current > 0 && current < 10 {
+			current *= 100
+		} else if word == "thousand" && total+current > 0 {
+			total, current = (total+current)*1000, 0
+		} else if n, exists := numberWords[word]; exists && joinsNumber(current, n) {
+			current += n
+		} else if word == "and" && length > 0 && current >= 100 && length+1 < len(words) {
+			// "one hundred and five"
+			if _, _, next := parseNumber(words[length+1:]); next == 0 {
+				break
+			}
+		} else {
+			break
+		}
+		length++
+	}
+	if length == 0 {
+		return 0, false, 0
+	}
+	// A trailing "and" isn't part of the number
+	for words[length-1] == "and" {
+		length--
+	}
+	return total + current, false, length
+}
+
+// joinsNumber reports whether n can follow the spelled-out number so far, as
+// "one" follows "twenty" or "five" follows "one hundred"
+func joinsNumber(current, n int) bool {
+	tens := current % 100
+	switch {
+	case current == 0:
+		return true
+	case tens == 0:
+		return n < 100
+	case tens%10 == 0 && tens >= 20:
+		return n < 10 && n > 0
+	default:
+		return false
+	}
+}
+
+// addBuiltinNumbers sets the number and ordinal variables from text when the
+// config enables builtin_entities and doesn't define entities of those names.
+// Extracted entity values are left out, so the digits of a phone number or a
+// date aren't read as numbers.
+func (p *EnhancedLocalProvider) addBuiltinNumbers(text string, entities map[string][]string, vars map[string]interface{}) {
+	if !p.config.BuiltinEntities {
+		return
+	}
+
+	for _, values := range entities {
+		for _, value := range values {
+			if value != "" {
+				text = strings.ReplaceAll(text, value, " ")
+			}
+		}
+	}
+
+	number, hasNumber, ordinal, hasOrdinal := extractNumbers(text)
+	if _, defined := p.config.Entities[numberVar]; hasNumber && !defined {
+		vars[numberVar] = number
+	}
+	if _, defined := p.config.Entities[ordinalVar]; hasOrdinal && !defined {
+		vars[ordinalVar] = ordinal
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractNumbers(t *testing.T) {
+	tests := []struct {
+		text        string
+		wantNumber  int
+		hasNumber   bool
+		wantOrdinal int
+		hasOrdinal  bool
+	}{
+		{text: "create 3 tasks", wantNumber: 3, hasNumber: true},
+		{text: "create two tasks", wantNumber: 2, hasNumber: true},
+		{text: "add twenty-one items", wantNumber: 21, hasNumber: true},
+		{text: "order two hundred and fifty chairs", wantNumber: 250, hasNumber: true},
+		{text: "three thousand four hundred steps", wantNumber: 3400, hasNumber: true},
+		{text: "open the second contact", wantOrdinal: 2, hasOrdinal: true},
+		{text: "delete the 3rd note", wantOrdinal: 3, hasOrdinal: true},
+		{text: "the twenty-first item", wantOrdinal: 21, hasOrdinal: true},
+		{text: "the one hundredth visitor", wantOrdinal: 100, hasOrdinal: true},
+		{text: "show 5 notes from the third page", wantNumber: 5, hasNumber: true, wantOrdinal: 3, hasOrdinal: true},
+		{text: "call me at 3pm or 10:30", hasNumber: false},
+		{text: "add contact Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			number, hasNumber, ordinal, hasOrdinal := extractNumbers(tt.text)
+			if hasNumber != tt.hasNumber || number != tt.wantNumber {
+				t.Errorf("number = %d, %v, want %d, %v", number, hasNumber, tt.wantNumber, tt.hasNumber)
+			}
+			if hasOrdinal != tt.hasOrdinal || ordinal != tt.wantOrdinal {
+				t.Errorf("ordinal = %d, %v, want %d, %v", ordinal, hasOrdinal, tt.wantOrdinal, tt.hasOrdinal)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_BuiltinEntities(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		input   string
+		want    map[string]interface{}
+	}{
+		{name: "digits", enabled: true, input: "add 3 contacts", want: map[string]interface{}{"number": 3}},
+		{name: "spelled out", enabled: true, input: "add two contacts", want: map[string]interface{}{"number": 2}},
+		{name: "ordinal", enabled: true, input: "add the second contact", want: map[string]interface{}{"ordinal": 2}},
+		{name: "entity values are skipped", enabled: true, input: "add contact named Alice phone 415 555 2671", want: map[string]interface{}{}},
+		{name: "disabled", input: "add 3 contacts", want: map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := phoneContactConfig("")
+			config.BuiltinEntities = tt.enabled
+			provider := newTestEnhancedProvider(t, config)
+
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			for _, name := range []string{"number", "ordinal"} {
+				got, exists := intent.Vars[name]
+				want, wantExists := tt.want[name]
+				if exists != wantExists || got != want {
+					t.Errorf("Vars[%s] = %v (set %v), want %v (set %v)", name, got, exists, want, wantExists)
+				}
+			}
+		})
+	}
+}
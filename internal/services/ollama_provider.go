@@ -8,13 +8,15 @@ import (
 	"io"
 	"myllm/internal/models"
 	"net/http"
-	"time"
+	"strings"
+	"text/template"
 )
 
 // OllamaProvider implements AIProvider for Ollama
 type OllamaProvider struct {
 	client *http.Client
 	config AIProviderConfig
+	prompt *template.Template // Extraction prompt; the default if nil
 }
 
 // OllamaRequest represents the request structure for Ollama API
@@ -22,9 +24,25 @@ type OllamaRequest struct {
 	Model   string        `json:"model"`
 	Prompt  string        `json:"prompt"`
 	Stream  bool          `json:"stream"`
+	Format  string        `json:"format,omitempty"` // "json" constrains the output to valid JSON
 	Options OllamaOptions `json:"options,omitempty"`
 }
 
+// OllamaChatRequest represents the request structure for the Ollama chat API
+type OllamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []OllamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"` // "json" constrains the output to valid JSON
+	Options  OllamaOptions   `json:"options,omitempty"`
+}
+
+// OllamaMessage is a chat message sent to or returned by the Ollama chat API
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
 // OllamaOptions represents Ollama generation options
 type OllamaOptions struct {
 	Temperature float64 `json:"temperature,omitempty"`
@@ -33,10 +51,19 @@ type OllamaOptions struct {
 
 // OllamaResponse represents the response structure from Ollama API
 type OllamaResponse struct {
-	Model     string `json:"model"`
-	Response  string `json:"response"`
-	Done      bool   `json:"done"`
-	CreatedAt string `json:"created_at"`
+	Model     string         `json:"model"`
+	Response  string         `json:"response"`
+	Message   *OllamaMessage `json:"message,omitempty"` // Set instead of Response by the chat API
+	Done      bool           `json:"done"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// text returns the generated text of a generate or chat response
+func (r OllamaResponse) text() string {
+	if r.Message != nil {
+		return r.Message.Content
+	}
+	return r.Response
 }
 
 // NewOllamaProvider creates a new Ollama provider
@@ -46,9 +73,11 @@ func NewOllamaProvider(config AIProviderConfig) (AIProvider, error) {
 		baseURL = "http://localhost:11434"
 	}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	prompt, err := parsePromptTemplate(config.PromptTemplate)
+	if err != nil {
+		return nil, err
 	}
+	client := newHTTPClient(config)
 
 	// Test connection to Ollama
 	testURL := baseURL + "/api/tags"
@@ -65,55 +94,162 @@ func NewOllamaProvider(config AIProviderConfig) (AIProvider, error) {
 	return &OllamaProvider{
 		client: client,
 		config: config,
+		prompt: prompt,
 	}, nil
 }
 
 // ExtractIntent extracts intent using Ollama
 func (p *OllamaProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
-	baseURL := p.config.BaseURL
-	if baseURL == "" {
-		baseURL = "http://localhost:11434"
+	request, err := p.newRequest(text, false)
+	if err != nil {
+		return nil, err
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Ollama request: %w", err)
 	}
 
-	model := p.config.Model
-	if model == "" {
-		model = "llama2" // Default model
+	ctx, cancel := context.WithTimeout(ctx, p.config.requestTimeout())
+	defer cancel()
+
+	// Retry transient failures such as 429s and connection resets
+	var ollamaResp *OllamaResponse
+	err = retryWithBackoff(ctx, p.config.MaxRetries, p.config.RetryBackoff, func() error {
+		var err error
+		ollamaResp, err = p.generate(ctx, requestBody)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	prompt := fmt.Sprintf(`Extract intent and variables from this text: "%s"
+	// Parse AI response
+	intent, err := models.FromJSONLenient(ollamaResp.text())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
 
-Return a JSON object with this structure:
-{
-  "task": "TASK_NAME",
-  "vars": {
-    "name": "extracted_name",
-    "email": "extracted_email", 
-    "phone": "extracted_phone"
-  }
+	return intent, nil
 }
 
-Common tasks: CREATE_CONTACT, FIND_CONTACT, UPDATE_CONTACT, DELETE_CONTACT
-If no specific task is found, use "UNKNOWN" as task.
-Extract any names, emails, or phone numbers you can find.
+// StreamIntent extracts intent with a streamed generate call, passing each
+// token to onToken as Ollama produces it
+func (p *OllamaProvider) StreamIntent(ctx context.Context, text string, onToken func(token string) error) (*models.Intent, error) {
+	request, err := p.newRequest(text, true)
+	if err != nil {
+		return nil, err
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Ollama request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.requestTimeout())
+	defer cancel()
+
+	// Only opening the stream is retried; tokens already sent can't be taken back
+	var resp *http.Response
+	err = retryWithBackoff(ctx, p.config.MaxRetries, p.config.RetryBackoff, func() error {
+		var err error
+		resp, err = p.post(ctx, requestBody)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-Respond with valid JSON only:`, text)
+	// The stream is one JSON object per line, the last with done set
+	var reply strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk OllamaResponse
+		if err := decoder.Decode(&chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode Ollama stream: %w", err)
+		}
 
-	request := OllamaRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false,
-		Options: OllamaOptions{
-			Temperature: p.config.Temperature,
-			NumPredict:  p.config.MaxTokens,
-		},
+		if token := chunk.text(); token != "" {
+			reply.WriteString(token)
+			if err := onToken(token); err != nil {
+				return nil, err
+			}
+		}
+		if chunk.Done {
+			break
+		}
 	}
 
-	requestBody, err := json.Marshal(request)
+	// Parse AI response
+	intent, err := models.FromJSONLenient(reply.String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal Ollama request: %w", err)
+		return nil, fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
+
+	return intent, nil
+}
+
+// newRequest builds the generate request for text, or the chat request when
+// OllamaUseChat is set
+func (p *OllamaProvider) newRequest(text string, stream bool) (interface{}, error) {
+	model := p.config.Model
+	if model == "" {
+		model = "llama2" // Default model
+	}
+	options := OllamaOptions{
+		Temperature: p.config.Temperature,
+		NumPredict:  p.config.MaxTokens,
+	}
+	var format string
+	if p.config.OllamaJSONFormat {
+		format = "json"
+	}
+
+	prompt, err := buildPrompt(p.prompt, text, p.config.KnownTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config.OllamaUseChat {
+		return OllamaChatRequest{
+			Model: model,
+			Messages: []OllamaMessage{
+				{Role: "system", Content: jsonOnlySystemPrompt},
+				{Role: "user", Content: prompt},
+			},
+			Stream:  stream,
+			Format:  format,
+			Options: options,
+		}, nil
+	}
+
+	return OllamaRequest{
+		Model:   model,
+		Prompt:  prompt + "\n\nRespond with valid JSON only:",
+		Stream:  stream,
+		Format:  format,
+		Options: options,
+	}, nil
+}
+
+// endpoint returns the API path requests are sent to
+func (p *OllamaProvider) endpoint() string {
+	if p.config.OllamaUseChat {
+		return "/api/chat"
+	}
+	return "/api/generate"
+}
+
+// post sends a request to the generate or chat endpoint, returning the open response
+// on 200 and a providerStatusError otherwise
+func (p *OllamaProvider) post(ctx context.Context, requestBody []byte) (*http.Response, error) {
+	baseURL := p.config.BaseURL
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/generate", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+p.endpoint(), bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Ollama request: %w", err)
 	}
@@ -124,25 +260,30 @@ Respond with valid JSON only:`, text)
 	if err != nil {
 		return nil, fmt.Errorf("Ollama request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
+		return nil, &providerStatusError{Provider: "Ollama", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return resp, nil
+}
+
+// generate makes a single non-streaming call to the generate or chat endpoint
+func (p *OllamaProvider) generate(ctx context.Context, requestBody []byte) (*OllamaResponse, error) {
+	resp, err := p.post(ctx, requestBody)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var ollamaResp OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return nil, fmt.Errorf("failed to decode Ollama response: %w", err)
 	}
 
-	// Parse AI response
-	intent, err := models.FromJSON(ollamaResp.Response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse Ollama response: %w", err)
-	}
-
-	return intent, nil
+	return &ollamaResp, nil
 }
 
 // Name returns the provider name
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ollamaRecordingServer answers every Ollama API call with reply, recording
+// the path and decoded body of the last one
+func ollamaRecordingServer(t *testing.T, reply string, path *string, body *map[string]json.RawMessage) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+		*path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaProvider_Endpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		useChat  bool
+		wantPath string
+		reply    string
+	}{
+		{
+			name:     "generate by default",
+			wantPath: "/api/generate",
+			reply:    `{"model": "llama2", "response": "{\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"Alice\"}}", "done": true}`,
+		},
+		{
+			name:     "chat with a system prompt",
+			useChat:  true,
+			wantPath: "/api/chat",
+			reply:    `{"model": "llama3", "message": {"role": "assistant", "content": "{\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"Alice\"}}"}, "done": true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			var got map[string]json.RawMessage
+			server := ollamaRecordingServer(t, tt.reply, &gotPath, &got)
+			provider, err := NewOllamaProvider(AIProviderConfig{BaseURL: server.URL, OllamaUseChat: tt.useChat})
+			if err != nil {
+				t.Fatalf("NewOllamaProvider() error = %v", err)
+			}
+
+			intent, err := provider.ExtractIntent(context.Background(), "add contact Alice")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "CREATE_CONTACT" || intent.Vars["name"] != "Alice" {
+				t.Errorf("intent = %+v, want CREATE_CONTACT with name Alice", intent)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("request path = %q, want %q", gotPath, tt.wantPath)
+			}
+
+			if !tt.useChat {
+				if _, exists := got["prompt"]; !exists {
+					t.Errorf("generate request has no prompt: %v", got)
+				}
+				return
+			}
+			var messages []OllamaMessage
+			if err := json.Unmarshal(got["messages"], &messages); err != nil {
+				t.Fatalf("failed to decode chat messages: %v", err)
+			}
+			if len(messages) != 2 || messages[0].Role != "system" || messages[0].Content != jsonOnlySystemPrompt || messages[1].Role != "user" {
+				t.Errorf("messages = %+v, want the system prompt then the user prompt", messages)
+			}
+		})
+	}
+}
+
+func TestOllamaProvider_JSONFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     AIProviderConfig
+		wantFormat string
+	}{
+		{name: "generate", config: AIProviderConfig{OllamaJSONFormat: true}, wantFormat: `"json"`},
+		{name: "chat", config: AIProviderConfig{OllamaJSONFormat: true, OllamaUseChat: true}, wantFormat: `"json"`},
+		{name: "disabled", config: AIProviderConfig{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			var got map[string]json.RawMessage
+			server := ollamaRecordingServer(t, ollamaSuccessBody, &path, &got)
+			tt.config.BaseURL = server.URL
+			provider, err := NewOllamaProvider(tt.config)
+			if err != nil {
+				t.Fatalf("NewOllamaProvider() error = %v", err)
+			}
+
+			if _, err := provider.ExtractIntent(context.Background(), "add contact Alice"); err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			// Without the option the field is left out rather than sent empty
+			if format, sent := got["format"]; string(format) != tt.wantFormat || sent != (tt.wantFormat != "") {
+				t.Errorf("format = %s (sent %v), want %s", format, sent, tt.wantFormat)
+			}
+		})
+	}
+}
@@ -2,74 +2,135 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"myllm/internal/models"
+	"strings"
+	"text/template"
 
 	openai "github.com/sashabaranov/go-openai"
 )
 
+// intentToolName is the function the model calls with the extracted intent
+const intentToolName = "record_intent"
+
+// intentTool declares the function-calling schema for an Intent's task and vars
+var intentTool = openai.Tool{
+	Type: openai.ToolTypeFunction,
+	Function: openai.FunctionDefinition{
+		Name:        intentToolName,
+		Description: "Record the intent and variables extracted from the user's text",
+		Parameters: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "task": {"type": "string", "description": "Task name such as CREATE_CONTACT, or UNKNOWN"},
+    "vars": {"type": "object", "description": "Extracted variables such as name, email and phone"}
+  },
+  "required": ["task", "vars"]
+}`),
+	},
+}
+
+// jsonOnlySystemPrompt is the system message chat-style providers send ahead
+// of the extraction prompt
+const jsonOnlySystemPrompt = "You are an intent extraction assistant. Always respond with valid JSON only."
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is configured
+const DefaultAzureAPIVersion = "2023-05-15"
+
 // OpenAIProvider implements AIProvider for OpenAI
 type OpenAIProvider struct {
 	client *openai.Client
 	config AIProviderConfig
+	prompt *template.Template // Extraction prompt; the default if nil
 }
 
-// NewOpenAIProvider creates a new OpenAI provider
+// NewOpenAIProvider creates a new OpenAI provider. BaseURL, when set, replaces
+// api.openai.com, e.g. with a proxy; in Azure mode it is the resource endpoint.
 func NewOpenAIProvider(config AIProviderConfig) (AIProvider, error) {
 	if config.APIKey == "" {
 		return nil, fmt.Errorf("OpenAI API key is required")
 	}
 
-	client := openai.NewClient(config.APIKey)
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	if config.AzureOpenAI {
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("Azure OpenAI requires a base URL")
+		}
+		clientConfig = openai.DefaultAzureConfig(config.APIKey, config.BaseURL)
+		if config.AzureAPIVersion != "" {
+			clientConfig.APIVersion = config.AzureAPIVersion
+		}
+		if deployment := config.AzureDeployment; deployment != "" {
+			clientConfig.AzureModelMapperFunc = func(string) string { return deployment }
+		}
+	} else if config.BaseURL != "" {
+		clientConfig.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	}
+	clientConfig.HTTPClient = newHTTPClient(config)
+	client := openai.NewClientWithConfig(clientConfig)
+
+	prompt, err := parsePromptTemplate(config.PromptTemplate)
+	if err != nil {
+		return nil, err
+	}
 
 	return &OpenAIProvider{
 		client: client,
 		config: config,
+		prompt: prompt,
 	}, nil
 }
 
 // ExtractIntent extracts intent using OpenAI
 func (p *OpenAIProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
-	prompt := fmt.Sprintf(`Extract intent and variables from this text: "%s"
-
-Return a JSON object with this structure:
-{
-  "task": "TASK_NAME",
-  "vars": {
-    "name": "extracted_name",
-    "email": "extracted_email", 
-    "phone": "extracted_phone"
-  }
-}
-
-Common tasks: CREATE_CONTACT, FIND_CONTACT, UPDATE_CONTACT, DELETE_CONTACT
-If no specific task is found, use "UNKNOWN" as task.
-Extract any names, emails, or phone numbers you can find.`, text)
+	prompt, err := buildPrompt(p.prompt, text, p.config.KnownTasks)
+	if err != nil {
+		return nil, err
+	}
 
 	model := p.config.Model
 	if model == "" {
 		model = openai.GPT3Dot5Turbo
 	}
 
-	resp, err := p.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model:       model,
-			Temperature: float32(p.config.Temperature),
-			MaxTokens:   p.config.MaxTokens,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are an intent extraction assistant. Always respond with valid JSON only.",
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
+	ctx, cancel := context.WithTimeout(ctx, p.config.requestTimeout())
+	defer cancel()
+
+	request := openai.ChatCompletionRequest{
+		Model:       model,
+		Temperature: float32(p.config.Temperature),
+		MaxTokens:   p.config.MaxTokens,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: jsonOnlySystemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
 			},
 		},
-	)
+	}
 
+	// Have the model answer through a function whose arguments follow the
+	// Intent schema instead of free text
+	if p.config.OpenAIFunctionCalling {
+		request.Tools = []openai.Tool{intentTool}
+		request.ToolChoice = openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: intentToolName},
+		}
+	}
+
+	// Retry transient failures such as 429s and connection resets
+	var resp openai.ChatCompletionResponse
+	err = retryWithBackoff(ctx, p.config.MaxRetries, p.config.RetryBackoff, func() error {
+		var err error
+		resp, err = p.client.CreateChatCompletion(ctx, request)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI extraction failed: %w", err)
 	}
@@ -77,10 +138,23 @@ Extract any names, emails, or phone numbers you can find.`, text)
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response from OpenAI")
 	}
+	message := resp.Choices[0].Message
+
+	if p.config.OpenAIFunctionCalling {
+		for _, call := range message.ToolCalls {
+			if call.Function.Name == intentToolName {
+				return intentFromToolArguments(call.Function.Arguments)
+			}
+		}
+		// The model answered in text despite the tool choice; parse it as before
+		if message.Content == "" {
+			return nil, fmt.Errorf("OpenAI returned no %s tool call", intentToolName)
+		}
+		slog.Warn("OpenAI returned no tool call, parsing the message content", "model", model)
+	}
 
 	// Parse AI response
-	aiResponse := resp.Choices[0].Message.Content
-	intent, err := models.FromJSON(aiResponse)
+	intent, err := models.FromJSONLenient(message.Content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse OpenAI response: %w", err)
 	}
@@ -88,6 +162,21 @@ Extract any names, emails, or phone numbers you can find.`, text)
 	return intent, nil
 }
 
+// intentFromToolArguments decodes the arguments of a record_intent call
+func intentFromToolArguments(arguments string) (*models.Intent, error) {
+	var intent models.Intent
+	if err := json.Unmarshal([]byte(arguments), &intent); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAI tool arguments: %w", err)
+	}
+	if intent.Task == "" {
+		return nil, fmt.Errorf("OpenAI tool call has no task")
+	}
+	if intent.Vars == nil {
+		intent.Vars = make(map[string]interface{})
+	}
+	return &intent, nil
+}
+
 // Name returns the provider name
 func (p *OpenAIProvider) Name() string {
 	return "OpenAI"
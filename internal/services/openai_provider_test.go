@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// newTestOpenAIProvider points an OpenAIProvider at a test server
+func newTestOpenAIProvider(t *testing.T, config AIProviderConfig, handler http.HandlerFunc) *OpenAIProvider {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config.APIKey = "test-key"
+	config.BaseURL = server.URL + "/v1"
+	provider, err := NewOpenAIProvider(config)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error = %v", err)
+	}
+	return provider.(*OpenAIProvider)
+}
+
+// chatCompletion answers a chat completion request with the given message
+func chatCompletion(t *testing.T, got *openai.ChatCompletionRequest, message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": ` + message + `}]}`))
+	}
+}
+
+func TestOpenAIProvider_BaseURL(t *testing.T) {
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"task\": \"FIND_CONTACT\", \"vars\": {}}"}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(AIProviderConfig{APIKey: "test-key", BaseURL: server.URL + "/proxy/v1/"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error = %v", err)
+	}
+	if !provider.IsAvailable() {
+		t.Error("IsAvailable() = false, want true")
+	}
+
+	intent, err := provider.ExtractIntent(context.Background(), "find Alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "FIND_CONTACT" {
+		t.Errorf("Task = %q, want FIND_CONTACT", intent.Task)
+	}
+	if path != "/proxy/v1/chat/completions" {
+		t.Errorf("path = %q, want /proxy/v1/chat/completions", path)
+	}
+	if auth != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want the bearer key", auth)
+	}
+}
+
+func TestOpenAIProvider_Azure(t *testing.T) {
+	var path, version, key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, version, key = r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"task\": \"FIND_CONTACT\", \"vars\": {}}"}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		config      AIProviderConfig
+		wantPath    string
+		wantVersion string
+	}{
+		{
+			name:        "named deployment",
+			config:      AIProviderConfig{AzureDeployment: "intents-prod", AzureAPIVersion: "2024-02-01"},
+			wantPath:    "/openai/deployments/intents-prod/chat/completions",
+			wantVersion: "2024-02-01",
+		},
+		{
+			name:        "deployment from model",
+			config:      AIProviderConfig{Model: "gpt-3.5-turbo"},
+			wantPath:    "/openai/deployments/gpt-35-turbo/chat/completions",
+			wantVersion: DefaultAzureAPIVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.APIKey, config.BaseURL, config.AzureOpenAI = "azure-key", server.URL, true
+			provider, err := NewOpenAIProvider(config)
+			if err != nil {
+				t.Fatalf("NewOpenAIProvider() error = %v", err)
+			}
+
+			if _, err := provider.ExtractIntent(context.Background(), "find Alice"); err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if path != tt.wantPath {
+				t.Errorf("path = %q, want %q", path, tt.wantPath)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("api-version = %q, want %q", version, tt.wantVersion)
+			}
+			if key != "azure-key" {
+				t.Errorf("api-key = %q, want azure-key", key)
+			}
+		})
+	}
+}
+
+func TestNewOpenAIProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  AIProviderConfig
+		wantErr string
+	}{
+		{name: "no API key", config: AIProviderConfig{BaseURL: "http://proxy.local/v1"}, wantErr: "API key is required"},
+		{name: "Azure without base URL", config: AIProviderConfig{APIKey: "key", AzureOpenAI: true}, wantErr: "requires a base URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOpenAIProvider(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_FunctionCalling(t *testing.T) {
+	var got openai.ChatCompletionRequest
+	provider := newTestOpenAIProvider(t, AIProviderConfig{OpenAIFunctionCalling: true}, chatCompletion(t, &got,
+		`{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "record_intent", "arguments": "{\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"Alice\"}}"}}]}`))
+
+	intent, err := provider.ExtractIntent(context.Background(), "add contact Alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CREATE_CONTACT" || intent.Vars["name"] != "Alice" {
+		t.Errorf("intent = %+v, want CREATE_CONTACT for Alice", intent)
+	}
+
+	if len(got.Tools) != 1 || got.Tools[0].Function.Name != intentToolName {
+		t.Errorf("tools = %+v, want the %s function", got.Tools, intentToolName)
+	}
+	choice, _ := json.Marshal(got.ToolChoice)
+	if !strings.Contains(string(choice), intentToolName) {
+		t.Errorf("tool_choice = %s, want %s forced", choice, intentToolName)
+	}
+}
+
+func TestOpenAIProvider_FunctionCallingWithoutToolCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		wantTask string
+		wantErr  string
+	}{
+		{name: "text answer", message: `{"role": "assistant", "content": "{\"task\": \"FIND_CONTACT\", \"vars\": {}}"}`, wantTask: "FIND_CONTACT"},
+		{name: "fenced text answer", message: `{"role": "assistant", "content": "Here you go:\n` + "```" + `json\n{\"task\": \"FIND_CONTACT\", \"vars\": {}}\n` + "```" + `"}`, wantTask: "FIND_CONTACT"},
+		{name: "empty answer", message: `{"role": "assistant", "content": ""}`, wantErr: "no record_intent tool call"},
+		{name: "tool call without task", message: `{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "record_intent", "arguments": "{\"vars\": {}}"}}]}`, wantErr: "no task"},
+		{name: "malformed arguments", message: `{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "record_intent", "arguments": "{\"task\": "}}]}`, wantErr: "failed to parse OpenAI tool arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got openai.ChatCompletionRequest
+			provider := newTestOpenAIProvider(t, AIProviderConfig{OpenAIFunctionCalling: true}, chatCompletion(t, &got, tt.message))
+
+			intent, err := provider.ExtractIntent(context.Background(), "find Alice")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.wantTask {
+				t.Errorf("Task = %s, want %s", intent.Task, tt.wantTask)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_PromptModeSendsNoTools(t *testing.T) {
+	var got openai.ChatCompletionRequest
+	provider := newTestOpenAIProvider(t, AIProviderConfig{}, chatCompletion(t, &got,
+		`{"role": "assistant", "content": "{\"task\": \"CREATE_CONTACT\", \"vars\": {}}"}`))
+
+	if _, err := provider.ExtractIntent(context.Background(), "add contact Alice"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if len(got.Tools) != 0 || got.ToolChoice != nil {
+		t.Errorf("tools = %+v, tool_choice = %v, want none without function calling", got.Tools, got.ToolChoice)
+	}
+}
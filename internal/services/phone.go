@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// phoneRegion describes how phone numbers are written in a region
+type phoneRegion struct {
+	countryCode string         // International calling code, e.g. "44"
+	trunkPrefix string         // Dialled before national numbers, e.g. "0" in the UK
+	national    *regexp.Regexp // Valid national significant numbers
+}
+
+// phoneRegions are the regions phone numbers can be parsed for, keyed by ISO
+// 3166 alpha-2 code. The patterns check the length and leading digits of each
+// numbering plan rather than every allocated range.
+var phoneRegions = map[string]phoneRegion{
+	"US": {countryCode: "1", national: regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`)},
+	"CA": {countryCode: "1", national: regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`)},
+	"GB": {countryCode: "44", trunkPrefix: "0", national: regexp.MustCompile(`^[1235789]\d{8,9}$`)},
+	"IE": {countryCode: "353", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]\d{6,9}$`)},
+	"FR": {countryCode: "33", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]\d{8}$`)},
+	"DE": {countryCode: "49", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]\d{5,13}$`)},
+	"ES": {countryCode: "34", national: regexp.MustCompile(`^[5-9]\d{8}$`)},
+	"IT": {countryCode: "39", national: regexp.MustCompile(`^(?:0\d{5,10}|3\d{8,9})$`)},
+	"NL": {countryCode: "31", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]\d{8}$`)},
+	"AU": {countryCode: "61", trunkPrefix: "0", national: regexp.MustCompile(`^[2-478]\d{8}$`)},
+	"IN": {countryCode: "91", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]\d{9}$`)},
+	"MX": {countryCode: "52", national: regexp.MustCompile(`^[1-9]\d{9}$`)},
+	"BR": {countryCode: "55", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]{2}9?\d{8}$`)},
+	"JP": {countryCode: "81", trunkPrefix: "0", national: regexp.MustCompile(`^[1-9]\d{8,9}$`)},
+}
+
+// mainPhoneRegions is the region reported for a calling code several regions
+// share when the number's own region can't be told apart
+var mainPhoneRegions = map[string]string{"1": "US"}
+
+// PhoneRegions returns the region codes phone numbers can be parsed for
+func PhoneRegions() []string {
+	regions := make([]string, 0, len(phoneRegions))
+	for region := range phoneRegions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// normalizeRegion uppercases a region code such as "gb"
+func normalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+// regionKey carries the region a request's phone numbers are dialled from
+type regionKey struct{}
+
+// WithRegion returns a context asking providers to parse phone numbers without
+// a country code as numbers of region, e.g. "GB". An empty region leaves the
+// config's default_region in effect.
+func WithRegion(ctx context.Context, region string) (context.Context, error) {
+	region = normalizeRegion(region)
+	if region == "" {
+		return ctx, nil
+	}
+	if _, exists := phoneRegions[region]; !exists {
+		return ctx, fmt.Errorf("unsupported region %q (supported: %s)", region, strings.Join(PhoneRegions(), ", "))
+	}
+	return context.WithValue(ctx, regionKey{}, region), nil
+}
+
+// regionFromContext returns the requested region, or "" if none was set
+func regionFromContext(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
+
+// parsePhoneNumber parses a phone number dialled from region and returns it in
+// E.164 form with the region it belongs to. Numbers with an international
+// prefix ("+" or "00") are checked against their own region's plan; calling
+// codes without a known plan are accepted by length and reported without a
+// region.
+func parsePhoneNumber(raw, region string) (e164, country string, err error) {
+	home, exists := phoneRegions[region]
+	if !exists {
+		return "", "", fmt.Errorf("unsupported region %q", region)
+	}
+	number, ok := validatePhone(raw)
+	if !ok {
+		return "", "", fmt.Errorf("not a phone number: %q", raw)
+	}
+
+	if digits, international := strings.CutPrefix(number, "+"); international {
+		for length := 1; length <= 3 && length < len(digits); length++ {
+			code := digits[:length]
+			country, national := phoneRegionFor(code, region), digits[length:]
+			if country == "" {
+				continue
+			}
+			plan := phoneRegions[country]
+			// "+44 (0)20 7946 0958" keeps the trunk prefix out of habit
+			if !plan.national.MatchString(national) && plan.trunkPrefix != "" {
+				national = strings.TrimPrefix(national, plan.trunkPrefix)
+			}
+			if !plan.national.MatchString(national) {
+				return "", "", fmt.Errorf("%q is not a valid %s phone number", raw, country)
+			}
+			return "+" + code + national, country, nil
+		}
+		if len(digits) < 8 {
+			return "", "", fmt.Errorf("phone number %q is too short", raw)
+		}
+		return number, "", nil
+	}
+
+	// A national number, possibly written with the trunk prefix or with the
+	// calling code but no "+", as in "1 415 555 2671"
+	national := number
+	if home.trunkPrefix != "" && strings.HasPrefix(national, home.trunkPrefix) {
+		national = strings.TrimPrefix(national, home.trunkPrefix)
+	} else if withoutCode := strings.TrimPrefix(national, home.countryCode); withoutCode != national && home.national.MatchString(withoutCode) {
+		national = withoutCode
+	}
+	if !home.national.MatchString(national) {
+		return "", "", fmt.Errorf("%q is not a valid %s phone number", raw, region)
+	}
+	return "+" + home.countryCode + national, region, nil
+}
+
+// phoneRegionFor returns the region of a calling code: home when it shares the
+// code, else the code's main region
+func phoneRegionFor(code, home string) string {
+	if phoneRegions[home].countryCode == code {
+		return home
+	}
+	if main, exists := mainPhoneRegions[code]; exists {
+		return main
+	}
+	for _, region := range PhoneRegions() {
+		if phoneRegions[region].countryCode == code {
+			return region
+		}
+	}
+	return ""
+}
+
+// phoneRegion returns the region phone numbers are parsed for: the request's,
+// else the config's default_region. "" means numbers are left as extracted.
+func (p *EnhancedLocalProvider) phoneRegion(ctx context.Context) string {
+	if region := regionFromContext(ctx); region != "" {
+		return region
+	}
+	return normalizeRegion(p.config.DefaultRegion)
+}
+
+// parsePhoneEntities rewrites the values of phone entities in E.164 form for
+// region and drops the ones that aren't valid there, so required phones are
+// asked for again. It returns the regions of the kept values by entity.
+func (p *EnhancedLocalProvider) parsePhoneEntities(region string, entities map[string][]string) map[string][]string {
+	countries := make(map[string][]string)
+	for name, values := range entities {
+		if entity, exists := p.config.Entities[name]; !exists || entity.Type != "phone" {
+			continue
+		}
+
+		var parsed, parsedCountries []string
+		for _, value := range values {
+			number, country, err := parsePhoneNumber(value, region)
+			if err != nil {
+				continue
+			}
+			if slices.Contains(parsed, number) {
+				continue
+			}
+			parsed = append(parsed, number)
+			parsedCountries = append(parsedCountries, country)
+		}
+		if len(parsed) == 0 {
+			delete(entities, name)
+			continue
+		}
+		entities[name] = parsed
+		countries[name] = parsedCountries
+	}
+	return countries
+}
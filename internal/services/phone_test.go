@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestParsePhoneNumber(t *testing.T) {
+	tests := []struct {
+		raw         string
+		region      string
+		want        string
+		wantCountry string
+		wantErr     bool
+	}{
+		{raw: "(415) 555-2671", region: "US", want: "+14155552671", wantCountry: "US"},
+		{raw: "1 415 555 2671", region: "US", want: "+14155552671", wantCountry: "US"},
+		{raw: "+1 415 555 2671", region: "GB", want: "+14155552671", wantCountry: "US"},
+		{raw: "+1 416 555 0199", region: "CA", want: "+14165550199", wantCountry: "CA"},
+		{raw: "020 7946 0958", region: "GB", want: "+442079460958", wantCountry: "GB"},
+		{raw: "07911 123456", region: "GB", want: "+447911123456", wantCountry: "GB"},
+		{raw: "+44 (0)20 7946 0958", region: "US", want: "+442079460958", wantCountry: "GB"},
+		{raw: "0044 20 7946 0958", region: "US", want: "+442079460958", wantCountry: "GB"},
+		{raw: "+686 7312 3456", region: "US", want: "+68673123456"}, // No plan for the calling code
+		{raw: "123-456-7890", region: "US", wantErr: true},          // Area codes never start with 1
+		{raw: "555-1234", region: "US", wantErr: true},
+		{raw: "+44 20 7946", region: "US", wantErr: true},
+		{raw: "020 7946 0958", region: "XX", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region+" "+tt.raw, func(t *testing.T) {
+			got, country, err := parsePhoneNumber(tt.raw, tt.region)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePhoneNumber() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePhoneNumber() error = %v", err)
+			}
+			if got != tt.want || country != tt.wantCountry {
+				t.Errorf("parsePhoneNumber() = %q, %q, want %q, %q", got, country, tt.want, tt.wantCountry)
+			}
+		})
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	ctx, err := WithRegion(context.Background(), "gb")
+	if err != nil {
+		t.Fatalf("WithRegion() error = %v", err)
+	}
+	if got := regionFromContext(ctx); got != "GB" {
+		t.Errorf("region = %q, want GB", got)
+	}
+
+	if _, err := WithRegion(context.Background(), "Atlantis"); err == nil || !strings.Contains(err.Error(), "unsupported region") {
+		t.Errorf("WithRegion(Atlantis) error = %v, want unsupported region", err)
+	}
+}
+
+// phoneContactConfig is contactConfig with a required phone entity
+func phoneContactConfig(defaultRegion string) *models.IntentConfig {
+	config := contactConfig()
+	config.DefaultRegion = defaultRegion
+	createContact := config.Intents["CreateContact"]
+	createContact.Required = []string{"name", "phone"}
+	config.Intents["CreateContact"] = createContact
+	config.Entities["phone"] = models.EntityPattern{
+		Type:  "phone",
+		Regex: []string{`(?P<value>(?:\+|\b00)[1-9][\d\s().-]{6,18}\d|\(?\b\d{3}\)?[-.\s]?\d{3}[-.\s]?\d{4}\b|\b0\d{2,4}[-.\s]?\d{3,4}[-.\s]?\d{3,4}\b)`},
+	}
+	return config
+}
+
+func TestEnhancedLocalProvider_PhoneRegion(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultRegion string
+		region        string
+		input         string
+		wantPhone     string
+		wantCountry   string
+	}{
+		{name: "US default", defaultRegion: "US", input: "add contact named Alice phone (415) 555-2671", wantPhone: "+14155552671", wantCountry: "US"},
+		{name: "UK default", defaultRegion: "GB", input: "add contact named Alice phone 020 7946 0958", wantPhone: "+442079460958", wantCountry: "GB"},
+		{name: "request region wins", defaultRegion: "US", region: "GB", input: "add contact named Alice phone 07911 123456", wantPhone: "+447911123456", wantCountry: "GB"},
+		{name: "invalid for region", defaultRegion: "US", input: "add contact named Alice phone 123-456-7890"},
+		{name: "no region keeps the number as extracted", input: "add contact named Alice phone 020 7946 0958", wantPhone: "020 7946 0958"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, phoneContactConfig(tt.defaultRegion))
+			ctx, err := WithRegion(context.Background(), tt.region)
+			if err != nil {
+				t.Fatalf("WithRegion() error = %v", err)
+			}
+
+			intent, err := provider.ExtractIntent(ctx, tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if tt.wantPhone == "" {
+				if phone, exists := intent.Vars["phone"]; exists {
+					t.Errorf("phone = %v, want the invalid number dropped", phone)
+				}
+				if len(intent.Missing) != 1 || intent.Missing[0] != "phone" || len(intent.FollowUp) == 0 {
+					t.Errorf("missing = %v, follow_up = %v, want a follow-up for phone", intent.Missing, intent.FollowUp)
+				}
+				return
+			}
+			if got := intent.Vars["phone"]; got != tt.wantPhone {
+				t.Errorf("phone = %v, want %s", got, tt.wantPhone)
+			}
+			if tt.wantCountry == "" {
+				if country, exists := intent.Vars["phone_country"]; exists {
+					t.Errorf("phone_country = %v, want none without a region", country)
+				}
+			} else if got := intent.Vars["phone_country"]; got != tt.wantCountry {
+				t.Errorf("phone_country = %v, want %s", got, tt.wantCountry)
+			}
+		})
+	}
+}
+
+func TestCompileConfig_DefaultRegion(t *testing.T) {
+	if _, err := compileConfig(phoneContactConfig("gb")); err != nil {
+		t.Errorf("compileConfig() with default_region gb error = %v", err)
+	}
+	if _, err := compileConfig(phoneContactConfig("ZZ")); err == nil || !strings.Contains(err.Error(), "unsupported default_region") {
+		t.Errorf("compileConfig() with default_region ZZ error = %v, want unsupported default_region", err)
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"myllm/internal/models"
+)
+
+// TextPreprocessor rewrites request text before it is normalized and
+// classified, e.g. to correct spelling or expand abbreviations
+type TextPreprocessor interface {
+	Process(text string) string
+}
+
+// AddPreprocessor appends p to the preprocessors run, in the order they were
+// added, on every request's text. The chain is empty unless the intent config
+// defines abbreviations.
+func (s *IntentService) AddPreprocessor(p TextPreprocessor) {
+	s.setPreprocessors(append(s.loadPreprocessors(), p))
+}
+
+// loadPreprocessors returns the current preprocessor chain
+func (s *IntentService) loadPreprocessors() []TextPreprocessor {
+	if chain := s.preprocessors.Load(); chain != nil {
+		return *chain
+	}
+	return nil
+}
+
+// setPreprocessors swaps in a new preprocessor chain. Chains are never
+// modified in place, so requests can run the old one meanwhile.
+func (s *IntentService) setPreprocessors(chain []TextPreprocessor) {
+	chain = append([]TextPreprocessor(nil), chain...)
+	s.preprocessors.Store(&chain)
+	// Cached results were extracted from differently preprocessed text
+	s.configVersion.Add(1)
+}
+
+// preprocess runs text through the preprocessor chain
+func (s *IntentService) preprocess(text string) string {
+	for _, p := range s.loadPreprocessors() {
+		text = p.Process(text)
+	}
+	return text
+}
+
+// applyConfigAbbreviations replaces the chain's abbreviation expander with
+// one for config's abbreviations, at the head of the chain, or removes it
+// when config has none
+func (s *IntentService) applyConfigAbbreviations(config *models.IntentConfig) {
+	var chain []TextPreprocessor
+	if config != nil && len(config.Abbreviations) > 0 {
+		chain = append(chain, NewAbbreviationExpander(config.Abbreviations))
+	}
+	for _, p := range s.loadPreprocessors() {
+		if _, isAbbreviations := p.(*AbbreviationExpander); !isAbbreviations {
+			chain = append(chain, p)
+		}
+	}
+	s.setPreprocessors(chain)
+}
+
+// AbbreviationExpander is a TextPreprocessor that writes out abbreviations,
+// such as "appt" for "appointment", wherever they appear as whole words in
+// any case
+type AbbreviationExpander struct {
+	expansions map[string]string // Expansion by lowercased abbreviation
+	regex      *regexp.Regexp
+}
+
+// NewAbbreviationExpander creates an expander for the given expansions, keyed
+// by abbreviation
+func NewAbbreviationExpander(abbreviations map[string]string) *AbbreviationExpander {
+	expansions := make(map[string]string, len(abbreviations))
+	alternatives := make([]string, 0, len(abbreviations))
+	for abbreviation, expansion := range abbreviations {
+		abbreviation = strings.ToLower(strings.TrimSpace(abbreviation))
+		if abbreviation == "" {
+			continue
+		}
+		expansions[abbreviation] = expansion
+		alternatives = append(alternatives, regexp.QuoteMeta(abbreviation))
+	}
+	// Longest first, so "appts" wins over "appt"
+	sort.Slice(alternatives, func(i, j int) bool {
+		if len(alternatives[i]) != len(alternatives[j]) {
+			return len(alternatives[i]) > len(alternatives[j])
+		}
+		return alternatives[i] < alternatives[j]
+	})
+
+	expander := &AbbreviationExpander{expansions: expansions}
+	if len(alternatives) > 0 {
+		expander.regex = regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+	}
+	return expander
+}
+
+// Process expands the abbreviations in text
+func (e *AbbreviationExpander) Process(text string) string {
+	if e.regex == nil {
+		return text
+	}
+	return e.regex.ReplaceAllStringFunc(text, func(abbreviation string) string {
+		return e.expansions[strings.ToLower(abbreviation)]
+	})
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAbbreviationExpander(t *testing.T) {
+	expander := NewAbbreviationExpander(map[string]string{
+		"appt":  "appointment",
+		"appts": "appointments",
+		"Mtg":   "meeting",
+		" ":     "ignored",
+	})
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"appt with bob", "appointment with bob"},
+		{"Book an APPT, then a mtg", "Book an appointment, then a meeting"},
+		{"list my appts", "list my appointments"},
+		{"happtly ever after", "happtly ever after"},
+		{"nothing to expand", "nothing to expand"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := expander.Process(tt.input); got != tt.want {
+				t.Errorf("Process(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingPreprocessor is a TextPreprocessor that records the text it was given
+type recordingPreprocessor struct {
+	seen []string
+}
+
+func (p *recordingPreprocessor) Process(text string) string {
+	p.seen = append(p.seen, text)
+	return text
+}
+
+func TestIntentService_Preprocessors(t *testing.T) {
+	config := eventConfig()
+	event := config.Intents["CreateEvent"]
+	event.Phrases = append(event.Phrases, "appointment with")
+	event.Priority = 0
+	config.Intents["CreateEvent"] = event
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, config)}
+
+	// The chain is empty without abbreviations
+	intent, err := service.ExtractIntent(context.Background(), "appt with bob")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "UNKNOWN" {
+		t.Fatalf("Task = %v without abbreviations, want UNKNOWN", intent.Task)
+	}
+
+	config.Abbreviations = map[string]string{"appt": "appointment"}
+	service.applyConfigAbbreviations(config)
+	recorder := &recordingPreprocessor{}
+	service.AddPreprocessor(recorder)
+
+	intent, err = service.ExtractIntent(context.Background(), "Appt with Bob")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" {
+		t.Errorf("Task = %v, want CreateEvent once appt is expanded", intent.Task)
+	}
+	// Preprocessors run in order, on the text before it's normalized
+	if len(recorder.seen) != 1 || recorder.seen[0] != "appointment with Bob" {
+		t.Errorf("later preprocessor saw %q, want the expanded, unnormalized text", recorder.seen)
+	}
+	if trace := service.Trace(context.Background(), "appt with bob"); !strings.Contains(trace.NormalizedText, "appointment") {
+		t.Errorf("Trace().NormalizedText = %q, want the expanded text", trace.NormalizedText)
+	}
+
+	// A config without abbreviations drops the expander but keeps the rest
+	config.Abbreviations = nil
+	service.applyConfigAbbreviations(config)
+	if chain := service.loadPreprocessors(); len(chain) != 1 || chain[0] != recorder {
+		t.Errorf("preprocessors = %v, want only the registered one", chain)
+	}
+}
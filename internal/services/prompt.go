@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"myllm/internal/models"
+)
+
+// DefaultPromptTemplate is the extraction prompt LLM providers send unless
+// AI_PROMPT_TEMPLATE or AI_PROMPT_TEMPLATE_FILE replaces it
+const DefaultPromptTemplate = `Extract intent and variables from this text: "{{.Text}}"
+
+Return a JSON object with this structure:
+{
+  "task": "TASK_NAME",
+  "vars": {
+    "name": "extracted_name",
+    "email": "extracted_email",
+    "phone": "extracted_phone"
+  }
+}
+
+Common tasks: {{.TaskList}}
+If no specific task is found, use "UNKNOWN" as task.
+Extract any names, emails, or phone numbers you can find.`
+
+// defaultPromptTasks are the tasks listed when no intent config is known
+var defaultPromptTasks = []string{"CREATE_CONTACT", "FIND_CONTACT", "UPDATE_CONTACT", "DELETE_CONTACT"}
+
+var defaultPrompt = template.Must(template.New("prompt").Parse(DefaultPromptTemplate))
+
+// PromptData is what a prompt template is executed with
+type PromptData struct {
+	Text  string   // The text to classify
+	Tasks []string // The tasks the model may answer with
+}
+
+// TaskList returns the tasks comma-separated, e.g. "CreateEvent, CreateNote"
+func (d PromptData) TaskList() string {
+	return strings.Join(d.Tasks, ", ")
+}
+
+// parsePromptTemplate parses a prompt template, or returns the default one
+// for an empty source. The template is tried once so a reference to an
+// unknown field fails at startup rather than on every request.
+func parsePromptTemplate(source string) (*template.Template, error) {
+	if source == "" {
+		return defaultPrompt, nil
+	}
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, PromptData{Text: "text", Tasks: defaultPromptTasks}); err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// buildPrompt renders the extraction prompt for text, listing tasks or the
+// default tasks without any. A nil template is the default one.
+func buildPrompt(tmpl *template.Template, text string, tasks []string) (string, error) {
+	if tmpl == nil {
+		tmpl = defaultPrompt
+	}
+	if len(tasks) == 0 {
+		tasks = defaultPromptTasks
+	}
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, PromptData{Text: text, Tasks: tasks}); err != nil {
+		return "", fmt.Errorf("failed to build prompt: %w", err)
+	}
+	return prompt.String(), nil
+}
+
+// loadPromptTemplate returns the prompt template source from
+// AI_PROMPT_TEMPLATE_FILE, else AI_PROMPT_TEMPLATE
+func loadPromptTemplate() (string, error) {
+	if path := getEnv("AI_PROMPT_TEMPLATE_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		return string(data), nil
+	}
+	return getEnv("AI_PROMPT_TEMPLATE", ""), nil
+}
+
+// configTasks returns the task names of config's enabled intents, in order,
+// as providers should report them
+func configTasks(config *models.IntentConfig) []string {
+	var tasks []string
+	for _, intentName := range sortedKeys(config.Intents) {
+		if config.Intents[intentName].IsEnabled() {
+			tasks = append(tasks, config.TaskName(intentName))
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestBuildPrompt(t *testing.T) {
+	prompt, err := buildPrompt(nil, "add bob", nil)
+	if err != nil {
+		t.Fatalf("buildPrompt() error = %v", err)
+	}
+	for _, want := range []string{`from this text: "add bob"`, "Common tasks: CREATE_CONTACT, FIND_CONTACT, UPDATE_CONTACT, DELETE_CONTACT\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("default prompt = %q, want it to contain %q", prompt, want)
+		}
+	}
+
+	// Known tasks replace the built-in ones
+	prompt, err = buildPrompt(nil, "add bob", []string{"CreateEvent", "CreateNote"})
+	if err != nil {
+		t.Fatalf("buildPrompt() error = %v", err)
+	}
+	if !strings.Contains(prompt, "Common tasks: CreateEvent, CreateNote\n") || strings.Contains(prompt, "CREATE_CONTACT") {
+		t.Errorf("prompt = %q, want only the known tasks listed", prompt)
+	}
+
+	tmpl, err := parsePromptTemplate(`Classify "{{.Text}}" as one of:{{range .Tasks}} [{{.}}]{{end}}`)
+	if err != nil {
+		t.Fatalf("parsePromptTemplate() error = %v", err)
+	}
+	if prompt, _ = buildPrompt(tmpl, "add bob", []string{"CreateEvent", "CreateNote"}); prompt != `Classify "add bob" as one of: [CreateEvent] [CreateNote]` {
+		t.Errorf("custom prompt = %q", prompt)
+	}
+}
+
+func TestParsePromptTemplate_Invalid(t *testing.T) {
+	for _, source := range []string{"{{.Text", "{{.Intents}}"} {
+		if _, err := parsePromptTemplate(source); err == nil {
+			t.Errorf("parsePromptTemplate(%q) error = nil, want an error", source)
+		}
+	}
+}
+
+func TestLoadPromptTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("From file: {{.Text}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"AI_PROMPT_TEMPLATE": "Inline: {{.Text}}"}
+	originalGetEnv := getEnvVar
+	defer func() { getEnvVar = originalGetEnv }()
+	getEnvVar = func(key string) string { return env[key] }
+
+	if source, err := loadPromptTemplate(); err != nil || source != "Inline: {{.Text}}" {
+		t.Errorf("loadPromptTemplate() = %q, %v, want the inline template", source, err)
+	}
+	env["AI_PROMPT_TEMPLATE_FILE"] = path
+	if source, err := loadPromptTemplate(); err != nil || source != "From file: {{.Text}}" {
+		t.Errorf("loadPromptTemplate() = %q, %v, want the file to win", source, err)
+	}
+	env["AI_PROMPT_TEMPLATE_FILE"] = filepath.Join(t.TempDir(), "missing.tmpl")
+	if _, err := loadPromptTemplate(); err == nil {
+		t.Error("loadPromptTemplate() error = nil, want the missing file reported")
+	}
+}
+
+func TestConfigTasks(t *testing.T) {
+	disabled := false
+	config := &models.IntentConfig{
+		Intents: map[string]models.IntentPattern{
+			"CreateNote":  {},
+			"CreateEvent": {},
+			"DeleteAll":   {Enabled: &disabled},
+		},
+		Aliases: map[string]string{"CreateEvent": "NEW_EVENT"},
+	}
+	if got, want := configTasks(config), []string{"NEW_EVENT", "CreateNote"}; !slices.Equal(got, want) {
+		t.Errorf("configTasks() = %v, want %v", got, want)
+	}
+}
+
+func TestOllamaProvider_PromptListsKnownTasks(t *testing.T) {
+	var path string
+	var body map[string]json.RawMessage
+	server := ollamaRecordingServer(t, `{"response": "{\"task\": \"CreateNote\", \"vars\": {}}", "done": true}`, &path, &body)
+
+	provider, err := NewOllamaProvider(AIProviderConfig{BaseURL: server.URL, KnownTasks: []string{"CreateEvent", "CreateNote"}})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error = %v", err)
+	}
+	if _, err := provider.ExtractIntent(context.Background(), "note milk"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	var prompt string
+	if err := json.Unmarshal(body["prompt"], &prompt); err != nil {
+		t.Fatalf("failed to decode prompt: %v", err)
+	}
+	if !strings.Contains(prompt, "Common tasks: CreateEvent, CreateNote") {
+		t.Errorf("prompt = %q, want the known tasks listed", prompt)
+	}
+
+	if _, err := NewOllamaProvider(AIProviderConfig{BaseURL: server.URL, PromptTemplate: "{{.Nope}}"}); err == nil {
+		t.Error("NewOllamaProvider() error = nil, want the invalid template reported")
+	}
+}
+
+func TestOpenAIProvider_PromptTemplate(t *testing.T) {
+	var request openai.ChatCompletionRequest
+	provider := newTestOpenAIProvider(t, AIProviderConfig{
+		PromptTemplate: "Tasks: {{.TaskList}}\nText: {{.Text}}",
+		KnownTasks:     []string{"CreateEvent", "CreateNote"},
+	}, chatCompletion(t, &request, `{"role": "assistant", "content": "{\"task\": \"CreateNote\", \"vars\": {}}"}`))
+
+	if _, err := provider.ExtractIntent(context.Background(), "note milk"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if len(request.Messages) != 2 || request.Messages[1].Content != "Tasks: CreateEvent, CreateNote\nText: note milk" {
+		t.Errorf("messages = %+v, want the custom prompt with the known tasks", request.Messages)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"myllm/internal/models"
+)
+
+// RedactedValue replaces sensitive values in logged request and response bodies
+const RedactedValue = "[REDACTED]"
+
+// Patterns for sensitive entities of well-known types, tried on logged
+// request text in addition to the entity's own regexes
+var sensitiveTypePatterns = map[string]*regexp.Regexp{
+	"email": regexp.MustCompile(`[^\s@<>"']+@[^\s@<>"']+\.[^\s@<>"'.,;!?]+`),
+	"phone": regexp.MustCompile(`\+?\d[\d\s().-]{5,}\d`),
+}
+
+// sensitiveEntities returns the entities marked sensitive in the active
+// provider's config, or in INTENT_CONFIG_PATH or the validation schema for
+// providers that aren't config-driven
+func (s *IntentService) sensitiveEntities() map[string]models.EntityPattern {
+	config := s.knownIntentConfig()
+	if config == nil {
+		return nil
+	}
+	sensitive := make(map[string]models.EntityPattern)
+	for name, entity := range config.Entities {
+		if entity.Sensitive {
+			sensitive[name] = entity
+		}
+	}
+	return sensitive
+}
+
+// RedactIntent returns a copy of intent for logging, with the values of
+// sensitive entities masked, as well as any other var that contains one, such
+// as a derived var built from it
+func (s *IntentService) RedactIntent(intent *models.Intent) *models.Intent {
+	if intent == nil {
+		return nil
+	}
+	redacted := copyIntent(intent)
+	values := sensitiveValues(intent, s.sensitiveEntities())
+	if len(values) == 0 {
+		return redacted
+	}
+	for key, value := range redacted.Vars {
+		redacted.Vars[key] = redactVar(value, values)
+	}
+	return redacted
+}
+
+// RedactText masks sensitive values in request text for logging. This is
+// best-effort: the values the intent holds for sensitive entities are
+// masked, then whatever the entities' regexes, or the built-in email and
+// phone patterns for those types, still match.
+func (s *IntentService) RedactText(text string, intent *models.Intent) string {
+	entities := s.sensitiveEntities()
+	if len(entities) == 0 {
+		return text
+	}
+	if intent != nil {
+		for _, value := range sensitiveValues(intent, entities) {
+			text = replaceFold(text, value)
+		}
+	}
+	for _, name := range sortedKeys(entities) {
+		entity := entities[name]
+		for _, pattern := range entity.Regex {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				continue
+			}
+			text = redactMatches(re, text)
+		}
+		if re, exists := sensitiveTypePatterns[entity.Type]; exists {
+			text = redactMatches(re, text)
+		}
+	}
+	return text
+}
+
+// sensitiveValues returns the non-empty values intent holds for entities
+func sensitiveValues(intent *models.Intent, entities map[string]models.EntityPattern) []string {
+	var values []string
+	for name := range entities {
+		switch value := intent.Vars[name].(type) {
+		case string:
+			values = appendUnique(values, value)
+		case []string:
+			for _, v := range value {
+				values = appendUnique(values, v)
+			}
+		case []interface{}:
+			for _, v := range value {
+				if v, ok := v.(string); ok {
+					values = appendUnique(values, v)
+				}
+			}
+		}
+	}
+	nonEmpty := values[:0]
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+	return nonEmpty
+}
+
+// redactVar masks a var holding or containing any of values
+func redactVar(value interface{}, values []string) interface{} {
+	switch value := value.(type) {
+	case string:
+		for _, sensitive := range values {
+			if strings.Contains(strings.ToLower(value), strings.ToLower(sensitive)) {
+				return RedactedValue
+			}
+		}
+		return value
+	case []string:
+		redacted := make([]string, len(value))
+		for i, v := range value {
+			redacted[i] = redactVar(v, values).(string)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for i, v := range value {
+			redacted[i] = redactVar(v, values)
+		}
+		return redacted
+	}
+	return value
+}
+
+// replaceFold masks every occurrence of value in text, ignoring case, since
+// normalization may have changed the case of the extracted value
+func replaceFold(text, value string) string {
+	return regexp.MustCompile(`(?i)`+regexp.QuoteMeta(value)).ReplaceAllLiteralString(text, RedactedValue)
+}
+
+// redactMatches masks what re matches in text: its first capture group when
+// it has one, else the whole match
+func redactMatches(re *regexp.Regexp, text string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(text, RedactedValue)
+	}
+	var redacted strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringSubmatchIndex(text, -1) {
+		if match[2] < 0 {
+			continue
+		}
+		redacted.WriteString(text[last:match[2]])
+		redacted.WriteString(RedactedValue)
+		last = match[3]
+	}
+	redacted.WriteString(text[last:])
+	return redacted.String()
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// sensitiveContactConfig is contactConfig with the email marked sensitive
+func sensitiveContactConfig() *models.IntentConfig {
+	config := contactConfig()
+	email := config.Entities["email"]
+	email.Sensitive = true
+	config.Entities["email"] = email
+	return config
+}
+
+func TestIntentService_RedactIntent(t *testing.T) {
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, sensitiveContactConfig())}
+	intent := &models.Intent{
+		Task: "CreateContact",
+		Vars: map[string]interface{}{
+			"name":    "Bob",
+			"email":   "bob@example.com",
+			"summary": "Bob <bob@example.com>", // e.g. a derived var
+			"cc":      []interface{}{"Alice", "BOB@example.com"},
+		},
+	}
+
+	redacted := service.RedactIntent(intent)
+	want := map[string]interface{}{"name": "Bob", "email": RedactedValue, "summary": RedactedValue}
+	for key, value := range want {
+		if redacted.Vars[key] != value {
+			t.Errorf("%s = %v, want %v", key, redacted.Vars[key], value)
+		}
+	}
+	if cc := redacted.Vars["cc"].([]interface{}); cc[0] != "Alice" || cc[1] != RedactedValue {
+		t.Errorf("cc = %v, want only the email masked", cc)
+	}
+	if intent.Vars["email"] != "bob@example.com" {
+		t.Errorf("original email = %v, want the intent left unchanged", intent.Vars["email"])
+	}
+}
+
+func TestIntentService_RedactText(t *testing.T) {
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, sensitiveContactConfig())}
+	text := "Add contact Bob, Bob@Example.com, cc alice@example.org"
+
+	intent, err := service.ExtractIntent(context.Background(), text)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	redacted := service.RedactText(text, intent)
+	if strings.Contains(strings.ToLower(redacted), "example") {
+		t.Errorf("RedactText() = %q, want every email masked", redacted)
+	}
+	if !strings.Contains(redacted, "Add contact Bob, "+RedactedValue) {
+		t.Errorf("RedactText() = %q, want the rest of the text kept", redacted)
+	}
+
+	// Without an intent the entity's regex still finds the email
+	if redacted := service.RedactText("mail bob@example.com", nil); redacted != "mail "+RedactedValue {
+		t.Errorf("RedactText() = %q, want the email masked", redacted)
+	}
+
+	// Nothing is masked without sensitive entities
+	plain := &IntentService{aiProvider: newTestEnhancedProvider(t, contactConfig())}
+	if redacted := plain.RedactText(text, intent); redacted != text {
+		t.Errorf("RedactText() = %q, want the text unchanged", redacted)
+	}
+}
+
+func TestIntentService_RedactWithoutConfigDrivenProvider(t *testing.T) {
+	// LLM providers take the sensitive flags from INTENT_CONFIG_PATH
+	service := &IntentService{aiProvider: &stubProvider{name: "stub"}, intentConfig: sensitiveContactConfig()}
+	intent := &models.Intent{Task: "CreateContact", Vars: map[string]interface{}{"email": "bob@example.com"}}
+
+	if redacted := service.RedactIntent(intent); redacted.Vars["email"] != RedactedValue {
+		t.Errorf("email = %v, want it masked", redacted.Vars["email"])
+	}
+	if redacted := service.RedactText("add bob@example.com", intent); redacted != "add "+RedactedValue {
+		t.Errorf("RedactText() = %q, want the email masked", redacted)
+	}
+}
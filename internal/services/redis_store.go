@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisConnectTimeout bounds the connection check when a RedisStore is created
+const redisConnectTimeout = 5 * time.Second
+
+// RedisStore is a Store kept in Redis, so its entries survive restarts and
+// are shared by every instance using the same server
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the Redis server at url, e.g.
+// "redis://:password@localhost:6379/0", and checks that it answers
+func NewRedisStore(url string) (*RedisStore, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisConnectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Redis not available at %s: %w", options.Addr, err)
+	}
+
+	return &RedisStore{client: client}, nil
+}
+
+// Get returns the value under key, or nil if there is none
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from Redis: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set stores value under key, letting Redis expire it after ttl
+func (r *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write %s to Redis: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes key
+func (r *RedisStore) Delete(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete %s from Redis: %w", key, err)
+	}
+	return nil
+}
+
+// Close closes the connections to Redis
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
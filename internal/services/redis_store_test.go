@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"myllm/internal/models"
+)
+
+// newTestRedisStore starts an in-process Redis server and connects a store to it
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	store, err := NewRedisStore("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("NewRedisStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, server
+}
+
+func TestRedisStore(t *testing.T) {
+	store, server := newTestRedisStore(t)
+
+	testStore(t, store, server.FastForward)
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	if _, err := NewRedisStore("redis://" + addr); err == nil {
+		t.Error("NewRedisStore() error = nil, want the failed connection reported")
+	}
+	if _, err := NewRedisStore("not a url"); err == nil {
+		t.Error("NewRedisStore() error = nil, want the invalid URL reported")
+	}
+}
+
+func TestIntentService_RedisSessions(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	conversation := Conversation{SessionID: "session-1"}
+	ctx := context.Background()
+
+	// Two services sharing the store stand in for two instances, or one
+	// instance before and after a restart
+	newService := func() *IntentService {
+		return &IntentService{
+			aiProvider:       newTestEnhancedProvider(t, eventConfig()),
+			sessions:         NewKeyValueSessionStore(store, time.Minute),
+			maxFollowUpDepth: DefaultMaxFollowUpDepth,
+		}
+	}
+
+	intent, err := newService().ExtractIntentWithContext(ctx, `schedule a meeting "Standup"`, conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" || len(intent.Missing) == 0 {
+		t.Fatalf("first turn = %s missing %v, want CreateEvent with missing fields", intent.Task, intent.Missing)
+	}
+
+	intent, err = newService().ExtractIntentWithContext(ctx, "tomorrow", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" || intent.Vars["date"] != "tomorrow" || intent.Vars["title"] != "Standup" {
+		t.Errorf("second turn = %s %v, want the session continued from the store", intent.Task, intent.Vars)
+	}
+}
+
+func TestIntentService_RedisCache(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	stub := &stubProvider{name: "stub", intent: &models.Intent{Task: "CreateNote", Vars: map[string]interface{}{"count": 2}}}
+	service := &IntentService{aiProvider: stub, cache: NewStoreIntentCache(store, time.Minute)}
+
+	for i := 0; i < 2; i++ {
+		intent, err := service.ExtractIntent(context.Background(), "add a note")
+		if err != nil {
+			t.Fatalf("ExtractIntent() error = %v", err)
+		}
+		if count := intent.Vars["count"]; intent.Task != "CreateNote" || count != 2 {
+			t.Errorf("intent = %+v, want CreateNote with count 2", intent)
+		}
+	}
+	if stub.calls != 1 {
+		t.Errorf("provider calls = %d, want the second answered from Redis", stub.calls)
+	}
+	if keys := server.Keys(); len(keys) != 1 || keys[0][:len(cacheKeyPrefix)] != cacheKeyPrefix {
+		t.Errorf("Redis keys = %v, want one %s key", keys, cacheKeyPrefix)
+	}
+	if ttl := server.TTL(server.Keys()[0]); ttl != time.Minute {
+		t.Errorf("TTL = %v, want the cache TTL", ttl)
+	}
+}
+
+func TestNewIntentService_SessionStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	tests := []struct {
+		name      string
+		store     string
+		redisURL  string
+		wantRedis bool
+		wantErr   bool
+	}{
+		{name: "memory by default"},
+		{name: "redis", store: "redis", redisURL: "redis://" + server.Addr(), wantRedis: true},
+		{name: "redis unavailable", store: "redis", redisURL: "redis://127.0.0.1:1", wantErr: true},
+		{name: "unknown", store: "memcached", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AI_PROVIDER", "local")
+			t.Setenv("CACHE_SIZE", "10")
+			t.Setenv("SESSION_STORE", tt.store)
+			t.Setenv("REDIS_URL", tt.redisURL)
+
+			service, err := NewIntentService()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewIntentService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			_, sessionsInRedis := service.sessions.(*KeyValueSessionStore).store.(*RedisStore)
+			_, cacheInRedis := service.cache.store.(*RedisStore)
+			if sessionsInRedis != tt.wantRedis || cacheInRedis != tt.wantRedis {
+				t.Errorf("sessions in Redis %v, cache in Redis %v, want %v", sessionsInRedis, cacheInRedis, tt.wantRedis)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import "fmt"
+
+// Defaults for REGEX_MAX_LENGTH and REGEX_MAX_PATTERNS
+const (
+	DefaultMaxRegexLength   = 1000
+	DefaultMaxRegexPatterns = 50
+)
+
+// regexLimits bounds the regexes a config may define. Go's regexp runs in
+// time linear in the text, so a pattern can't backtrack catastrophically,
+// but its compile time, memory and per-match cost grow with its size.
+type regexLimits struct {
+	maxLength   int // Characters per pattern; 0 for no limit
+	maxPatterns int // Patterns per intent or entity; 0 for no limit
+}
+
+// currentRegexLimits reads REGEX_MAX_LENGTH and REGEX_MAX_PATTERNS
+func currentRegexLimits() regexLimits {
+	return regexLimits{
+		maxLength:   getIntEnvVar("REGEX_MAX_LENGTH", DefaultMaxRegexLength),
+		maxPatterns: getIntEnvVar("REGEX_MAX_PATTERNS", DefaultMaxRegexPatterns),
+	}
+}
+
+// check reports the patterns of owner, e.g. "intent CreateNote", that break
+// the limits, and returns the ones short enough to compile
+func (l regexLimits) check(owner string, patterns []string) ([]string, []error) {
+	var errs []error
+	if l.maxPatterns > 0 && len(patterns) > l.maxPatterns {
+		errs = append(errs, fmt.Errorf("invalid regex for %s: %d patterns exceed REGEX_MAX_PATTERNS (%d)", owner, len(patterns), l.maxPatterns))
+	}
+	if l.maxLength <= 0 {
+		return patterns, errs
+	}
+	allowed := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) > l.maxLength {
+			errs = append(errs, fmt.Errorf("invalid regex for %s: pattern of %d characters exceeds REGEX_MAX_LENGTH (%d)", owner, len(pattern), l.maxLength))
+			continue
+		}
+		allowed = append(allowed, pattern)
+	}
+	return allowed, errs
+}
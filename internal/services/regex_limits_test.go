@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestCompileConfig_RegexLimits(t *testing.T) {
+	oversized := "(?i)note " + strings.Repeat("a", DefaultMaxRegexLength)
+	tooMany := make([]string, DefaultMaxRegexPatterns+1)
+	for i := range tooMany {
+		tooMany[i] = "(?i)note"
+	}
+
+	tests := []struct {
+		name       string
+		maxLength  string
+		intent     []string
+		entity     []string
+		wantErrors []string
+	}{
+		{name: "within limits", intent: []string{"(?i)^note:"}, entity: []string{`(\d+)`}},
+		{name: "oversized intent regex", intent: []string{oversized}, wantErrors: []string{"intent CreateNote: pattern of 1009 characters exceeds REGEX_MAX_LENGTH (1000)"}},
+		{name: "oversized entity regex", entity: []string{"(" + oversized + ")"}, wantErrors: []string{"entity count: pattern of 1011 characters exceeds REGEX_MAX_LENGTH"}},
+		{name: "too many regexes", intent: tooMany, wantErrors: []string{"intent CreateNote: 51 patterns exceed REGEX_MAX_PATTERNS (50)"}},
+		{name: "lower limit", maxLength: "5", intent: []string{"(?i)^note:"}, wantErrors: []string{"exceeds REGEX_MAX_LENGTH (5)"}},
+		{name: "no limit", maxLength: "0", intent: []string{oversized}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REGEX_MAX_LENGTH", tt.maxLength)
+			config := &models.IntentConfig{
+				Domain:   "test",
+				Intents:  map[string]models.IntentPattern{"CreateNote": {Regex: tt.intent}},
+				Entities: map[string]models.EntityPattern{"count": {Regex: tt.entity}},
+			}
+
+			_, err := compileConfig(config)
+			if len(tt.wantErrors) == 0 {
+				if err != nil {
+					t.Errorf("compileConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("compileConfig() error = nil, want %q", tt.wantErrors)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("compileConfig() error = %v, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
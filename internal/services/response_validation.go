@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"myllm/internal/logging"
+	"myllm/internal/models"
+)
+
+// Provider response validation modes
+const (
+	ResponseValidationOff    = "off"    // Trust provider responses as-is
+	ResponseValidationWarn   = "warn"   // Keep the response but attach warnings
+	ResponseValidationReject = "reject" // Fail the extraction
+)
+
+// LLM task validation modes, see IntentService.validateTask
+const (
+	TaskValidationOff     = "off"     // Pass tasks through
+	TaskValidationUnknown = "unknown" // Report unknown tasks as UNKNOWN, or as the config's fallback intent
+	TaskValidationFlag    = "flag"    // Keep unknown tasks and set the task_unknown var
+)
+
+// taskUnknownVar is set to true on intents whose task isn't configured, in flag mode
+const taskUnknownVar = "task_unknown"
+
+// ErrInvalidProviderResponse is returned when a provider response fails validation in reject mode
+var ErrInvalidProviderResponse = errors.New("invalid provider response")
+
+// validateProviderResponse checks a provider's intent against the config: the
+// task must be a configured intent (or UNKNOWN) and non-empty vars must be
+// declared variables of that intent holding scalar values. A task that only
+// differs in spelling ("CREATE_CONTACT" vs "CreateContact") is rewritten to
+// the configured name. It returns the problems found.
+func validateProviderResponse(intent *models.Intent, config *models.IntentConfig) []string {
+	if intent.Task == "UNKNOWN" {
+		return nil
+	}
+
+	var problems []string
+
+	task, exists := configuredTask(intent.Task, config)
+	if !exists {
+		return append(problems, fmt.Sprintf("unknown task %q", intent.Task))
+	}
+	intent.Task = task
+	pattern := config.Intents[task]
+
+	declared := make(map[string]bool, len(pattern.Variables))
+	for _, variable := range pattern.Variables {
+		declared[variable] = true
+	}
+
+	keys := make([]string, 0, len(intent.Vars))
+	for key := range intent.Vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := intent.Vars[key]
+		if value == nil || value == "" || key == "confidence" {
+			continue
+		}
+		if !declared[key] {
+			problems = append(problems, fmt.Sprintf("undeclared variable %q for task %s", key, intent.Task))
+			continue
+		}
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			problems = append(problems, fmt.Sprintf("variable %q must be a string, number or boolean", key))
+		}
+	}
+
+	return problems
+}
+
+// configuredTask returns the configured intent task names, allowing for
+// spelling differences such as "CREATE_CONTACT" for "CreateContact"
+func configuredTask(task string, config *models.IntentConfig) (string, bool) {
+	if _, exists := config.Intents[task]; exists {
+		return task, true
+	}
+	for intentName := range config.Intents {
+		if canonicalCommandName(intentName) == canonicalCommandName(task) {
+			return intentName, true
+		}
+	}
+	return "", false
+}
+
+// validateTask applies VALIDATE_LLM_TASK to a provider response: a task
+// spelled differently from its intent is renamed, and one that isn't
+// configured at all is replaced or flagged. Config-driven providers are
+// trusted since they only produce configured intents.
+func (s *IntentService) validateTask(ctx context.Context, intent *models.Intent) {
+	if s.schema == nil || s.taskValidation == TaskValidationOff || intent.Task == "UNKNOWN" {
+		return
+	}
+	if _, ok := s.aiProvider.(ConfigurableProvider); ok {
+		return
+	}
+
+	if task, exists := configuredTask(intent.Task, s.schema); exists {
+		intent.Task = task
+		return
+	}
+	logging.FromContext(ctx).Warn("Provider returned an unknown task", "provider", s.GetAIProviderName(),
+		"task", intent.Task, "mode", s.taskValidation)
+
+	switch s.taskValidation {
+	case TaskValidationFlag:
+		if intent.Vars == nil {
+			intent.Vars = make(map[string]interface{})
+		}
+		intent.Vars[taskUnknownVar] = true
+	case TaskValidationUnknown:
+		// The missing fields and questions were the unknown task's
+		intent.Task = "UNKNOWN"
+		intent.Missing = nil
+		intent.FollowUp = nil
+		intent.IsComplete = false
+		if s.schema.FallbackIntent != "" {
+			intent.Task = s.schema.FallbackIntent
+			intent.FollowUp = append([]string(nil), s.schema.FallbackFollowUp...)
+		}
+	}
+}
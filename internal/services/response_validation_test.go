@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// hallucinatedIntent returns a provider response with the given task and an invented variable
+func hallucinatedIntent(task string) *models.Intent {
+	return &models.Intent{
+		Task: task,
+		Vars: map[string]interface{}{
+			"name":          "Alice",
+			"favorite_food": "pizza",
+			"email":         "",
+		},
+	}
+}
+
+func TestValidateProviderResponse(t *testing.T) {
+	config := contactConfig()
+
+	tests := []struct {
+		name         string
+		intent       *models.Intent
+		wantTask     string
+		wantProblems int
+	}{
+		{
+			name:     "declared vars only",
+			intent:   &models.Intent{Task: "CreateContact", Vars: map[string]interface{}{"name": "Alice", "confidence": 0.9}},
+			wantTask: "CreateContact",
+		},
+		{
+			name:     "task spelling is normalized",
+			intent:   &models.Intent{Task: "CREATE_CONTACT", Vars: map[string]interface{}{"name": "Alice"}},
+			wantTask: "CreateContact",
+		},
+		{
+			name:         "unknown task",
+			intent:       hallucinatedIntent("BOOK_FLIGHT"),
+			wantTask:     "BOOK_FLIGHT",
+			wantProblems: 1,
+		},
+		{
+			name:         "undeclared variable",
+			intent:       hallucinatedIntent("CreateContact"),
+			wantTask:     "CreateContact",
+			wantProblems: 1,
+		},
+		{
+			name:         "non-scalar value",
+			intent:       &models.Intent{Task: "CreateContact", Vars: map[string]interface{}{"name": map[string]interface{}{"first": "Alice"}}},
+			wantTask:     "CreateContact",
+			wantProblems: 1,
+		},
+		{
+			name:     "unknown intent is always allowed",
+			intent:   &models.Intent{Task: "UNKNOWN", Vars: map[string]interface{}{"anything": "goes"}},
+			wantTask: "UNKNOWN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateProviderResponse(tt.intent, config)
+			if len(problems) != tt.wantProblems {
+				t.Errorf("problems = %v, want %d", problems, tt.wantProblems)
+			}
+			if tt.intent.Task != tt.wantTask {
+				t.Errorf("Task = %v, want %v", tt.intent.Task, tt.wantTask)
+			}
+		})
+	}
+}
+
+func TestIntentService_ResponseValidationModes(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		wantErr      bool
+		wantWarnings int
+	}{
+		{"off trusts the response", ResponseValidationOff, false, 0},
+		{"warn flags problems", ResponseValidationWarn, false, 1},
+		{"reject fails extraction", ResponseValidationReject, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &IntentService{
+				aiProvider:         &stubProvider{name: "remote", intent: hallucinatedIntent("BOOK_FLIGHT")},
+				responseValidation: tt.mode,
+				schema:             contactConfig(),
+			}
+
+			intent, err := service.ExtractIntent(context.Background(), "book me a flight")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidProviderResponse) {
+					t.Fatalf("error = %v, want ErrInvalidProviderResponse", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if len(intent.Warnings) != tt.wantWarnings {
+				t.Errorf("Warnings = %v, want %d", intent.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestIntentService_TaskValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		fallback string
+		task     string
+		wantTask string
+		wantFlag bool
+	}{
+		{name: "known task", mode: TaskValidationUnknown, task: "CreateContact", wantTask: "CreateContact"},
+		{name: "known task spelled differently", mode: TaskValidationFlag, task: "create_contact", wantTask: "CreateContact"},
+		{name: "off passes unknown tasks", mode: TaskValidationOff, task: "BOOK_FLIGHT", wantTask: "BOOK_FLIGHT"},
+		{name: "unknown task becomes UNKNOWN", mode: TaskValidationUnknown, task: "BOOK_FLIGHT", wantTask: "UNKNOWN"},
+		{name: "unknown task becomes the fallback", mode: TaskValidationUnknown, fallback: "Clarify", task: "BOOK_FLIGHT", wantTask: "Clarify"},
+		{name: "unknown task is flagged", mode: TaskValidationFlag, task: "BOOK_FLIGHT", wantTask: "BOOK_FLIGHT", wantFlag: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := contactConfig()
+			schema.FallbackIntent = tt.fallback
+			schema.FallbackFollowUp = []string{"Could you rephrase that?"}
+			returned := hallucinatedIntent(tt.task)
+			returned.Missing = []string{"email"}
+			returned.FollowUp = []string{"What is their email?"}
+			service := &IntentService{
+				aiProvider:     &stubProvider{name: "remote", intent: returned},
+				taskValidation: tt.mode,
+				schema:         schema,
+			}
+
+			intent, err := service.ExtractIntent(context.Background(), "book me a flight")
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != tt.wantTask {
+				t.Errorf("Task = %v, want %v", intent.Task, tt.wantTask)
+			}
+			if flagged := intent.Vars[taskUnknownVar] == true; flagged != tt.wantFlag {
+				t.Errorf("Vars[%s] = %v, want %v", taskUnknownVar, intent.Vars[taskUnknownVar], tt.wantFlag)
+			}
+			if tt.wantTask != tt.task && tt.mode == TaskValidationUnknown {
+				if len(intent.Missing) != 0 || intent.IsComplete {
+					t.Errorf("Missing = %v, IsComplete = %v, want the unknown task's cleared", intent.Missing, intent.IsComplete)
+				}
+				if tt.fallback != "" && len(intent.FollowUp) != 1 {
+					t.Errorf("FollowUp = %v, want the fallback's", intent.FollowUp)
+				}
+			}
+		})
+	}
+}
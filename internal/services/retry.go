@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"myllm/internal/logging"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Retry defaults for provider calls
+const (
+	DefaultMaxRetries   = 2
+	DefaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 10 * time.Second
+)
+
+// providerStatusError reports a non-200 response from a provider API
+type providerStatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *providerStatusError) Error() string {
+	return fmt.Sprintf("%s API error %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// retryWithBackoff runs operation, retrying transient failures up to maxRetries
+// times. The wait doubles from initialBackoff with jitter, and retrying stops
+// early when the next attempt could not start before ctx's deadline.
+func retryWithBackoff(ctx context.Context, maxRetries int, initialBackoff time.Duration, operation func() error) error {
+	if initialBackoff <= 0 {
+		initialBackoff = DefaultRetryBackoff
+	}
+	backoff := initialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= maxRetries || ctx.Err() != nil || !isRetryableError(err) {
+			return err
+		}
+
+		// Equal jitter: wait between half and all of the current backoff
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+
+		logging.FromContext(ctx).Debug("Retrying provider call", "attempt", attempt+1, "wait", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// isRetryableError reports whether err is transient: a 429 or 5xx response, or
+// a network failure. Other 4xx responses are deterministic and not retried.
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *providerStatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.StatusCode)
+	}
+	// RequestError can wrap an APIError without a status, so check it first
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return isRetryableStatus(requestErr.HTTPStatusCode)
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// isRetryableStatus reports whether an HTTP status is worth retrying
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
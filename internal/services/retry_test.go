@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// flakyServer answers /api/tags immediately and fails the first failures
+// calls to every other path with status before serving body
+func flakyServer(t *testing.T, failures int32, status int, body string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+		if atomic.AddInt32(&calls, 1) <= failures {
+			http.Error(w, `{"error": "try again"}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+const ollamaSuccessBody = `{"response": "{\"task\": \"CREATE_CONTACT\", \"vars\": {\"name\": \"Alice\"}}", "done": true}`
+
+func TestOllamaProvider_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		status    int
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "fails twice then succeeds", failures: 2, status: http.StatusServiceUnavailable, wantCalls: 3},
+		{name: "rate limited twice then succeeds", failures: 2, status: http.StatusTooManyRequests, wantCalls: 3},
+		{name: "persistent 5xx gives up", failures: 10, status: http.StatusBadGateway, wantErr: true, wantCalls: 3},
+		{name: "4xx is not retried", failures: 10, status: http.StatusBadRequest, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := flakyServer(t, tt.failures, tt.status, ollamaSuccessBody)
+			provider, err := NewOllamaProvider(AIProviderConfig{
+				BaseURL:      server.URL,
+				MaxRetries:   2,
+				RetryBackoff: time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewOllamaProvider() error = %v", err)
+			}
+
+			intent, err := provider.ExtractIntent(context.Background(), "add contact Alice")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractIntent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("generate calls = %d, want %d", got, tt.wantCalls)
+			}
+			if !tt.wantErr && intent.Task != "CREATE_CONTACT" {
+				t.Errorf("Task = %q, want CREATE_CONTACT", intent.Task)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_RetriesTransientFailures(t *testing.T) {
+	body := `{"choices": [{"message": {"role": "assistant", "content": "{\"task\": \"CREATE_CONTACT\", \"vars\": {}}"}}]}`
+	server, calls := flakyServer(t, 2, http.StatusInternalServerError, body)
+
+	provider, err := NewOpenAIProvider(AIProviderConfig{
+		APIKey:       "test-key",
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error = %v", err)
+	}
+	clientConfig := openai.DefaultConfig("test-key")
+	clientConfig.BaseURL = server.URL + "/v1"
+	provider.(*OpenAIProvider).client = openai.NewClientWithConfig(clientConfig)
+
+	intent, err := provider.ExtractIntent(context.Background(), "add contact Alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CREATE_CONTACT" {
+		t.Errorf("Task = %q, want CREATE_CONTACT", intent.Task)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("chat completion calls = %d, want 3", got)
+	}
+}
+
+func TestRetryWithBackoff_RespectsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	transient := &providerStatusError{Provider: "Test", StatusCode: http.StatusServiceUnavailable}
+	start := time.Now()
+	err := retryWithBackoff(ctx, 5, time.Minute, func() error {
+		calls++
+		return transient
+	})
+
+	if !errors.Is(err, transient) {
+		t.Errorf("retryWithBackoff() error = %v, want last operation error", err)
+	}
+	if calls != 1 {
+		t.Errorf("operation calls = %d, want 1 when the backoff outlasts the deadline", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retryWithBackoff() took %v, want it to give up immediately", elapsed)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "429", err: &providerStatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "503", err: &providerStatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "401", err: &providerStatusError{StatusCode: http.StatusUnauthorized}, want: false},
+		{name: "openai 429", err: &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "openai 400", err: &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "other", err: errors.New("failed to parse response"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"myllm/internal/logging"
+	"myllm/internal/models"
+)
+
+// RoutingConfig holds rules for picking between a local and a remote provider
+type RoutingConfig struct {
+	LocalProvider  string   // Provider type for cheap inputs (default "enhanced_local")
+	RemoteProvider string   // Provider type for complex inputs (default "openai")
+	MaxLocalChars  int      // Inputs longer than this go remote (0 = no limit)
+	MaxLocalTokens int      // Inputs with more tokens than this go remote (0 = no limit)
+	LocalKeywords  []string // If set, short inputs must contain one of these to stay local
+	RemoteKeywords []string // Inputs containing any of these always go remote
+}
+
+// Provider routes
+const (
+	RouteLocal  = "local"
+	RouteRemote = "remote"
+)
+
+// RouterProvider implements AIProvider by routing each input to a local or remote provider
+type RouterProvider struct {
+	local  AIProvider
+	remote AIProvider
+	rules  RoutingConfig
+}
+
+// NewRouterProvider creates a router over the given providers. remote may be nil,
+// in which case every input is handled locally.
+func NewRouterProvider(local, remote AIProvider, rules RoutingConfig) (*RouterProvider, error) {
+	if local == nil {
+		return nil, fmt.Errorf("router requires a local provider")
+	}
+
+	return &RouterProvider{
+		local:  local,
+		remote: remote,
+		rules:  rules,
+	}, nil
+}
+
+// Route decides which provider should handle the given text
+func (p *RouterProvider) Route(text string) string {
+	if p.remote == nil {
+		return RouteLocal
+	}
+
+	tokens := strings.Fields(text)
+	words := routingWords(text)
+
+	// Explicit remote keywords always win
+	if containsAnyWord(words, p.rules.RemoteKeywords) {
+		return RouteRemote
+	}
+
+	// Long inputs are likely complex
+	if p.rules.MaxLocalChars > 0 && len(text) > p.rules.MaxLocalChars {
+		return RouteRemote
+	}
+	if p.rules.MaxLocalTokens > 0 && len(tokens) > p.rules.MaxLocalTokens {
+		return RouteRemote
+	}
+
+	// Short inputs stay local unless they lack every known command keyword
+	if len(p.rules.LocalKeywords) > 0 && !containsAnyWord(words, p.rules.LocalKeywords) {
+		return RouteRemote
+	}
+
+	return RouteLocal
+}
+
+// ExtractIntent extracts intent using the provider selected by Route
+func (p *RouterProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	if p.Route(text) == RouteLocal {
+		return p.local.ExtractIntent(ctx, text)
+	}
+
+	intent, err := p.remote.ExtractIntent(ctx, text)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Remote provider failed, falling back to local",
+			"remote", p.remote.Name(), "local", p.local.Name(), "error", err)
+		return p.local.ExtractIntent(ctx, text)
+	}
+
+	return intent, nil
+}
+
+// Name returns the provider name
+func (p *RouterProvider) Name() string {
+	if p.remote == nil {
+		return fmt.Sprintf("Router (local: %s)", p.local.Name())
+	}
+	return fmt.Sprintf("Router (local: %s, remote: %s)", p.local.Name(), p.remote.Name())
+}
+
+// IsAvailable checks if the router can serve requests
+func (p *RouterProvider) IsAvailable() bool {
+	return p.local.IsAvailable()
+}
+
+// routingWords lowercases text and splits it into words, dropping punctuation
+func routingWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// containsAnyWord reports whether any keyword appears as a word (or word sequence) in words
+func containsAnyWord(words []string, keywords []string) bool {
+	joined := " " + strings.Join(words, " ") + " "
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && strings.Contains(joined, " "+keyword+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestRouter(t *testing.T, remote AIProvider) (*RouterProvider, *stubProvider) {
+	t.Helper()
+
+	local := &stubProvider{name: "local"}
+	router, err := NewRouterProvider(local, remote, RoutingConfig{
+		MaxLocalChars:  60,
+		MaxLocalTokens: 8,
+		LocalKeywords:  []string{"create", "add", "delete", "find"},
+		RemoteKeywords: []string{"explain"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouterProvider() error = %v", err)
+	}
+	return router, local
+}
+
+func TestRouterProvider_Route(t *testing.T) {
+	router, _ := newTestRouter(t, &stubProvider{name: "remote"})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"short known command", "add contact Bob", RouteLocal},
+		{"keyword with punctuation", "Delete, Alice!", RouteLocal},
+		{"short without known keyword", "what about bob", RouteRemote},
+		{"remote keyword", "explain contacts", RouteRemote},
+		{"too many tokens", "add a contact for the guy I met at the conference last week", RouteRemote},
+		{"too many characters", "create supercalifragilisticexpialidocious-antidisestablishmentarianism", RouteRemote},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := router.Route(tt.input); got != tt.expected {
+				t.Errorf("Route(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRouterProvider_ExtractIntent(t *testing.T) {
+	remote := &stubProvider{name: "remote"}
+	router, local := newTestRouter(t, remote)
+	ctx := context.Background()
+
+	if _, err := router.ExtractIntent(ctx, "add contact Bob"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if local.calls != 1 || remote.calls != 0 {
+		t.Errorf("short command: local calls = %d, remote calls = %d, want 1 and 0", local.calls, remote.calls)
+	}
+
+	if _, err := router.ExtractIntent(ctx, "hmm so I guess I should probably get in touch with whoever it was"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if local.calls != 1 || remote.calls != 1 {
+		t.Errorf("long ambiguous input: local calls = %d, remote calls = %d, want 1 and 1", local.calls, remote.calls)
+	}
+}
+
+func TestRouterProvider_RemoteFailureFallsBackToLocal(t *testing.T) {
+	remote := &stubProvider{name: "remote", err: errors.New("rate limited")}
+	router, local := newTestRouter(t, remote)
+
+	if _, err := router.ExtractIntent(context.Background(), "explain my contacts"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if remote.calls != 1 || local.calls != 1 {
+		t.Errorf("remote calls = %d, local calls = %d, want 1 and 1", remote.calls, local.calls)
+	}
+}
+
+func TestRouterProvider_NoRemoteRoutesLocal(t *testing.T) {
+	router, _ := newTestRouter(t, nil)
+
+	if got := router.Route("explain everything about all of my contacts please"); got != RouteLocal {
+		t.Errorf("Route() = %v, want %v", got, RouteLocal)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sort"
+)
+
+// ExampleResult is the outcome of classifying one of an intent's examples
+type ExampleResult struct {
+	Language     string  // Language of the config that lists the example
+	Intent       string  // Intent that lists the example
+	Example      string  // Example text
+	ExpectedTask string  // Task the example should classify as
+	Task         string  // Task it classified as
+	Confidence   float64 // Confidence of Task
+	Err          error   // Extraction error, if any
+}
+
+// Passed reports whether the example classified as its own intent
+func (r ExampleResult) Passed() bool {
+	return r.Err == nil && r.Task == r.ExpectedTask
+}
+
+// RunExamples classifies the examples of every enabled intent, in every
+// language, and reports how each one classified. Results are ordered by
+// language, then intent, then the example's position in the config.
+func (p *EnhancedLocalProvider) RunExamples(ctx context.Context) []ExampleResult {
+	configs := p.snapshot().allConfigs()
+
+	var results []ExampleResult
+	for _, language := range sortedKeys(configs) {
+		config := configs[language]
+		languageCtx := WithLanguage(ctx, language)
+		for _, intentName := range sortedKeys(config.Intents) {
+			pattern := config.Intents[intentName]
+			if !pattern.IsEnabled() {
+				continue
+			}
+			for _, example := range pattern.Examples {
+				result := ExampleResult{
+					Language:     language,
+					Intent:       intentName,
+					Example:      example,
+					ExpectedTask: config.TaskName(intentName),
+				}
+				intent, err := p.ExtractIntent(languageCtx, example)
+				if err != nil {
+					result.Err = err
+				} else {
+					result.Task, result.Confidence = intent.Task, intent.Confidence
+				}
+				results = append(results, result)
+			}
+		}
+	}
+	return results
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
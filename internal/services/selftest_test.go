@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnhancedLocalProvider_RunExamples(t *testing.T) {
+	config := contactAndEventConfig()
+	createContact := config.Intents["CreateContact"]
+	createContact.Examples = []string{"add contact Alice", "schedule a meeting tomorrow"}
+	config.Intents["CreateContact"] = createContact
+	createEvent := config.Intents["CreateEvent"]
+	createEvent.Examples = []string{"schedule a meeting tomorrow"}
+	config.Intents["CreateEvent"] = createEvent
+	config.Aliases = map[string]string{"CreateEvent": "NEW_EVENT"}
+	// Exact matching would classify every example as its own intent
+	config.ExactMatch.Disabled = true
+	provider := newTestEnhancedProvider(t, config)
+
+	results := provider.RunExamples(context.Background())
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3: %+v", len(results), results)
+	}
+
+	want := []struct {
+		intent   string
+		example  string
+		expected string
+		passed   bool
+	}{
+		{intent: "CreateContact", example: "add contact Alice", expected: "CreateContact", passed: true},
+		{intent: "CreateContact", example: "schedule a meeting tomorrow", expected: "CreateContact", passed: false},
+		{intent: "CreateEvent", example: "schedule a meeting tomorrow", expected: "NEW_EVENT", passed: true},
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Intent != w.intent || got.Example != w.example || got.ExpectedTask != w.expected {
+			t.Errorf("results[%d] = %+v, want %s example %q expecting %s", i, got, w.intent, w.example, w.expected)
+		}
+		if got.Passed() != w.passed {
+			t.Errorf("results[%d].Passed() = %v (task %s), want %v", i, got.Passed(), got.Task, w.passed)
+		}
+	}
+	if results[1].Task != "NEW_EVENT" || results[1].Confidence <= 0 {
+		t.Errorf("misclassified example = %+v, want task NEW_EVENT with its confidence", results[1])
+	}
+}
+
+func TestEnhancedLocalProvider_RunExamplesSkipsDisabledIntents(t *testing.T) {
+	config := contactAndEventConfig()
+	disabled := false
+	createEvent := config.Intents["CreateEvent"]
+	createEvent.Examples = []string{"schedule a meeting tomorrow"}
+	createEvent.Enabled = &disabled
+	config.Intents["CreateEvent"] = createEvent
+	provider := newTestEnhancedProvider(t, config)
+
+	for _, result := range provider.RunExamples(context.Background()) {
+		if result.Intent == "CreateEvent" {
+			t.Errorf("ran example of disabled intent: %+v", result)
+		}
+	}
+}
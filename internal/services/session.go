@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"myllm/internal/logging"
+	"myllm/internal/models"
+)
+
+// Session defaults
+const (
+	DefaultSessionTTL       = 30 * time.Minute
+	DefaultMaxFollowUpDepth = 5
+)
+
+// Conversation carries the state a client sends with a multi-turn request
+type Conversation struct {
+	SessionID string                 // Session to continue; empty for a stateless request
+	Context   map[string]interface{} // Vars collected in earlier turns
+}
+
+// Session is the conversation state kept between turns
+type Session struct {
+	ID        string         `json:"id"`
+	Intent    *models.Intent `json:"intent"` // Last intent returned in the session
+	Depth     int            `json:"depth"`  // Follow-up answers received for Intent
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// SessionStore keeps sessions between requests. Implementations must be safe
+// for concurrent use.
+type SessionStore interface {
+	// Get returns the session with the given ID, or nil if there is none
+	Get(ctx context.Context, id string) (*Session, error)
+	// Save creates or replaces a session
+	Save(ctx context.Context, session *Session) error
+	// Delete removes a session; deleting an unknown session is not an error
+	Delete(ctx context.Context, id string) error
+}
+
+// sessionKeyPrefix namespaces session keys in a shared Store
+const sessionKeyPrefix = "session:"
+
+// KeyValueSessionStore keeps sessions as JSON in a Store until they have been
+// idle for the TTL
+type KeyValueSessionStore struct {
+	store Store
+	ttl   time.Duration
+}
+
+// NewKeyValueSessionStore creates a session store over store whose sessions
+// expire after ttl without activity
+func NewKeyValueSessionStore(store Store, ttl time.Duration) *KeyValueSessionStore {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &KeyValueSessionStore{store: store, ttl: ttl}
+}
+
+// NewMemorySessionStore creates a session store kept in memory. Sessions are
+// lost on restart and not shared between instances.
+func NewMemorySessionStore(ttl time.Duration) *KeyValueSessionStore {
+	return NewKeyValueSessionStore(NewMemoryStore(0), ttl)
+}
+
+// Get returns the session, or nil if it is unknown or expired
+func (k *KeyValueSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	encoded, err := k.store.Get(ctx, sessionKeyPrefix+id)
+	if err != nil || encoded == nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(encoded, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", id, err)
+	}
+	return &session, nil
+}
+
+// Save stores the session, stamped with the current time, for another TTL
+func (k *KeyValueSessionStore) Save(ctx context.Context, session *Session) error {
+	stored := *session
+	stored.UpdatedAt = time.Now()
+	encoded, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", session.ID, err)
+	}
+	return k.store.Set(ctx, sessionKeyPrefix+session.ID, encoded, k.ttl)
+}
+
+// Delete removes the session
+func (k *KeyValueSessionStore) Delete(ctx context.Context, id string) error {
+	return k.store.Delete(ctx, sessionKeyPrefix+id)
+}
+
+// copyIntent returns a copy of intent with its own Vars map and slices
+func copyIntent(intent *models.Intent) *models.Intent {
+	copied := *intent
+	copied.Vars = make(map[string]interface{}, len(intent.Vars))
+	for key, value := range intent.Vars {
+		copied.Vars[key] = value
+	}
+	copied.Missing = append([]string(nil), intent.Missing...)
+	copied.FollowUp = append([]string(nil), intent.FollowUp...)
+	copied.Warnings = append([]string(nil), intent.Warnings...)
+	copied.Alternatives = append([]models.IntentCandidate(nil), intent.Alternatives...)
+	if intent.Spans != nil {
+		copied.Spans = make(map[string][]models.Span, len(intent.Spans))
+		for name, spans := range intent.Spans {
+			copied.Spans[name] = append([]models.Span(nil), spans...)
+		}
+	}
+	return &copied
+}
+
+// ExtractIntentWithContext extracts intent like ExtractIntent, then merges in
+// the conversation so far: vars from the client's context and, for a session
+// with a pending follow-up, the previous intent. A reply that names no known
+// intent, such as just "tomorrow", answers the first missing field. Missing,
+// FollowUp and IsComplete are recalculated for the merged intent.
+func (s *IntentService) ExtractIntentWithContext(ctx context.Context, text string, conversation Conversation) (*models.Intent, error) {
+	intent, err := s.extractIntent(ctx, text, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty text neither answers nor uses up a follow-up turn
+	if (conversation.SessionID != "" || len(conversation.Context) > 0) && !isEmptyInput(intent) {
+		intent = s.mergeConversation(ctx, text, intent, conversation)
+	}
+
+	s.dispatchWebhook(intent)
+	return intent, nil
+}
+
+// mergeConversation combines a freshly extracted intent with the session and
+// client context, enforcing the follow-up depth cap
+func (s *IntentService) mergeConversation(ctx context.Context, text string, fresh *models.Intent, conversation Conversation) *models.Intent {
+	logger := logging.FromContext(ctx)
+
+	var previous *Session
+	if conversation.SessionID != "" && s.sessions != nil {
+		var err error
+		previous, err = s.sessions.Get(ctx, conversation.SessionID)
+		if err != nil {
+			logger.Warn("Failed to load session, continuing without it", "session_id", conversation.SessionID, "error", err)
+		}
+	}
+
+	// The text continues the previous intent when that intent is waiting on a
+	// follow-up and the text doesn't start a different one
+	result := fresh
+	depth := 0
+	continuing := previous != nil && previous.Intent != nil && len(previous.Intent.Missing) > 0 &&
+		(s.Unrecognized(fresh.Task) || fresh.Task == previous.Intent.Task)
+	if continuing {
+		result = copyIntent(previous.Intent)
+		pending := previous.Intent.Missing
+		answered := false
+		for key, value := range fresh.Vars {
+			if isEmptyVar(value) {
+				continue
+			}
+			result.Vars[key] = value
+			answered = answered || slices.Contains(pending, key)
+		}
+		// Nothing recognizable was extracted, so the text itself is the answer
+		if !answered {
+			result.Vars[pending[0]] = strings.TrimSpace(text)
+		}
+		depth = previous.Depth + 1
+		// Spans point into this turn's text, not the earlier ones
+		result.Spans = fresh.Spans
+	}
+
+	// Client-supplied context fills gaps but never overrides this turn's values
+	for key, value := range conversation.Context {
+		if existing, exists := result.Vars[key]; !exists || isEmptyVar(existing) {
+			result.Vars[key] = value
+		}
+	}
+
+	if result.Task != "UNKNOWN" {
+		s.recalculateMissing(result)
+	}
+
+	// Stop asking once the intent has used up its follow-up turns
+	if continuing && s.maxFollowUpDepth > 0 && depth >= s.maxFollowUpDepth && !result.IsComplete {
+		result.MaxDepthReached = true
+		result.FollowUp = nil
+	}
+
+	if conversation.SessionID != "" && s.sessions != nil {
+		var err error
+		if result.MaxDepthReached {
+			err = s.sessions.Delete(ctx, conversation.SessionID)
+		} else {
+			err = s.sessions.Save(ctx, &Session{ID: conversation.SessionID, Intent: result, Depth: depth})
+		}
+		if err != nil {
+			logger.Warn("Failed to update session", "session_id", conversation.SessionID, "error", err)
+		}
+	}
+
+	return result
+}
+
+// Unrecognized reports whether task means the input matched no intent:
+// UNKNOWN, or the config's fallback_intent
+func (s *IntentService) Unrecognized(task string) bool {
+	if task == "UNKNOWN" {
+		return true
+	}
+	config, ok := s.GetIntentConfig()
+	return ok && config.FallbackIntent != "" && task == config.FallbackIntent
+}
+
+// recalculateMissing refreshes Missing, FollowUp and IsComplete after vars
+// were merged. Config-driven intents are re-checked against their required
+// fields; otherwise fields that now have values are dropped from Missing.
+func (s *IntentService) recalculateMissing(intent *models.Intent) {
+	if enhanced, ok := s.aiProvider.(*EnhancedLocalProvider); ok {
+		enhanced.completeIntent(intent, enhanced.GetConfig().IntentName(intent.Task))
+		return
+	}
+
+	var missing, followUp []string
+	for _, field := range intent.Missing {
+		if isEmptyVar(intent.Vars[field]) {
+			missing = append(missing, field)
+		}
+	}
+	// Keep the questions that still mention an unanswered field
+	for _, question := range intent.FollowUp {
+		for _, field := range missing {
+			if strings.Contains(strings.ToLower(question), strings.ToLower(field)) {
+				followUp = append(followUp, question)
+				break
+			}
+		}
+	}
+	intent.Missing = missing
+	intent.FollowUp = followUp
+	intent.IsComplete = len(missing) == 0
+}
+
+// isEmptyVar reports whether a var holds no value
+func isEmptyVar(value interface{}) bool {
+	return value == nil || value == ""
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// newSessionTestService creates a service backed by the enhanced local provider
+// with an in-memory session store
+func newSessionTestService(t *testing.T, config *models.IntentConfig, maxDepth int) *IntentService {
+	t.Helper()
+
+	return &IntentService{
+		aiProvider:       newTestEnhancedProvider(t, config),
+		sessions:         NewMemorySessionStore(time.Minute),
+		maxFollowUpDepth: maxDepth,
+	}
+}
+
+func TestMemorySessionStore_Expiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore(10 * time.Minute)
+	store.store.(*MemoryStore).now = func() time.Time { return now }
+	ctx := context.Background()
+
+	intent := &models.Intent{Task: "CreateEvent", Vars: map[string]interface{}{"title": "Standup"}}
+	if err := store.Save(ctx, &Session{ID: "abc", Intent: intent}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// The stored copy must not change with the caller's intent
+	intent.Vars["title"] = "changed"
+
+	now = now.Add(9 * time.Minute)
+	session, err := store.Get(ctx, "abc")
+	if err != nil || session == nil {
+		t.Fatalf("Get() = %v, %v, want the session before the TTL", session, err)
+	}
+	if got := session.Intent.Vars["title"]; got != "Standup" {
+		t.Errorf("title = %v, want the value at Save time", got)
+	}
+
+	now = now.Add(11 * time.Minute)
+	if session, _ := store.Get(ctx, "abc"); session != nil {
+		t.Errorf("Get() = %+v, want nil after the TTL", session)
+	}
+}
+
+func TestIntentService_SessionAnswersFollowUps(t *testing.T) {
+	service := newSessionTestService(t, eventConfig(), DefaultMaxFollowUpDepth)
+	conversation := Conversation{SessionID: "session-1"}
+	ctx := context.Background()
+
+	intent, err := service.ExtractIntentWithContext(ctx, `schedule a meeting "Standup"`, conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" || !reflect.DeepEqual(intent.Missing, []string{"date", "duration"}) {
+		t.Fatalf("first turn = %s missing %v, want CreateEvent missing [date duration]", intent.Task, intent.Missing)
+	}
+
+	// An extracted entity answers its own field
+	intent, err = service.ExtractIntentWithContext(ctx, "tomorrow", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" || intent.Vars["date"] != "tomorrow" || intent.Vars["title"] != "Standup" {
+		t.Fatalf("second turn = %s %v, want CreateEvent with title and date", intent.Task, intent.Vars)
+	}
+	if !reflect.DeepEqual(intent.Missing, []string{"duration"}) || len(intent.FollowUp) != 1 {
+		t.Errorf("second turn missing %v follow-up %v, want only duration", intent.Missing, intent.FollowUp)
+	}
+
+	// Anything else answers the first missing field verbatim
+	intent, err = service.ExtractIntentWithContext(ctx, "30 minutes", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Vars["duration"] != "30 minutes" || !intent.IsComplete || len(intent.Missing) != 0 {
+		t.Errorf("third turn = %v complete=%v missing %v, want duration set and complete", intent.Vars, intent.IsComplete, intent.Missing)
+	}
+}
+
+func TestIntentService_SessionNewIntentStartsOver(t *testing.T) {
+	config := eventConfig()
+	config.Intents["CreateNote"] = noteConfig().Intents["CreateNote"]
+	config.Entities["content"] = noteConfig().Entities["content"]
+	service := newSessionTestService(t, config, DefaultMaxFollowUpDepth)
+	conversation := Conversation{SessionID: "session-1"}
+	ctx := context.Background()
+
+	if _, err := service.ExtractIntentWithContext(ctx, `schedule a meeting "Standup"`, conversation); err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	intent, err := service.ExtractIntentWithContext(ctx, "make a note that the printer is broken", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Task != "CreateNote" {
+		t.Fatalf("Task = %s, want CreateNote", intent.Task)
+	}
+	if _, exists := intent.Vars["title"]; exists {
+		t.Errorf("Vars = %v, want nothing carried over from the abandoned event", intent.Vars)
+	}
+}
+
+func TestIntentService_ContextWithoutSession(t *testing.T) {
+	service := newSessionTestService(t, eventConfig(), DefaultMaxFollowUpDepth)
+
+	intent, err := service.ExtractIntentWithContext(context.Background(), "schedule a meeting tomorrow", Conversation{
+		Context: map[string]interface{}{"title": "Standup", "date": "today"},
+	})
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if intent.Vars["title"] != "Standup" {
+		t.Errorf("title = %v, want it filled from the context", intent.Vars["title"])
+	}
+	if intent.Vars["date"] != "tomorrow" {
+		t.Errorf("date = %v, want this turn's value to win over the context", intent.Vars["date"])
+	}
+	if !reflect.DeepEqual(intent.Missing, []string{"duration"}) {
+		t.Errorf("Missing = %v, want [duration]", intent.Missing)
+	}
+}
+
+// chainConfig requires five fields that have no extraction patterns, so each
+// follow-up answer fills exactly one of them
+func chainConfig() *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain: "test",
+		Intents: map[string]models.IntentPattern{
+			"PlanTrip": {
+				Description: "Plan a trip",
+				Keywords:    []string{"trip", "plan"},
+				Priority:    5,
+				Variables:   []string{"origin", "destination", "departure", "return", "travelers"},
+				Required:    []string{"origin", "destination", "departure", "return", "travelers"},
+			},
+		},
+	}
+}
+
+func TestIntentService_SessionMaxDepth(t *testing.T) {
+	service := newSessionTestService(t, chainConfig(), 3)
+	conversation := Conversation{SessionID: "deep"}
+	ctx := context.Background()
+
+	intent, err := service.ExtractIntentWithContext(ctx, "plan a trip", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if len(intent.Missing) != 5 {
+		t.Fatalf("Missing = %v, want all five fields", intent.Missing)
+	}
+
+	for i, answer := range []string{"Berlin", "Lisbon"} {
+		intent, err = service.ExtractIntentWithContext(ctx, answer, conversation)
+		if err != nil {
+			t.Fatalf("ExtractIntentWithContext() error = %v", err)
+		}
+		if intent.MaxDepthReached || len(intent.FollowUp) == 0 {
+			t.Fatalf("turn %d: MaxDepthReached = %v, follow-up %v, want more questions below the cap", i+1, intent.MaxDepthReached, intent.FollowUp)
+		}
+	}
+
+	intent, err = service.ExtractIntentWithContext(ctx, "May 3", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if !intent.MaxDepthReached {
+		t.Fatal("MaxDepthReached = false, want true at the cap")
+	}
+	if !reflect.DeepEqual(intent.Missing, []string{"return", "travelers"}) || intent.IsComplete {
+		t.Errorf("Missing = %v, IsComplete = %v, want the remaining fields left missing", intent.Missing, intent.IsComplete)
+	}
+	if len(intent.FollowUp) != 0 {
+		t.Errorf("FollowUp = %v, want no more questions", intent.FollowUp)
+	}
+	if intent.Vars["departure"] != "May 3" {
+		t.Errorf("departure = %v, want the last answer kept", intent.Vars["departure"])
+	}
+
+	// The session is over, so the next reply no longer continues the trip
+	intent, err = service.ExtractIntentWithContext(ctx, "two adults", conversation)
+	if err != nil {
+		t.Fatalf("ExtractIntentWithContext() error = %v", err)
+	}
+	if _, exists := intent.Vars["origin"]; exists {
+		t.Errorf("Vars = %v after the cap, want a fresh extraction", intent.Vars)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+
+	"myllm/internal/logging"
+	"myllm/internal/metrics"
+	"myllm/internal/models"
+)
+
+// ShadowComparison records how the shadow provider's result differed from the
+// active one. The request text is left out because it may hold personal data.
+type ShadowComparison struct {
+	ActiveTask string `json:"active_task"`
+	ShadowTask string `json:"shadow_task"`
+	Agree      bool   `json:"agree"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ShadowStats summarizes shadow classification results
+type ShadowStats struct {
+	Provider      string             `json:"provider"`
+	Total         int                `json:"total"`
+	Agreements    int                `json:"agreements"`
+	Disagreements int                `json:"disagreements"`
+	Errors        int                `json:"errors"`
+	Recent        []ShadowComparison `json:"recent_disagreements,omitempty"`
+}
+
+// maxRecentShadowDisagreements bounds the disagreements kept for inspection
+const maxRecentShadowDisagreements = 20
+
+// ShadowRunner classifies requests with a candidate provider alongside the
+// active one. Shadow results are only recorded, never returned.
+type ShadowRunner struct {
+	provider AIProvider
+	mu       sync.Mutex
+	stats    ShadowStats
+	wg       sync.WaitGroup
+}
+
+// NewShadowRunner creates a shadow runner for the given candidate provider
+func NewShadowRunner(provider AIProvider) *ShadowRunner {
+	return &ShadowRunner{
+		provider: provider,
+		stats:    ShadowStats{Provider: provider.Name()},
+	}
+}
+
+// Start begins classifying text with the shadow provider in the background and
+// returns a function that must be called with the active result once known
+func (r *ShadowRunner) Start(ctx context.Context, text string) func(active *models.Intent) {
+	activeResult := make(chan *models.Intent, 1)
+
+	// The shadow run must outlive the request without delaying it
+	shadowCtx := context.WithoutCancel(ctx)
+	logger := logging.FromContext(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				r.record(logger, ShadowComparison{Error: fmt.Sprintf("shadow provider panicked: %v", recovered)})
+			}
+		}()
+
+		shadow, err := r.provider.ExtractIntent(shadowCtx, text)
+		active := <-activeResult
+		if active == nil {
+			return // Active extraction failed, nothing to compare against
+		}
+
+		comparison := ShadowComparison{ActiveTask: active.Task}
+		if err != nil {
+			comparison.Error = err.Error()
+		} else {
+			comparison.ShadowTask = shadow.Task
+			comparison.Agree = shadow.Task == active.Task && reflect.DeepEqual(comparableVars(shadow), comparableVars(active))
+		}
+		r.record(logger, comparison)
+	}()
+
+	return func(active *models.Intent) {
+		if active == nil {
+			activeResult <- nil
+			return
+		}
+		// Hand over a snapshot so later changes to the response don't race
+		snapshot := &models.Intent{Task: active.Task, Vars: comparableVars(active)}
+		activeResult <- snapshot
+	}
+}
+
+// record updates the stats and metrics and logs disagreements
+func (r *ShadowRunner) record(logger *slog.Logger, comparison ShadowComparison) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Total++
+	switch {
+	case comparison.Error != "":
+		r.stats.Errors++
+		metrics.ObserveShadowComparison("error")
+		logger.Warn("Shadow classification error", "active_task", comparison.ActiveTask, "error", comparison.Error)
+	case comparison.Agree:
+		r.stats.Agreements++
+		metrics.ObserveShadowComparison("agree")
+	default:
+		r.stats.Disagreements++
+		metrics.ObserveShadowComparison("disagree")
+		r.stats.Recent = append(r.stats.Recent, comparison)
+		if len(r.stats.Recent) > maxRecentShadowDisagreements {
+			r.stats.Recent = r.stats.Recent[1:]
+		}
+		logger.Info("Shadow disagreement", "active_task", comparison.ActiveTask, "shadow_task", comparison.ShadowTask)
+	}
+}
+
+// Stats returns a copy of the current shadow stats
+func (r *ShadowRunner) Stats() ShadowStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Recent = append([]ShadowComparison(nil), r.stats.Recent...)
+	return stats
+}
+
+// Wait blocks until all in-flight shadow classifications have finished
+func (r *ShadowRunner) Wait() {
+	r.wg.Wait()
+}
+
+// comparableVars copies an intent's vars without per-run scoring details
+func comparableVars(intent *models.Intent) map[string]interface{} {
+	vars := make(map[string]interface{}, len(intent.Vars))
+	for key, value := range intent.Vars {
+		if key != "confidence" {
+			vars[key] = value
+		}
+	}
+	return vars
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// blockingProvider waits for release before answering
+type blockingProvider struct {
+	stubProvider
+	release chan struct{}
+}
+
+func (p *blockingProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	<-p.release
+	return p.stubProvider.ExtractIntent(ctx, text)
+}
+
+func TestIntentService_ShadowRecordedNotReturned(t *testing.T) {
+	active := &stubProvider{name: "active", intent: &models.Intent{Task: "CreateContact", Vars: map[string]interface{}{"name": "alice"}}}
+	shadow := &stubProvider{name: "shadow", intent: &models.Intent{Task: "ShowContact", Vars: map[string]interface{}{"name": "alice"}}}
+	service := &IntentService{aiProvider: active, shadow: NewShadowRunner(shadow)}
+
+	intent, err := service.ExtractIntent(context.Background(), "add alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CreateContact" {
+		t.Errorf("Task = %v, want the active result CreateContact", intent.Task)
+	}
+
+	service.shadow.Wait()
+	stats := service.ShadowStats()
+	if stats.Total != 1 || stats.Disagreements != 1 || stats.Agreements != 0 {
+		t.Errorf("stats = %+v, want 1 disagreement", stats)
+	}
+	if len(stats.Recent) != 1 || stats.Recent[0].ShadowTask != "ShowContact" || stats.Recent[0].ActiveTask != "CreateContact" {
+		t.Errorf("Recent = %+v, want the recorded disagreement", stats.Recent)
+	}
+}
+
+func TestIntentService_ShadowAgreementIgnoresConfidence(t *testing.T) {
+	active := &stubProvider{name: "active", intent: &models.Intent{Task: "CreateContact", Vars: map[string]interface{}{"name": "alice", "confidence": 0.8}}}
+	shadow := &stubProvider{name: "shadow", intent: &models.Intent{Task: "CreateContact", Vars: map[string]interface{}{"name": "alice", "confidence": 0.6}}}
+	service := &IntentService{aiProvider: active, shadow: NewShadowRunner(shadow)}
+
+	if _, err := service.ExtractIntent(context.Background(), "add alice"); err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	service.shadow.Wait()
+
+	if stats := service.ShadowStats(); stats.Agreements != 1 || stats.Disagreements != 0 {
+		t.Errorf("stats = %+v, want 1 agreement", stats)
+	}
+}
+
+func TestIntentService_ShadowDoesNotDelayResponse(t *testing.T) {
+	active := &stubProvider{name: "active"}
+	shadow := &blockingProvider{stubProvider: stubProvider{name: "shadow"}, release: make(chan struct{})}
+	service := &IntentService{aiProvider: active, shadow: NewShadowRunner(shadow)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := service.ExtractIntent(ctx, "add alice"); err != nil {
+			t.Errorf("ExtractIntent() error = %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ExtractIntent waited for the shadow provider")
+	}
+
+	// The shadow run survives the request being cancelled
+	cancel()
+	close(shadow.release)
+	service.shadow.Wait()
+
+	if stats := service.ShadowStats(); stats.Total != 1 {
+		t.Errorf("Total = %d, want 1", stats.Total)
+	}
+}
+
+func TestIntentService_ShadowStatsNilWhenDisabled(t *testing.T) {
+	service := &IntentService{aiProvider: &stubProvider{name: "active"}}
+	if stats := service.ShadowStats(); stats != nil {
+		t.Errorf("ShadowStats() = %+v, want nil", stats)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"myllm/internal/models"
+)
+
+// entitySpans locates extracted entity values in text. Every extraction path
+// returns a substring of text, so each value is found by searching for it,
+// ignoring case for keyword matches such as "tomorrow" in "Tomorrow".
+// Occurrences on word boundaries win, so "Al" is found in "call Al" rather
+// than inside "call", and each value of a multi-value entity gets its own
+// occurrence. Values that aren't in text, such as honorifics written in
+// their configured spelling, get no span.
+func entitySpans(text string, entities map[string][]string) map[string][]models.Span {
+	spans := make(map[string][]models.Span)
+	for name, values := range entities {
+		var found []models.Span
+		for _, value := range values {
+			if span, ok := locateValue(text, value, found); ok {
+				found = append(found, span)
+			}
+		}
+		if len(found) > 0 {
+			spans[name] = found
+		}
+	}
+	return spans
+}
+
+// locateValue returns the first occurrence of value in text that doesn't
+// overlap taken, preferring one on word boundaries
+func locateValue(text, value string, taken []models.Span) (models.Span, bool) {
+	if value == "" {
+		return models.Span{}, false
+	}
+
+	var fallback *models.Span
+	for _, match := range regexp.MustCompile(`(?i)`+regexp.QuoteMeta(value)).FindAllStringIndex(text, -1) {
+		span := models.Span{Start: match[0], End: match[1]}
+		if overlapsAny(span, taken) {
+			continue
+		}
+		if onWordBoundaries(text, span) {
+			return span, true
+		}
+		if fallback == nil {
+			fallback = &span
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return models.Span{}, false
+}
+
+// overlapsAny reports whether span overlaps one of spans
+func overlapsAny(span models.Span, spans []models.Span) bool {
+	for _, other := range spans {
+		if span.Start < other.End && other.Start < span.End {
+			return true
+		}
+	}
+	return false
+}
+
+// onWordBoundaries reports whether span neither starts nor ends inside a word
+func onWordBoundaries(text string, span models.Span) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:span.Start]); span.Start > 0 && isWordRune(before) {
+		if first, _ := utf8.DecodeRuneInString(text[span.Start:]); isWordRune(first) {
+			return false
+		}
+	}
+	if after, _ := utf8.DecodeRuneInString(text[span.End:]); span.End < len(text) && isWordRune(after) {
+		if last, _ := utf8.DecodeLastRuneInString(text[:span.End]); isWordRune(last) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
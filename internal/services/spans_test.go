@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestLocateValue(t *testing.T) {
+	tests := []struct {
+		text  string
+		value string
+		taken []models.Span
+		want  string // Substring the span covers, empty for none
+		start int
+	}{
+		{text: "recall Al tomorrow", value: "Al", want: "Al", start: 7},
+		{text: "Tomorrow at noon", value: "tomorrow", want: "Tomorrow", start: 0},
+		{text: "Bob and Bob", value: "Bob", taken: []models.Span{{Start: 0, End: 3}}, want: "Bob", start: 8},
+		{text: "Dr Smith", value: "Dr.", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			span, ok := locateValue(tt.text, tt.value, tt.taken)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("locateValue() = %+v, want no span", span)
+				}
+				return
+			}
+			if !ok || span.Start != tt.start || tt.text[span.Start:span.End] != tt.want {
+				t.Errorf("locateValue() = %+v, %v, want %q at %d", span, ok, tt.want, tt.start)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_Spans(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactConfig())
+	text := "add contact named Alice, email Alice@Example.com"
+
+	intent, err := provider.ExtractIntent(WithSpans(context.Background()), text)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	for name, want := range map[string]string{"name": "Alice", "email": "Alice@Example.com"} {
+		spans := intent.Spans[name]
+		if len(spans) != 1 || text[spans[0].Start:spans[0].End] != want {
+			t.Errorf("Spans[%s] = %+v, want the span of %q", name, spans, want)
+		}
+	}
+	// The name is the one after "named", not the start of the email
+	if span := intent.Spans["name"][0]; span.Start != 18 {
+		t.Errorf("Spans[name] starts at %d, want 18", span.Start)
+	}
+
+	intent, err = provider.ExtractIntent(context.Background(), text)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Spans != nil {
+		t.Errorf("Spans = %+v without WithSpans, want none", intent.Spans)
+	}
+}
+
+func TestEnhancedLocalProvider_SubIntentSpans(t *testing.T) {
+	provider := newTestEnhancedProvider(t, contactAndEventConfig())
+	text := `add contact Bob and schedule a meeting "Intro" tomorrow`
+
+	intent, err := provider.ExtractIntent(WithSpans(context.Background()), text)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	subIntents, ok := intent.Vars["sub_intents"].([]*models.Intent)
+	if !ok || len(subIntents) != 2 {
+		t.Fatalf("sub_intents = %#v, want two intents", intent.Vars["sub_intents"])
+	}
+	for name, want := range map[string]string{"title": "Intro", "date": "tomorrow"} {
+		spans := subIntents[1].Spans[name]
+		if len(spans) != 1 || text[spans[0].Start:spans[0].End] != want {
+			t.Errorf("sub_intents[1].Spans[%s] = %+v, want the span of %q in the whole text", name, spans, want)
+		}
+	}
+}
+
+func TestIntentService_SpansPointIntoRequestText(t *testing.T) {
+	service := &IntentService{aiProvider: newTestEnhancedProvider(t, contactConfig())}
+	text := "  Add   contact  named Bob,   email BOB@Example.com"
+
+	intent, err := service.ExtractIntent(WithSpans(context.Background()), text)
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	for name, want := range map[string]string{"name": "Bob", "email": "BOB@Example.com"} {
+		spans := intent.Spans[name]
+		if len(spans) != 1 || text[spans[0].Start:spans[0].End] != want {
+			t.Errorf("Spans[%s] = %+v, want the span of %q in the request text", name, spans, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"myllm/internal/models"
+)
+
+// foldWord returns the form words are compared in for stop-word lookups:
+// NFC-normalized and Unicode case folded, so "Naïve" written with a combining
+// diaeresis and "NAÏVE" both become "naïve"
+func foldWord(word string) string {
+	// A Caser keeps state between calls, so each call gets its own
+	return cases.Fold().String(norm.NFC.String(word))
+}
+
+// compileStopWords builds the folded stop-word set for a config: its
+// stop_words added to models.DefaultStopWords, or used alone when
+// ReplaceStopWords is set
+func compileStopWords(config *models.IntentConfig) map[string]bool {
+	words := config.StopWords
+	if !config.ReplaceStopWords {
+		words = append(append([]string{}, models.DefaultStopWords...), config.StopWords...)
+	}
+
+	stopWords := make(map[string]bool, len(words))
+	for _, word := range words {
+		if folded := foldWord(word); folded != "" {
+			stopWords[folded] = true
+		}
+	}
+	return stopWords
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestFoldWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"The", "the"},
+		{"NAÏVE", "naïve"},
+		{"Nai\u0308ve", "naïve"}, // Decomposed diaeresis
+		{"FÜR", "für"},
+		{"Straße", "strasse"},
+	}
+
+	for _, tt := range tests {
+		if got := foldWord(tt.word); got != tt.want {
+			t.Errorf("foldWord(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+// germanConfig replaces the English stop words for a German domain
+func germanConfig(replace bool) *models.IntentConfig {
+	return &models.IntentConfig{
+		Domain:           "termine",
+		StopWords:        []string{"der", "die", "das", "einen", "für", "Naïve"},
+		ReplaceStopWords: replace,
+		Intents: map[string]models.IntentPattern{
+			"TerminErstellen": {Description: "Einen Termin erstellen", Keywords: []string{"termin", "erstelle"}},
+		},
+	}
+}
+
+func TestEnhancedLocalProvider_ConfiguredStopWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace bool
+		input   string
+		want    []string
+	}{
+		{name: "custom words are folded", replace: true, input: "Erstelle DER Termin FÜR Anna", want: []string{"erstelle", "termin", "anna"}},
+		{name: "replacing drops the defaults", replace: true, input: "the Termin", want: []string{"the", "termin"}},
+		{name: "merging keeps the defaults", input: "the Termin für Anna", want: []string{"termin", "anna"}},
+		{name: "decomposed accents match", replace: true, input: "nai\u0308ve Termin", want: []string{"termin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestEnhancedProvider(t, germanConfig(tt.replace))
+			if got := provider.tokenize(provider.normalizeText(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnhancedLocalProvider_DefaultStopWordsIgnoreCase(t *testing.T) {
+	provider := newTestEnhancedProvider(t, noteConfig())
+
+	for _, word := range []string{"the", "THE", "With"} {
+		if !provider.isStopWord(word) {
+			t.Errorf("isStopWord(%q) = false, want true", word)
+		}
+	}
+	if provider.isStopWord("note") {
+		t.Error("isStopWord(\"note\") = true, want false")
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store backends selected with SESSION_STORE
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// DefaultRedisURL is the Redis server used when REDIS_URL is unset
+const DefaultRedisURL = "redis://localhost:6379/0"
+
+// Store keeps values under string keys for a limited time. The session store
+// and the intent cache keep their JSON-encoded entries in one. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Get returns the value stored under key, or nil if there is none
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for ttl; a zero ttl keeps it until it is
+	// deleted or evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key; deleting an unknown key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// newStore creates the store backend of the given kind
+func newStore(kind, redisURL string) (Store, error) {
+	switch kind {
+	case StoreMemory:
+		return NewMemoryStore(0), nil
+	case StoreRedis:
+		return NewRedisStore(redisURL)
+	default:
+		return nil, fmt.Errorf("unknown store %q, want %s or %s", kind, StoreMemory, StoreRedis)
+	}
+}
+
+// memorySweepInterval is how often an unbounded MemoryStore drops expired
+// entries
+const memorySweepInterval = time.Minute
+
+// MemoryStore is a Store kept in memory, so its entries are lost on restart
+// and not shared between instances. With a size limit it drops the least
+// recently used entry when full.
+type MemoryStore struct {
+	mu        sync.Mutex
+	size      int // Maximum entries; 0 for no limit
+	entries   map[string]*list.Element
+	order     *list.List // Most recently used first
+	lastSweep time.Time
+	now       func() time.Time // Clock used for expiry (time.Now if nil)
+}
+
+// memoryEntry is one value in a MemoryStore
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // Zero for no expiry
+}
+
+// NewMemoryStore creates an in-memory store of up to size entries, or of any
+// number when size is not positive
+func NewMemoryStore(size int) *MemoryStore {
+	if size < 0 {
+		size = 0
+	}
+	return &MemoryStore{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns a copy of the value under key, or nil if it is unknown or expired
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, exists := m.entries[key]
+	if !exists {
+		return nil, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if m.expired(entry, m.currentTime()) {
+		m.remove(element)
+		return nil, nil
+	}
+	m.order.MoveToFront(element)
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set stores a copy of value under key, evicting the least recently used
+// entry when the store is full. Expired entries are swept at most once a
+// minute.
+func (m *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.currentTime()
+	if now.Sub(m.lastSweep) > memorySweepInterval {
+		for _, element := range m.entries {
+			if m.expired(element.Value.(*memoryEntry), now) {
+				m.remove(element)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	entry := &memoryEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expires = now.Add(ttl)
+	}
+	if element, exists := m.entries[key]; exists {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(entry)
+	if m.size > 0 && m.order.Len() > m.size {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+// Delete removes key
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, exists := m.entries[key]; exists {
+		m.remove(element)
+	}
+	return nil
+}
+
+// Len returns the number of entries, expired ones included
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// expired reports whether entry has outlived its TTL at now
+func (m *MemoryStore) expired(entry *memoryEntry, now time.Time) bool {
+	return !entry.expires.IsZero() && !now.Before(entry.expires)
+}
+
+// remove drops an entry; the caller holds mu
+func (m *MemoryStore) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
+
+// currentTime returns the store's clock reading
+func (m *MemoryStore) currentTime() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// testStore checks the behavior every Store shares. advance moves the
+// store's clock forward.
+func testStore(t *testing.T, store Store, advance func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+
+	if value, err := store.Get(ctx, "missing"); err != nil || value != nil {
+		t.Errorf("Get(missing) = %q, %v, want nil, nil", value, err)
+	}
+
+	if err := store.Set(ctx, "a", []byte("one"), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set(ctx, "forever", []byte("kept"), 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if value, err := store.Get(ctx, "a"); err != nil || string(value) != "one" {
+		t.Errorf("Get(a) = %q, %v, want one", value, err)
+	}
+
+	// Setting again replaces the value and restarts its TTL
+	advance(30 * time.Second)
+	if err := store.Set(ctx, "a", []byte("two"), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	advance(59 * time.Second)
+	if value, _ := store.Get(ctx, "a"); string(value) != "two" {
+		t.Errorf("Get(a) = %q before the TTL, want two", value)
+	}
+	advance(time.Second)
+	if value, _ := store.Get(ctx, "a"); value != nil {
+		t.Errorf("Get(a) = %q after the TTL, want nil", value)
+	}
+	if value, _ := store.Get(ctx, "forever"); string(value) != "kept" {
+		t.Errorf("Get(forever) = %q, want a zero TTL to never expire", value)
+	}
+
+	if err := store.Delete(ctx, "forever"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if value, _ := store.Get(ctx, "forever"); value != nil {
+		t.Errorf("Get(forever) = %q after Delete, want nil", value)
+	}
+	if err := store.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Delete(missing) error = %v, want nil", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(0)
+	store.now = func() time.Time { return now }
+
+	testStore(t, store, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemoryStore(2)
+	ctx := context.Background()
+
+	store.Set(ctx, "a", []byte("A"), 0)
+	store.Set(ctx, "b", []byte("B"), 0)
+	store.Get(ctx, "a") // "b" is now the least recently used
+	store.Set(ctx, "c", []byte("C"), 0)
+
+	if value, _ := store.Get(ctx, "b"); value != nil {
+		t.Errorf("Get(b) = %q, want it evicted", value)
+	}
+	if got := store.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestMemoryStore_ReturnsCopies(t *testing.T) {
+	store := NewMemoryStore(0)
+	ctx := context.Background()
+
+	value := []byte("Bob")
+	store.Set(ctx, "a", value, 0)
+	value[0] = 'R'
+	got, _ := store.Get(ctx, "a")
+	got[1] = 'X'
+
+	if again, _ := store.Get(ctx, "a"); string(again) != "Bob" {
+		t.Errorf("Get() = %q, want the stored value unchanged by callers", again)
+	}
+}
+
+func TestKeyValueSessionStore_SharedStore(t *testing.T) {
+	// Sessions and cached intents can share one store without clashing
+	store := NewMemoryStore(0)
+	sessions := NewKeyValueSessionStore(store, time.Minute)
+	cache := NewStoreIntentCache(store, time.Minute)
+	ctx := context.Background()
+
+	intent := &models.Intent{Task: "CreateEvent", Vars: map[string]interface{}{"title": "Standup"}, Missing: []string{"date"}}
+	if err := sessions.Save(ctx, &Session{ID: "abc", Intent: intent, Depth: 2}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cache.Add(ctx, "abc", &models.Intent{Task: "CreateNote"})
+
+	session, err := sessions.Get(ctx, "abc")
+	if err != nil || session == nil {
+		t.Fatalf("Get() = %v, %v, want the session", session, err)
+	}
+	if session.Intent.Task != "CreateEvent" || session.Intent.Vars["title"] != "Standup" || session.Depth != 2 {
+		t.Errorf("session = %+v %+v, want the saved one", session, session.Intent)
+	}
+	if session.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt is zero, want the Save time")
+	}
+	if cached, hit := cache.Get(ctx, "abc"); !hit || cached.Task != "CreateNote" {
+		t.Errorf("cache.Get() = %+v, %v, want the cached CreateNote", cached, hit)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"myllm/internal/models"
+)
+
+// Stream event types, in the order they are emitted
+const (
+	StreamEventToken    = "token"     // Generated text, streaming providers only
+	StreamEventTask     = "task"      // The classified task
+	StreamEventEntity   = "entity"    // One extracted variable
+	StreamEventFollowUp = "follow_up" // One question for a missing field
+)
+
+// StreamEvent is one stage of a streamed extraction
+type StreamEvent struct {
+	Type string
+	Data interface{}
+}
+
+// StreamIntent extracts intent like ExtractIntent, reporting each stage to emit
+// as soon as it is known: tokens while a streaming provider generates, then the
+// task, each entity and each follow-up question. An emit error, such as a
+// disconnected client, aborts the extraction.
+func (s *IntentService) StreamIntent(ctx context.Context, text string, emit func(StreamEvent) error) (*models.Intent, error) {
+	intent, err := s.extractIntent(ctx, text, func(token string) error {
+		return emit(StreamEvent{Type: StreamEventToken, Data: models.TokenEvent{Token: token}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.dispatchWebhook(intent)
+
+	if err := emitIntentStages(intent, emit); err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+// emitIntentStages reports the task, then each variable sorted by name, then
+// each follow-up question
+func emitIntentStages(intent *models.Intent, emit func(StreamEvent) error) error {
+	if err := emit(StreamEvent{Type: StreamEventTask, Data: models.TaskEvent{Task: intent.Task, Confidence: intent.Confidence}}); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(intent.Vars))
+	for name := range intent.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := emit(StreamEvent{Type: StreamEventEntity, Data: models.EntityEvent{Name: name, Value: intent.Vars[name]}}); err != nil {
+			return err
+		}
+	}
+
+	for _, question := range intent.FollowUp {
+		if err := emit(StreamEvent{Type: StreamEventFollowUp, Data: models.FollowUpEvent{Question: question}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// ollamaStreamServer streams reply from /api/generate one chunk per line
+func ollamaStreamServer(t *testing.T, chunks []string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": []}`))
+			return
+		}
+		if !strings.Contains(readBody(t, r), `"stream":true`) {
+			t.Errorf("generate request did not ask for a stream")
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "{\"response\": %q, \"done\": false}\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprintln(w, `{"response": "", "done": true}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func readBody(t *testing.T, r *http.Request) string {
+	t.Helper()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Errorf("failed to read request body: %v", err)
+	}
+	return string(body)
+}
+
+func TestIntentService_StreamIntentForwardsOllamaTokens(t *testing.T) {
+	chunks := []string{`{"task": "CREATE_CONTACT", `, `"vars": {"name": "Alice", `, `"email": "alice@example.com"}}`}
+	server := ollamaStreamServer(t, chunks)
+	provider, err := NewOllamaProvider(AIProviderConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error = %v", err)
+	}
+	service := &IntentService{aiProvider: provider}
+
+	var types []string
+	var tokens []string
+	intent, err := service.StreamIntent(context.Background(), "add contact Alice", func(event StreamEvent) error {
+		types = append(types, event.Type)
+		if event.Type == StreamEventToken {
+			tokens = append(tokens, event.Data.(models.TokenEvent).Token)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamIntent() error = %v", err)
+	}
+
+	if intent.Task != "CREATE_CONTACT" {
+		t.Errorf("Task = %q, want CREATE_CONTACT", intent.Task)
+	}
+	if !reflect.DeepEqual(tokens, chunks) {
+		t.Errorf("tokens = %q, want %q", tokens, chunks)
+	}
+	want := []string{"token", "token", "token", "task", "entity", "entity"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("event types = %v, want %v", types, want)
+	}
+}
+
+func TestIntentService_StreamIntentStopsOnEmitError(t *testing.T) {
+	server := ollamaStreamServer(t, []string{`{"task": `, `"UNKNOWN", "vars": {}}`})
+	provider, err := NewOllamaProvider(AIProviderConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error = %v", err)
+	}
+	service := &IntentService{aiProvider: provider}
+
+	disconnected := errors.New("client disconnected")
+	events := 0
+	_, err = service.StreamIntent(context.Background(), "hello", func(StreamEvent) error {
+		events++
+		return disconnected
+	})
+	if !errors.Is(err, disconnected) {
+		t.Errorf("StreamIntent() error = %v, want %v", err, disconnected)
+	}
+	if events != 1 {
+		t.Errorf("emitted %d events, want 1", events)
+	}
+}
+
+func TestIntentService_StreamIntentWithoutStreamingProvider(t *testing.T) {
+	stub := &stubProvider{intent: &models.Intent{
+		Task:     "CREATE_CONTACT",
+		Vars:     map[string]interface{}{"name": "Alice"},
+		FollowUp: []string{"What is Alice's email?"},
+	}}
+	service := &IntentService{aiProvider: stub}
+
+	var types []string
+	if _, err := service.StreamIntent(context.Background(), "add contact Alice", func(event StreamEvent) error {
+		types = append(types, event.Type)
+		return nil
+	}); err != nil {
+		t.Fatalf("StreamIntent() error = %v", err)
+	}
+
+	want := []string{"task", "entity", "follow_up"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("event types = %v, want %v", types, want)
+	}
+}
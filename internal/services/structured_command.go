@@ -0,0 +1,111 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	structuredCommandRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_-]*)\s*:\s*(.+)$`)
+	structuredKeyRegex     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// isStructuredCommand reports whether text is a structured command
+func isStructuredCommand(text string) bool {
+	_, _, ok := parseStructuredCommand(text)
+	return ok
+}
+
+// parseStructuredCommand parses power-user input of the form
+// `command: key=value, key="quoted, value"`. It returns ok=false unless the
+// whole input has that shape, so natural language falls through untouched.
+func parseStructuredCommand(text string) (string, map[string]string, bool) {
+	matches := structuredCommandRegex.FindStringSubmatch(strings.TrimSpace(text))
+	if matches == nil {
+		return "", nil, false
+	}
+
+	pairs, ok := splitStructuredPairs(matches[2])
+	if !ok || len(pairs) == 0 {
+		return "", nil, false
+	}
+
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || !structuredKeyRegex.MatchString(key) {
+			return "", nil, false
+		}
+
+		value, ok := unquoteStructuredValue(strings.TrimSpace(value))
+		if !ok {
+			return "", nil, false
+		}
+		vars[key] = value
+	}
+
+	return matches[1], vars, true
+}
+
+// splitStructuredPairs splits on commas that are outside quotes
+func splitStructuredPairs(text string) ([]string, bool) {
+	var pairs []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == ',':
+			pairs = append(pairs, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if quote != 0 {
+		return nil, false // Unterminated quote
+	}
+	if last := strings.TrimSpace(current.String()); last != "" {
+		pairs = append(pairs, last)
+	}
+	return pairs, true
+}
+
+// unquoteStructuredValue strips surrounding quotes and unescapes \" and \'
+func unquoteStructuredValue(value string) (string, bool) {
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') {
+		return value, true
+	}
+
+	quote := value[0]
+	if value[len(value)-1] != quote {
+		return "", false
+	}
+
+	inner := value[1 : len(value)-1]
+	inner = strings.ReplaceAll(inner, `\`+string(quote), string(quote))
+	return strings.ReplaceAll(inner, `\\`, `\`), true
+}
+
+// canonicalCommandName reduces a command or intent name to lowercase letters and
+// digits so "create-event", "create_event" and "CreateEvent" compare equal
+func canonicalCommandName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
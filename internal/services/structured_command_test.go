@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseStructuredCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantCommand string
+		wantVars    map[string]string
+		wantOK      bool
+	}{
+		{
+			name:        "simple pairs",
+			input:       "create-event: title=Standup, time=9am, duration=15m",
+			wantCommand: "create-event",
+			wantVars:    map[string]string{"title": "Standup", "time": "9am", "duration": "15m"},
+			wantOK:      true,
+		},
+		{
+			name:        "quoted value with commas",
+			input:       `create-note: title="Groceries, weekly", content='milk, eggs, and bread'`,
+			wantCommand: "create-note",
+			wantVars:    map[string]string{"title": "Groceries, weekly", "content": "milk, eggs, and bread"},
+			wantOK:      true,
+		},
+		{
+			name:        "escaped quote and equals sign in value",
+			input:       `CreateNote: content="she said \"a=b\""`,
+			wantCommand: "CreateNote",
+			wantVars:    map[string]string{"content": `she said "a=b"`},
+			wantOK:      true,
+		},
+		{
+			name:        "trailing comma",
+			input:       "create-event: title=Standup,",
+			wantCommand: "create-event",
+			wantVars:    map[string]string{"title": "Standup"},
+			wantOK:      true,
+		},
+		{"natural language with colon", "note: buy milk, eggs, and bread", "", nil, false},
+		{"no colon", "create event title=Standup", "", nil, false},
+		{"unterminated quote", `create-event: title="Standup`, "", nil, false},
+		{"empty key", "create-event: =Standup", "", nil, false},
+		{"plain sentence", "schedule a meeting tomorrow at 3pm", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, vars, ok := parseStructuredCommand(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if command != tt.wantCommand {
+				t.Errorf("command = %q, want %q", command, tt.wantCommand)
+			}
+			if tt.wantOK && !reflect.DeepEqual(vars, tt.wantVars) {
+				t.Errorf("vars = %v, want %v", vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestIntentService_StructuredCommand(t *testing.T) {
+	provider := newTestEnhancedProvider(t, eventConfig())
+	service := &IntentService{aiProvider: provider}
+
+	intent, err := service.ExtractIntent(context.Background(), "create-event: title=Standup, time=9am, duration=15m")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+
+	if intent.Task != "CreateEvent" {
+		t.Errorf("Task = %v, want CreateEvent", intent.Task)
+	}
+	if intent.Vars["title"] != "Standup" || intent.Vars["time"] != "9am" || intent.Vars["duration"] != "15m" {
+		t.Errorf("Vars = %v, want title/time/duration from the command", intent.Vars)
+	}
+	if intent.Confidence != 1.0 {
+		t.Errorf("Confidence = %v, want 1.0", intent.Confidence)
+	}
+	if len(intent.Missing) != 1 || intent.Missing[0] != "date" {
+		t.Errorf("Missing = %v, want [date]", intent.Missing)
+	}
+}
+
+func TestIntentService_StructuredCommandFallsBack(t *testing.T) {
+	provider := newTestEnhancedProvider(t, eventConfig())
+	service := &IntentService{aiProvider: provider}
+
+	// Unknown command names fall back to natural-language processing
+	intent, err := service.ExtractIntent(context.Background(), "launch-rocket: target=moon")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if _, exists := intent.Vars["target"]; exists {
+		t.Errorf("unknown command should not be treated as structured, got %+v", intent)
+	}
+
+	// Without any config there is nothing to match the command against
+	stub := &stubProvider{name: "stub"}
+	service = &IntentService{aiProvider: stub}
+	intent, err = service.ExtractIntent(context.Background(), "create-event: title=Standup")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "UNKNOWN" || stub.calls != 1 {
+		t.Errorf("Task = %v, provider calls = %d, want the provider's UNKNOWN", intent.Task, stub.calls)
+	}
+}
+
+func TestIntentService_StructuredCommandUsesIntentConfig(t *testing.T) {
+	stub := &stubProvider{name: "stub"}
+	service := &IntentService{aiProvider: stub, intentConfig: eventConfig()}
+
+	intent, err := service.ExtractIntent(context.Background(), "create-event: title=Standup")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	if intent.Task != "CreateEvent" || intent.Vars["title"] != "Standup" || stub.calls != 0 {
+		t.Errorf("intent = %+v, provider calls = %d, want CreateEvent from the config without calling the provider", intent, stub.calls)
+	}
+}
+
+func TestIntentService_StructuredCommandRejectsUnknownTask(t *testing.T) {
+	tests := []string{"Re: subject=hello", "Note: price=5, qty=3"}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			stub := &stubProvider{name: "stub"}
+			service := &IntentService{
+				aiProvider:         stub,
+				schema:             eventConfig(),
+				responseValidation: ResponseValidationReject,
+			}
+
+			intent, err := service.ExtractIntent(context.Background(), input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if intent.Task != "UNKNOWN" || stub.calls != 1 {
+				t.Errorf("Task = %v, provider calls = %d, want the input sent to the provider", intent.Task, stub.calls)
+			}
+		})
+	}
+}
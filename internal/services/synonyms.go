@@ -0,0 +1,70 @@
+package services
+
+import "strings"
+
+// DefaultSynonymDepth applies when a config doesn't set synonym_depth: only
+// words listed together in synonyms match each other
+const DefaultSynonymDepth = 1
+
+// compileSynonyms returns, for each word in synonyms, the words it matches:
+// those reachable within depth steps, where each step goes from a word to one
+// of its synonyms or back. With "create": ["add"] and "add": ["new"], depth 1
+// relates create and add, and depth 2 also create and new. Words are
+// lowercased; a depth of 0 uses DefaultSynonymDepth and a negative one
+// disables synonym matching.
+func compileSynonyms(synonyms map[string][]string, depth int) map[string][]string {
+	if depth == 0 {
+		depth = DefaultSynonymDepth
+	}
+	expanded := make(map[string][]string)
+	if depth < 0 {
+		return expanded
+	}
+
+	// Relate words both ways, in config order so matches are reported the
+	// same way on every load
+	related := make(map[string][]string)
+	relate := func(a, b string) {
+		for _, word := range related[a] {
+			if word == b {
+				return
+			}
+		}
+		related[a] = append(related[a], b)
+	}
+	for _, word := range sortedKeys(synonyms) {
+		wordLower := strings.ToLower(word)
+		for _, synonym := range synonyms[word] {
+			synonymLower := strings.ToLower(synonym)
+			if synonymLower == wordLower {
+				continue
+			}
+			relate(wordLower, synonymLower)
+			relate(synonymLower, wordLower)
+		}
+	}
+
+	// Breadth-first from each word; visited words are never queued again, so
+	// cycles such as add -> new -> add end
+	for word := range related {
+		visited := map[string]bool{word: true}
+		frontier := []string{word}
+		var reachable []string
+		for step := 0; step < depth && len(frontier) > 0; step++ {
+			var next []string
+			for _, current := range frontier {
+				for _, synonym := range related[current] {
+					if visited[synonym] {
+						continue
+					}
+					visited[synonym] = true
+					reachable = append(reachable, synonym)
+					next = append(next, synonym)
+				}
+			}
+			frontier = next
+		}
+		expanded[word] = reachable
+	}
+	return expanded
+}
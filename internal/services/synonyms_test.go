@@ -0,0 +1,95 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestCompileSynonyms(t *testing.T) {
+	synonyms := map[string][]string{
+		"create": {"add"},
+		"add":    {"new"},
+		"new":    {"Create"}, // Closes a cycle
+		"delete": {"remove"},
+	}
+
+	tests := []struct {
+		name  string
+		depth int
+		word  string
+		want  []string
+	}{
+		{name: "default depth", word: "create", want: []string{"add", "new"}},
+		{name: "backwards", depth: 1, word: "remove", want: []string{"delete"}},
+		{name: "depth 1 in a cycle", depth: 1, word: "add", want: []string{"new", "create"}},
+		{name: "transitive", depth: 2, word: "delete", want: []string{"remove"}},
+		{name: "deep cycle", depth: 10, word: "new", want: []string{"add", "create"}},
+		{name: "disabled", depth: -1, word: "create"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compileSynonyms(synonyms, tt.depth)[tt.word]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compileSynonyms(depth %d)[%s] = %v, want %v", tt.depth, tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileSynonyms_Transitive(t *testing.T) {
+	synonyms := map[string][]string{
+		"create": {"add"},
+		"add":    {"insert"},
+		"insert": {"new"},
+	}
+
+	for depth, want := range map[int][]string{
+		1: {"add"},
+		2: {"add", "insert"},
+		3: {"add", "insert", "new"},
+		4: {"add", "insert", "new"},
+	} {
+		if got := compileSynonyms(synonyms, depth)["create"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("compileSynonyms(depth %d)[create] = %v, want %v", depth, got, want)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_SynonymDepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		depth   int
+		keyword string
+		text    string
+		wantVia string // Empty for no match
+	}{
+		{name: "forward", keyword: "create", text: "add a note", wantVia: "add"},
+		{name: "backward", keyword: "add", text: "create a note", wantVia: "create"},
+		{name: "two steps at depth 1", keyword: "create", text: "new note"},
+		{name: "two steps at depth 2", depth: 2, keyword: "create", text: "new note", wantVia: "new"},
+		{name: "disabled", depth: -1, keyword: "create", text: "add a note"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := noteConfig()
+			config.Synonyms = map[string][]string{"create": {"add"}, "add": {"new"}}
+			config.SynonymDepth = tt.depth
+			provider := newTestEnhancedProvider(t, config)
+
+			got := provider.matchKeyword(tt.text, provider.tokenize(tt.text), tt.keyword, nil, 0)
+			if tt.wantVia == "" {
+				if got.Match != "" {
+					t.Errorf("matchKeyword(%s) = %+v, want no match", tt.keyword, got)
+				}
+				return
+			}
+			if got.Match != models.KeywordMatchSynonym || got.Via != tt.wantVia {
+				t.Errorf("matchKeyword(%s) = %+v, want a synonym match via %s", tt.keyword, got, tt.wantVia)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"myllm/internal/logging"
+)
+
+// Text overflow modes for TEXT_OVERFLOW, applied to texts over MAX_TEXT_LENGTH
+const (
+	TextOverflowTruncate = "truncate" // Classify the first MAX_TEXT_LENGTH characters
+	TextOverflowReject   = "reject"   // Fail with ErrTextTooLong
+)
+
+// ErrTextTooLong is returned for texts over MAX_TEXT_LENGTH in reject mode
+var ErrTextTooLong = errors.New("text too long")
+
+// truncatedVar is set to true on intents extracted from truncated text
+const truncatedVar = "truncated"
+
+// textLengthKey carries the length of a text before it was truncated
+type textLengthKey struct{}
+
+// textLength returns the length text had before truncation, or its own
+// length when it wasn't truncated. Scoring uses it so truncation doesn't
+// change the length bonus.
+func textLength(ctx context.Context, text string) int {
+	if length, ok := ctx.Value(textLengthKey{}).(int); ok {
+		return length
+	}
+	return len(text)
+}
+
+// limitTextLength applies MAX_TEXT_LENGTH, counted in characters, to text.
+// In truncate mode it returns the start of an overlong text and a context
+// recording the original length; in reject mode it returns ErrTextTooLong.
+func (s *IntentService) limitTextLength(ctx context.Context, text string) (context.Context, string, bool, error) {
+	if s.maxTextLength <= 0 {
+		return ctx, text, false, nil
+	}
+	characters := utf8.RuneCountInString(text)
+	if characters <= s.maxTextLength {
+		return ctx, text, false, nil
+	}
+
+	if s.textOverflow == TextOverflowReject {
+		return ctx, "", false, fmt.Errorf("%w: %d characters, at most %d allowed", ErrTextTooLong, characters, s.maxTextLength)
+	}
+
+	logging.FromContext(ctx).Info("Truncated overlong text", "characters", characters, "max", s.maxTextLength)
+	return context.WithValue(ctx, textLengthKey{}, len(text)), firstCharacters(text, s.maxTextLength), true, nil
+}
+
+// firstCharacters returns the first n characters of text, or all of it
+func firstCharacters(text string, n int) string {
+	cut := 0
+	for i := 0; i < n && cut < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[cut:])
+		cut += size
+	}
+	return text[:cut]
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+// recordingProvider answers like stubProvider and remembers the text it was asked about
+type recordingProvider struct {
+	stubProvider
+	text string
+}
+
+func (p *recordingProvider) ExtractIntent(ctx context.Context, text string) (*models.Intent, error) {
+	p.text = text
+	return p.stubProvider.ExtractIntent(ctx, text)
+}
+
+func TestIntentService_MaxTextLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxLength     int
+		overflow      string
+		text          string
+		wantText      string
+		wantTruncated bool
+		wantErr       error
+	}{
+		{name: "no cap", text: "add contact bob, then call him", wantText: "add contact bob, then call him"},
+		{name: "within the cap", maxLength: 30, overflow: TextOverflowTruncate, text: "add contact bob, then call him", wantText: "add contact bob, then call him"},
+		{name: "truncated", maxLength: 15, overflow: TextOverflowTruncate, text: "add contact bob, then call him", wantText: "add contact bob", wantTruncated: true},
+		{name: "truncated by characters", maxLength: 7, overflow: TextOverflowTruncate, text: "héllo wörld", wantText: "héllo w", wantTruncated: true},
+		{name: "rejected", maxLength: 15, overflow: TextOverflowReject, text: "add contact bob, then call him", wantErr: ErrTextTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &recordingProvider{stubProvider: stubProvider{name: "stub"}}
+			service := &IntentService{aiProvider: provider, maxTextLength: tt.maxLength, textOverflow: tt.overflow}
+
+			intent, err := service.ExtractIntent(context.Background(), tt.text)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ExtractIntent() error = %v, want %v", err, tt.wantErr)
+				}
+				if provider.calls != 0 {
+					t.Errorf("provider called %d times, want 0", provider.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if provider.text != tt.wantText {
+				t.Errorf("provider text = %q, want %q", provider.text, tt.wantText)
+			}
+			if truncated := intent.Vars[truncatedVar] == true; truncated != tt.wantTruncated {
+				t.Errorf("Vars[%s] = %v, want %v", truncatedVar, intent.Vars[truncatedVar], tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestIntentService_MaxTextLengthKeepsLengthBonus(t *testing.T) {
+	service := &IntentService{maxTextLength: 15, textOverflow: TextOverflowTruncate}
+	ctx, text, truncated, err := service.limitTextLength(context.Background(), "add contact Bob, then call him")
+	if err != nil || !truncated {
+		t.Fatalf("limitTextLength() = %q, %v, %v, want a truncated text", text, truncated, err)
+	}
+
+	provider := newTestEnhancedProvider(t, contactConfig())
+	contact := provider.config.Intents["CreateContact"]
+	if bonus := provider.scoreIntent(text, textLength(ctx, text), "CreateContact", contact).LengthBonus; bonus != models.DefaultLengthWeight {
+		t.Errorf("LengthBonus = %v for the truncated text, want %v from the original length", bonus, models.DefaultLengthWeight)
+	}
+	if bonus := provider.calculateIntentScore(text, "CreateContact", contact).LengthBonus; bonus != 0 {
+		t.Errorf("LengthBonus = %v for the same text untruncated, want 0", bonus)
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timezoneAbbreviations maps common abbreviations to IANA zones
+var timezoneAbbreviations = map[string]string{
+	"utc":  "UTC",
+	"gmt":  "UTC",
+	"est":  "America/New_York",
+	"edt":  "America/New_York",
+	"cst":  "America/Chicago",
+	"cdt":  "America/Chicago",
+	"mst":  "America/Denver",
+	"mdt":  "America/Denver",
+	"pst":  "America/Los_Angeles",
+	"pdt":  "America/Los_Angeles",
+	"akst": "America/Anchorage",
+	"akdt": "America/Anchorage",
+	"hst":  "Pacific/Honolulu",
+	"bst":  "Europe/London",
+	"cet":  "Europe/Paris",
+	"cest": "Europe/Paris",
+	"eet":  "Europe/Athens",
+	"eest": "Europe/Athens",
+	"ist":  "Asia/Kolkata",
+	"jst":  "Asia/Tokyo",
+	"aest": "Australia/Sydney",
+	"aedt": "Australia/Sydney",
+}
+
+// shortTimezoneAbbreviations are only recognized right after a time ("9am PT")
+// because on their own they are too easily confused with ordinary words
+var shortTimezoneAbbreviations = map[string]string{
+	"et": "America/New_York",
+	"ct": "America/Chicago",
+	"mt": "America/Denver",
+	"pt": "America/Los_Angeles",
+}
+
+var (
+	timezoneOffsetRegex    = regexp.MustCompile(`(?i)\b(?:utc|gmt)\s*([+-])\s*(\d{1,2})(?::?(\d{2}))?\b`)
+	timezoneIANARegex      = regexp.MustCompile(`(?i)\b([a-z]+(?:_[a-z]+)*/[a-z]+(?:_[a-z]+)*(?:/[a-z]+(?:_[a-z]+)*)?)\b`)
+	timezoneAfterTimeRegex = regexp.MustCompile(`(?i)\d(?:\s*[ap]\.?m\.?)?\s+([a-z]{2,4})\b`)
+	timezoneWordRegex      = regexp.MustCompile(`(?i)\b([a-z]{3,4})\b`)
+	clockTimeRegex         = regexp.MustCompile(`(?i)^(\d{1,2})(?::(\d{2}))?\s*(?:([ap])\.?m\.?)?$`)
+	numericDateRegex       = regexp.MustCompile(`^(\d{1,2})[/-](\d{1,2})[/-](\d{4})$`)
+)
+
+// parseTimezone finds a time zone in text and returns its normalized name: an
+// IANA zone such as "America/New_York" or a fixed offset such as "UTC+02:00"
+func parseTimezone(text string) (string, bool) {
+	// Explicit offsets first, so "UTC+2" isn't read as plain UTC
+	if matches := timezoneOffsetRegex.FindStringSubmatch(text); matches != nil {
+		hours, _ := strconv.Atoi(matches[2])
+		minutes, _ := strconv.Atoi(matches[3])
+		if hours <= 14 && minutes < 60 {
+			if hours == 0 && minutes == 0 {
+				return "UTC", true
+			}
+			return fmt.Sprintf("UTC%s%02d:%02d", matches[1], hours, minutes), true
+		}
+	}
+
+	// IANA names such as "Europe/Berlin"
+	for _, matches := range timezoneIANARegex.FindAllStringSubmatch(text, -1) {
+		if name := canonicalIANAName(matches[1]); name != "" {
+			return name, true
+		}
+	}
+
+	// Abbreviations directly after a time, including the short ones
+	for _, matches := range timezoneAfterTimeRegex.FindAllStringSubmatch(text, -1) {
+		abbreviation := strings.ToLower(matches[1])
+		if zone, exists := timezoneAbbreviations[abbreviation]; exists {
+			return zone, true
+		}
+		if zone, exists := shortTimezoneAbbreviations[abbreviation]; exists {
+			return zone, true
+		}
+	}
+
+	// Standalone abbreviations
+	for _, matches := range timezoneWordRegex.FindAllStringSubmatch(text, -1) {
+		if zone, exists := timezoneAbbreviations[strings.ToLower(matches[1])]; exists {
+			return zone, true
+		}
+	}
+
+	return "", false
+}
+
+// canonicalIANAName restores the casing of an IANA zone name ("america/new_york"
+// -> "America/New_York") and returns "" if the zone is unknown
+func canonicalIANAName(name string) string {
+	if _, err := time.LoadLocation(name); err == nil {
+		return name
+	}
+
+	parts := strings.Split(strings.ToLower(name), "/")
+	for i, part := range parts {
+		words := strings.Split(part, "_")
+		for j, word := range words {
+			if word != "" {
+				words[j] = strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+		parts[i] = strings.Join(words, "_")
+	}
+
+	canonical := strings.Join(parts, "/")
+	if _, err := time.LoadLocation(canonical); err != nil {
+		return ""
+	}
+	return canonical
+}
+
+// loadTimezone returns the location for a name produced by parseTimezone or an IANA zone name
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "UTC" {
+		return time.UTC, nil
+	}
+
+	if strings.HasPrefix(name, "UTC+") || strings.HasPrefix(name, "UTC-") {
+		sign := 1
+		if name[3] == '-' {
+			sign = -1
+		}
+		var hours, minutes int
+		if _, err := fmt.Sscanf(name[4:], "%d:%d", &hours, &minutes); err != nil {
+			return nil, fmt.Errorf("invalid UTC offset %q: %w", name, err)
+		}
+		return time.FixedZone(name, sign*(hours*3600+minutes*60)), nil
+	}
+
+	return time.LoadLocation(name)
+}
+
+// resolveDateTime combines a date reference understood by resolveDate
+// ("tomorrow", "12/25/2024" or empty for today) and a clock time ("3pm", "14:30") into an absolute time
+// in loc, relative to now
+func resolveDateTime(date, clock string, loc *time.Location, now time.Time) (time.Time, bool) {
+	clockMatches := clockTimeRegex.FindStringSubmatch(strings.TrimSpace(clock))
+	if clockMatches == nil {
+		return time.Time{}, false
+	}
+
+	hour, _ := strconv.Atoi(clockMatches[1])
+	minute, _ := strconv.Atoi(clockMatches[2])
+	switch strings.ToLower(clockMatches[3]) {
+	case "a":
+		if hour == 12 {
+			hour = 0
+		}
+	case "p":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+
+	day, ok := resolveDate(date, now.In(loc))
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc), true
+}
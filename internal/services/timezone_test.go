@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+func TestParseTimezone(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"meeting at 3pm EST", "America/New_York"},
+		{"call at 9am PT", "America/Los_Angeles"},
+		{"call at 9 am pt tomorrow", "America/Los_Angeles"},
+		{"sync at 14:00 UTC+2", "UTC+02:00"},
+		{"sync at 14:00 gmt-05:30", "UTC-05:30"},
+		{"standup at 10am UTC", "UTC"},
+		{"lunch at noon utc+0", "UTC"},
+		{"dinner at 7pm Europe/Berlin", "Europe/Berlin"},
+		{"dinner at 7pm america/new_york", "America/New_York"},
+		{"review at 4pm in CEST", "Europe/Paris"},
+		{"pt session at 3pm", ""},
+		{"meeting at 3pm", ""},
+		{"buy milk and/or eggs", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseTimezone(tt.input)
+			if got != tt.expected || ok != (tt.expected != "") {
+				t.Errorf("parseTimezone(%q) = %q, %v, want %q", tt.input, got, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadTimezoneOffsets(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantOffset int
+	}{
+		{"UTC", 0},
+		{"UTC+02:00", 2 * 3600},
+		{"UTC-05:30", -(5*3600 + 30*60)},
+	}
+
+	for _, tt := range tests {
+		loc, err := loadTimezone(tt.name)
+		if err != nil {
+			t.Fatalf("loadTimezone(%q) error = %v", tt.name, err)
+		}
+		_, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone()
+		if offset != tt.wantOffset {
+			t.Errorf("loadTimezone(%q) offset = %d, want %d", tt.name, offset, tt.wantOffset)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_TimezoneTimestamps(t *testing.T) {
+	config := eventConfig()
+	config.Timezone = "Europe/London"
+	config.Entities["timezone"] = models.EntityPattern{Type: "timezone"}
+	provider := newTestEnhancedProvider(t, config)
+	provider.now = func() time.Time { return time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name         string
+		input        string
+		wantTimezone interface{}
+		wantDatetime string
+	}{
+		{"abbreviation", `schedule event "Sync" tomorrow at 3pm EST`, "America/New_York", "2024-01-16T15:00:00-05:00"},
+		{"short abbreviation after time", `schedule event "Sync" at 9am PT`, "America/Los_Angeles", "2024-01-15T09:00:00-08:00"},
+		{"utc offset", `schedule event "Sync" tomorrow at 14:30 UTC+2`, "UTC+02:00", "2024-01-16T14:30:00+02:00"},
+		{"config default zone", `schedule event "Sync" tomorrow at 3pm`, nil, "2024-01-16T15:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent, err := provider.ExtractIntent(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			if got := intent.Vars["timezone"]; got != tt.wantTimezone {
+				t.Errorf("timezone = %v, want %v", got, tt.wantTimezone)
+			}
+			if got := intent.Vars["datetime"]; got != tt.wantDatetime {
+				t.Errorf("datetime = %v, want %v", got, tt.wantDatetime)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"myllm/internal/models"
+)
+
+// Tokenizer splits lowercased, NFC-normalized text into the words that
+// keyword scoring and word overlap compare
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// newTokenizer returns the tokenizer a config's "tokenizer" names
+func newTokenizer(name string) (Tokenizer, error) {
+	switch name {
+	case "", models.TokenizerWhitespace:
+		return WhitespaceTokenizer{}, nil
+	case models.TokenizerUnicode:
+		return UnicodeTokenizer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q, want %s or %s", name, models.TokenizerWhitespace, models.TokenizerUnicode)
+	}
+}
+
+// WhitespaceTokenizer splits text at whitespace, keeping punctuation on the
+// words it is attached to
+type WhitespaceTokenizer struct{}
+
+// Tokenize splits text at whitespace
+func (WhitespaceTokenizer) Tokenize(text string) []string {
+	return strings.Fields(text)
+}
+
+// UnicodeTokenizer splits text at word boundaries in the manner of Unicode
+// text segmentation (UAX #29): words are runs of letters, marks and digits,
+// which stay joined across an apostrophe or a dot between letters or digits
+// ("don't", "3.5"), and punctuation is dropped. Text in scripts written
+// without spaces, Han and Hiragana, yields one token per character, since
+// splitting it into words needs a dictionary; Katakana runs stay together.
+type UnicodeTokenizer struct{}
+
+// Tokenize splits text at Unicode word boundaries
+func (UnicodeTokenizer) Tokenize(text string) []string {
+	runes := []rune(text)
+	var tokens []string
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, string(runes[start:end]))
+			start = -1
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana):
+			flush(i)
+			tokens = append(tokens, string(r))
+		case isSegmentRune(r):
+			// A run never mixes Katakana with other letters
+			if start >= 0 && unicode.Is(unicode.Katakana, r) != unicode.Is(unicode.Katakana, runes[i-1]) {
+				flush(i)
+			}
+			if start < 0 {
+				start = i
+			}
+		case isMidWordRune(r) && start >= 0 && i+1 < len(runes) && isSegmentRune(runes[i+1]):
+			// Part of the word, e.g. the apostrophe in "don't"
+		default:
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return tokens
+}
+
+// isSegmentRune reports whether r can be part of a multi-character word
+func isSegmentRune(r rune) bool {
+	return (isWordRune(r) || unicode.IsMark(r)) && !unicode.In(r, unicode.Han, unicode.Hiragana)
+}
+
+// isMidWordRune reports whether r joins the word characters around it
+func isMidWordRune(r rune) bool {
+	return r == '\'' || r == '’' || r == '.'
+}
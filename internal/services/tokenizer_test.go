@@ -0,0 +1,73 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizers(t *testing.T) {
+	tests := []struct {
+		text           string
+		wantWhitespace []string
+		wantUnicode    []string
+	}{
+		{
+			text:           "note: call bob, then (maybe) email him!",
+			wantWhitespace: []string{"note:", "call", "bob,", "then", "(maybe)", "email", "him!"},
+			wantUnicode:    []string{"note", "call", "bob", "then", "maybe", "email", "him"},
+		},
+		{
+			text:           "don't forget the 3.5 kg order.",
+			wantWhitespace: []string{"don't", "forget", "the", "3.5", "kg", "order."},
+			wantUnicode:    []string{"don't", "forget", "the", "3.5", "kg", "order"},
+		},
+		{
+			text:           "¿crear una nota? «sí»",
+			wantWhitespace: []string{"¿crear", "una", "nota?", "«sí»"},
+			wantUnicode:    []string{"crear", "una", "nota", "sí"},
+		},
+		{
+			text:           "记笔记：买牛奶",
+			wantWhitespace: []string{"记笔记：买牛奶"},
+			wantUnicode:    []string{"记", "笔", "记", "买", "牛", "奶"},
+		},
+		{
+			text:           "メモを作成",
+			wantWhitespace: []string{"メモを作成"},
+			wantUnicode:    []string{"メモ", "を", "作", "成"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := (WhitespaceTokenizer{}).Tokenize(tt.text); !reflect.DeepEqual(got, tt.wantWhitespace) {
+			t.Errorf("WhitespaceTokenizer.Tokenize(%q) = %q, want %q", tt.text, got, tt.wantWhitespace)
+		}
+		if got := (UnicodeTokenizer{}).Tokenize(tt.text); !reflect.DeepEqual(got, tt.wantUnicode) {
+			t.Errorf("UnicodeTokenizer.Tokenize(%q) = %q, want %q", tt.text, got, tt.wantUnicode)
+		}
+	}
+}
+
+func TestEnhancedLocalProvider_Tokenizer(t *testing.T) {
+	text := "Note: remember the milk!"
+
+	whitespace := newTestEnhancedProvider(t, noteConfig())
+	config := noteConfig()
+	config.Tokenizer = "unicode"
+	unicodeProvider := newTestEnhancedProvider(t, config)
+
+	if got := whitespace.tokenize(text); !reflect.DeepEqual(got, []string{"note:", "remember", "milk!"}) {
+		t.Errorf("whitespace tokens = %q", got)
+	}
+	if got := unicodeProvider.tokenize(text); !reflect.DeepEqual(got, []string{"note", "remember", "milk"}) {
+		t.Errorf("unicode tokens = %q, want punctuation dropped and stop words removed", got)
+	}
+
+	// "note:" doesn't overlap the intent's "note"; "note" does
+	intent := config.Intents["CreateNote"]
+	before := whitespace.calculateIntentScore(text, "CreateNote", intent).WordOverlap
+	after := unicodeProvider.calculateIntentScore(text, "CreateNote", intent).WordOverlap
+	if after <= before {
+		t.Errorf("word overlap = %v with the unicode tokenizer, want more than %v with whitespace", after, before)
+	}
+}
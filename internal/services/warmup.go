@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// warmupInputs are classified in every language on top of the configs' own
+// examples, so entity extraction runs on dates, numbers and contact details
+var warmupInputs = []string{
+	"hello",
+	"add contact John Smith john@example.com +1 555 123 4567",
+	`schedule a meeting "Standup" tomorrow at 3pm for 30 minutes`,
+	"remind me to buy 3 apples next friday",
+}
+
+// benchmarkRounds is how many times BenchmarkScoring scores each input
+const benchmarkRounds = 20
+
+// IntentTiming is the average time one intent took to score an input
+type IntentTiming struct {
+	Language string        // Language of the config that defines the intent
+	Intent   string        // Intent name
+	Calls    int           // Inputs scored, counting every round
+	Average  time.Duration // Mean time per input
+}
+
+// Warmup primes the provider before the first request, which would otherwise
+// pay for state built on first use, and logs how long that took. Only the
+// enhanced local provider needs it; other providers return at once.
+func (s *IntentService) Warmup(ctx context.Context) {
+	enhanced, ok := s.aiProvider.(*EnhancedLocalProvider)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	inputs, err := enhanced.Warmup(ctx)
+	if err != nil {
+		slog.Warn("Warmup stopped early", "inputs", inputs, "error", err)
+		return
+	}
+	slog.Info("Warmup complete", "inputs", inputs, "duration_ms", float64(time.Since(start).Microseconds())/1000)
+}
+
+// Warmup classifies a sample of inputs in every language: an example or
+// phrase of each enabled intent and a few generic inputs. Regex matchers and
+// other lazily built state are ready afterwards. It returns the number of
+// inputs classified.
+func (p *EnhancedLocalProvider) Warmup(ctx context.Context) (int, error) {
+	current := p.snapshot()
+	configs := current.allConfigs()
+
+	classified := 0
+	for _, language := range sortedKeys(configs) {
+		languageCtx := WithLanguage(ctx, language)
+		for _, input := range append(sampleInputs(configs[language], 1), warmupInputs...) {
+			if _, err := current.ExtractIntent(languageCtx, input); err != nil {
+				return classified, err
+			}
+			classified++
+		}
+	}
+	return classified, nil
+}
+
+// BenchmarkScoring scores every example and phrase of the configs, and the
+// warmup inputs, against each enabled intent of their language and reports
+// the average time per intent, slowest first. Slow intents usually have a
+// regex that is expensive to match.
+func (p *EnhancedLocalProvider) BenchmarkScoring() []IntentTiming {
+	current := p.snapshot()
+	configs := current.allConfigs()
+
+	var timings []IntentTiming
+	for _, language := range sortedKeys(configs) {
+		provider := current.forLanguage(language)
+		var inputs []string
+		for _, input := range append(sampleInputs(configs[language], 0), warmupInputs...) {
+			inputs = append(inputs, provider.normalizeText(input))
+		}
+
+		for _, intentName := range sortedKeys(provider.config.Intents) {
+			intent := provider.config.Intents[intentName]
+			if !intent.IsEnabled() {
+				continue
+			}
+			start := time.Now()
+			for round := 0; round < benchmarkRounds; round++ {
+				for _, input := range inputs {
+					provider.calculateIntentScore(input, intentName, intent)
+				}
+			}
+			calls := benchmarkRounds * len(inputs)
+			timings = append(timings, IntentTiming{
+				Language: language,
+				Intent:   intentName,
+				Calls:    calls,
+				Average:  time.Since(start) / time.Duration(calls),
+			})
+		}
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool { return timings[i].Average > timings[j].Average })
+	return timings
+}
+
+// sampleInputs returns up to perIntent examples of each enabled intent, or
+// its phrases when it has no examples. A perIntent of 0 returns them all.
+func sampleInputs(config *models.IntentConfig, perIntent int) []string {
+	var inputs []string
+	for _, intentName := range sortedKeys(config.Intents) {
+		intent := config.Intents[intentName]
+		if !intent.IsEnabled() {
+			continue
+		}
+		samples := intent.Examples
+		if len(samples) == 0 {
+			samples = intent.Phrases
+		}
+		if perIntent > 0 && len(samples) > perIntent {
+			samples = samples[:perIntent]
+		}
+		inputs = append(inputs, samples...)
+	}
+	return inputs
+}
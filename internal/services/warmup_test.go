@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestEnhancedLocalProvider_Warmup(t *testing.T) {
+	config := contactConfig()
+	disabled := false
+	config.Intents["Disabled"] = models.IntentPattern{Description: "Off", Keywords: []string{"off"}, Examples: []string{"turn it off"}, Enabled: &disabled}
+	provider := newTestEnhancedProvider(t, config)
+
+	inputs, err := provider.Warmup(context.Background())
+	if err != nil {
+		t.Fatalf("Warmup() error = %v", err)
+	}
+	if want := len(sampleInputs(config, 1)) + len(warmupInputs); inputs != want {
+		t.Errorf("Warmup() classified %d inputs, want %d", inputs, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.Warmup(ctx); err == nil {
+		t.Error("Warmup() with a cancelled context error = nil, want it to stop")
+	}
+}
+
+func TestIntentService_Warmup(t *testing.T) {
+	// Providers other than the enhanced local one are left alone
+	stub := &stubProvider{name: "stub", intent: &models.Intent{Task: "UNKNOWN"}}
+	(&IntentService{aiProvider: stub}).Warmup(context.Background())
+	if stub.calls != 0 {
+		t.Errorf("provider calls = %d, want no warmup for other providers", stub.calls)
+	}
+
+	(&IntentService{aiProvider: newTestEnhancedProvider(t, contactConfig())}).Warmup(context.Background())
+}
+
+func TestEnhancedLocalProvider_BenchmarkScoring(t *testing.T) {
+	config := contactConfig()
+	disabled := false
+	config.Intents["Disabled"] = models.IntentPattern{Description: "Off", Keywords: []string{"off"}, Enabled: &disabled}
+	provider := newTestEnhancedProvider(t, config)
+
+	timings := provider.BenchmarkScoring()
+	if len(timings) != len(config.Intents)-1 {
+		t.Fatalf("BenchmarkScoring() = %d timings, want one per enabled intent", len(timings))
+	}
+	for i, timing := range timings {
+		if timing.Intent == "Disabled" {
+			t.Error("BenchmarkScoring() timed a disabled intent")
+		}
+		if timing.Calls != benchmarkRounds*(len(sampleInputs(config, 0))+len(warmupInputs)) {
+			t.Errorf("%s scored %d inputs, want every sample in every round", timing.Intent, timing.Calls)
+		}
+		if i > 0 && timing.Average > timings[i-1].Average {
+			t.Errorf("timings not ordered slowest first: %v after %v", timing.Average, timings[i-1].Average)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// Webhook event types
+const (
+	WebhookEventIntentDetected  = "intent.detected"
+	WebhookEventIntentCompleted = "intent.completed"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookConfig holds configuration for webhook delivery
+type WebhookConfig struct {
+	Secret     string        // HMAC secret used to sign payloads (unsigned if empty)
+	MaxRetries int           // Retries after the first attempt
+	Timeout    time.Duration // Timeout per delivery attempt
+	Backoff    time.Duration // Delay before the first retry, doubled for each retry
+}
+
+// WebhookEvent is the payload POSTed to webhook receivers
+type WebhookEvent struct {
+	Event     string         `json:"event"`
+	Timestamp time.Time      `json:"timestamp"`
+	Intent    *models.Intent `json:"intent"`
+}
+
+// WebhookDispatcher delivers webhook events asynchronously on a best-effort basis
+type WebhookDispatcher struct {
+	client *http.Client
+	config WebhookConfig
+	wg     sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher
+func NewWebhookDispatcher(config WebhookConfig) *WebhookDispatcher {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 500 * time.Millisecond
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+
+	return &WebhookDispatcher{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+}
+
+// Dispatch sends the event to url in the background. The payload is encoded
+// before returning, so the caller may keep using the intent.
+func (d *WebhookDispatcher) Dispatch(url, event string, intent *models.Intent) {
+	body, err := json.Marshal(WebhookEvent{
+		Event:     event,
+		Timestamp: time.Now().UTC(),
+		Intent:    intent,
+	})
+	if err != nil {
+		slog.Warn("Webhook payload encoding failed", "url", url, "error", err)
+		return
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.deliver(url, body); err != nil {
+			slog.Warn("Webhook delivery failed, giving up", "url", url, "error", err)
+		}
+	}()
+}
+
+// Wait blocks until all in-flight deliveries have finished
+func (d *WebhookDispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver POSTs body to url, retrying with exponential backoff
+func (d *WebhookDispatcher) deliver(url string, body []byte) error {
+	var lastErr error
+	backoff := d.config.Backoff
+
+	for attempt := 0; attempt <= d.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		if lastErr = d.post(url, body); lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%d attempt(s) failed, last error: %w", d.config.MaxRetries+1, lastErr)
+}
+
+// post performs a single signed delivery attempt
+func (d *WebhookDispatcher) post(url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(d.config.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook receiver returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the hex-encoded HMAC-SHA256 of body using secret
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
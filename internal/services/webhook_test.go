@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"myllm/internal/models"
+)
+
+// webhookReceiver records deliveries and fails the first failFirst of them
+type webhookReceiver struct {
+	mu        sync.Mutex
+	attempts  int
+	failFirst int
+	bodies    [][]byte
+	sigs      []string
+	release   chan struct{}
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.release != nil {
+		<-r.release
+	}
+
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.attempts <= r.failFirst {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	r.bodies = append(r.bodies, body)
+	r.sigs = append(r.sigs, req.Header.Get(WebhookSignatureHeader))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newWebhookTestService(t *testing.T, url string, config WebhookConfig) *IntentService {
+	t.Helper()
+
+	intentConfig := contactConfig()
+	contact := intentConfig.Intents["CreateContact"]
+	contact.Webhook = url
+	intentConfig.Intents["CreateContact"] = contact
+
+	return &IntentService{
+		aiProvider: newTestEnhancedProvider(t, intentConfig),
+		webhooks:   NewWebhookDispatcher(config),
+	}
+}
+
+func TestIntentService_WebhookDelivery(t *testing.T) {
+	receiver := &webhookReceiver{failFirst: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newWebhookTestService(t, server.URL, WebhookConfig{
+		Secret:     "s3cret",
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	})
+
+	intent, err := service.ExtractIntent(context.Background(), "add contact named Alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	service.webhooks.Wait()
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if receiver.attempts != 2 {
+		t.Errorf("attempts = %d, want 2 (one failure, one retry)", receiver.attempts)
+	}
+	if len(receiver.bodies) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(receiver.bodies))
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(receiver.bodies[0], &event); err != nil {
+		t.Fatalf("failed to decode webhook payload: %v", err)
+	}
+	if event.Event != WebhookEventIntentDetected {
+		t.Errorf("Event = %v, want %v", event.Event, WebhookEventIntentDetected)
+	}
+	if event.Intent == nil || event.Intent.Task != intent.Task || event.Intent.Vars["name"] != intent.Vars["name"] {
+		t.Errorf("Intent = %+v, want the extracted intent", event.Intent)
+	}
+
+	wantSig := "sha256=" + SignWebhookPayload("s3cret", receiver.bodies[0])
+	if receiver.sigs[0] != wantSig {
+		t.Errorf("signature = %v, want %v", receiver.sigs[0], wantSig)
+	}
+}
+
+func TestIntentService_WebhookDoesNotBlockResponse(t *testing.T) {
+	receiver := &webhookReceiver{release: make(chan struct{})}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newWebhookTestService(t, server.URL, WebhookConfig{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := service.ExtractIntent(context.Background(), "add contact named Alice"); err != nil {
+			t.Errorf("ExtractIntent() error = %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ExtractIntent blocked on webhook delivery")
+	}
+
+	close(receiver.release)
+	service.webhooks.Wait()
+}
+
+func TestIntentService_WebhookSkippedForOtherIntents(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	service := newWebhookTestService(t, server.URL, WebhookConfig{})
+	configured := service.aiProvider.(ConfigurableProvider).GetConfig()
+	configured.Intents["FindContact"] = models.IntentPattern{
+		Description: "Find a contact",
+		Keywords:    []string{"find", "search"},
+		Phrases:     []string{"find contact"},
+		Priority:    20,
+	}
+	service.aiProvider = newTestEnhancedProvider(t, configured)
+
+	intent, err := service.ExtractIntent(context.Background(), "find contact Alice")
+	if err != nil {
+		t.Fatalf("ExtractIntent() error = %v", err)
+	}
+	service.webhooks.Wait()
+
+	if intent.Task != "FindContact" {
+		t.Fatalf("Task = %v, want FindContact", intent.Task)
+	}
+	if receiver.attempts != 0 {
+		t.Errorf("attempts = %d, want 0 for an intent without a webhook", receiver.attempts)
+	}
+}
+
+func TestIntentService_CompletedIntentWebhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		tasks     map[string]bool
+		text      string
+		wantEvent bool
+	}{
+		{name: "complete intent", text: "add contact named Alice", wantEvent: true},
+		{name: "selected task", tasks: map[string]bool{"CreateContact": true}, text: "add contact named Alice", wantEvent: true},
+		{name: "other task", tasks: map[string]bool{"DeleteContact": true}, text: "add contact named Alice"},
+		{name: "incomplete intent", text: "add contact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			service := &IntentService{
+				aiProvider:            newTestEnhancedProvider(t, contactConfig()),
+				webhooks:              NewWebhookDispatcher(WebhookConfig{Secret: "s3cret"}),
+				completedWebhook:      server.URL,
+				completedWebhookTasks: tt.tasks,
+			}
+
+			if _, err := service.ExtractIntent(context.Background(), tt.text); err != nil {
+				t.Fatalf("ExtractIntent() error = %v", err)
+			}
+			service.webhooks.Wait()
+
+			receiver.mu.Lock()
+			defer receiver.mu.Unlock()
+
+			if !tt.wantEvent {
+				if receiver.attempts != 0 {
+					t.Errorf("attempts = %d, want no delivery", receiver.attempts)
+				}
+				return
+			}
+			if len(receiver.bodies) != 1 {
+				t.Fatalf("deliveries = %d, want 1", len(receiver.bodies))
+			}
+
+			var event WebhookEvent
+			if err := json.Unmarshal(receiver.bodies[0], &event); err != nil {
+				t.Fatalf("failed to decode webhook payload: %v", err)
+			}
+			if event.Event != WebhookEventIntentCompleted || event.Intent == nil || !event.Intent.IsComplete {
+				t.Errorf("event = %+v, want %s with the complete intent", event, WebhookEventIntentCompleted)
+			}
+			if want := "sha256=" + SignWebhookPayload("s3cret", receiver.bodies[0]); receiver.sigs[0] != want {
+				t.Errorf("signature = %v, want %v", receiver.sigs[0], want)
+			}
+		})
+	}
+}
@@ -2,38 +2,94 @@ package main
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embed the zone database for time zone extraction
 
 	"myllm/config"
 	"myllm/internal/handlers"
+	"myllm/internal/logging"
+	"myllm/internal/metrics"
+	"myllm/internal/models"
 	"myllm/internal/services"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	validatePath := flag.String("validate", "", "validate an intent config file and exit without starting the server")
+	selftestPath := flag.String("selftest", "", "classify the examples in an intent config file and exit non-zero if any is misclassified")
+	extract := flag.Bool("extract", false, "extract the intent of the text on stdin, print it as JSON and exit")
+	benchmarkPath := flag.String("benchmark-config", "", "report the average time each intent in an intent config file takes to score, slowest first, and exit")
+	flag.Parse()
+	if *validatePath != "" {
+		os.Exit(validateConfigFile(*validatePath))
+	}
+	if *selftestPath != "" {
+		os.Exit(selfTestConfigFile(*selftestPath))
+	}
+	if *extract {
+		os.Exit(extractFromReader(os.Stdin, os.Stdout))
+	}
+	if *benchmarkPath != "" {
+		os.Exit(benchmarkConfigFile(*benchmarkPath, os.Stdout))
 	}
 
+	// Load environment variables
+	envErr := godotenv.Load()
+
 	// Load configuration
 	cfg := config.Load()
 
+	// Log JSON lines, tagged with request IDs where available
+	slog.SetDefault(logging.New(os.Stdout, cfg.Logging.Level))
+	if envErr != nil {
+		slog.Info("No .env file found, using system environment variables")
+	}
+
+	// Fail fast on an unusable HOST, PORT or BIND_ADDR
+	network, address, err := cfg.Server.ListenAddress()
+	if err != nil {
+		slog.Error("Invalid listen address", "error", err)
+		os.Exit(1)
+	}
+
+	// Register Prometheus metrics once, before anything records them
+	metrics.Register()
+
 	// Initialize services
-	intentService := services.NewIntentService()
+	intentService, err := services.NewIntentService()
+	if err != nil {
+		slog.Error("Failed to create intent service", "error", err)
+		os.Exit(1)
+	}
 
 	// Log which AI provider is being used
-	log.Printf("Using AI provider: %s", intentService.GetAIProviderName())
+	slog.Info("Using AI provider", "provider", intentService.GetAIProviderName())
+
+	// Pay the first-use costs now rather than on the first request
+	intentService.Warmup(context.Background())
 
 	// Initialize handlers
-	intentHandler := handlers.NewIntentHandler(intentService)
+	intentHandler := handlers.NewIntentHandler(intentService, cfg.Server.MaxBodyBytes)
+	intentHandler.SetDefaultResponseFields(cfg.Server.DefaultResponseFields)
+	intentHandler.SetFailOnUnknown(cfg.Server.FailOnUnknown)
+	if cfg.Server.LogBodies {
+		slog.Warn("Logging intent request bodies; values of sensitive entities are masked")
+		intentHandler.SetLogBodies(true)
+	}
 
 	// Setup router
 	router := mux.NewRouter()
@@ -41,26 +97,62 @@ func main() {
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 	api.HandleFunc("/intent", intentHandler.ExtractIntent).Methods("POST")
-	api.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+	api.HandleFunc("/intent/stream", intentHandler.StreamIntent).Methods("GET")
+	api.HandleFunc("/health", handlers.ReadinessHandler(intentService)).Methods("GET")
+	api.HandleFunc("/health/ready", handlers.ReadinessHandler(intentService)).Methods("GET")
+	api.HandleFunc("/health/live", handlers.HealthCheck).Methods("GET")
 	api.HandleFunc("/debug", handlers.DebugHandler(intentService)).Methods("GET")
+	api.HandleFunc("/intents", handlers.ListIntentsHandler(intentService)).Methods("GET")
+	api.HandleFunc("/explain", handlers.ExplainHandler(intentService, cfg.Server.MaxBodyBytes)).Methods("POST")
+	api.HandleFunc("/validate-config", handlers.ValidateConfigHandler).Methods("POST")
+	api.HandleFunc("/schema", handlers.SchemaHandler).Methods("GET")
+
+	// Prometheus scrape endpoint
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	// Config reload and intent toggles (disabled without a token)
+	if handlers.RegisterAdminRoutes(router, intentService, cfg.Admin.AuthToken) {
+		slog.Info("Admin endpoints enabled at /api/v1/reload and /api/v1/intents/{name}")
+	} else {
+		slog.Info("ADMIN_AUTH_TOKEN is empty; /api/v1/reload and PATCH /api/v1/intents/{name} not mounted")
+	}
+
+	// Profiling and runtime stats (disabled by default)
+	if handlers.RegisterDebugRoutes(router, intentService, cfg.Debug.Enabled, cfg.Debug.AuthToken) {
+		slog.Info("Debug endpoints enabled at /debug/pprof, /api/v1/stats, /api/v1/debug/compiled and /api/v1/debug/shadow")
+	} else if cfg.Debug.Enabled {
+		slog.Warn("DEBUG_ENDPOINTS_ENABLED is set but DEBUG_AUTH_TOKEN is empty; debug endpoints not mounted")
+	}
 
 	// Middleware
 	router.Use(handlers.LoggingMiddleware)
+	router.Use(handlers.MetricsMiddleware)
+	inFlight := &handlers.InFlightTracker{}
+	router.Use(inFlight.Middleware)
+	router.Use(handlers.CompressionMiddleware(cfg.Server.CompressionMinBytes))
 
 	// Create server with configuration
 	server := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
+		Addr:         address,
 		Handler:      router,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	// Bind before serving so a taken port or bad socket path fails at startup
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		slog.Error("Failed to listen", "network", network, "address", address, "error", err)
+		os.Exit(1)
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on port %s", cfg.Server.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+		slog.Info("Starting server", "network", network, "address", address)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("Server error", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -69,16 +161,168 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server", "in_flight", inFlight.Count(), "timeout", cfg.Server.ShutdownTimeout)
 
-	// Create context with timeout for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Stop accepting connections and wait for in-flight requests to finish
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Attempt graceful shutdown
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		slog.Error("Server forced to shutdown", "error", err, "in_flight", inFlight.Count())
+		os.Exit(1)
+	}
+
+	slog.Info("Server exited")
+}
+
+// validateConfigFile reports every problem in an intent config file on stderr
+// and returns the process exit code
+func validateConfigFile(path string) int {
+	config, err := models.ReadIntentConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return 1
+	}
+
+	problems := services.ValidateConfig(config)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s) found\n", path, len(problems))
+		return 1
 	}
 
-	log.Println("Server exited")
+	fmt.Printf("%s: OK (%d intents, %d entities)\n", path, len(config.Intents), len(config.Entities))
+	return 0
+}
+
+// selfTestConfigFile classifies every intent example in a config file with
+// the enhanced local provider, reports the misclassified ones and a pass/fail
+// count per intent, and returns the process exit code
+func selfTestConfigFile(path string) int {
+	// Keep the provider's startup logs out of the report
+	slog.SetDefault(logging.New(os.Stderr, "warn"))
+
+	provider, err := services.NewEnhancedLocalProvider(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return 1
+	}
+	results := provider.(*services.EnhancedLocalProvider).RunExamples(context.Background())
+
+	languages := make(map[string]bool)
+	for _, result := range results {
+		languages[result.Language] = true
+	}
+
+	type tally struct{ passed, failed int }
+	var intents []string
+	tallies := make(map[string]*tally)
+	failed := 0
+	for _, result := range results {
+		intent := result.Intent
+		if len(languages) > 1 {
+			intent = result.Language + "/" + intent
+		}
+		if tallies[intent] == nil {
+			intents = append(intents, intent)
+			tallies[intent] = &tally{}
+		}
+
+		switch {
+		case result.Err != nil:
+			fmt.Printf("FAIL %s: %q: %v\n", intent, result.Example, result.Err)
+		case !result.Passed():
+			fmt.Printf("FAIL %s: %q classified as %s (%.2f), want %s\n",
+				intent, result.Example, result.Task, result.Confidence, result.ExpectedTask)
+		default:
+			tallies[intent].passed++
+			continue
+		}
+		tallies[intent].failed++
+		failed++
+	}
+
+	for _, intent := range intents {
+		status := "ok  "
+		if tallies[intent].failed > 0 {
+			status = "FAIL"
+		}
+		fmt.Printf("%s %s: %d passed, %d failed\n", status, intent, tallies[intent].passed, tallies[intent].failed)
+	}
+	fmt.Printf("%s: %d of %d examples passed\n", path, len(results)-failed, len(results))
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// benchmarkConfigFile loads a config file into the enhanced local provider,
+// writes how long that took and the average scoring time of each intent,
+// slowest first, to out, and returns the process exit code
+func benchmarkConfigFile(path string, out io.Writer) int {
+	// Keep the provider's startup logs out of the report
+	slog.SetDefault(logging.New(os.Stderr, "warn"))
+
+	start := time.Now()
+	provider, err := services.NewEnhancedLocalProvider(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return 1
+	}
+	fmt.Fprintf(out, "%s: loaded and compiled in %v\n", path, time.Since(start).Round(time.Microsecond))
+
+	timings := provider.(*services.EnhancedLocalProvider).BenchmarkScoring()
+	languages := make(map[string]bool)
+	for _, timing := range timings {
+		languages[timing.Language] = true
+	}
+	for _, timing := range timings {
+		intent := timing.Intent
+		if len(languages) > 1 {
+			intent = timing.Language + "/" + intent
+		}
+		fmt.Fprintf(out, "%-40s %12v per input (%d scored)\n", intent, timing.Average, timing.Calls)
+	}
+	return 0
+}
+
+// extractFromReader extracts the intent of all the text read from in with the
+// provider the environment configures, as the server would, writes the intent
+// to out as JSON and returns the process exit code. Problems go to stderr.
+func extractFromReader(in io.Reader, out io.Writer) int {
+	// The server's .env applies, but its startup logs would clutter the output
+	_ = godotenv.Load()
+	slog.SetDefault(logging.New(os.Stderr, "warn"))
+
+	input, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read stdin: %v\n", err)
+		return 1
+	}
+	text := strings.TrimSpace(string(input))
+	if text == "" {
+		fmt.Fprintln(os.Stderr, "no text on stdin")
+		return 1
+	}
+
+	intentService, err := services.NewIntentService()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create intent service: %v\n", err)
+		return 1
+	}
+	intent, err := intentService.ExtractIntent(context.Background(), text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to extract intent: %v\n", err)
+		return 1
+	}
+
+	if err := json.NewEncoder(out).Encode(intent); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write intent: %v\n", err)
+		return 1
+	}
+	return 0
 }
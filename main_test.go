@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"myllm/internal/models"
+)
+
+func TestExtractFromReader(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+	t.Setenv("INTENT_CONFIG_PATH", "configs/personal_assistant.json")
+
+	var out bytes.Buffer
+	if code := extractFromReader(strings.NewReader("add contact alice@example.com\n"), &out); code != 0 {
+		t.Fatalf("extractFromReader() = %d, want 0", code)
+	}
+
+	var intent models.Intent
+	if err := json.Unmarshal(out.Bytes(), &intent); err != nil {
+		t.Fatalf("output is not an intent: %v\n%s", err, out.String())
+	}
+	if intent.Task != "CreateContact" {
+		t.Errorf("Task = %v, want CreateContact", intent.Task)
+	}
+	if email := intent.Vars["email"]; email != "alice@example.com" {
+		t.Errorf("Vars[email] = %v, want alice@example.com", email)
+	}
+}
+
+func TestExtractFromReader_NoText(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "enhanced_local")
+
+	var out bytes.Buffer
+	if code := extractFromReader(strings.NewReader(" \n"), &out); code != 1 {
+		t.Errorf("extractFromReader() = %d, want 1", code)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want nothing", out.String())
+	}
+}
+
+func TestBenchmarkConfigFile(t *testing.T) {
+	var out bytes.Buffer
+	if code := benchmarkConfigFile("configs/personal_assistant.json", &out); code != 0 {
+		t.Fatalf("benchmarkConfigFile() = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "loaded and compiled in") || !strings.Contains(out.String(), "CreateContact") {
+		t.Errorf("output = %q, want the compile time and a line per intent", out.String())
+	}
+
+	if code := benchmarkConfigFile("configs/missing.json", &out); code != 1 {
+		t.Errorf("benchmarkConfigFile() of a missing file = %d, want 1", code)
+	}
+}